@@ -196,6 +196,27 @@ func (p *proxySearchLogProvider) PutLog(
 	return 0, nil
 }
 
+// PutLogWithStats implements the SearchStatsLogProvider interface
+func (p *proxySearchLogProvider) PutLogWithStats(
+	ctx context.Context,
+	initiator database.MsgchainType,
+	executor database.MsgchainType,
+	engine database.SearchengineType,
+	query string,
+	result string,
+	stats tools.SearchStats,
+	taskID *int64,
+	subtaskID *int64,
+) (int64, error) {
+	id, err := p.PutLog(ctx, initiator, executor, engine, query, result, taskID, subtaskID)
+
+	terminal.PrintKeyValue("Duration", stats.Duration.String())
+	terminal.PrintKeyValueFormat("Result Count", "%d", stats.ResultCount)
+	terminal.PrintKeyValueFormat("Cached", "%t", stats.Cached)
+
+	return id, err
+}
+
 // proxyTermLogProvider is a proxy implementation of TermLogProvider
 type proxyTermLogProvider struct{}
 
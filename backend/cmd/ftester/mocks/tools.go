@@ -63,10 +63,26 @@ func MockResponse(funcName string, args json.RawMessage) (string, error) {
 			resultObj = fmt.Sprintf("# Mock page for %s\n\n## Introduction\n\nThis is a mock page content that simulates what the real browser tool would return in markdown format.\n\n## Main Content\n\nHere is some example text that would appear on the page.\n\n* List item 1\n* List item 2\n* List item 3\n\n## Conclusion\n\nThis mock content is designed to look like real markdown content from a web page.", browserArgs.Url)
 		case tools.HTML:
 			resultObj = fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head>\n  <title>Mock Page for %s</title>\n</head>\n<body>\n  <h1>Mock HTML Content</h1>\n  <p>This is a mock HTML page that simulates what the real browser tool would return.</p>\n  <ul>\n    <li>HTML Element 1</li>\n    <li>HTML Element 2</li>\n    <li>HTML Element 3</li>\n  </ul>\n</body>\n</html>", browserArgs.Url)
+		case tools.Text:
+			resultObj = fmt.Sprintf("Mock readable text of %s\n\nThis is the main content of the page without navigation, ads and other boilerplate that the real browser tool would return in text mode.", browserArgs.Url)
+		case tools.Crawl:
+			resultObj = fmt.Sprintf("# Page 1: %s\n\nMock content of the start page.\n\n# Page 2: %s/docs\n\nMock content of the linked page on the same host.\n\n", browserArgs.Url, strings.TrimRight(browserArgs.Url, "/"))
 		case tools.Links:
 			resultObj = fmt.Sprintf("Links list from URL '%s'\n[Homepage](https://example.com)\n[About Us](https://example.com/about)\n[Products](https://example.com/products)\n[Documentation](https://example.com/docs)\n[Contact](https://example.com/contact)", browserArgs.Url)
 		}
 
+	case tools.HTTPFetchToolName:
+		var fetchArgs tools.HTTPFetchAction
+		if err := json.Unmarshal(args, &fetchArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling http fetch arguments: %w", err)
+		}
+
+		terminal.PrintMock("HTTP fetch:")
+		terminal.PrintKeyValue("Method", fetchArgs.Method)
+		terminal.PrintKeyValue("URL", fetchArgs.URL)
+
+		resultObj = fmt.Sprintf("# Status\nHTTP/1.1 200 OK\n\n# Headers\nContent-Type: application/json\n\n# Body\n{\"mock\": true, \"url\": %q}\n", fetchArgs.URL)
+
 	case tools.GoogleToolName:
 		var searchArgs tools.SearchAction
 		if err := json.Unmarshal(args, &searchArgs); err != nil {
@@ -154,7 +170,7 @@ func MockResponse(funcName string, args json.RawMessage) (string, error) {
 		resultObj = builder.String()
 
 	case tools.PerplexityToolName:
-		var searchArgs tools.SearchAction
+		var searchArgs tools.PerplexitySearchAction
 		if err := json.Unmarshal(args, &searchArgs); err != nil {
 			return "", fmt.Errorf("error unmarshaling search arguments: %w", err)
 		}
@@ -162,6 +178,7 @@ func MockResponse(funcName string, args json.RawMessage) (string, error) {
 		terminal.PrintMock("Perplexity search:")
 		terminal.PrintKeyValue("Query", searchArgs.Query)
 		terminal.PrintKeyValueFormat("Max results", "%d", searchArgs.MaxResults.Int())
+		terminal.PrintKeyValueFormat("History messages", "%d", len(searchArgs.History))
 
 		var builder strings.Builder
 		builder.WriteString("# Answer\n\n")
@@ -209,6 +226,206 @@ func MockResponse(funcName string, args json.RawMessage) (string, error) {
 
 		resultObj = builder.String()
 
+	case tools.GithubToolName:
+		var searchArgs tools.GithubSearchAction
+		if err := json.Unmarshal(args, &searchArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling search arguments: %w", err)
+		}
+
+		terminal.PrintMock("GitHub search:")
+		terminal.PrintKeyValue("Query", searchArgs.Query)
+		terminal.PrintKeyValue("Kind", searchArgs.Kind)
+		terminal.PrintKeyValueFormat("Max results", "%d", searchArgs.MaxResults.Int())
+
+		var builder strings.Builder
+		for i := 1; i <= min(searchArgs.MaxResults.Int(), 5); i++ {
+			builder.WriteString(fmt.Sprintf("# %d. mock-owner/mock-repo-%d\n\n", i, i))
+			builder.WriteString(fmt.Sprintf("## URL\nhttps://github.com/mock-owner/mock-repo-%d\n\n", i))
+			builder.WriteString(fmt.Sprintf("## Stars\n%d\n\n", 100*i))
+			builder.WriteString(fmt.Sprintf("## Description\n\nThis is a mock GitHub %s result %d that matches your query '%s'.\n\n", searchArgs.Kind, i, searchArgs.Query))
+		}
+		resultObj = builder.String()
+
+	case tools.MetasearchToolName:
+		var searchArgs tools.SearchAction
+		if err := json.Unmarshal(args, &searchArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling search arguments: %w", err)
+		}
+
+		terminal.PrintMock("Metasearch:")
+		terminal.PrintKeyValue("Query", searchArgs.Query)
+		terminal.PrintKeyValueFormat("Max results", "%d", searchArgs.MaxResults.Int())
+
+		engines := []string{tools.GoogleToolName, tools.DuckDuckGoToolName, tools.TavilyToolName}
+		items := make([]tools.SearchResultItem, 0, 5)
+		for i := 1; i <= min(searchArgs.MaxResults.Int(), 5); i++ {
+			items = append(items, tools.SearchResultItem{
+				Title:   fmt.Sprintf("Mock Result %d about %s", i, searchArgs.Query),
+				URL:     fmt.Sprintf("https://example.com/metasearch/result%d", i),
+				Source:  engines[(i-1)%len(engines)],
+				Snippet: fmt.Sprintf("This is a mock result %d merged from several search engines for query '%s'.", i, searchArgs.Query),
+			})
+		}
+		resultObj = tools.FormatResults(items, tools.FormatOptions{Separator: true})
+
+	case tools.AbuseIPDBToolName:
+		var checkArgs tools.AbuseIPDBAction
+		if err := json.Unmarshal(args, &checkArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling IP reputation arguments: %w", err)
+		}
+
+		terminal.PrintMock("AbuseIPDB check:")
+		terminal.PrintKeyValue("IP", checkArgs.IP)
+		terminal.PrintKeyValueFormat("Max age in days", "%d", checkArgs.MaxAgeInDays.Int())
+
+		var builder strings.Builder
+		builder.WriteString(fmt.Sprintf("# IP Reputation of %s\n\n", checkArgs.IP))
+		builder.WriteString("## Abuse Confidence Score\n42%\n\n")
+		builder.WriteString("## Total Reports\n17 from 9 distinct users\n\n")
+		builder.WriteString("## Country\nNL\n\n")
+		builder.WriteString("## ISP\nMock Hosting B.V.\n\n")
+		builder.WriteString("## Usage Type\nData Center/Web Hosting/Transit\n\n")
+		resultObj = builder.String()
+
+	case tools.HIBPToolName:
+		var hibpArgs tools.HIBPAction
+		if err := json.Unmarshal(args, &hibpArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling breach lookup arguments: %w", err)
+		}
+
+		terminal.PrintMock("HIBP lookup:")
+		terminal.PrintKeyValue("Account", hibpArgs.Account)
+
+		var builder strings.Builder
+		builder.WriteString(fmt.Sprintf("# Breaches of %s\n\n", hibpArgs.Account))
+		builder.WriteString("Found in 1 breaches\n\n")
+		builder.WriteString("## Mock Forum (forum.example.com)\n")
+		builder.WriteString("- Breach date: 2021-06-01\n")
+		builder.WriteString("- Added: 2021-07-15T10:00:00Z\n")
+		builder.WriteString("- Affected accounts: 120000\n")
+		builder.WriteString("- Data classes: Email addresses, Passwords, Usernames\n\n")
+		resultObj = builder.String()
+
+	case tools.WhoisToolName:
+		var whoisArgs tools.WhoisAction
+		if err := json.Unmarshal(args, &whoisArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling whois arguments: %w", err)
+		}
+
+		terminal.PrintMock("WHOIS lookup:")
+		terminal.PrintKeyValue("Domain", whoisArgs.Domain)
+
+		var builder strings.Builder
+		builder.WriteString(fmt.Sprintf("# WHOIS of %s\n\n", whoisArgs.Domain))
+		builder.WriteString("## Registrar\nMock Registrar, Inc.\n\n")
+		builder.WriteString("## Registrant Organization\nMock Organization\n\n")
+		builder.WriteString("## Created\n2010-04-12T08:30:00Z\n\n")
+		builder.WriteString("## Expires\n2030-04-12T08:30:00Z\n\n")
+		builder.WriteString("## Name Servers\nns1.mock-dns.net, ns2.mock-dns.net\n\n")
+		resultObj = builder.String()
+
+	case tools.DNSToolName:
+		var dnsArgs tools.DNSAction
+		if err := json.Unmarshal(args, &dnsArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling dns arguments: %w", err)
+		}
+
+		terminal.PrintMock("DNS lookup:")
+		terminal.PrintKeyValue("Domain", dnsArgs.Domain)
+		terminal.PrintKeyValue("Record types", strings.Join(dnsArgs.RecordTypes, ", "))
+
+		var builder strings.Builder
+		builder.WriteString(fmt.Sprintf("# DNS records of %s\n\n", dnsArgs.Domain))
+		builder.WriteString("## A\n93.184.216.34\n\n")
+		builder.WriteString("## MX\n10 mail.mock-dns.net\n\n")
+		builder.WriteString("## NS\nns1.mock-dns.net\nns2.mock-dns.net\n\n")
+		builder.WriteString("## TXT\n\"v=spf1 -all\"\n\n")
+		resultObj = builder.String()
+
+	case tools.WaybackToolName:
+		var waybackArgs tools.WaybackAction
+		if err := json.Unmarshal(args, &waybackArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling wayback arguments: %w", err)
+		}
+
+		terminal.PrintMock("Wayback Machine lookup:")
+		terminal.PrintKeyValue("URL", waybackArgs.URL)
+		if waybackArgs.Timestamp != "" {
+			terminal.PrintKeyValue("Timestamp", waybackArgs.Timestamp)
+		}
+
+		var builder strings.Builder
+		builder.WriteString(fmt.Sprintf("# Wayback Machine snapshots of %s\n\n", waybackArgs.URL))
+		builder.WriteString("## Closest Snapshot\n\n")
+		builder.WriteString(fmt.Sprintf("- URL: https://web.archive.org/web/20240101000000/%s\n", waybackArgs.URL))
+		builder.WriteString("- Captured: 2024-01-01 00:00:00 UTC\n")
+		builder.WriteString("- Status: 200\n")
+		resultObj = builder.String()
+
+	case tools.AttackToolName:
+		var attackArgs tools.AttackAction
+		if err := json.Unmarshal(args, &attackArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling attack arguments: %w", err)
+		}
+
+		terminal.PrintMock("ATT&CK lookup:")
+		terminal.PrintKeyValue("Query", attackArgs.Query)
+
+		var builder strings.Builder
+		builder.WriteString("# T1059: Command and Scripting Interpreter\n\n")
+		builder.WriteString("- Tactics: Execution\n")
+		builder.WriteString("- Platforms: Linux, macOS, Windows\n")
+		builder.WriteString("- URL: https://attack.mitre.org/techniques/T1059\n\n")
+		builder.WriteString("## Description\n\nAdversaries may abuse command and script interpreters to execute commands, scripts, or binaries.\n\n")
+		builder.WriteString("## Detection\n\nMonitor process execution with command-line arguments.\n\n")
+		builder.WriteString("## Mitigations\n\n- M1038 Execution Prevention: Block execution of code on a system through application control.\n")
+		resultObj = builder.String()
+
+	case tools.IPInfoToolName:
+		var ipinfoArgs tools.IPInfoAction
+		if err := json.Unmarshal(args, &ipinfoArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling ipinfo arguments: %w", err)
+		}
+
+		terminal.PrintMock("IP information:")
+		terminal.PrintKeyValue("IP", ipinfoArgs.IP)
+
+		var builder strings.Builder
+		builder.WriteString(fmt.Sprintf("# IP information of %s\n\n", ipinfoArgs.IP))
+		builder.WriteString("- Hostname: dns.google\n")
+		builder.WriteString("- ASN: AS15169\n")
+		builder.WriteString("- Organization: Google LLC\n")
+		builder.WriteString("- Location: Mountain View, California, US\n")
+		builder.WriteString("- Coordinates: 37.4056,-122.0775\n")
+		builder.WriteString("- Timezone: America/Los_Angeles\n")
+		resultObj = builder.String()
+
+	case tools.ExploitDBToolName:
+		var exploitdbArgs tools.ExploitDBAction
+		if err := json.Unmarshal(args, &exploitdbArgs); err != nil {
+			return "", fmt.Errorf("error unmarshaling exploitdb arguments: %w", err)
+		}
+
+		terminal.PrintMock("ExploitDB search:")
+		terminal.PrintKeyValue("Query", exploitdbArgs.Query)
+		if exploitdbArgs.Platform != "" {
+			terminal.PrintKeyValue("Platform", exploitdbArgs.Platform)
+		}
+		if exploitdbArgs.Type != "" {
+			terminal.PrintKeyValue("Type", exploitdbArgs.Type)
+		}
+
+		var builder strings.Builder
+		builder.WriteString(fmt.Sprintf("# ExploitDB exploits matching %q\n\n", exploitdbArgs.Query))
+		builder.WriteString("## EDB-ID 50689: PolicyKit-1 0.105-31 - Privilege Escalation\n\n")
+		builder.WriteString("- Platform: linux\n")
+		builder.WriteString("- Type: local\n")
+		builder.WriteString("- Published: 2022-01-27\n")
+		builder.WriteString("- Codes: CVE-2021-4034\n")
+		builder.WriteString("- Path: exploits/linux/local/50689.txt\n")
+		builder.WriteString("- URL: https://www.exploit-db.com/exploits/50689\n")
+		resultObj = builder.String()
+
 	case tools.SearchToolName:
 		var searchArgs tools.ComplexSearch
 		if err := json.Unmarshal(args, &searchArgs); err != nil {
@@ -45,6 +45,11 @@ var describeFuncInfo = FunctionInfo{
 	},
 }
 
+var healthFuncInfo = FunctionInfo{
+	Name:        "health",
+	Description: "Check API keys and proxies of the configured search tools by a minimal request of every tool",
+}
+
 // GetAvailableFunctions returns all available functions with their descriptions
 func GetAvailableFunctions() []FunctionInfo {
 	funcInfos := []FunctionInfo{}
@@ -63,7 +68,7 @@ func GetAvailableFunctions() []FunctionInfo {
 	}
 
 	// Add custom ftester functions
-	funcInfos = append(funcInfos, describeFuncInfo)
+	funcInfos = append(funcInfos, describeFuncInfo, healthFuncInfo)
 
 	return funcInfos
 }
@@ -74,6 +79,9 @@ func GetFunctionInfo(funcName string) (FunctionInfo, error) {
 	if funcName == "describe" {
 		return describeFuncInfo, nil
 	}
+	if funcName == "health" {
+		return healthFuncInfo, nil
+	}
 
 	definitions := tools.GetRegistryDefinitions()
 
@@ -290,12 +298,23 @@ func getStructTypeForFunction(funcName string) (reflect.Type, error) {
 		tools.TerminalToolName:          &tools.TerminalAction{},
 		tools.FileToolName:              &tools.FileAction{},
 		tools.BrowserToolName:           &tools.Browser{},
-		tools.GoogleToolName:            &tools.SearchAction{},
+		tools.HTTPFetchToolName:         &tools.HTTPFetchAction{},
+		tools.GoogleToolName:            &tools.GoogleSearchAction{},
 		tools.DuckDuckGoToolName:        &tools.SearchAction{},
 		tools.TavilyToolName:            &tools.SearchAction{},
 		tools.TraversaalToolName:        &tools.SearchAction{},
-		tools.PerplexityToolName:        &tools.SearchAction{},
+		tools.PerplexityToolName:        &tools.PerplexitySearchAction{},
 		tools.SearxngToolName:           &tools.SearchAction{},
+		tools.GithubToolName:            &tools.GithubSearchAction{},
+		tools.MetasearchToolName:        &tools.SearchAction{},
+		tools.AbuseIPDBToolName:         &tools.AbuseIPDBAction{},
+		tools.HIBPToolName:              &tools.HIBPAction{},
+		tools.WhoisToolName:             &tools.WhoisAction{},
+		tools.DNSToolName:               &tools.DNSAction{},
+		tools.WaybackToolName:           &tools.WaybackAction{},
+		tools.AttackToolName:            &tools.AttackAction{},
+		tools.IPInfoToolName:            &tools.IPInfoAction{},
+		tools.ExploitDBToolName:         &tools.ExploitDBAction{},
 		tools.MemoristToolName:          &tools.MemoristAction{},
 		tools.SearchInMemoryToolName:    &tools.SearchInMemoryAction{},
 		tools.SearchGuideToolName:       &tools.SearchGuideAction{},
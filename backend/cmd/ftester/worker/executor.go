@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"pentagi/cmd/ftester/mocks"
 	"pentagi/pkg/config"
@@ -34,6 +35,10 @@ func (at *agentTool) IsAvailable() bool {
 	return at.handler != nil
 }
 
+func (at *agentTool) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
 // toolExecutor holds the necessary data for creating and managing tools
 type toolExecutor struct {
 	flowExecutor   tools.FlowToolsExecutor
@@ -101,6 +106,19 @@ func (te *toolExecutor) GetTool(ctx context.Context, funcName string) (tools.Too
 		containerLID = cnt.LocalID.String
 	}
 
+	resultTemplate, err := tools.LoadResultTemplate(te.cfg.SearchResultTemplatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyPool, err := tools.NewProxyPool(te.cfg.ProxyPoolURLs, te.cfg.ProxyPoolRotation)
+	if err != nil {
+		return nil, err
+	}
+
+	retryPolicy := tools.NewRetryPolicy(te.cfg.SearchRetries,
+		time.Duration(te.cfg.SearchRetryMaxDelay)*time.Second, time.Duration(te.cfg.SearchRetryDeadline)*time.Second)
+
 	// Check which tool to create based on function name
 	switch funcName {
 	case tools.TerminalToolName:
@@ -127,93 +145,290 @@ func (te *toolExecutor) GetTool(ctx context.Context, funcName string) (tools.Too
 		), nil
 
 	case tools.BrowserToolName:
-		return tools.NewBrowserTool(
+		policy, err := tools.NewURLPolicy(te.cfg.ScraperBlockMetadata, te.cfg.ScraperAllowCIDRs, te.cfg.ScraperDenyCIDRs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create browser url policy: %w", err)
+		}
+		scope, err := tools.NewDomainScope(te.cfg.ScraperAllowDomains, te.cfg.ScraperDenyDomains)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create browser domain scope: %w", err)
+		}
+		scTLS, err := tools.NewTLSConfig(te.cfg.ScraperInsecureSkipVerify, te.cfg.ScraperCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load scraper tls settings: %w", err)
+		}
+		return tools.NewBrowserToolWithConfig(tools.BrowserConfig{
+			FlowID:          te.flowID,
+			TaskID:          te.taskID,
+			SubtaskID:       te.subtaskID,
+			DataDir:         te.cfg.DataDir,
+			ScPrvURL:        te.cfg.ScraperPrivateURL,
+			ScPubURL:        te.cfg.ScraperPublicURL,
+			ScTLS:           scTLS,
+			MaxScreenshots:  te.cfg.ScraperMaxScreenshots,
+			MaxContentBytes: te.cfg.ScraperMaxContentBytes,
+			MinSizes: tools.MinContentSizes{
+				Markdown: te.cfg.ScraperMinMdContentSize,
+				HTML:     te.cfg.ScraperMinHtmlContentSize,
+				Image:    te.cfg.ScraperMinImgContentSize,
+			},
+			DedupScreenshots: te.cfg.ScraperDedupScreenshots,
+			Policy:           policy,
+			Scope:            scope,
+			Screenshots:      te.proxies.GetScreenshotProvider(),
+		}), nil
+
+	case tools.HTTPFetchToolName:
+		fetchTLS, err := tools.NewTLSConfig(te.cfg.HTTPFetchInsecureSkipVerify, te.cfg.HTTPFetchCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load http fetch tls settings: %w", err)
+		}
+		policy, err := tools.NewURLPolicy(te.cfg.ScraperBlockMetadata, te.cfg.ScraperAllowCIDRs, te.cfg.ScraperDenyCIDRs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create http fetch url policy: %w", err)
+		}
+		scope, err := tools.NewDomainScope(te.cfg.ScraperAllowDomains, te.cfg.ScraperDenyDomains)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create http fetch domain scope: %w", err)
+		}
+		return tools.NewHTTPFetchTool(
 			te.flowID,
 			te.taskID,
 			te.subtaskID,
-			te.cfg.DataDir,
-			te.cfg.ScraperPrivateURL,
-			te.cfg.ScraperPublicURL,
-			te.proxies.GetScreenshotProvider(),
+			te.cfg.HTTPFetchEnabled,
+			te.cfg.ProxyURL,
+			proxyPool,
+			time.Duration(te.cfg.HTTPFetchTimeout)*time.Second,
+			te.cfg.HTTPFetchMaxBodySize,
+			fetchTLS,
+			policy,
+			scope,
 		), nil
 
 	case tools.GoogleToolName:
-		return tools.NewGoogleTool(
+		return tools.NewGoogleToolWithConfig(tools.GoogleConfig{
+			FlowID:       te.flowID,
+			TaskID:       te.taskID,
+			SubtaskID:    te.subtaskID,
+			APIKey:       te.cfg.GoogleAPIKey,
+			CXKey:        te.cfg.GoogleCXKey,
+			LRKey:        te.cfg.GoogleLRKey,
+			SafeSearch:   te.cfg.SearchSafeSearch,
+			ProxyURL:     te.cfg.ProxyURL,
+			Limits:       tools.ResultLimits{Default: te.cfg.GoogleDefaultResults, Max: te.cfg.GoogleMaxResults},
+			DryRun:       te.cfg.SearchDryRun,
+			SourceFooter: te.cfg.SearchSourceFooter,
+			Highlight:    te.cfg.SearchHighlightTerms,
+			Template:     resultTemplate,
+			SearchLog:    te.proxies.GetSearchLogProvider(),
+		}), nil
+
+	case tools.DuckDuckGoToolName:
+		return tools.NewDuckDuckGoToolWithConfig(tools.DuckDuckGoConfig{
+			FlowID:       te.flowID,
+			TaskID:       te.taskID,
+			SubtaskID:    te.subtaskID,
+			Enabled:      te.cfg.DuckDuckGoEnabled,
+			ProxyURL:     te.cfg.ProxyURL,
+			ProxyPool:    proxyPool,
+			Retry:        retryPolicy,
+			SafeSearch:   te.cfg.SearchSafeSearch,
+			Limits:       tools.ResultLimits{Default: te.cfg.DuckDuckGoDefaultResults, Max: te.cfg.DuckDuckGoMaxResults},
+			SourceFooter: te.cfg.SearchSourceFooter,
+			Template:     resultTemplate,
+			SearchLog:    te.proxies.GetSearchLogProvider(),
+		}), nil
+
+	case tools.TavilyToolName:
+		return tools.NewTavilyToolWithConfig(tools.TavilyConfig{
+			FlowID:       te.flowID,
+			TaskID:       te.taskID,
+			SubtaskID:    te.subtaskID,
+			APIKey:       te.cfg.TavilyAPIKey,
+			ProxyURL:     te.cfg.ProxyURL,
+			Timeout:      time.Duration(te.cfg.TavilyTimeout) * time.Second,
+			Retry:        retryPolicy,
+			MaxBody:      te.cfg.SearchMaxResponseSize,
+			Limits:       tools.ResultLimits{Default: te.cfg.TavilyDefaultResults, Max: te.cfg.TavilyMaxResults},
+			DryRun:       te.cfg.SearchDryRun,
+			SourceFooter: te.cfg.SearchSourceFooter,
+			Highlight:    te.cfg.SearchHighlightTerms,
+			SearchLog:    te.proxies.GetSearchLogProvider(),
+			Summarizer:   te.GetSummarizer(),
+		}), nil
+
+	case tools.TraversaalToolName:
+		return tools.NewTraversaalToolWithConfig(tools.TraversaalConfig{
+			FlowID:       te.flowID,
+			TaskID:       te.taskID,
+			SubtaskID:    te.subtaskID,
+			APIKey:       te.cfg.TraversaalAPIKey,
+			ProxyURL:     te.cfg.ProxyURL,
+			Timeout:      time.Duration(te.cfg.TraversaalTimeout) * time.Second,
+			Retry:        retryPolicy,
+			MaxBody:      te.cfg.SearchMaxResponseSize,
+			Limits:       tools.ResultLimits{Default: te.cfg.TraversaalDefaultResults, Max: te.cfg.TraversaalMaxResults},
+			Fields:       tools.TraversaalFields{Answer: te.cfg.TraversaalAnswerPath, Links: te.cfg.TraversaalLinksPath},
+			DryRun:       te.cfg.SearchDryRun,
+			SourceFooter: te.cfg.SearchSourceFooter,
+			SearchLog:    te.proxies.GetSearchLogProvider(),
+		}), nil
+
+	case tools.PerplexityToolName:
+		return tools.NewPerplexityToolWithConfig(tools.PerplexityConfig{
+			FlowID:           te.flowID,
+			TaskID:           te.taskID,
+			SubtaskID:        te.subtaskID,
+			APIKey:           te.cfg.PerplexityAPIKey,
+			BaseURL:          te.cfg.PerplexityServerURL,
+			ProxyURL:         te.cfg.ProxyURL,
+			Model:            te.cfg.PerplexityModel,
+			ContextSize:      te.cfg.PerplexityContextSize,
+			SystemPrompt:     te.cfg.PerplexitySystemPrompt,
+			RelatedQuestions: te.cfg.PerplexityRelatedQuestions,
+			DisableSummarize: !te.cfg.PerplexitySummarize,
+			KeepReasoning:    te.cfg.PerplexityKeepReasoning,
+			DryRun:           te.cfg.SearchDryRun,
+			SourceFooter:     te.cfg.SearchSourceFooter,
+			SearchLog:        te.proxies.GetSearchLogProvider(),
+			Summarizer:       te.GetSummarizer(),
+			Gateway:          tools.NewLLMGateway(te.cfg),
+		}), nil
+
+	case tools.SearxngToolName:
+		return tools.NewSearxngToolWithConfig(tools.SearxngConfig{
+			FlowID:       te.flowID,
+			TaskID:       te.taskID,
+			SubtaskID:    te.subtaskID,
+			BaseURL:      te.cfg.SearxngURL,
+			Categories:   te.cfg.SearxngCategories,
+			Language:     te.cfg.SearxngLanguage,
+			SafeSearch:   te.cfg.SearxngSafeSearch,
+			TimeRange:    te.cfg.SearxngTimeRange,
+			ProxyURL:     te.cfg.ProxyURL,
+			SourceFooter: te.cfg.SearchSourceFooter,
+			SearchLog:    te.proxies.GetSearchLogProvider(),
+			Summarizer:   te.GetSummarizer(),
+		}), nil
+
+	case tools.GithubToolName:
+		return tools.NewGithubTool(
 			te.flowID,
 			te.taskID,
 			te.subtaskID,
-			te.cfg.GoogleAPIKey,
-			te.cfg.GoogleCXKey,
-			te.cfg.GoogleLRKey,
+			te.cfg.GithubSearchEnabled,
+			te.cfg.GithubSearchToken,
 			te.cfg.ProxyURL,
+			tools.ResultLimits{Default: te.cfg.GithubSearchDefaultResults, Max: te.cfg.GithubSearchMaxResults},
+			te.cfg.SearchSourceFooter,
 			te.proxies.GetSearchLogProvider(),
 		), nil
 
-	case tools.DuckDuckGoToolName:
-		return tools.NewDuckDuckGoTool(
+	case tools.AbuseIPDBToolName:
+		return tools.NewAbuseIPDBTool(
 			te.flowID,
 			te.taskID,
 			te.subtaskID,
-			te.cfg.DuckDuckGoEnabled,
+			te.cfg.AbuseIPDBAPIKey,
 			te.cfg.ProxyURL,
-			"", // region (default)
-			"", // safeSearch (default)
-			"", // timeRange (default)
 			te.proxies.GetSearchLogProvider(),
 		), nil
 
-	case tools.TavilyToolName:
-		return tools.NewTavilyTool(
+	case tools.HIBPToolName:
+		return tools.NewHIBPTool(
 			te.flowID,
 			te.taskID,
 			te.subtaskID,
-			te.cfg.TavilyAPIKey,
+			te.cfg.HIBPAPIKey,
 			te.cfg.ProxyURL,
+			retryPolicy,
 			te.proxies.GetSearchLogProvider(),
-			te.GetSummarizer(),
 		), nil
 
-	case tools.TraversaalToolName:
-		return tools.NewTraversaalTool(
+	case tools.WhoisToolName:
+		return tools.NewWhoisTool(
 			te.flowID,
 			te.taskID,
 			te.subtaskID,
-			te.cfg.TraversaalAPIKey,
+			te.cfg.WhoisEnabled,
+			te.cfg.WhoisServer,
 			te.cfg.ProxyURL,
-			te.proxies.GetSearchLogProvider(),
+			time.Duration(te.cfg.WhoisTimeout)*time.Second,
 		), nil
 
-	case tools.PerplexityToolName:
-		return tools.NewPerplexityTool(
+	case tools.DNSToolName:
+		return tools.NewDNSTool(
 			te.flowID,
 			te.taskID,
 			te.subtaskID,
-			te.cfg.PerplexityAPIKey,
+			te.cfg.DNSEnabled,
+			te.cfg.DNSServer,
+			time.Duration(te.cfg.DNSTimeout)*time.Second,
+		), nil
+
+	case tools.WaybackToolName:
+		return tools.NewWaybackTool(
+			te.flowID,
+			te.taskID,
+			te.subtaskID,
+			te.cfg.WaybackEnabled,
 			te.cfg.ProxyURL,
-			te.cfg.PerplexityModel,
-			te.cfg.PerplexityContextSize,
-			0, // default temperature
-			0, // default topP
-			0, // default maxTokens
-			0, // default timeout
-			te.proxies.GetSearchLogProvider(),
-			te.GetSummarizer(),
+			time.Duration(te.cfg.WaybackTimeout)*time.Second,
 		), nil
 
-	case tools.SearxngToolName:
-		return tools.NewSearxngTool(
+	case tools.AttackToolName:
+		return tools.NewAttackTool(
 			te.flowID,
 			te.taskID,
 			te.subtaskID,
-			te.cfg.SearxngURL,
-			te.cfg.SearxngCategories,
-			te.cfg.SearxngLanguage,
-			te.cfg.SearxngSafeSearch,
-			te.cfg.SearxngTimeRange,
+			te.cfg.AttackEnabled,
+			te.cfg.AttackDataPath,
+		), nil
+
+	case tools.IPInfoToolName:
+		return tools.NewIPInfoTool(
+			te.flowID,
+			te.taskID,
+			te.subtaskID,
+			te.cfg.IPInfoEnabled,
+			te.cfg.IPInfoToken,
 			te.cfg.ProxyURL,
-			0, // timeout (will use default)
+			time.Duration(te.cfg.IPInfoTimeout)*time.Second,
+		), nil
+
+	case tools.ExploitDBToolName:
+		return tools.NewExploitDBTool(
+			te.flowID,
+			te.taskID,
+			te.subtaskID,
+			te.cfg.ExploitDBPath,
+		), nil
+
+	case tools.MetasearchToolName:
+		engines := make(map[string]tools.Tool)
+		for _, name := range []string{
+			tools.GoogleToolName,
+			tools.DuckDuckGoToolName,
+			tools.TavilyToolName,
+			tools.SearxngToolName,
+		} {
+			engine, err := te.GetTool(ctx, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create metasearch engine %s: %w", name, err)
+			}
+			engines[name] = engine
+		}
+
+		return tools.NewMetasearchTool(
+			te.flowID,
+			te.taskID,
+			te.subtaskID,
+			engines,
+			0, // default timeout
+			tools.ResultLimits{Default: te.cfg.MetasearchDefaultResults, Max: te.cfg.MetasearchMaxResults},
+			te.cfg.SearchSourceFooter,
+			resultTemplate,
 			te.proxies.GetSearchLogProvider(),
-			te.GetSummarizer(),
 		), nil
 
 	case tools.SearchInMemoryToolName:
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 
 	"pentagi/cmd/ftester/mocks"
 	"pentagi/pkg/config"
@@ -249,6 +251,11 @@ func (t *tester) Execute(args []string) error {
 		return t.executeDescribe(t.ctx, funcArgs.(*DescribeParams))
 	}
 
+	// Handle the health function
+	if funcName == "health" {
+		return t.executeHealth(t.ctx)
+	}
+
 	// Check if arguments are provided
 	if len(args) == 1 {
 		terminal.PrintInfo("No arguments provided, using interactive mode")
@@ -279,6 +286,38 @@ func (t *tester) Execute(args []string) error {
 	return t.toolExecutor.ExecuteFunctionWithMode(t.ctx, funcName, funcArgs)
 }
 
+// executeHealth runs health checks of the configured search tools concurrently,
+// tools without keys or disabled ones are reported as not configured and aren't checked
+func (t *tester) executeHealth(ctx context.Context) error {
+	searchTools := make(map[string]tools.Tool)
+	for _, name := range tools.GetToolsByType()[tools.SearchNetworkToolType] {
+		tool, err := t.toolExecutor.GetTool(ctx, name)
+		if err != nil {
+			terminal.PrintWarning("Failed to create %s tool: %v", name, err)
+			continue
+		}
+		searchTools[name] = tool
+	}
+
+	terminal.PrintHeader("Health of search tools:")
+	failed := tools.CheckToolsHealth(ctx, searchTools)
+	for _, name := range slices.Sorted(maps.Keys(searchTools)) {
+		if err, ok := failed[name]; ok {
+			terminal.PrintError("%s: %v", name, err)
+		} else if searchTools[name].IsAvailable() {
+			terminal.PrintSuccess("%s: ok", name)
+		} else {
+			terminal.PrintKeyValue(name, "not configured")
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d search tools failed the health check", len(failed))
+	}
+
+	return nil
+}
+
 // executeDescribe shows information about tasks and subtasks for the current flow
 func (t *tester) executeDescribe(ctx context.Context, params *DescribeParams) error {
 	// If flowID is 0, show list of all flows
@@ -562,6 +601,8 @@ func (t *tester) showGeneralHelp() error {
 	terminal.PrintHeader("Built-in functions:")
 	terminal.PrintValueFormat("  %-20s", "describe")
 	fmt.Printf(" - %s\n", describeFuncInfo.Description)
+	terminal.PrintValueFormat("  %-20s", "health")
+	fmt.Printf(" - %s\n", healthFuncInfo.Description)
 
 	// Define type names for better readability
 	typeNames := map[tools.ToolType]string{
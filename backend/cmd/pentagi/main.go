@@ -21,6 +21,7 @@ import (
 	obs "pentagi/pkg/observability"
 	"pentagi/pkg/providers"
 	router "pentagi/pkg/server"
+	"pentagi/pkg/tools"
 
 	_ "github.com/lib/pq"
 	"github.com/pressly/goose/v3"
@@ -65,6 +66,23 @@ func main() {
 
 	obs.Observer.StartProcessMetricCollect(attribute.String("component", "server"))
 	obs.Observer.StartGoRuntimeMetricCollect(attribute.String("component", "server"))
+	if err := tools.InitMetrics(obs.Observer); err != nil {
+		log.Printf("Unable to init tools metrics: %v\n", err)
+	}
+	tools.InitSearchRateLimit(cfg.SearchRateLimit)
+	searchTimeouts := make(map[string]time.Duration, len(cfg.SearchTimeouts))
+	for engine, timeout := range cfg.SearchTimeouts {
+		searchTimeouts[engine] = time.Duration(timeout) * time.Second
+	}
+	tools.InitDefaultTimeouts(searchTimeouts)
+	tools.InitSearchPricing(cfg.SearchRequestPrices, cfg.SearchResultPrices, cfg.SearchTokenPrices)
+	tools.InitFlowBudget(cfg.FlowBudgetUSD)
+	tools.InitHTTPTransport(cfg.SearchMaxIdleConns, cfg.SearchMaxIdleConnsPerHost,
+		time.Duration(cfg.SearchIdleConnTimeout)*time.Second)
+	tools.InitResultPages(cfg.ToolResultPageSize)
+	if err := tools.InitDisabledTools(cfg.DisabledTools); err != nil {
+		log.Printf("Unable to disable tools: %v\n", err)
+	}
 
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
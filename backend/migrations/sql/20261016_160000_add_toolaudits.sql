@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE toolaudits (
+  id                 BIGINT              PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  initiator          MSGCHAIN_TYPE       NOT NULL DEFAULT 'primary_agent',
+  executor           MSGCHAIN_TYPE       NOT NULL DEFAULT 'primary_agent',
+  name               TEXT                NOT NULL,
+  args               TEXT                NOT NULL DEFAULT '',
+  success            BOOLEAN             NOT NULL,
+  error              TEXT                NOT NULL DEFAULT '',
+  result_length      BIGINT              NOT NULL DEFAULT 0,
+  duration_seconds   DOUBLE PRECISION    NOT NULL DEFAULT 0.0,
+  flow_id            BIGINT              NOT NULL REFERENCES flows(id) ON DELETE CASCADE,
+  task_id            BIGINT              NULL REFERENCES tasks(id) ON DELETE CASCADE,
+  subtask_id         BIGINT              NULL REFERENCES subtasks(id) ON DELETE CASCADE,
+  created_at         TIMESTAMPTZ         DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX toolaudits_name_idx ON toolaudits(name);
+CREATE INDEX toolaudits_success_idx ON toolaudits(success);
+CREATE INDEX toolaudits_flow_id_idx ON toolaudits(flow_id);
+CREATE INDEX toolaudits_task_id_idx ON toolaudits(task_id);
+CREATE INDEX toolaudits_subtask_id_idx ON toolaudits(subtask_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE toolaudits;
+-- +goose StatementEnd
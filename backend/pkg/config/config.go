@@ -49,8 +49,66 @@ type Config struct {
 	CookieSigningSalt string `env:"COOKIE_SIGNING_SALT"`
 
 	// Scraper (browser)
-	ScraperPublicURL  string `env:"SCRAPER_PUBLIC_URL"`
-	ScraperPrivateURL string `env:"SCRAPER_PRIVATE_URL"`
+	ScraperPublicURL        string `env:"SCRAPER_PUBLIC_URL"`
+	ScraperPrivateURL       string `env:"SCRAPER_PRIVATE_URL"`
+	ScraperMaxScreenshots   int    `env:"SCRAPER_MAX_SCREENSHOTS" envDefault:"4"`
+	ScraperMaxContentBytes  int    `env:"SCRAPER_MAX_CONTENT_BYTES" envDefault:"0"`
+	ScraperDedupScreenshots bool   `env:"SCRAPER_DEDUP_SCREENSHOTS" envDefault:"false"`
+
+	// Minimum sizes in bytes of the scraped markdown, html and screenshots (0 keeps the defaults)
+	ScraperMinMdContentSize   int `env:"SCRAPER_MIN_MD_CONTENT_SIZE" envDefault:"0"`
+	ScraperMinHtmlContentSize int `env:"SCRAPER_MIN_HTML_CONTENT_SIZE" envDefault:"0"`
+	ScraperMinImgContentSize  int `env:"SCRAPER_MIN_IMG_CONTENT_SIZE" envDefault:"0"`
+
+	// Targets of the browser: cloud metadata endpoints and networks in CIDR notation (empty lists allow any)
+	ScraperBlockMetadata bool     `env:"SCRAPER_BLOCK_METADATA" envDefault:"false"`
+	ScraperAllowCIDRs    []string `env:"SCRAPER_ALLOW_CIDRS"`
+	ScraperDenyCIDRs     []string `env:"SCRAPER_DENY_CIDRS"`
+
+	// Scope of the browser by domain names, "*.example.com" matches any subdomain (empty lists allow any)
+	ScraperAllowDomains []string `env:"SCRAPER_ALLOW_DOMAINS"`
+	ScraperDenyDomains  []string `env:"SCRAPER_DENY_DOMAINS"`
+
+	// TLS of the private scraper URL: the bundled scraper has a self-signed certificate,
+	// so it's trusted either by skipping the verification or by the CA file in PEM format
+	ScraperInsecureSkipVerify bool   `env:"SCRAPER_INSECURE_SKIP_VERIFY" envDefault:"true"`
+	ScraperCAFile             string `env:"SCRAPER_CA_FILE"`
+
+	// HTTP fetch tool for arbitrary API requests (timeout in seconds)
+	HTTPFetchEnabled     bool `env:"HTTP_FETCH_ENABLED" envDefault:"false"`
+	HTTPFetchTimeout     int  `env:"HTTP_FETCH_TIMEOUT" envDefault:"30"`
+	HTTPFetchMaxBodySize int  `env:"HTTP_FETCH_MAX_BODY_SIZE" envDefault:"65536"`
+
+	// TLS of the targets requested by the HTTP fetch tool, certificates are verified by default,
+	// self-signed ones of the lab need either the insecure mode or the CA file in PEM format
+	HTTPFetchInsecureSkipVerify bool   `env:"HTTP_FETCH_INSECURE_SKIP_VERIFY" envDefault:"false"`
+	HTTPFetchCAFile             string `env:"HTTP_FETCH_CA_FILE"`
+
+	// WHOIS lookup tool (timeout in seconds)
+	WhoisEnabled bool   `env:"WHOIS_ENABLED" envDefault:"false"`
+	WhoisServer  string `env:"WHOIS_SERVER"`
+	WhoisTimeout int    `env:"WHOIS_TIMEOUT" envDefault:"30"`
+
+	// DNS lookup tool (timeout in seconds)
+	DNSEnabled bool   `env:"DNS_ENABLED" envDefault:"false"`
+	DNSServer  string `env:"DNS_SERVER"`
+	DNSTimeout int    `env:"DNS_TIMEOUT" envDefault:"10"`
+
+	// Wayback Machine lookup tool (timeout in seconds)
+	WaybackEnabled bool `env:"WAYBACK_ENABLED" envDefault:"false"`
+	WaybackTimeout int  `env:"WAYBACK_TIMEOUT" envDefault:"30"`
+
+	// MITRE ATT&CK lookup tool, the embedded dataset is used when the path to STIX bundle is empty
+	AttackEnabled  bool   `env:"ATTACK_ENABLED" envDefault:"false"`
+	AttackDataPath string `env:"ATTACK_DATA_PATH"`
+
+	// IPInfo lookup tool, the token is optional (timeout in seconds)
+	IPInfoEnabled bool   `env:"IPINFO_ENABLED" envDefault:"false"`
+	IPInfoToken   string `env:"IPINFO_TOKEN"`
+	IPInfoTimeout int    `env:"IPINFO_TIMEOUT" envDefault:"30"`
+
+	// Offline ExploitDB search tool, it's available when the path to files_exploits.csv exists
+	ExploitDBPath string `env:"EXPLOITDB_PATH"`
 
 	// OpenAI
 	OpenAIKey       string `env:"OPEN_AI_KEY"`
@@ -126,15 +184,35 @@ type Config struct {
 	PublicURL string `env:"PUBLIC_URL" envDefault:""`
 
 	// Traversaal search engine
-	TraversaalAPIKey string `env:"TRAVERSAAL_API_KEY"`
+	TraversaalAPIKey  string `env:"TRAVERSAAL_API_KEY"`
+	TraversaalTimeout int    `env:"TRAVERSAAL_TIMEOUT" envDefault:"30"`
+
+	// Paths of the answer and the links in Traversaal response with keys joined by dots (empty keeps the defaults)
+	TraversaalAnswerPath string `env:"TRAVERSAAL_ANSWER_PATH"`
+	TraversaalLinksPath  string `env:"TRAVERSAAL_LINKS_PATH"`
 
 	// Tavily search engine
-	TavilyAPIKey string `env:"TAVILY_API_KEY"`
+	TavilyAPIKey  string `env:"TAVILY_API_KEY"`
+	TavilyTimeout int    `env:"TAVILY_TIMEOUT" envDefault:"60"`
 
 	// Perplexity search engine
-	PerplexityAPIKey      string `env:"PERPLEXITY_API_KEY"`
-	PerplexityModel       string `env:"PERPLEXITY_MODEL" envDefault:"sonar"`
-	PerplexityContextSize string `env:"PERPLEXITY_CONTEXT_SIZE" envDefault:"low"`
+	PerplexityAPIKey           string `env:"PERPLEXITY_API_KEY"`
+	PerplexityServerURL        string `env:"PERPLEXITY_SERVER_URL"`
+	PerplexityModel            string `env:"PERPLEXITY_MODEL" envDefault:"sonar"`
+	PerplexityContextSize      string `env:"PERPLEXITY_CONTEXT_SIZE"`
+	PerplexitySystemPrompt     string `env:"PERPLEXITY_SYSTEM_PROMPT"`
+	PerplexityRelatedQuestions bool   `env:"PERPLEXITY_RELATED_QUESTIONS" envDefault:"false"`
+	PerplexitySummarize        bool   `env:"PERPLEXITY_SUMMARIZE" envDefault:"true"`
+	PerplexityKeepReasoning    bool   `env:"PERPLEXITY_KEEP_REASONING" envDefault:"false"`
+	// Timeouts in seconds of connecting to Perplexity and of waiting for the response headers (0 doesn't limit the headers)
+	PerplexityConnectTimeout        int `env:"PERPLEXITY_CONNECT_TIMEOUT" envDefault:"10"`
+	PerplexityResponseHeaderTimeout int `env:"PERPLEXITY_RESPONSE_HEADER_TIMEOUT" envDefault:"0"`
+
+	// Shared OpenAI-compatible gateway for LLM-backed tools
+	LLMGatewayURL          string            `env:"LLM_GATEWAY_URL"`
+	LLMGatewayAPIKey       string            `env:"LLM_GATEWAY_API_KEY"`
+	LLMGatewayOrganization string            `env:"LLM_GATEWAY_ORGANIZATION"`
+	LLMGatewayHeaders      map[string]string `env:"LLM_GATEWAY_HEADERS"`
 
 	// Searxng search engine
 	SearxngURL        string `env:"SEARXNG_URL"`
@@ -143,6 +221,94 @@ type Config struct {
 	SearxngSafeSearch string `env:"SEARXNG_SAFESEARCH" envDefault:"0"`
 	SearxngTimeRange  string `env:"SEARXNG_TIME_RANGE"`
 
+	// GitHub code, repositories and advisories search
+	GithubSearchEnabled bool   `env:"GITHUB_SEARCH_ENABLED" envDefault:"false"`
+	GithubSearchToken   string `env:"GITHUB_SEARCH_TOKEN"`
+
+	// AbuseIPDB IP reputation lookup
+	AbuseIPDBAPIKey string `env:"ABUSEIPDB_API_KEY"`
+
+	// Have I Been Pwned breach lookup
+	HIBPAPIKey string `env:"HIBP_API_KEY"`
+
+	// Search results cache (TTL in seconds, 0 disables cache)
+	SearchCacheTTL int `env:"SEARCH_CACHE_TTL" envDefault:"0"`
+	// Age in seconds after which cached search results are marked as stale (0 disables the mark)
+	SearchCacheStaleAfter int `env:"SEARCH_CACHE_STALE_AFTER" envDefault:"86400"`
+
+	// Maximum size in bytes of search engine response body
+	SearchMaxResponseSize int64 `env:"SEARCH_MAX_RESPONSE_SIZE" envDefault:"10485760"`
+
+	// Retries of rate limited and failed by server search requests (tavily, traversaal), the ceiling
+	// of the backoff and the deadline of all attempts in seconds are shared by every retrying tool
+	SearchRetries       int `env:"SEARCH_RETRIES" envDefault:"2"`
+	SearchRetryMaxDelay int `env:"SEARCH_RETRY_MAX_DELAY" envDefault:"10"`
+	SearchRetryDeadline int `env:"SEARCH_RETRY_DEADLINE" envDefault:"60"`
+
+	// Dry run of search requests returns the outbound request instead of sending it (perplexity, tavily, traversaal)
+	SearchDryRun bool `env:"SEARCH_DRY_RUN" envDefault:"false"`
+
+	// Fake search replaces every search engine by the fake with a canned answer for offline demos
+	SearchFake bool `env:"SEARCH_FAKE" envDefault:"false"`
+
+	// Source footer names the engine at the end of every search result
+	SearchSourceFooter bool `env:"SEARCH_SOURCE_FOOTER" envDefault:"false"`
+
+	// Highlighting bolds the query terms in snippets of search results (google, tavily)
+	SearchHighlightTerms bool `env:"SEARCH_HIGHLIGHT_TERMS" envDefault:"false"`
+
+	// Path to text/template file rendering results of search tools instead of the built-in markdown (google, duckduckgo, metasearch)
+	SearchResultTemplatePath string `env:"SEARCH_RESULT_TEMPLATE_PATH"`
+
+	// Safe search level of web search tools (off, moderate, strict), empty keeps the provider default (google, duckduckgo)
+	SearchSafeSearch string `env:"SEARCH_SAFE_SEARCH"`
+
+	// Requests per second to every search engine, searches over the limit are queued by priority (0 disables)
+	SearchRateLimit float64 `env:"SEARCH_RATE_LIMIT" envDefault:"0"`
+
+	// Default request timeouts in seconds of the engines as `engine:seconds` pairs, used when the tool has no own timeout
+	SearchTimeouts map[string]int `env:"SEARCH_TIMEOUTS"`
+
+	// Prices in US dollars of the paid engines as `engine:price` pairs for the cost estimates, they override the list prices
+	SearchRequestPrices map[string]float64 `env:"SEARCH_REQUEST_PRICES"`
+	SearchResultPrices  map[string]float64 `env:"SEARCH_RESULT_PRICES"`
+	SearchTokenPrices   map[string]float64 `env:"SEARCH_TOKEN_PRICES"`
+
+	// Cap in US dollars of the estimated spend of the paid tools of every flow (0 disables)
+	FlowBudgetUSD float64 `env:"FLOW_BUDGET_USD" envDefault:"0"`
+
+	// Connection pool of the HTTP transports shared by the tools (idle timeout in seconds, 0 keeps the defaults)
+	SearchMaxIdleConns        int `env:"SEARCH_MAX_IDLE_CONNS" envDefault:"100"`
+	SearchMaxIdleConnsPerHost int `env:"SEARCH_MAX_IDLE_CONNS_PER_HOST" envDefault:"10"`
+	SearchIdleConnTimeout     int `env:"SEARCH_IDLE_CONN_TIMEOUT" envDefault:"90"`
+
+	// Summaries of CVEs referenced in search results looked up in NVD (the API key is optional)
+	CVEEnrichEnabled bool   `env:"CVE_ENRICH_ENABLED" envDefault:"false"`
+	NVDAPIKey        string `env:"NVD_API_KEY"`
+
+	// Audit trail of tool calls stored in the database (name, truncated args, duration, outcome)
+	ToolAuditEnabled bool `env:"TOOL_AUDIT_ENABLED" envDefault:"false"`
+
+	// Page size in bytes of long results of environment and search tools, the rest pages are read by continuation token (0 disables)
+	ToolResultPageSize int `env:"TOOL_RESULT_PAGE_SIZE" envDefault:"0"`
+
+	// Tools turned off for all flows, they are hidden from agents and their calls are rejected
+	DisabledTools []string `env:"DISABLED_TOOLS"`
+
+	// Default and maximum number of results per search tool (0 keeps the tool limits)
+	DuckDuckGoDefaultResults   int `env:"DUCKDUCKGO_DEFAULT_RESULTS" envDefault:"0"`
+	DuckDuckGoMaxResults       int `env:"DUCKDUCKGO_MAX_RESULTS" envDefault:"0"`
+	GoogleDefaultResults       int `env:"GOOGLE_DEFAULT_RESULTS" envDefault:"0"`
+	GoogleMaxResults           int `env:"GOOGLE_MAX_RESULTS" envDefault:"0"`
+	TavilyDefaultResults       int `env:"TAVILY_DEFAULT_RESULTS" envDefault:"0"`
+	TavilyMaxResults           int `env:"TAVILY_MAX_RESULTS" envDefault:"0"`
+	TraversaalDefaultResults   int `env:"TRAVERSAAL_DEFAULT_RESULTS" envDefault:"0"`
+	TraversaalMaxResults       int `env:"TRAVERSAAL_MAX_RESULTS" envDefault:"0"`
+	GithubSearchDefaultResults int `env:"GITHUB_SEARCH_DEFAULT_RESULTS" envDefault:"0"`
+	GithubSearchMaxResults     int `env:"GITHUB_SEARCH_MAX_RESULTS" envDefault:"0"`
+	MetasearchDefaultResults   int `env:"METASEARCH_DEFAULT_RESULTS" envDefault:"0"`
+	MetasearchMaxResults       int `env:"METASEARCH_MAX_RESULTS" envDefault:"0"`
+
 	// Assistant
 	AssistantUseAgents                bool `env:"ASSISTANT_USE_AGENTS" envDefault:"false"`
 	AssistantSummarizerPreserveLast   bool `env:"ASSISTANT_SUMMARIZER_PRESERVE_LAST" envDefault:"true"`
@@ -155,6 +321,10 @@ type Config struct {
 	// Proxy
 	ProxyURL string `env:"PROXY_URL"`
 
+	// Pool of proxies rotated per request by the scraping tools (round-robin or random)
+	ProxyPoolURLs     []string `env:"PROXY_POOL_URLS"`
+	ProxyPoolRotation string   `env:"PROXY_POOL_ROTATION" envDefault:"round-robin"`
+
 	// SSL Trusted CA Certificate Path (for external communication with LLM backends)
 	ExternalSSLCAPath   string `env:"EXTERNAL_SSL_CA_PATH" envDefault:""`
 	ExternalSSLInsecure bool   `env:"EXTERNAL_SSL_INSECURE" envDefault:"false"`
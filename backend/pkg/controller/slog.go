@@ -7,6 +7,9 @@ import (
 
 	"pentagi/pkg/database"
 	"pentagi/pkg/graph/subscriptions"
+	"pentagi/pkg/tools"
+
+	"github.com/sirupsen/logrus"
 )
 
 type FlowSearchLogWorker interface {
@@ -20,6 +23,17 @@ type FlowSearchLogWorker interface {
 		taskID *int64,
 		subtaskID *int64,
 	) (int64, error)
+	PutLogWithStats(
+		ctx context.Context,
+		initiator database.MsgchainType,
+		executor database.MsgchainType,
+		engine database.SearchengineType,
+		query string,
+		result string,
+		stats tools.SearchStats,
+		taskID *int64,
+		subtaskID *int64,
+	) (int64, error)
 	GetLog(ctx context.Context, msgID int64) (database.Searchlog, error)
 }
 
@@ -72,6 +86,34 @@ func (slw *flowSearchLogWorker) PutLog(
 	return slLog.ID, nil
 }
 
+func (slw *flowSearchLogWorker) PutLogWithStats(
+	ctx context.Context,
+	initiator database.MsgchainType,
+	executor database.MsgchainType,
+	engine database.SearchengineType,
+	query string,
+	result string,
+	stats tools.SearchStats,
+	taskID *int64,
+	subtaskID *int64,
+) (int64, error) {
+	slLogID, err := slw.PutLog(ctx, initiator, executor, engine, query, result, taskID, subtaskID)
+	if err != nil {
+		return 0, err
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"flow_id":       slw.flowID,
+		"search_log_id": slLogID,
+		"engine":        engine,
+		"duration_ms":   stats.Duration.Milliseconds(),
+		"result_count":  stats.ResultCount,
+		"cached":        stats.Cached,
+	}).Info("search completed")
+
+	return slLogID, nil
+}
+
 func (slw *flowSearchLogWorker) GetLog(ctx context.Context, msgID int64) (database.Searchlog, error) {
 	msg, err := slw.db.GetFlowSearchLog(ctx, database.GetFlowSearchLogParams{
 		ID:     msgID,
@@ -469,6 +469,10 @@ const (
 	SearchengineTypeDuckduckgo SearchengineType = "duckduckgo"
 	SearchengineTypePerplexity SearchengineType = "perplexity"
 	SearchengineTypeSearxng    SearchengineType = "searxng"
+	SearchengineTypeGithub     SearchengineType = "github"
+	SearchengineTypeMetasearch SearchengineType = "metasearch"
+	SearchengineTypeAbuseipdb  SearchengineType = "abuseipdb"
+	SearchengineTypeHibp       SearchengineType = "hibp"
 )
 
 func (e *SearchengineType) Scan(src interface{}) error {
@@ -1059,6 +1063,22 @@ type Termlog struct {
 	SubtaskID   sql.NullInt64 `json:"subtask_id"`
 }
 
+type Toolaudit struct {
+	ID              int64         `json:"id"`
+	Initiator       MsgchainType  `json:"initiator"`
+	Executor        MsgchainType  `json:"executor"`
+	Name            string        `json:"name"`
+	Args            string        `json:"args"`
+	Success         bool          `json:"success"`
+	Error           string        `json:"error"`
+	ResultLength    int64         `json:"result_length"`
+	DurationSeconds float64       `json:"duration_seconds"`
+	FlowID          int64         `json:"flow_id"`
+	TaskID          sql.NullInt64 `json:"task_id"`
+	SubtaskID       sql.NullInt64 `json:"subtask_id"`
+	CreatedAt       sql.NullTime  `json:"created_at"`
+}
+
 type Toolcall struct {
 	ID              int64           `json:"id"`
 	CallID          string          `json:"call_id"`
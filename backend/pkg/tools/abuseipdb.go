@@ -0,0 +1,255 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"pentagi/pkg/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	abuseipdbAPIURL         = "https://api.abuseipdb.com/api/v2"
+	abuseipdbDefaultMaxAge  = 90
+	abuseipdbMaxAge         = 365
+	abuseipdbTimeout        = 30 * time.Second
+	abuseipdbHealthCheckIP  = "127.0.0.1"
+	abuseipdbHealthCheckAge = 1
+)
+
+type abuseipdbReport struct {
+	IPAddress            string   `json:"ipAddress"`
+	IsPublic             bool     `json:"isPublic"`
+	IPVersion            int      `json:"ipVersion"`
+	IsWhitelisted        *bool    `json:"isWhitelisted"`
+	AbuseConfidenceScore int      `json:"abuseConfidenceScore"`
+	CountryCode          string   `json:"countryCode"`
+	UsageType            string   `json:"usageType"`
+	ISP                  string   `json:"isp"`
+	Domain               string   `json:"domain"`
+	Hostnames            []string `json:"hostnames"`
+	IsTor                bool     `json:"isTor"`
+	TotalReports         int      `json:"totalReports"`
+	NumDistinctUsers     int      `json:"numDistinctUsers"`
+	LastReportedAt       string   `json:"lastReportedAt"`
+}
+
+type abuseipdbCheckResult struct {
+	Data abuseipdbReport `json:"data"`
+}
+
+type abuseipdb struct {
+	flowID    int64
+	taskID    *int64
+	subtaskID *int64
+	apiKey    string
+	proxyURL  string
+	apiURL    string
+	cache     CacheProvider
+	slp       SearchLogProvider
+	tracer    Tracer
+}
+
+func NewAbuseIPDBTool(flowID int64, taskID, subtaskID *int64,
+	apiKey, proxyURL string, slp SearchLogProvider,
+) Tool {
+	return &abuseipdb{
+		flowID:    flowID,
+		taskID:    taskID,
+		subtaskID: subtaskID,
+		apiKey:    apiKey,
+		proxyURL:  proxyURL,
+		slp:       slp,
+	}
+}
+
+// Handle looks up the reputation of the IP address reported to AbuseIPDB
+func (a *abuseipdb) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action AbuseIPDBAction
+	ctx, emitter := startTrace(ctx, a.tracer)
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal abuseipdb action")
+		return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+	}
+
+	maxAge := int(action.MaxAgeInDays)
+	if maxAge < 1 {
+		maxAge = abuseipdbDefaultMaxAge
+	} else if maxAge > abuseipdbMaxAge {
+		maxAge = abuseipdbMaxAge
+	}
+
+	ip := strings.TrimSpace(action.IP)
+	logger = logger.WithFields(logrus.Fields{
+		"ip":              ip,
+		"max_age_in_days": maxAge,
+	})
+
+	if net.ParseIP(ip) == nil {
+		logger.Error("invalid IP address to check in abuseipdb")
+		return fmt.Sprintf("invalid IP address %q, expected IPv4 or IPv6 address", ip), nil
+	}
+
+	cacheKey := searchCacheKey(database.SearchengineTypeAbuseipdb, ip, maxAge)
+	stats, start := SearchStats{Cached: true}, time.Now()
+	result, err := withSearchCache(ctx, a.cache, cacheKey, &stats, func() (string, error) {
+		report, err := a.check(ctx, ip, maxAge)
+		if err != nil {
+			stats.Cached = false
+			return "", err
+		}
+		stats.Cached, stats.ResultCount = false, 1
+		return a.formatReport(report), nil
+	})
+	recordSearchCall(ctx, database.SearchengineTypeAbuseipdb, stats.Cached, time.Since(start), err)
+	if err != nil {
+		emitter.Emit(searchErrorEvent(ip, err, map[string]any{
+			"tool_name":       AbuseIPDBToolName,
+			"engine":          "abuseipdb",
+			"max_age_in_days": maxAge,
+		}))
+
+		logger.WithError(err).Error("failed to check IP address in abuseipdb")
+		return fmt.Sprintf("failed to check IP address in abuseipdb: %v", err), nil
+	}
+
+	stats.Duration = time.Since(start)
+	_, _ = putSearchLog(ctx, a.slp, database.SearchengineTypeAbuseipdb, ip, result, stats, a.taskID, a.subtaskID)
+
+	return result, nil
+}
+
+func (a *abuseipdb) check(ctx context.Context, ip string, maxAge int) (*abuseipdbReport, error) {
+	apiURL := a.apiURL
+	if apiURL == "" {
+		apiURL = abuseipdbAPIURL
+	}
+
+	params := url.Values{}
+	params.Set("ipAddress", ip)
+	params.Set("maxAgeInDays", strconv.Itoa(maxAge))
+
+	reqURL := strings.TrimRight(apiURL, "/") + "/check?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Key", a.apiKey)
+
+	resp, err := a.createHTTPClient().Do(req)
+	if err != nil {
+		return nil, newNetworkError(err, "failed to do request")
+	}
+	defer resp.Body.Close()
+
+	if err := a.handleErrorResponse(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := readLimitedBody(resp.Body, defaultMaxResponseSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var result abuseipdbCheckResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	return &result.Data, nil
+}
+
+func (a *abuseipdb) handleErrorResponse(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return newSearchError(ErrAuth, "abuseipdb API key is wrong or has no access to the check endpoint")
+	case http.StatusTooManyRequests:
+		// the check endpoint has the daily quota, so retrying before its reset is useless
+		if reset := resp.Header.Get("X-RateLimit-Reset"); reset != "" {
+			if ts, err := strconv.ParseInt(reset, 10, 64); err == nil {
+				return newSearchError(ErrRateLimited, "abuseipdb daily quota exceeded, it resets at %s",
+					time.Unix(ts, 0).UTC().Format(time.RFC3339))
+			}
+		}
+		return newSearchError(ErrRateLimited, "abuseipdb daily quota exceeded")
+	case http.StatusUnprocessableEntity:
+		return fmt.Errorf("abuseipdb rejected the IP address or parameters as invalid")
+	default:
+		return newSearchError(errorKindByStatus(resp.StatusCode), "unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+func (a *abuseipdb) formatReport(report *abuseipdbReport) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("# IP Reputation of %s\n\n", report.IPAddress))
+	builder.WriteString(fmt.Sprintf("## Abuse Confidence Score\n%d%%\n\n", report.AbuseConfidenceScore))
+	builder.WriteString(fmt.Sprintf("## Total Reports\n%d from %d distinct users\n\n",
+		report.TotalReports, report.NumDistinctUsers))
+	if report.LastReportedAt != "" {
+		builder.WriteString(fmt.Sprintf("## Last Reported\n%s\n\n", report.LastReportedAt))
+	}
+	if report.CountryCode != "" {
+		builder.WriteString(fmt.Sprintf("## Country\n%s\n\n", report.CountryCode))
+	}
+	if report.ISP != "" {
+		builder.WriteString(fmt.Sprintf("## ISP\n%s\n\n", report.ISP))
+	}
+	if report.UsageType != "" {
+		builder.WriteString(fmt.Sprintf("## Usage Type\n%s\n\n", report.UsageType))
+	}
+	if report.Domain != "" {
+		builder.WriteString(fmt.Sprintf("## Domain\n%s\n\n", report.Domain))
+	}
+	if len(report.Hostnames) > 0 {
+		builder.WriteString(fmt.Sprintf("## Hostnames\n%s\n\n", strings.Join(report.Hostnames, ", ")))
+	}
+
+	var flags []string
+	if !report.IsPublic {
+		flags = append(flags, "private address")
+	}
+	if report.IsWhitelisted != nil && *report.IsWhitelisted {
+		flags = append(flags, "whitelisted")
+	}
+	if report.IsTor {
+		flags = append(flags, "Tor exit node")
+	}
+	if len(flags) > 0 {
+		builder.WriteString(fmt.Sprintf("## Flags\n%s\n\n", strings.Join(flags, ", ")))
+	}
+
+	return builder.String()
+}
+
+func (a *abuseipdb) createHTTPClient() *http.Client {
+	return newHTTPClient(a.proxyURL, resolveTimeout(AbuseIPDBToolName, 0))
+}
+
+func (a *abuseipdb) IsAvailable() bool {
+	return a.apiKey != "" && IsEnabled(AbuseIPDBToolName)
+}
+
+// HealthCheck checks the loopback address which is never reported, so it only verifies the API key,
+// note that the call is counted against the daily quota
+func (a *abuseipdb) HealthCheck(ctx context.Context) error {
+	_, err := a.check(ctx, abuseipdbHealthCheckIP, abuseipdbHealthCheckAge)
+	return err
+}
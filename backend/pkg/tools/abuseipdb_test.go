@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"pentagi/pkg/database"
+)
+
+func TestAbuseIPDBFormatReport(t *testing.T) {
+	data, err := os.ReadFile("testdata/abuseipdb_check.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var result abuseipdbCheckResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+
+	want := "# IP Reputation of 185.220.101.1\n\n" +
+		"## Abuse Confidence Score\n100%\n\n" +
+		"## Total Reports\n2891 from 412 distinct users\n\n" +
+		"## Last Reported\n2024-05-14T09:12:44+00:00\n\n" +
+		"## Country\nDE\n\n" +
+		"## ISP\nZwiebelfreunde e.V.\n\n" +
+		"## Usage Type\nReserved\n\n" +
+		"## Domain\ntorservers.net\n\n" +
+		"## Hostnames\ntor-exit-1.zbau.f3netze.de\n\n" +
+		"## Flags\nTor exit node\n\n"
+	if got := (&abuseipdb{}).formatReport(&result.Data); got != want {
+		t.Errorf("unexpected report:\n%s\nwant:\n%s", got, want)
+	}
+
+	empty := (&abuseipdb{}).formatReport(&abuseipdbReport{IPAddress: "10.0.0.1"})
+	if strings.Contains(empty, "## Country") || !strings.Contains(empty, "## Flags\nprivate address\n\n") {
+		t.Errorf("unexpected report without details:\n%s", empty)
+	}
+}
+
+func TestAbuseIPDBHandle(t *testing.T) {
+	data, err := os.ReadFile("testdata/abuseipdb_check.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	slp := &statsSearchLogProvider{}
+	a := &abuseipdb{apiKey: "key", apiURL: server.URL, slp: slp}
+
+	for _, tt := range []struct {
+		maxAge Int64
+		want   string
+	}{
+		{0, "90"},
+		{1000, "365"},
+		{30, "30"},
+	} {
+		args, _ := json.Marshal(AbuseIPDBAction{IP: " 185.220.101.1 ", MaxAgeInDays: tt.maxAge})
+		result, err := a.Handle(context.Background(), AbuseIPDBToolName, args)
+		if err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+		if !strings.HasPrefix(result, "# IP Reputation of 185.220.101.1") {
+			t.Errorf("unexpected result:\n%s", result)
+		}
+
+		last := requests[len(requests)-1]
+		if last.URL.Path != "/check" || last.Header.Get("Key") != "key" {
+			t.Errorf("unexpected request %s with key %q", last.URL.Path, last.Header.Get("Key"))
+		}
+		if got := last.URL.Query().Get("ipAddress"); got != "185.220.101.1" {
+			t.Errorf("ipAddress = %q", got)
+		}
+		if got := last.URL.Query().Get("maxAgeInDays"); got != tt.want {
+			t.Errorf("max_age_in_days %d: maxAgeInDays = %q, want %q", tt.maxAge, got, tt.want)
+		}
+	}
+
+	if len(slp.logs) != 3 {
+		t.Fatalf("expected 3 search logs, got %d", len(slp.logs))
+	}
+	if log := slp.logs[0]; log.engine != database.SearchengineTypeAbuseipdb || log.stats.ResultCount != 1 {
+		t.Errorf("unexpected search log %+v", log)
+	}
+}
+
+func TestAbuseIPDBInvalidIP(t *testing.T) {
+	a := &abuseipdb{apiKey: "key", apiURL: "http://127.0.0.1:0"}
+
+	args, _ := json.Marshal(AbuseIPDBAction{IP: "example.com"})
+	result, err := a.Handle(context.Background(), AbuseIPDBToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.HasPrefix(result, "invalid IP address") {
+		t.Errorf("unexpected result %q", result)
+	}
+}
+
+func TestAbuseIPDBErrorKinds(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		reset      string
+		kind       error
+		message    string
+	}{
+		{"unauthorized", http.StatusUnauthorized, "", ErrAuth, "API key is wrong"},
+		{"daily quota", http.StatusTooManyRequests, "1715731200", ErrRateLimited, "resets at 2024-05-15T00:00:00Z"},
+		{"daily quota without reset", http.StatusTooManyRequests, "", ErrRateLimited, "daily quota exceeded"},
+		{"unavailable", http.StatusServiceUnavailable, "", ErrUpstreamUnavailable, "unexpected status code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.reset != "" {
+					w.Header().Set("X-RateLimit-Reset", tt.reset)
+				}
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			a := &abuseipdb{apiKey: "key", apiURL: server.URL}
+			_, err := a.check(context.Background(), "185.220.101.1", 90)
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("expected %v, got %v", tt.kind, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected error to contain %q, got %v", tt.message, err)
+			}
+		})
+	}
+}
+
+func TestAbuseIPDBIsAvailable(t *testing.T) {
+	if (&abuseipdb{}).IsAvailable() {
+		t.Error("expected tool without API key to be unavailable")
+	}
+	if !(&abuseipdb{apiKey: "key"}).IsAvailable() {
+		t.Error("expected tool with API key to be available")
+	}
+}
@@ -26,12 +26,29 @@ const (
 	Markdown BrowserAction = "markdown"
 	HTML     BrowserAction = "html"
 	Links    BrowserAction = "links"
+	Text     BrowserAction = "text"
+	Crawl    BrowserAction = "crawl"
 )
 
 type Browser struct {
-	Url     string        `json:"url" jsonschema:"required" jsonschema_description:"url to open in the browser"`
-	Action  BrowserAction `json:"action" jsonschema:"required,enum=markdown,enum=html,enum=links" jsonschema_description:"action to perform in the browser. 'markdown' - Returns the content of the page in markdown format. 'html' - Returns the content of the page in html format. 'links' - Get the list of all URLs on the page to be used in later calls (e.g., open search results after the initial search lookup)."`
-	Message string        `json:"message" jsonschema:"required,title=Task result message" jsonschema_description:"Not so long message which explain what do you want to get, what format do you want to get and why do you need this to send to the user in user's language only"`
+	Url       string            `json:"url" jsonschema:"required" jsonschema_description:"url to open in the browser"`
+	Action    BrowserAction     `json:"action" jsonschema:"required,enum=markdown,enum=html,enum=links,enum=text,enum=crawl" jsonschema_description:"action to perform in the browser. 'markdown' - Returns the content of the page in markdown format. 'html' - Returns the content of the page in html format. 'text' - Returns only the main content of the page as plain text without navigation, ads and boilerplate, the most compact way to read articles and documentation. 'links' - Get the list of all URLs on the page to be used in later calls (e.g., open search results after the initial search lookup). 'crawl' - Returns markdown of the page and of pages on the same host linked from it, e.g. to read the whole documentation section at once."`
+	Method    string            `json:"method,omitempty" jsonschema_description:"HTTP method to open the page with, only for 'html' action, use POST to submit search forms or filters before capturing the page (default GET)"`
+	Body      string            `json:"body,omitempty" jsonschema_description:"Raw request body to submit with the method, only for 'html' action"`
+	FormData  map[string]string `json:"form_data,omitempty" jsonschema_description:"Form fields to submit url-encoded with the method, only for 'html' action, takes precedence over body"`
+	Depth     Int64             `json:"depth,omitempty" jsonschema:"type=integer" jsonschema_description:"How many links deep to follow from the page, only for 'crawl' action (minimum 0; maximum 2; default 0 means the page itself)"`
+	MaxPages  Int64             `json:"max_pages,omitempty" jsonschema:"type=integer" jsonschema_description:"Maximum number of pages to return, only for 'crawl' action (minimum 1; maximum 20; default 5)"`
+	WaitFor   string            `json:"wait_for,omitempty" jsonschema_description:"CSS selector of the element to wait for before capturing the page (e.g. #app .content), use it for single-page apps which render the content by scripts, not for 'crawl' action, leave empty to capture the page once it's loaded"`
+	WaitUntil string            `json:"wait_until,omitempty" jsonschema:"enum=load,enum=networkidle" jsonschema_description:"Load state to wait for before capturing the page: networkidle waits until the page stops loading data, e.g. for single-page apps whose content comes empty otherwise, not for 'crawl' action (default load)"`
+	Message   string            `json:"message" jsonschema:"required,title=Task result message" jsonschema_description:"Not so long message which explain what do you want to get, what format do you want to get and why do you need this to send to the user in user's language only"`
+}
+
+type HTTPFetchAction struct {
+	Method  string            `json:"method,omitempty" jsonschema:"enum=GET,enum=HEAD,enum=POST,enum=PUT,enum=PATCH,enum=DELETE,enum=OPTIONS" jsonschema_description:"HTTP method of the request (default GET)"`
+	URL     string            `json:"url" jsonschema:"required" jsonschema_description:"Absolute http or https url of the endpoint to request, redirects are not followed and returned as is"`
+	Headers map[string]string `json:"headers,omitempty" jsonschema_description:"Request headers (e.g. Authorization, Content-Type, Cookie)"`
+	Body    string            `json:"body,omitempty" jsonschema_description:"Raw request body to send with the method, set the Content-Type header to describe it"`
+	Message string            `json:"message" jsonschema:"required,title=Task result message" jsonschema_description:"Not so long message which explain what do you want to get by the request and why do you need this to send to the user in user's language only"`
 }
 
 type SubtaskInfo struct {
@@ -74,6 +91,11 @@ type SubtaskPatch struct {
 	Message    string             `json:"message" jsonschema:"required,title=Refinement summary" jsonschema_description:"Summary of changes made and justification for modifications to send to the user in user's language only"`
 }
 
+type ResultPageAction struct {
+	Token   string `json:"token" jsonschema:"required" jsonschema_description:"Continuation token from the end of the previous page of the tool result"`
+	Message string `json:"message" jsonschema:"required,title=Result page message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
 type TaskResult struct {
 	Success Bool   `json:"success" jsonschema:"title=Execution result,type=boolean" jsonschema_description:"True if the task was executed successfully and the user task result was reached"`
 	Result  string `json:"result" jsonschema:"required,title=Task result description" jsonschema_description:"Fully detailed report or error message of the task or subtask result what was achieved or not (in user's language only)"`
@@ -111,9 +133,90 @@ type ComplexSearch struct {
 }
 
 type SearchAction struct {
-	Query      string `json:"query" jsonschema:"required" jsonschema_description:"Query to search in the the specific search engine (e.g. google duckduckgo tavily traversaal perplexity serper etc.) Short and exact query is much better for better search result in English"`
-	MaxResults Int64  `json:"max_results" jsonschema:"required,type=integer" jsonschema_description:"Maximum number of results to return (minimum 1; maximum 10; default 5)"`
-	Message    string `json:"message" jsonschema:"required,title=Search query message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+	Query      string         `json:"query" jsonschema:"required" jsonschema_description:"Query to search in the the specific search engine (e.g. google duckduckgo tavily traversaal perplexity serper etc.) Short and exact query is much better for better search result in English"`
+	MaxResults Int64          `json:"max_results" jsonschema:"required,type=integer" jsonschema_description:"Maximum number of results to return (minimum 1; maximum 10; default 5)"`
+	Summarize  bool           `json:"summarize,omitempty" jsonschema_description:"Condense the results into the short summary with the top links to save the context, engines without the summarizer return the results as is (default false)"`
+	Priority   SearchPriority `json:"priority,omitempty" jsonschema:"enum=low,enum=normal,enum=high" jsonschema_description:"Priority of the search while searches wait for the rate limit of the engine: high for the interactive questions, low for the background enrichment (default normal)"`
+	Message    string         `json:"message" jsonschema:"required,title=Search query message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type GoogleSearchAction struct {
+	Query        string         `json:"query" jsonschema:"required" jsonschema_description:"Query to search in the google search engine. Short and exact query is much better for better search result in English"`
+	MaxResults   Int64          `json:"max_results" jsonschema:"required,type=integer" jsonschema_description:"Maximum number of results to return, results above 10 are fetched page by page (minimum 1; maximum 100; default 10)"`
+	Site         string         `json:"site,omitempty" jsonschema_description:"Restrict results to the specific site or domain (e.g. example.com), leave empty to search the whole web"`
+	FileType     string         `json:"file_type,omitempty" jsonschema_description:"Restrict results to files of the specific extension (e.g. pdf, xls, doc), leave empty for any type"`
+	DateRestrict string         `json:"date_restrict,omitempty" jsonschema_description:"Restrict results by date in format d[N], w[N], m[N] or y[N] (e.g. m6 means last 6 months), leave empty for any date"`
+	Lang         string         `json:"lang,omitempty" jsonschema_description:"Restrict results to documents in the language by ISO 639-1 code (e.g. de, ru, zh-TW) to reach non-English sources, leave empty for any language"`
+	Summarize    bool           `json:"summarize,omitempty" jsonschema_description:"Condense the results into the short summary with the top links to save the context, engines without the summarizer return the results as is (default false)"`
+	Priority     SearchPriority `json:"priority,omitempty" jsonschema:"enum=low,enum=normal,enum=high" jsonschema_description:"Priority of the search while searches wait for the rate limit of the engine: high for the interactive questions, low for the background enrichment (default normal)"`
+	Message      string         `json:"message" jsonschema:"required,title=Search query message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type PerplexitySearchAction struct {
+	Query      string         `json:"query" jsonschema:"required" jsonschema_description:"Question to research in perplexity, it's answered by the AI model based on the web search results in English"`
+	History    []Message      `json:"history,omitempty" jsonschema_description:"Previous questions and answers of the same research thread in chronological order to keep the context of the follow-up question, leave empty for the new question"`
+	Images     []string       `json:"images,omitempty" jsonschema_description:"Images to ask about together with the question as public http(s) URLs or base64 encoded data (e.g. data:image/png;base64,...), only multimodal models accept them (maximum 5), leave empty for the text question"`
+	MaxResults Int64          `json:"max_results" jsonschema:"required,type=integer" jsonschema_description:"Maximum number of results to return (minimum 1; maximum 10; default 5)"`
+	Lang       string         `json:"lang,omitempty" jsonschema_description:"Prefer sources in the language by ISO 639-1 code (e.g. de, ru, zh-TW) to research non-English sources, the answer stays in English, leave empty for any language"`
+	Mode       string         `json:"mode,omitempty" jsonschema:"enum=web,enum=academic,enum=news" jsonschema_description:"Focus of the search: academic for scholarly papers in the vulnerability research, news for recent publications about incidents and disclosures, web for the general web search (default web)"`
+	Priority   SearchPriority `json:"priority,omitempty" jsonschema:"enum=low,enum=normal,enum=high" jsonschema_description:"Priority of the search while searches wait for the rate limit of the engine: high for the interactive questions, low for the background enrichment (default normal)"`
+	Message    string         `json:"message" jsonschema:"required,title=Search query message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type GithubSearchAction struct {
+	Query      string         `json:"query" jsonschema:"required" jsonschema_description:"Query in github search syntax (e.g. 'CVE-2021-44228 language:python' or 'log4j exploit in:readme') for code and repositories, CVE identifier or affected package name for advisories"`
+	Kind       string         `json:"kind" jsonschema:"required,enum=code,enum=repositories,enum=advisories" jsonschema_description:"What to search: code for files in repositories, repositories for projects sorted by stars, advisories for reviewed security advisories"`
+	MaxResults Int64          `json:"max_results" jsonschema:"required,type=integer" jsonschema_description:"Maximum number of results to return (minimum 1; maximum 30; default 10)"`
+	Priority   SearchPriority `json:"priority,omitempty" jsonschema:"enum=low,enum=normal,enum=high" jsonschema_description:"Priority of the search while searches wait for the rate limit of the engine: high for the interactive questions, low for the background enrichment (default normal)"`
+	Message    string         `json:"message" jsonschema:"required,title=Search query message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type WhoisAction struct {
+	Domain  string `json:"domain" jsonschema:"required" jsonschema_description:"Domain name to look up registration data of (e.g. example.com), URL is accepted and its host is used"`
+	Message string `json:"message" jsonschema:"required,title=WHOIS lookup message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type DNSAction struct {
+	Domain      string   `json:"domain" jsonschema:"required" jsonschema_description:"Domain name to resolve (e.g. example.com), URL is accepted and its host is used"`
+	RecordTypes []string `json:"record_types,omitempty" jsonschema:"enum=A,enum=AAAA,enum=CNAME,enum=MX,enum=NS,enum=TXT" jsonschema_description:"DNS record types to resolve (default all of them)"`
+	Message     string   `json:"message" jsonschema:"required,title=DNS lookup message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type WaybackAction struct {
+	URL       string `json:"url" jsonschema:"required" jsonschema_description:"URL or domain to look up archived snapshots of (e.g. https://example.com/admin)"`
+	Timestamp string `json:"timestamp,omitempty" jsonschema_description:"Date to find the closest snapshot to in YYYYMMDD or YYYYMMDDhhmmss format (default the latest snapshot)"`
+	Captures  Int64  `json:"captures,omitempty" jsonschema:"type=integer" jsonschema_description:"Number of recent captures to list besides the closest snapshot (minimum 0; maximum 20; default 0 means none)"`
+	Message   string `json:"message" jsonschema:"required,title=Wayback lookup message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type AttackAction struct {
+	Query   string `json:"query" jsonschema:"required" jsonschema_description:"ATT&CK technique ID to get its details (e.g. T1059 or T1003.001) or keywords to search techniques by (e.g. credential dumping lsass)"`
+	Limit   Int64  `json:"limit,omitempty" jsonschema:"type=integer" jsonschema_description:"Maximum number of techniques found by keywords (minimum 1; maximum 10; default 5), it is ignored for the technique ID"`
+	Message string `json:"message" jsonschema:"required,title=ATT&CK lookup message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type ExploitDBAction struct {
+	Query    string `json:"query" jsonschema:"required" jsonschema_description:"Keywords to search exploits by in their titles, CVE codes and file paths (e.g. apache struts 2.5 or CVE-2021-4034)"`
+	Platform string `json:"platform,omitempty" jsonschema_description:"Platform of the exploits as named in ExploitDB (e.g. linux, windows, php, multiple), empty means any"`
+	Type     string `json:"type,omitempty" jsonschema_description:"Type of the exploits (dos, local, remote or webapps), empty means any"`
+	Limit    Int64  `json:"limit,omitempty" jsonschema:"type=integer" jsonschema_description:"Maximum number of exploits to return (minimum 1; maximum 30; default 10)"`
+	Message  string `json:"message" jsonschema:"required,title=ExploitDB search message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type IPInfoAction struct {
+	IP      string `json:"ip" jsonschema:"required" jsonschema_description:"IPv4 or IPv6 address to get the ASN, organization and geolocation of (e.g. 8.8.8.8)"`
+	Message string `json:"message" jsonschema:"required,title=IP information message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type AbuseIPDBAction struct {
+	IP           string `json:"ip" jsonschema:"required" jsonschema_description:"IPv4 or IPv6 address to check the reputation of (e.g. 185.220.101.1)"`
+	MaxAgeInDays Int64  `json:"max_age_in_days" jsonschema:"required,type=integer" jsonschema_description:"Only abuse reports not older than this number of days are considered (minimum 1; maximum 365; default 90)"`
+	Message      string `json:"message" jsonschema:"required,title=IP reputation message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
+}
+
+type HIBPAction struct {
+	Account string `json:"account" jsonschema:"required" jsonschema_description:"Email address to check the breaches it was exposed in, or domain to check the breaches of the site (e.g. admin@example.com or example.com)"`
+	Message string `json:"message" jsonschema:"required,title=Breach lookup message" jsonschema_description:"Not so long message with the expected result and path to reach goal to send to the user in user's language only"`
 }
 
 type SearchResult struct {
@@ -0,0 +1,473 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	attackDefaultLimit      = 5
+	attackMaxLimit          = 10
+	attackKillChainName     = "mitre-attack"
+	attackSummaryLength     = 300
+	attackDetectionLength   = 1000
+	attackDescriptionLength = 2000
+)
+
+// attackEmbedded is the subset of the Enterprise ATT&CK STIX bundle with techniques common in penetration
+// testing, ATTACK_DATA_PATH replaces it with the full enterprise-attack.json from the MITRE CTI repository
+//
+//go:embed attack.json
+var attackEmbedded []byte
+
+var (
+	attackIDPattern       = regexp.MustCompile(`^T\d{4}(\.\d{3})?$`)
+	attackCitationPattern = regexp.MustCompile(`\s*\(Citation:[^)]*\)`)
+
+	attackEmbeddedOnce    sync.Once
+	attackEmbeddedDataset *attackDataset
+	attackEmbeddedErr     error
+)
+
+type attackTechnique struct {
+	ID            string
+	Name          string
+	Description   string
+	Tactics       []string
+	Platforms     []string
+	Detection     string
+	URL           string
+	Mitigations   []attackMitigation
+	Parent        string
+	Subtechniques []string
+}
+
+type attackMitigation struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+type attackDataset struct {
+	techniques map[string]*attackTechnique
+	ordered    []*attackTechnique
+}
+
+// stixObject keeps the fields of the STIX objects which are used by the tool, the bundle mixes
+// techniques (attack-pattern), mitigations (course-of-action), tactics and relationships between them
+type stixObject struct {
+	Type            string `json:"type"`
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	Revoked         bool   `json:"revoked"`
+	Deprecated      bool   `json:"x_mitre_deprecated"`
+	KillChainPhases []struct {
+		KillChainName string `json:"kill_chain_name"`
+		PhaseName     string `json:"phase_name"`
+	} `json:"kill_chain_phases"`
+	Platforms          []string `json:"x_mitre_platforms"`
+	Detection          string   `json:"x_mitre_detection"`
+	ShortName          string   `json:"x_mitre_shortname"`
+	ExternalReferences []struct {
+		SourceName string `json:"source_name"`
+		ExternalID string `json:"external_id"`
+		URL        string `json:"url"`
+	} `json:"external_references"`
+	RelationshipType string `json:"relationship_type"`
+	SourceRef        string `json:"source_ref"`
+	TargetRef        string `json:"target_ref"`
+}
+
+// mitreReference returns ATT&CK ID and URL of the object from its external references
+func (o *stixObject) mitreReference() (string, string) {
+	for _, ref := range o.ExternalReferences {
+		if ref.SourceName == attackKillChainName && ref.ExternalID != "" {
+			return ref.ExternalID, ref.URL
+		}
+	}
+	return "", ""
+}
+
+type attack struct {
+	flowID    int64
+	taskID    *int64
+	subtaskID *int64
+	enabled   bool
+	dataPath  string
+
+	mx      sync.Mutex
+	dataset *attackDataset
+	modTime time.Time
+}
+
+// NewAttackTool creates the tool which looks up MITRE ATT&CK techniques by ID or keywords,
+// the bundled dataset is used when dataPath is empty or the file at the path can't be loaded
+func NewAttackTool(flowID int64, taskID, subtaskID *int64, enabled bool, dataPath string) Tool {
+	return &attack{
+		flowID:    flowID,
+		taskID:    taskID,
+		subtaskID: subtaskID,
+		enabled:   enabled,
+		dataPath:  dataPath,
+	}
+}
+
+func (a *attack) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action AttackAction
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal attack action")
+		return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+	}
+
+	query := strings.TrimSpace(action.Query)
+	if query == "" {
+		return "technique ID or keywords to look up in ATT&CK are empty", nil
+	}
+
+	dataset, err := a.load(ctx)
+	if err != nil {
+		logger.WithError(err).Error("failed to load ATT&CK dataset")
+		return "", fmt.Errorf("failed to load ATT&CK dataset: %w", err)
+	}
+
+	if id := strings.ToUpper(query); attackIDPattern.MatchString(id) {
+		technique, ok := dataset.techniques[id]
+		if !ok {
+			return fmt.Sprintf("Technique %s is not found in the ATT&CK dataset, "+
+				"check the ID or search the technique by keywords\n", id), nil
+		}
+		return formatAttackTechnique(dataset, technique), nil
+	}
+
+	limit := action.Limit.Int()
+	if limit <= 0 {
+		limit = attackDefaultLimit
+	}
+	limit = min(limit, attackMaxLimit)
+
+	techniques := dataset.search(query, limit)
+	if len(techniques) == 0 {
+		return fmt.Sprintf("No ATT&CK techniques found matching %q\n", query), nil
+	}
+
+	return formatAttackSearch(query, techniques), nil
+}
+
+// load returns the dataset from the file at dataPath and reloads it when the file is modified,
+// the embedded dataset is used as a fallback so the tool stays usable with a broken file
+func (a *attack) load(ctx context.Context) (*attackDataset, error) {
+	if a.dataPath == "" {
+		return embeddedAttackDataset()
+	}
+
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	logger := logrus.WithContext(ctx).WithField("path", a.dataPath)
+	info, err := os.Stat(a.dataPath)
+	if err != nil {
+		logger.WithError(err).Warn("failed to stat ATT&CK dataset, using embedded one")
+		return embeddedAttackDataset()
+	}
+	if a.dataset != nil && info.ModTime().Equal(a.modTime) {
+		return a.dataset, nil
+	}
+
+	data, err := os.ReadFile(a.dataPath)
+	if err == nil {
+		var dataset *attackDataset
+		if dataset, err = parseAttackDataset(data); err == nil {
+			a.dataset, a.modTime = dataset, info.ModTime()
+			return dataset, nil
+		}
+	}
+
+	logger.WithError(err).Warn("failed to load ATT&CK dataset, using embedded one")
+	return embeddedAttackDataset()
+}
+
+func embeddedAttackDataset() (*attackDataset, error) {
+	attackEmbeddedOnce.Do(func() {
+		attackEmbeddedDataset, attackEmbeddedErr = parseAttackDataset(attackEmbedded)
+	})
+	return attackEmbeddedDataset, attackEmbeddedErr
+}
+
+// parseAttackDataset builds techniques from the STIX bundle, revoked and deprecated objects are skipped
+func parseAttackDataset(data []byte) (*attackDataset, error) {
+	var bundle struct {
+		Objects []stixObject `json:"objects"`
+	}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode STIX bundle: %w", err)
+	}
+
+	var (
+		tactics       = make(map[string]string)
+		byStixID      = make(map[string]*attackTechnique)
+		mitigations   = make(map[string]attackMitigation)
+		relationships []stixObject
+	)
+	for _, object := range bundle.Objects {
+		if object.Revoked || object.Deprecated {
+			continue
+		}
+
+		switch object.Type {
+		case "x-mitre-tactic":
+			tactics[object.ShortName] = object.Name
+		case "course-of-action":
+			id, _ := object.mitreReference()
+			mitigations[object.ID] = attackMitigation{
+				ID:          id,
+				Name:        object.Name,
+				Description: object.Description,
+			}
+		case "relationship":
+			relationships = append(relationships, object)
+		case "attack-pattern":
+			id, url := object.mitreReference()
+			if id == "" {
+				continue
+			}
+			technique := &attackTechnique{
+				ID:          id,
+				Name:        object.Name,
+				Description: object.Description,
+				Platforms:   object.Platforms,
+				Detection:   object.Detection,
+				URL:         url,
+			}
+			for _, phase := range object.KillChainPhases {
+				if phase.KillChainName == attackKillChainName {
+					technique.Tactics = append(technique.Tactics, phase.PhaseName)
+				}
+			}
+			byStixID[object.ID] = technique
+		}
+	}
+
+	if len(byStixID) == 0 {
+		return nil, fmt.Errorf("STIX bundle has no ATT&CK techniques")
+	}
+
+	for _, rel := range relationships {
+		target, ok := byStixID[rel.TargetRef]
+		if !ok {
+			continue
+		}
+
+		switch rel.RelationshipType {
+		case "mitigates":
+			mitigation, ok := mitigations[rel.SourceRef]
+			if !ok {
+				continue
+			}
+			// the relationship describes how the mitigation applies to the technique
+			if rel.Description != "" {
+				mitigation.Description = rel.Description
+			}
+			target.Mitigations = append(target.Mitigations, mitigation)
+		case "subtechnique-of":
+			if source, ok := byStixID[rel.SourceRef]; ok {
+				source.Parent = target.ID
+				target.Subtechniques = append(target.Subtechniques, source.ID)
+			}
+		}
+	}
+
+	dataset := &attackDataset{
+		techniques: make(map[string]*attackTechnique, len(byStixID)),
+		ordered:    make([]*attackTechnique, 0, len(byStixID)),
+	}
+	for _, technique := range byStixID {
+		for idx, tactic := range technique.Tactics {
+			if name, ok := tactics[tactic]; ok {
+				technique.Tactics[idx] = name
+			}
+		}
+		sort.Slice(technique.Mitigations, func(i, j int) bool {
+			return technique.Mitigations[i].ID < technique.Mitigations[j].ID
+		})
+		sort.Strings(technique.Subtechniques)
+
+		dataset.techniques[technique.ID] = technique
+		dataset.ordered = append(dataset.ordered, technique)
+	}
+	sort.Slice(dataset.ordered, func(i, j int) bool {
+		return dataset.ordered[i].ID < dataset.ordered[j].ID
+	})
+
+	return dataset, nil
+}
+
+// search returns techniques which contain all words of the query, matches in the name rank higher
+// than matches in tactics and the description, equal scores keep the order of IDs
+func (d *attackDataset) search(query string, limit int) []*attackTechnique {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		technique *attackTechnique
+		score     int
+	}
+
+	var matches []scored
+	for _, technique := range d.ordered {
+		name := strings.ToLower(technique.Name)
+		tactics := strings.ToLower(strings.Join(technique.Tactics, " "))
+		text := strings.ToLower(technique.ID + " " + technique.Description + " " + strings.Join(technique.Platforms, " "))
+
+		score, matched := 0, true
+		for _, term := range terms {
+			switch {
+			case strings.Contains(name, term):
+				score += 10
+			case strings.Contains(tactics, term):
+				score += 3
+			case strings.Contains(text, term):
+				score += 1
+			default:
+				matched = false
+			}
+			if !matched {
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if name == strings.Join(terms, " ") {
+			score += 100
+		}
+		matches = append(matches, scored{technique: technique, score: score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]*attackTechnique, 0, min(limit, len(matches)))
+	for _, match := range matches[:min(limit, len(matches))] {
+		result = append(result, match.technique)
+	}
+
+	return result
+}
+
+func formatAttackTechnique(dataset *attackDataset, technique *attackTechnique) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# %s: %s\n\n", technique.ID, technique.Name))
+
+	if parent, ok := dataset.techniques[technique.Parent]; ok {
+		builder.WriteString(fmt.Sprintf("Sub-technique of %s: %s\n\n", parent.ID, parent.Name))
+	}
+	if len(technique.Tactics) > 0 {
+		builder.WriteString(fmt.Sprintf("- Tactics: %s\n", strings.Join(technique.Tactics, ", ")))
+	}
+	if len(technique.Platforms) > 0 {
+		builder.WriteString(fmt.Sprintf("- Platforms: %s\n", strings.Join(technique.Platforms, ", ")))
+	}
+	if technique.URL != "" {
+		builder.WriteString(fmt.Sprintf("- URL: %s\n", technique.URL))
+	}
+
+	if description := attackSummary(technique.Description, attackDescriptionLength, false); description != "" {
+		builder.WriteString(fmt.Sprintf("\n## Description\n\n%s\n", description))
+	}
+	if detection := attackSummary(technique.Detection, attackDetectionLength, false); detection != "" {
+		builder.WriteString(fmt.Sprintf("\n## Detection\n\n%s\n", detection))
+	}
+
+	if len(technique.Mitigations) > 0 {
+		builder.WriteString("\n## Mitigations\n\n")
+		for _, mitigation := range technique.Mitigations {
+			builder.WriteString(fmt.Sprintf("- %s %s: %s\n", mitigation.ID, mitigation.Name,
+				attackSummary(mitigation.Description, attackSummaryLength, true)))
+		}
+	}
+
+	if len(technique.Subtechniques) > 0 {
+		builder.WriteString("\n## Sub-techniques\n\n")
+		for _, id := range technique.Subtechniques {
+			if sub, ok := dataset.techniques[id]; ok {
+				builder.WriteString(fmt.Sprintf("- %s: %s\n", sub.ID, sub.Name))
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+func formatAttackSearch(query string, techniques []*attackTechnique) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# ATT&CK techniques matching %q\n\n", query))
+
+	for idx, technique := range techniques {
+		if idx > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("## %s: %s\n\n", technique.ID, technique.Name))
+		if len(technique.Tactics) > 0 {
+			builder.WriteString(fmt.Sprintf("- Tactics: %s\n", strings.Join(technique.Tactics, ", ")))
+		}
+		if len(technique.Platforms) > 0 {
+			builder.WriteString(fmt.Sprintf("- Platforms: %s\n", strings.Join(technique.Platforms, ", ")))
+		}
+		if technique.URL != "" {
+			builder.WriteString(fmt.Sprintf("- URL: %s\n", technique.URL))
+		}
+		if summary := attackSummary(technique.Description, attackSummaryLength, true); summary != "" {
+			builder.WriteString(fmt.Sprintf("\n%s\n", summary))
+		}
+	}
+
+	builder.WriteString("\nLook up the technique ID to get its detection and mitigations\n")
+
+	return builder.String()
+}
+
+// attackSummary strips citations from the ATT&CK text and cuts it to maxLen on the word boundary,
+// firstParagraph keeps only the leading paragraph for the short summaries in the lists
+func attackSummary(text string, maxLen int, firstParagraph bool) string {
+	text = strings.TrimSpace(attackCitationPattern.ReplaceAllString(text, ""))
+	if firstParagraph {
+		text, _, _ = strings.Cut(text, "\n")
+		text = strings.TrimSpace(text)
+	}
+	if len(text) <= maxLen {
+		return text
+	}
+
+	cut := strings.LastIndexAny(text[:maxLen], " \n")
+	if cut <= 0 {
+		cut = maxLen
+	}
+	return strings.TrimRight(text[:cut], " ,.;:\n") + "..."
+}
+
+func (a *attack) IsAvailable() bool {
+	return a.enabled && IsEnabled(AttackToolName)
+}
+
+func (a *attack) HealthCheck(ctx context.Context) error {
+	return nil
+}
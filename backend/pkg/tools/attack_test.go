@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmbeddedAttackDataset(t *testing.T) {
+	dataset, err := embeddedAttackDataset()
+	if err != nil {
+		t.Fatalf("failed to parse embedded dataset: %v", err)
+	}
+
+	technique, ok := dataset.techniques["T1059"]
+	if !ok {
+		t.Fatal("expected T1059 in the embedded dataset")
+	}
+	if technique.Name != "Command and Scripting Interpreter" {
+		t.Errorf("unexpected name %q", technique.Name)
+	}
+	if len(technique.Tactics) != 1 || technique.Tactics[0] != "Execution" {
+		t.Errorf("expected tactic names instead of short names, got %v", technique.Tactics)
+	}
+	if technique.URL != "https://attack.mitre.org/techniques/T1059" {
+		t.Errorf("unexpected url %q", technique.URL)
+	}
+	if len(technique.Mitigations) == 0 || technique.Detection == "" {
+		t.Errorf("expected detection and mitigations, got %+v", technique)
+	}
+	if len(technique.Subtechniques) == 0 || technique.Subtechniques[0] != "T1059.001" {
+		t.Errorf("expected sorted sub-techniques, got %v", technique.Subtechniques)
+	}
+
+	if sub := dataset.techniques["T1059.001"]; sub == nil || sub.Parent != "T1059" {
+		t.Errorf("expected T1059.001 to be a sub-technique of T1059, got %+v", sub)
+	}
+}
+
+func TestAttackHandle(t *testing.T) {
+	a := &attack{enabled: true}
+
+	for _, tt := range []struct {
+		name     string
+		action   AttackAction
+		contains []string
+		excludes []string
+	}{
+		{
+			name:   "technique id",
+			action: AttackAction{Query: "T1059"},
+			contains: []string{
+				"# T1059: Command and Scripting Interpreter",
+				"- Tactics: Execution",
+				"- Platforms: Linux, macOS, Windows",
+				"- URL: https://attack.mitre.org/techniques/T1059",
+				"## Description\n\nAdversaries may abuse command and script interpreters",
+				"## Detection\n\n",
+				"## Mitigations\n\n- M1026 Privileged Account Management: ",
+				"- M1038 Execution Prevention: Block execution of code",
+				"## Sub-techniques\n\n- T1059.001: PowerShell\n",
+			},
+			excludes: []string{"Sub-technique of"},
+		},
+		{
+			name:   "sub-technique id in lower case",
+			action: AttackAction{Query: " t1003.001 "},
+			contains: []string{
+				"# T1003.001: LSASS Memory",
+				"Sub-technique of T1003: OS Credential Dumping",
+				"- Tactics: Credential Access",
+				"- M1043 Credential Access Protection: ",
+			},
+			excludes: []string{"## Sub-techniques"},
+		},
+		{
+			name:     "unknown technique id",
+			action:   AttackAction{Query: "T9999"},
+			contains: []string{"Technique T9999 is not found in the ATT&CK dataset"},
+		},
+		{
+			name:   "keywords",
+			action: AttackAction{Query: "kerberos tickets"},
+			contains: []string{
+				"# ATT&CK techniques matching \"kerberos tickets\"",
+				"## T1558: Steal or Forge Kerberos Tickets",
+				"- Tactics: Credential Access",
+				"Look up the technique ID",
+			},
+			excludes: []string{"## Detection", "## Mitigations"},
+		},
+		{
+			name:     "keywords without matches",
+			action:   AttackAction{Query: "quantum teleportation"},
+			contains: []string{"No ATT&CK techniques found matching \"quantum teleportation\""},
+		},
+		{
+			name:     "empty query",
+			action:   AttackAction{Query: "  "},
+			contains: []string{"are empty"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(tt.action)
+			result, err := a.Handle(context.Background(), AttackToolName, args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, part := range tt.contains {
+				if !strings.Contains(result, part) {
+					t.Errorf("expected %q in result:\n%s", part, result)
+				}
+			}
+			for _, part := range tt.excludes {
+				if strings.Contains(result, part) {
+					t.Errorf("unexpected %q in result:\n%s", part, result)
+				}
+			}
+		})
+	}
+}
+
+func TestAttackSearch(t *testing.T) {
+	dataset, err := embeddedAttackDataset()
+	if err != nil {
+		t.Fatalf("failed to parse embedded dataset: %v", err)
+	}
+
+	// the name match ranks above the techniques which mention the words in the description only
+	found := dataset.search("PowerShell", attackMaxLimit)
+	if len(found) < 2 || found[0].ID != "T1059.001" {
+		t.Fatalf("expected T1059.001 first, got %v", attackTechniqueIDs(found))
+	}
+
+	// all words must match
+	for _, technique := range dataset.search("password spraying", attackMaxLimit) {
+		if technique.ID != "T1110.003" {
+			t.Errorf("unexpected technique %s for all words query", technique.ID)
+		}
+	}
+
+	if found := dataset.search("credential", 2); len(found) != 2 {
+		t.Errorf("expected the limit of 2 techniques, got %v", attackTechniqueIDs(found))
+	}
+}
+
+func TestAttackDataPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attack.json")
+	bundle := `{"type": "bundle", "objects": [
+		{"type": "x-mitre-tactic", "id": "x-mitre-tactic--1", "name": "Execution", "x_mitre_shortname": "execution"},
+		{"type": "attack-pattern", "id": "attack-pattern--1", "name": "Custom Technique",
+			"description": "Adversaries may do custom things. (Citation: Custom Report)",
+			"kill_chain_phases": [{"kill_chain_name": "mitre-attack", "phase_name": "execution"}],
+			"external_references": [{"source_name": "mitre-attack", "external_id": "T1059", "url": "https://example.com/T1059"}]},
+		{"type": "attack-pattern", "id": "attack-pattern--2", "name": "Revoked Technique", "revoked": true,
+			"external_references": [{"source_name": "mitre-attack", "external_id": "T1203"}]}
+	]}`
+	if err := os.WriteFile(path, []byte(bundle), 0o600); err != nil {
+		t.Fatalf("failed to write dataset: %v", err)
+	}
+
+	a := &attack{enabled: true, dataPath: path}
+	lookup := func(query string) string {
+		args, _ := json.Marshal(AttackAction{Query: query})
+		result, err := a.Handle(context.Background(), AttackToolName, args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	result := lookup("T1059")
+	if !strings.Contains(result, "# T1059: Custom Technique") ||
+		!strings.Contains(result, "Adversaries may do custom things.\n") {
+		t.Errorf("expected the technique from the file without citations:\n%s", result)
+	}
+	if result := lookup("T1203"); !strings.Contains(result, "is not found") {
+		t.Errorf("expected revoked technique to be skipped:\n%s", result)
+	}
+
+	// broken file falls back to the embedded dataset
+	if err := os.WriteFile(path, []byte("{broken"), 0o600); err != nil {
+		t.Fatalf("failed to write dataset: %v", err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("failed to touch dataset: %v", err)
+	}
+	if result := lookup("T1059"); !strings.Contains(result, "# T1059: Command and Scripting Interpreter") {
+		t.Errorf("expected the embedded technique:\n%s", result)
+	}
+}
+
+func TestAttackSummary(t *testing.T) {
+	text := "First paragraph with a citation.(Citation: Report 2020) More words here.\n\nSecond paragraph."
+
+	if got := attackSummary(text, 1000, false); got != "First paragraph with a citation. More words here.\n\nSecond paragraph." {
+		t.Errorf("unexpected full summary %q", got)
+	}
+	if got := attackSummary(text, 1000, true); got != "First paragraph with a citation. More words here." {
+		t.Errorf("unexpected first paragraph %q", got)
+	}
+	if got := attackSummary(text, 20, true); got != "First paragraph..." {
+		t.Errorf("unexpected cut summary %q", got)
+	}
+}
+
+func attackTechniqueIDs(techniques []*attackTechnique) []string {
+	ids := make([]string, 0, len(techniques))
+	for _, technique := range techniques {
+		ids = append(ids, technique.ID)
+	}
+	return ids
+}
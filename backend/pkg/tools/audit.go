@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"pentagi/pkg/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+// auditMaxArgsLength limits arguments stored in the audit record, the full arguments are kept in toolcalls
+const auditMaxArgsLength = 2048
+
+// ToolAudit is a structured record of a single tool call
+type ToolAudit struct {
+	Initiator    database.MsgchainType
+	Executor     database.MsgchainType
+	Name         string
+	Args         string
+	Duration     time.Duration
+	Err          error
+	ResultLength int
+	FlowID       int64
+	TaskID       *int64
+	SubtaskID    *int64
+}
+
+// AuditLogger persists the audit trail of tool calls, unlike SearchLogProvider
+// it covers every tool and keeps the outcome of the call instead of its result
+type AuditLogger interface {
+	PutAudit(ctx context.Context, audit ToolAudit) (int64, error)
+}
+
+type auditLogger struct {
+	db database.Querier
+}
+
+// NewAuditLogger creates audit logger which stores records into the toolaudits table
+func NewAuditLogger(db database.Querier) AuditLogger {
+	return &auditLogger{db: db}
+}
+
+func (al *auditLogger) PutAudit(ctx context.Context, audit ToolAudit) (int64, error) {
+	var errMsg string
+	if audit.Err != nil {
+		errMsg = database.SanitizeUTF8(audit.Err.Error())
+	}
+
+	record, err := al.db.CreateToolAudit(ctx, database.CreateToolAuditParams{
+		Initiator:       audit.Initiator,
+		Executor:        audit.Executor,
+		Name:            audit.Name,
+		Args:            database.SanitizeUTF8(audit.Args),
+		Success:         audit.Err == nil,
+		Error:           errMsg,
+		ResultLength:    int64(audit.ResultLength),
+		DurationSeconds: audit.Duration.Seconds(),
+		FlowID:          audit.FlowID,
+		TaskID:          database.Int64ToNullInt64(audit.TaskID),
+		SubtaskID:       database.Int64ToNullInt64(audit.SubtaskID),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tool audit: %w", err)
+	}
+
+	return record.ID, nil
+}
+
+// withToolAudit wraps the handler to record every call into the audit logger,
+// a failed record is only logged and never changes the result of the call
+func withToolAudit(al AuditLogger, flowID int64, taskID, subtaskID *int64, handler ExecutorHandler) ExecutorHandler {
+	if al == nil {
+		return handler
+	}
+
+	return func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		start := time.Now()
+		result, err := handler(ctx, name, args)
+
+		audit := ToolAudit{
+			Initiator:    database.MsgchainTypePrimaryAgent,
+			Executor:     database.MsgchainTypePrimaryAgent,
+			Name:         name,
+			Args:         truncateLogValue(string(args), auditMaxArgsLength),
+			Duration:     time.Since(start),
+			Err:          err,
+			ResultLength: len(result),
+			FlowID:       flowID,
+			TaskID:       taskID,
+			SubtaskID:    subtaskID,
+		}
+		if agentCtx, ok := GetAgentContext(ctx); ok {
+			audit.Initiator = agentCtx.ParentAgentType
+			audit.Executor = agentCtx.CurrentAgentType
+		}
+
+		// the record is written even if the call was canceled
+		if _, auditErr := al.PutAudit(context.WithoutCancel(ctx), audit); auditErr != nil {
+			logrus.WithContext(ctx).WithError(auditErr).WithField("tool", name).
+				Warn("failed to store tool call audit")
+		}
+
+		return result, err
+	}
+}
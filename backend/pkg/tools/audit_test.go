@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"pentagi/pkg/database"
+)
+
+type fakeAuditRecorder struct {
+	mx      sync.Mutex
+	records []ToolAudit
+	err     error
+}
+
+func (r *fakeAuditRecorder) PutAudit(ctx context.Context, audit ToolAudit) (int64, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.records = append(r.records, audit)
+
+	return int64(len(r.records)), nil
+}
+
+func TestWithToolAudit(t *testing.T) {
+	taskID, subtaskID := int64(2), int64(3)
+	handlerErr := errors.New("container is not running")
+
+	tests := []struct {
+		name    string
+		result  string
+		err     error
+		agent   bool
+		wantErr string
+	}{
+		{name: "success", result: "uid=0(root)", agent: true},
+		{name: "error", err: handlerErr, wantErr: handlerErr.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &fakeAuditRecorder{}
+			handler := withToolAudit(recorder, 1, &taskID, &subtaskID,
+				func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+					return tt.result, tt.err
+				})
+
+			ctx := context.Background()
+			if tt.agent {
+				ctx = PutAgentContext(ctx, database.MsgchainTypePentester)
+			}
+
+			result, err := handler(ctx, TerminalToolName, json.RawMessage(`{"input":"id"}`))
+			if result != tt.result || !errors.Is(err, tt.err) {
+				t.Fatalf("expected the handler outcome unchanged, got %q, %v", result, err)
+			}
+			if len(recorder.records) != 1 {
+				t.Fatalf("expected one audit record, got %d", len(recorder.records))
+			}
+
+			audit := recorder.records[0]
+			if audit.Name != TerminalToolName || audit.Args != `{"input":"id"}` || audit.FlowID != 1 ||
+				audit.TaskID != &taskID || audit.SubtaskID != &subtaskID {
+				t.Errorf("unexpected audit record %+v", audit)
+			}
+			if audit.ResultLength != len(tt.result) || audit.Duration <= 0 {
+				t.Errorf("unexpected result length %d or duration %v", audit.ResultLength, audit.Duration)
+			}
+			if gotErr := errorString(audit.Err); gotErr != tt.wantErr {
+				t.Errorf("expected error %q in the record, got %q", tt.wantErr, gotErr)
+			}
+
+			wantExecutor := database.MsgchainTypePrimaryAgent
+			if tt.agent {
+				wantExecutor = database.MsgchainTypePentester
+			}
+			if audit.Executor != wantExecutor {
+				t.Errorf("expected executor %q, got %q", wantExecutor, audit.Executor)
+			}
+		})
+	}
+}
+
+func TestWithToolAuditTruncatesArgs(t *testing.T) {
+	recorder := &fakeAuditRecorder{}
+	handler := withToolAudit(recorder, 1, nil, nil,
+		func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+			return "", nil
+		})
+
+	args, _ := json.Marshal(map[string]string{"content": strings.Repeat("a", 2*auditMaxArgsLength)})
+	if _, err := handler(context.Background(), FileToolName, args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := len([]rune(recorder.records[0].Args)); got != auditMaxArgsLength+len("...") {
+		t.Errorf("expected args cut to %d characters, got %d", auditMaxArgsLength, got)
+	}
+}
+
+func TestWithToolAuditFailure(t *testing.T) {
+	recorder := &fakeAuditRecorder{err: errors.New("database is unavailable")}
+	handler := withToolAudit(recorder, 1, nil, nil,
+		func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+			return "done", nil
+		})
+
+	// the call succeeds even if the record can't be stored
+	result, err := handler(context.Background(), TerminalToolName, json.RawMessage(`{}`))
+	if err != nil || result != "done" {
+		t.Errorf("expected the handler result, got %q, %v", result, err)
+	}
+
+	// nil logger keeps the handler as is
+	if withToolAudit(nil, 1, nil, nil, nil) != nil {
+		t.Error("expected the handler unchanged without audit logger")
+	}
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
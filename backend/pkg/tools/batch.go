@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	batchMaxQueries  = 10
+	batchConcurrency = 3
+)
+
+type batchResult struct {
+	query string
+	items []SearchResultItem
+	err   error
+}
+
+// SearchBatch runs distinct queries on the engine with bounded concurrency and returns the document
+// with the section per query, the engine queues every query in its rate limit queue on its own,
+// the failed query is reported in its section and the batch fails only when the engine can't be used
+func (r *SearchRegistry) SearchBatch(ctx context.Context, engine string, queries []string) (string, error) {
+	engine = r.engineName(engine)
+	searcher, ok := r.engines[engine]
+	if !ok || !searcher.IsAvailable() || !IsEnabled(engine) {
+		return "", fmt.Errorf("%s: engine is not available", engine)
+	}
+
+	queries = batchQueries(queries)
+	if len(queries) == 0 {
+		return "", fmt.Errorf("no queries to search")
+	}
+	if len(queries) > batchMaxQueries {
+		return "", fmt.Errorf("too many queries in the batch: %d, maximum is %d", len(queries), batchMaxQueries)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, batchConcurrency)
+		results = make([]batchResult, len(queries))
+	)
+	for i, query := range queries {
+		wg.Add(1)
+		go func(i int, query string) {
+			defer wg.Done()
+
+			results[i].query = query
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].err = ctx.Err()
+				return
+			}
+
+			items, err := r.search(ctx, searcher, query)
+			if errors.Is(err, ErrNoResults) {
+				// the engine answered, the section reports no results instead of the failure
+				err = nil
+			}
+			results[i].items, results[i].err = items, err
+		}(i, query)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return withSourceFooter(formatBatchResults(ctx, engine, results), engine, true), nil
+}
+
+// batchQueries sanitizes the queries and drops empty ones and duplicates keeping the order
+func batchQueries(queries []string) []string {
+	seen := make(map[string]struct{}, len(queries))
+	result := make([]string, 0, len(queries))
+	for _, query := range queries {
+		query = sanitizeQuery(query, maxQueryLength)
+		if query == "" {
+			continue
+		}
+		if _, ok := seen[query]; ok {
+			continue
+		}
+		seen[query] = struct{}{}
+		result = append(result, query)
+	}
+
+	return result
+}
+
+func formatBatchResults(ctx context.Context, engine string, results []batchResult) string {
+	var (
+		builder strings.Builder
+		failed  int
+	)
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+	}
+
+	builder.WriteString(fmt.Sprintf("# Results of %d queries\n\n", len(results)))
+	if failed > 0 {
+		builder.WriteString(fmt.Sprintf("%d of %d queries failed, their sections contain the errors\n\n", failed, len(results)))
+	}
+
+	for i, result := range results {
+		builder.WriteString(fmt.Sprintf("## Query %d: %s\n\n", i+1, result.query))
+		switch {
+		case result.err != nil:
+			logrus.WithContext(ctx).WithError(result.err).WithFields(logrus.Fields{
+				"engine": engine,
+				"query":  result.query,
+			}).Warn("batch search query failed")
+			builder.WriteString(fmt.Sprintf("failed to search: %v\n\n", result.err))
+		case len(result.items) == 0:
+			builder.WriteString(noResultsMessage + "\n\n")
+		default:
+			builder.WriteString(FormatResults(result.items, FormatOptions{Separator: true}))
+			builder.WriteString("\n\n")
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSearchBatch(t *testing.T) {
+	var (
+		mx      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("q")
+		mx.Lock()
+		queries = append(queries, query)
+		mx.Unlock()
+
+		switch query {
+		case "CVE-2021-44228":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(SearxngResponse{Results: []SearxngResult{
+				{Title: "Log4Shell", URL: "https://example.com/log4shell", Content: "Apache Log4j RCE"},
+			}})
+		case "CVE-2014-0160":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(SearxngResponse{})
+		}
+	}))
+	defer server.Close()
+
+	searxng := &SearxngTool{baseURL: server.URL, slp: &MockSearchLogProvider{}, timeout: 5 * time.Second}
+	registry := NewSearchRegistry(map[string]Tool{SearxngToolName: searxng})
+
+	result, err := registry.SearchBatch(context.Background(), SearxngToolName,
+		[]string{"CVE-2021-44228", " CVE-2014-0160 ", "", "CVE-2021-44228", "CVE-1999-0001"})
+	if err != nil {
+		t.Fatalf("SearchBatch() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"# Results of 3 queries",
+		"1 of 3 queries failed",
+		"## Query 1: CVE-2021-44228\n\n",
+		"https://example.com/log4shell",
+		"## Query 2: CVE-2014-0160\n\nfailed to search: ",
+		"status code: 503",
+		"## Query 3: CVE-1999-0001\n\nNo results found",
+		"_Source: searxng_",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %q in result:\n%s", want, result)
+		}
+	}
+	if strings.Index(result, "## Query 1") > strings.Index(result, "## Query 2") ||
+		strings.Index(result, "## Query 2") > strings.Index(result, "## Query 3") {
+		t.Errorf("sections are out of the order of queries:\n%s", result)
+	}
+	if len(queries) != 3 {
+		t.Errorf("expected 3 requests to the engine, got %d: %v", len(queries), queries)
+	}
+}
+
+func TestSearchBatchValidation(t *testing.T) {
+	items := []SearchResultItem{{Title: "Log4Shell", URL: "https://example.com/log4shell"}}
+	registry := NewSearchRegistry(map[string]Tool{
+		"google": &fakeResultsSearcher{available: true, items: items},
+		"tavily": &fakeResultsSearcher{available: false},
+	})
+
+	tooMany := make([]string, batchMaxQueries+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("q", i+1)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		engine  string
+		queries []string
+		wantErr string
+	}{
+		{"unknown engine", "bing", []string{"log4shell"}, "bing: engine is not available"},
+		{"unavailable engine", "tavily", []string{"log4shell"}, "tavily: engine is not available"},
+		{"no queries", "google", []string{" ", ""}, "no queries to search"},
+		{"too many queries", "google", tooMany, "too many queries in the batch"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := registry.SearchBatch(context.Background(), tt.engine, tt.queries)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSearchBatchConcurrency(t *testing.T) {
+	var (
+		mx              sync.Mutex
+		active, maximum int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mx.Lock()
+		active++
+		maximum = max(maximum, active)
+		mx.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mx.Lock()
+		active--
+		mx.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(SearxngResponse{})
+	}))
+	defer server.Close()
+
+	searxng := &SearxngTool{baseURL: server.URL, slp: &MockSearchLogProvider{}, timeout: 5 * time.Second}
+	registry := NewSearchRegistry(map[string]Tool{SearxngToolName: searxng})
+
+	queries := make([]string, batchMaxQueries)
+	for i := range queries {
+		queries[i] = strings.Repeat("q", i+1)
+	}
+	if _, err := registry.SearchBatch(context.Background(), SearxngToolName, queries); err != nil {
+		t.Fatalf("SearchBatch() error = %v", err)
+	}
+
+	if maximum > batchConcurrency {
+		t.Errorf("expected at most %d concurrent queries, got %d", batchConcurrency, maximum)
+	}
+}
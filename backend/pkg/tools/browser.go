@@ -1,9 +1,13 @@
 package tools
 
 import (
+	"bytes"
 	"context"
-	"crypto/tls"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,9 +16,11 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	obs "pentagi/pkg/observability"
 	"pentagi/pkg/observability/langfuse"
@@ -24,10 +30,22 @@ import (
 
 const (
 	minMdContentSize   = 50
+	minTextContentSize = 100
 	minHtmlContentSize = 300
 	minImgContentSize  = 2048
+
+	// defaultMaxScreenshots bounds in-flight screenshot requests to keep the scraper from running out of memory
+	defaultMaxScreenshots = 4
+
+	crawlMaxDepth     = 2
+	crawlDefaultPages = 5
+	crawlMaxPages     = 20
+	crawlTimeout      = 3 * time.Minute
 )
 
+// browserWaitConditions are the load states of the page which the scraper can wait for before capturing it
+var browserWaitConditions = []string{"load", "networkidle"}
+
 var localZones = []string{
 	".localdomain",
 	".local",
@@ -42,26 +60,346 @@ var localZones = []string{
 	".home.arpa",
 }
 
+// FetchRequest describes how the scraper should request the target page before capturing it,
+// zero value of Method sends GET request, or POST request when the body or the form data is set
+type FetchRequest struct {
+	URL      string
+	Method   string
+	Headers  map[string]string
+	Body     string
+	FormData map[string]string
+
+	// WaitFor is the CSS selector and WaitUntil is the load state (load, networkidle) which the scraper waits for
+	// before capturing the page, e.g. until the single-page app is rendered, empty ones keep the default wait
+	WaitFor   string
+	WaitUntil string
+}
+
+// scraperFetchOptions is sent to the scraper to reproduce the request to the target page
+type scraperFetchOptions struct {
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+func (r FetchRequest) isDefault() bool {
+	return (r.Method == "" || strings.EqualFold(r.Method, http.MethodGet)) &&
+		len(r.Headers) == 0 && r.Body == "" && len(r.FormData) == 0
+}
+
+func (r FetchRequest) scraperOptions() scraperFetchOptions {
+	opts := scraperFetchOptions{
+		Method:  strings.ToUpper(r.Method),
+		Headers: make(map[string]string, len(r.Headers)+1),
+		Body:    r.Body,
+	}
+	if opts.Method == "" {
+		// the empty method keeps GET of the regular page unless there is something to submit
+		opts.Method = http.MethodGet
+		if r.Body != "" || len(r.FormData) != 0 {
+			opts.Method = http.MethodPost
+		}
+	}
+	for key, value := range r.Headers {
+		opts.Headers[key] = value
+	}
+
+	if len(r.FormData) != 0 {
+		form := url.Values{}
+		for key, value := range r.FormData {
+			form.Set(key, value)
+		}
+		opts.Body = form.Encode()
+		if _, ok := opts.Headers["Content-Type"]; !ok {
+			opts.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+		}
+	}
+
+	return opts
+}
+
+// addRenderWait passes the wait conditions to the scraper in the query, so they are sent with GET requests too
+func (r FetchRequest) addRenderWait(query url.Values) {
+	if r.WaitFor != "" {
+		query.Set("waitFor", r.WaitFor)
+	}
+	if r.WaitUntil != "" {
+		query.Set("waitUntil", r.WaitUntil)
+	}
+}
+
+// normalizeWaitUntil checks the load state to wait for, the empty one keeps the default wait of the scraper
+func normalizeWaitUntil(waitUntil string) (string, error) {
+	waitUntil = strings.ToLower(strings.TrimSpace(waitUntil))
+	if waitUntil != "" && !slices.Contains(browserWaitConditions, waitUntil) {
+		return "", fmt.Errorf("unknown load state %q, use one of: %s", waitUntil, strings.Join(browserWaitConditions, ", "))
+	}
+
+	return waitUntil, nil
+}
+
+// FetchOption changes the request to the target page which the scraper reproduces, e.g. authenticates it
+type FetchOption func(*FetchRequest)
+
+// WithBasicAuth authenticates the request to the target page by the username and password,
+// the credentials are encoded to the Authorization header
+func WithBasicAuth(username, password string) FetchOption {
+	return func(r *FetchRequest) {
+		r.setHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+	}
+}
+
+// WithBearer authenticates the request to the target page by the bearer token
+func WithBearer(token string) FetchOption {
+	return func(r *FetchRequest) {
+		r.setHeader("Authorization", "Bearer "+token)
+	}
+}
+
+// withOptions returns the copy of the request changed by the options
+func (r FetchRequest) withOptions(opts []FetchOption) FetchRequest {
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return r
+}
+
+// setHeader replaces the header in the copy of the headers, so the map of the caller stays untouched,
+// other spellings of the same header name are dropped
+func (r *FetchRequest) setHeader(key, value string) {
+	headers := make(map[string]string, len(r.Headers)+1)
+	for name, current := range r.Headers {
+		if !strings.EqualFold(name, key) {
+			headers[name] = current
+		}
+	}
+	headers[key] = value
+	r.Headers = headers
+}
+
+// logFields describes the request for logs, credentials in headers and URL are redacted
+func (r FetchRequest) logFields() logrus.Fields {
+	headers := make(map[string]string, len(r.Headers))
+	for key, value := range r.Headers {
+		if isRedactedHeader(key) {
+			value = redactedValue
+		}
+		headers[key] = value
+	}
+
+	return logrus.Fields{
+		"url":     redactURL(r.URL),
+		"method":  r.Method,
+		"headers": headers,
+	}
+}
+
+// redactURL hides the password of the URL user info, unparsable URLs are kept as is
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	return u.Redacted()
+}
+
+// redactScraperURL hides the credentials of the scraper URL and of the target URL passed in its query,
+// unparsable URLs are replaced entirely because they can't be redacted partially
+func redactScraperURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redactedValue
+	}
+
+	query := u.Query()
+	if target := query.Get("url"); target != "" {
+		query.Set("url", redactURL(target))
+		u.RawQuery = query.Encode()
+	}
+
+	return u.Redacted()
+}
+
+// withoutRequestURL drops the request URL which the HTTP client adds to its errors, the URL
+// of the scraper request holds the target URL with its credentials
+func withoutRequestURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+
+	return err
+}
+
+// Screenshot is a raw page screenshot returned by in-memory browser calls instead of the file name
+type Screenshot struct {
+	Data     []byte
+	MIMEType string
+}
+
+// PageMetadata is the context of the page detected by the scraper besides its content,
+// empty fields mean the page doesn't declare them or the scraper doesn't support metadata
+type PageMetadata struct {
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	CanonicalURL string `json:"canonical"`
+	Language     string `json:"lang"`
+}
+
+// IsEmpty reports whether the scraper detected nothing about the page
+func (m PageMetadata) IsEmpty() bool {
+	return m == PageMetadata{}
+}
+
+// String renders the compact block which is prepended to the content of the page
+func (m PageMetadata) String() string {
+	if m.IsEmpty() {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("Page metadata:\n")
+	for _, field := range []struct{ name, value string }{
+		{"Title", m.Title},
+		{"Description", m.Description},
+		{"Canonical URL", m.CanonicalURL},
+		{"Language", m.Language},
+	} {
+		if field.value != "" {
+			builder.WriteString(fmt.Sprintf("- %s: %s\n", field.name, field.value))
+		}
+	}
+	builder.WriteString("\n")
+
+	return builder.String()
+}
+
 type browser struct {
-	flowID    int64
-	taskID    *int64
-	subtaskID *int64
-	dataDir   string
-	scPrvURL  string
-	scPubURL  string
-	scp       ScreenshotProvider
+	flowID          int64
+	taskID          *int64
+	subtaskID       *int64
+	dataDir         string
+	scPrvURL        string
+	scPubURL        string
+	scTLS           TLSConfig
+	scSem           chan struct{}
+	scp             ScreenshotProvider
+	policy          *URLPolicy
+	scope           *DomainScope
+	maxContentBytes int
+	minSizes        MinContentSizes
+	// dedupScreenshots names screenshots by the hash of the image to store identical ones once
+	dedupScreenshots bool
+}
+
+// MinContentSizes overrides the minimum sizes in bytes of the content scraped by the browser,
+// smaller content is rejected as an empty page, zero field keeps the default of the method
+type MinContentSizes struct {
+	Markdown int
+	HTML     int
+	Image    int
+}
+
+// BrowserConfig configures the browser tool, zero fields keep the defaults of the tool
+type BrowserConfig struct {
+	FlowID    int64
+	TaskID    *int64
+	SubtaskID *int64
+
+	DataDir  string
+	ScPrvURL string
+	ScPubURL string
+	// ScTLS verifies the certificate of the scraper, the bundled one is self-signed
+	// and needs either the insecure mode or its CA
+	ScTLS TLSConfig
+
+	MaxScreenshots   int
+	MaxContentBytes  int
+	MinSizes         MinContentSizes
+	DedupScreenshots bool
+
+	Policy      *URLPolicy
+	Scope       *DomainScope
+	Screenshots ScreenshotProvider
 }
 
-func NewBrowserTool(flowID int64, taskID, subtaskID *int64, dataDir, scPrvURL, scPubURL string, scp ScreenshotProvider) Tool {
+// NewBrowserTool creates browser tool, it's kept for existing callers,
+// NewBrowserToolWithConfig is easier to read at the call site
+func NewBrowserTool(flowID int64, taskID, subtaskID *int64, dataDir, scPrvURL, scPubURL string,
+	maxScreenshots, maxContentBytes int, minSizes MinContentSizes, dedupScreenshots bool,
+	policy *URLPolicy, scp ScreenshotProvider,
+) Tool {
+	return NewBrowserToolWithConfig(BrowserConfig{
+		FlowID:           flowID,
+		TaskID:           taskID,
+		SubtaskID:        subtaskID,
+		DataDir:          dataDir,
+		ScPrvURL:         scPrvURL,
+		ScPubURL:         scPubURL,
+		MaxScreenshots:   maxScreenshots,
+		MaxContentBytes:  maxContentBytes,
+		MinSizes:         minSizes,
+		DedupScreenshots: dedupScreenshots,
+		Policy:           policy,
+		Screenshots:      scp,
+	})
+}
+
+// NewBrowserToolWithConfig creates browser tool, max content bytes truncates markdown and html content
+// returned to the agent and zero value keeps it unlimited, zero max screenshots means the default limit
+// of concurrent screenshots, nil policy and scope allow any target
+func NewBrowserToolWithConfig(cfg BrowserConfig) Tool {
 	return &browser{
-		flowID:    flowID,
-		taskID:    taskID,
-		subtaskID: subtaskID,
-		dataDir:   dataDir,
-		scPrvURL:  scPrvURL,
-		scPubURL:  scPubURL,
-		scp:       scp,
+		flowID:           cfg.FlowID,
+		taskID:           cfg.TaskID,
+		subtaskID:        cfg.SubtaskID,
+		dataDir:          cfg.DataDir,
+		scPrvURL:         cfg.ScPrvURL,
+		scPubURL:         cfg.ScPubURL,
+		scTLS:            cfg.ScTLS,
+		scSem:            newScreenshotSemaphore(cfg.MaxScreenshots),
+		scp:              cfg.Screenshots,
+		policy:           cfg.Policy,
+		scope:            cfg.Scope,
+		maxContentBytes:  cfg.MaxContentBytes,
+		minSizes:         cfg.MinSizes,
+		dedupScreenshots: cfg.DedupScreenshots,
+	}
+}
+
+func (b *browser) getMinMdContentSize() int {
+	if b.minSizes.Markdown > 0 {
+		return b.minSizes.Markdown
 	}
+	return minMdContentSize
+}
+
+// getMinHtmlContentSize keeps the markdown minimum by default because the html of small pages
+// is accepted as long as their markdown is
+func (b *browser) getMinHtmlContentSize() int {
+	if b.minSizes.HTML > 0 {
+		return b.minSizes.HTML
+	}
+	return minMdContentSize
+}
+
+func (b *browser) getMinImgContentSize() int {
+	if b.minSizes.Image > 0 {
+		return b.minSizes.Image
+	}
+	return minImgContentSize
+}
+
+// newScreenshotSemaphore returns semaphore to share between browser instances,
+// non-positive limit falls back to the default one
+func newScreenshotSemaphore(limit int) chan struct{} {
+	if limit <= 0 {
+		limit = defaultMaxScreenshots
+	}
+
+	return make(chan struct{}, limit)
 }
 
 func (b *browser) wrapCommandResult(ctx context.Context, name, result, url, screen string, err error) (string, error) {
@@ -91,7 +429,10 @@ func (b *browser) wrapCommandResult(ctx context.Context, name, result, url, scre
 		}).Error("browser tool failed")
 		return fmt.Sprintf("browser tool '%s' handled with error: %v", name, err), nil
 	}
-	_, _ = b.scp.PutScreenshot(ctx, screen, url, b.taskID, b.subtaskID)
+	// crawl results have no screenshot
+	if screen != "" {
+		_, _ = b.scp.PutScreenshot(ctx, screen, url, b.taskID, b.subtaskID)
+	}
 	return result, nil
 }
 
@@ -112,21 +453,41 @@ func (b *browser) Handle(ctx context.Context, name string, args json.RawMessage)
 		return "", fmt.Errorf("failed to unmarshal browser action: %w", err)
 	}
 
-	logger = logger.WithFields(logrus.Fields{
-		"action": action.Action,
-		"url":    action.Url,
+	waitUntil, err := normalizeWaitUntil(action.WaitUntil)
+	if err != nil {
+		logger.WithError(err).Error("invalid wait condition of the browser")
+		return fmt.Sprintf("invalid wait_until of the browser: %v", err), nil
+	}
+	req := FetchRequest{
+		URL:       action.Url,
+		WaitFor:   strings.TrimSpace(action.WaitFor),
+		WaitUntil: waitUntil,
+	}
+
+	logger = withToolFields(logger, "browser", action.Url, b.flowID, b.taskID, b.subtaskID, logrus.Fields{
+		"action":     action.Action,
+		"url":        action.Url,
+		"wait_for":   req.WaitFor,
+		"wait_until": req.WaitUntil,
 	})
 
 	switch action.Action {
 	case Markdown:
-		result, screen, err := b.ContentMD(action.Url)
-		return b.wrapCommandResult(ctx, name, result, action.Url, screen, err)
+		result, metadata, screen, err := b.ContentMDWithMetadata(ctx, req)
+		return b.wrapCommandResult(ctx, name, metadata.String()+result, action.Url, screen, err)
 	case HTML:
-		result, screen, err := b.ContentHTML(action.Url)
-		return b.wrapCommandResult(ctx, name, result, action.Url, screen, err)
+		req.Method, req.Body, req.FormData = action.Method, action.Body, action.FormData
+		result, metadata, screen, err := b.ContentHTMLWithMetadata(ctx, req)
+		return b.wrapCommandResult(ctx, name, metadata.String()+result, action.Url, screen, err)
 	case Links:
-		result, screen, err := b.Links(action.Url)
+		result, screen, err := b.LinksWithRequest(ctx, req)
+		return b.wrapCommandResult(ctx, name, result, action.Url, screen, err)
+	case Text:
+		result, screen, err := b.ContentTextWithRequest(ctx, req, true)
 		return b.wrapCommandResult(ctx, name, result, action.Url, screen, err)
+	case Crawl:
+		result, err := b.Crawl(ctx, action.Url, action.Depth.Int(), action.MaxPages.Int())
+		return b.wrapCommandResult(ctx, name, result, action.Url, "", err)
 	default:
 		logger.Error("unknown file action")
 		return "", fmt.Errorf("unknown file action: %s", action.Action)
@@ -134,7 +495,15 @@ func (b *browser) Handle(ctx context.Context, name string, args json.RawMessage)
 }
 
 func (b *browser) ContentMD(url string) (string, string, error) {
-	log.Println("Trying to get content from", url)
+	return b.ContentMDWithRequest(context.Background(), FetchRequest{URL: url})
+}
+
+// ContentMDWithRequest is the same as ContentMD but the scraper waits for the conditions of the request
+// before capturing the markdown and the screenshot of the page, the options (e.g. WithBearer) are applied
+// to the request
+func (b *browser) ContentMDWithRequest(ctx context.Context, req FetchRequest, opts ...FetchOption) (string, string, error) {
+	req = req.withOptions(opts)
+	log.Println("Trying to get content from", redactURL(req.URL))
 
 	var (
 		wg                        sync.WaitGroup
@@ -143,6 +512,40 @@ func (b *browser) ContentMD(url string) (string, string, error) {
 	)
 	wg.Add(2)
 
+	go func() {
+		defer wg.Done()
+		content, errContent = b.getMDWithRequest(ctx, req)
+	}()
+
+	go func() {
+		defer wg.Done()
+		screenshotName, errScreenshot = b.getScreenshotWithRequest(ctx, req)
+	}()
+
+	wg.Wait()
+
+	if errContent != nil {
+		return "", "", errContent
+	}
+	if errScreenshot != nil {
+		return "", "", errScreenshot
+	}
+
+	return content, screenshotName, nil
+}
+
+// ContentMDInMemory is the same as ContentMD but returns screenshot bytes without writing them to the data dir
+func (b *browser) ContentMDInMemory(url string) (string, Screenshot, error) {
+	log.Println("Trying to get content from", redactURL(url))
+
+	var (
+		wg                        sync.WaitGroup
+		content                   string
+		screenshot                Screenshot
+		errContent, errScreenshot error
+	)
+	wg.Add(2)
+
 	go func() {
 		defer wg.Done()
 		content, errContent = b.getMD(url)
@@ -150,7 +553,53 @@ func (b *browser) ContentMD(url string) (string, string, error) {
 
 	go func() {
 		defer wg.Done()
-		screenshotName, errScreenshot = b.getScreenshot(url)
+		screenshot, errScreenshot = b.fetchScreenshot(context.Background(), FetchRequest{URL: url})
+	}()
+
+	wg.Wait()
+
+	if errContent != nil {
+		return "", Screenshot{}, errContent
+	}
+	if errScreenshot != nil {
+		return "", Screenshot{}, errScreenshot
+	}
+
+	return content, screenshot, nil
+}
+
+// ContentText returns the main content of the page as plain text without navigation, ads and
+// other boilerplate, the screenshot is captured to the data dir only when it's requested
+func (b *browser) ContentText(url string, screenshot bool) (string, string, error) {
+	return b.ContentTextWithRequest(context.Background(), FetchRequest{URL: url}, screenshot)
+}
+
+// ContentTextWithRequest is the same as ContentText but the scraper waits for the conditions
+// of the request before extracting the text, the options are applied to the request
+func (b *browser) ContentTextWithRequest(ctx context.Context, req FetchRequest, screenshot bool, opts ...FetchOption) (string, string, error) {
+	req = req.withOptions(opts)
+	log.Println("Trying to get readable text from", redactURL(req.URL))
+
+	if !screenshot {
+		content, err := b.getText(ctx, req)
+		return content, "", err
+	}
+
+	var (
+		wg                        sync.WaitGroup
+		content, screenshotName   string
+		errContent, errScreenshot error
+	)
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		content, errContent = b.getText(ctx, req)
+	}()
+
+	go func() {
+		defer wg.Done()
+		screenshotName, errScreenshot = b.getScreenshotWithRequest(ctx, req)
 	}()
 
 	wg.Wait()
@@ -166,7 +615,15 @@ func (b *browser) ContentMD(url string) (string, string, error) {
 }
 
 func (b *browser) ContentHTML(url string) (string, string, error) {
-	log.Println("Trying to get content from", url)
+	return b.ContentHTMLWithRequest(context.Background(), FetchRequest{URL: url})
+}
+
+// ContentHTMLWithRequest asks the scraper to reproduce the request (e.g. submit the form)
+// before capturing HTML content and screenshot of the resulting page, the options
+// (e.g. WithBasicAuth) are applied to the request
+func (b *browser) ContentHTMLWithRequest(ctx context.Context, req FetchRequest, opts ...FetchOption) (string, string, error) {
+	req = req.withOptions(opts)
+	log.Println("Trying to get content from", redactURL(req.URL))
 
 	var (
 		wg                        sync.WaitGroup
@@ -177,12 +634,12 @@ func (b *browser) ContentHTML(url string) (string, string, error) {
 
 	go func() {
 		defer wg.Done()
-		content, errContent = b.getHTML(url)
+		content, errContent = b.getHTML(ctx, req)
 	}()
 
 	go func() {
 		defer wg.Done()
-		screenshotName, errScreenshot = b.getScreenshot(url)
+		screenshotName, errScreenshot = b.getScreenshotWithRequest(ctx, req)
 	}()
 
 	wg.Wait()
@@ -197,8 +654,66 @@ func (b *browser) ContentHTML(url string) (string, string, error) {
 	return content, screenshotName, nil
 }
 
+// ContentMDWithMetadata is the same as ContentMDWithRequest but also returns the metadata of the page,
+// the metadata is optional, so it's empty if the scraper failed to detect it
+func (b *browser) ContentMDWithMetadata(ctx context.Context, req FetchRequest, opts ...FetchOption) (string, PageMetadata, string, error) {
+	req = req.withOptions(opts)
+
+	var (
+		wg       sync.WaitGroup
+		metadata PageMetadata
+	)
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		metadata = b.getMetadata(ctx, req)
+	}()
+
+	content, screenshotName, err := b.ContentMDWithRequest(ctx, req)
+	wg.Wait()
+	if err != nil {
+		return "", PageMetadata{}, "", err
+	}
+
+	return content, metadata, screenshotName, nil
+}
+
+// ContentHTMLWithMetadata is the same as ContentHTMLWithRequest but also returns the metadata
+// of the resulting page, the metadata is optional, so it's empty if the scraper failed to detect it
+func (b *browser) ContentHTMLWithMetadata(ctx context.Context, req FetchRequest, opts ...FetchOption) (string, PageMetadata, string, error) {
+	req = req.withOptions(opts)
+
+	var (
+		wg       sync.WaitGroup
+		metadata PageMetadata
+	)
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		metadata = b.getMetadata(ctx, req)
+	}()
+
+	content, screenshotName, err := b.ContentHTMLWithRequest(ctx, req)
+	wg.Wait()
+	if err != nil {
+		return "", PageMetadata{}, "", err
+	}
+
+	return content, metadata, screenshotName, nil
+}
+
 func (b *browser) Links(url string) (string, string, error) {
-	log.Println("Trying to get urls from", url)
+	return b.LinksWithRequest(context.Background(), FetchRequest{URL: url})
+}
+
+// LinksWithRequest is the same as Links but the scraper waits for the conditions of the request
+// before collecting the links, e.g. the ones which the single-page app renders, the options
+// are applied to the request
+func (b *browser) LinksWithRequest(ctx context.Context, req FetchRequest, opts ...FetchOption) (string, string, error) {
+	req = req.withOptions(opts)
+	log.Println("Trying to get urls from", redactURL(req.URL))
 
 	var (
 		wg                      sync.WaitGroup
@@ -209,12 +724,12 @@ func (b *browser) Links(url string) (string, string, error) {
 
 	go func() {
 		defer wg.Done()
-		links, errLinks = b.getLinks(url)
+		links, errLinks = b.getLinks(ctx, req)
 	}()
 
 	go func() {
 		defer wg.Done()
-		screenshotName, errScreenshot = b.getScreenshot(url)
+		screenshotName, errScreenshot = b.getScreenshotWithRequest(ctx, req)
 	}()
 
 	wg.Wait()
@@ -229,45 +744,126 @@ func (b *browser) Links(url string) (string, string, error) {
 	return links, screenshotName, nil
 }
 
+// crawlPage is the page waiting in the crawl queue with its distance from the start page
+type crawlPage struct {
+	url   string
+	depth int
+}
+
+// Crawl returns markdown of the page and of pages of the same host linked from it up to the depth,
+// pages are visited once in the breadth-first order without screenshots until maxPages are collected
+// or the time budget is over, the start page must be fetched while other failed pages are only noted
+func (b *browser) Crawl(ctx context.Context, targetURL string, depth, maxPages int) (string, error) {
+	log.Println("Trying to crawl", redactURL(targetURL))
+
+	start, err := url.Parse(targetURL)
+	if err != nil || (start.Scheme != "http" && start.Scheme != "https") || start.Host == "" {
+		return "", fmt.Errorf("invalid url to crawl '%s'", targetURL)
+	}
+	start.Fragment = ""
+
+	depth = min(max(depth, 0), crawlMaxDepth)
+	if maxPages <= 0 {
+		maxPages = crawlDefaultPages
+	}
+	maxPages = min(maxPages, crawlMaxPages)
+
+	ctx, cancel := context.WithTimeout(ctx, crawlTimeout)
+	defer cancel()
+
+	var (
+		builder strings.Builder
+		pages   int
+		queue   = []crawlPage{{url: start.String()}}
+		visited = map[string]struct{}{start.String(): {}}
+	)
+	for len(queue) > 0 && pages < maxPages {
+		if ctx.Err() != nil {
+			builder.WriteString(fmt.Sprintf("[crawl stopped: time budget is over after %d pages]\n", pages))
+			break
+		}
+
+		page := queue[0]
+		queue = queue[1:]
+		pages++
+
+		content, err := b.getMDContext(ctx, page.url)
+		if err != nil && pages == 1 {
+			return "", err
+		}
+
+		builder.WriteString(fmt.Sprintf("# Page %d: %s\n\n", pages, page.url))
+		if err != nil {
+			builder.WriteString(fmt.Sprintf("failed to fetch the page: %v\n\n", err))
+			continue
+		}
+		builder.WriteString(strings.TrimSpace(content))
+		builder.WriteString("\n\n")
+
+		if page.depth >= depth {
+			continue
+		}
+
+		links, err := b.fetchLinks(ctx, FetchRequest{URL: page.url})
+		if err != nil {
+			log.Println("Failed to get links to crawl from", redactURL(page.url), err)
+			continue
+		}
+		for _, link := range crawlLinks(page.url, start.Host, links) {
+			if _, ok := visited[link]; ok {
+				continue
+			}
+			visited[link] = struct{}{}
+			queue = append(queue, crawlPage{url: link, depth: page.depth + 1})
+		}
+	}
+
+	return b.truncateContent(builder.String(), b.getMinMdContentSize()), nil
+}
+
+// crawlLinks resolves links of the page and keeps http(s) links of the same host without fragments
+func crawlLinks(pageURL, host string, links []scraperLink) []string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	var result []string
+	for _, l := range links {
+		link, err := base.Parse(strings.TrimSpace(l.Link))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || !strings.EqualFold(link.Host, host) {
+			continue
+		}
+		link.Fragment = ""
+		result = append(result, link.String())
+	}
+
+	return result
+}
+
+// resolveUrl picks the scraper by the target host after the scope and the policy allowed it,
+// the scope goes first since it needs no lookup of the host
 func (b *browser) resolveUrl(targetURL string) (*url.URL, error) {
 	u, err := url.Parse(targetURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse url: %w", err)
 	}
 
+	if err := b.scope.Check(u.Hostname()); err != nil {
+		return nil, err
+	}
+	if err := b.policy.Check(context.Background(), u.Hostname()); err != nil {
+		return nil, err
+	}
+
 	host, _, err := net.SplitHostPort(u.Host)
 	if err != nil {
 		host = u.Host
 	}
 
-	// determine if target is private or public
-	isPrivate := false
-
-	hostIP := net.ParseIP(host)
-	if hostIP != nil {
-		isPrivate = hostIP.IsPrivate() || hostIP.IsLoopback()
-	} else {
-		ip, err := net.ResolveIPAddr("ip", host)
-		if err == nil {
-			isPrivate = ip.IP.IsPrivate() || ip.IP.IsLoopback()
-		} else {
-			lowerHost := strings.ToLower(host)
-			if strings.Contains(lowerHost, "localhost") || !strings.Contains(lowerHost, ".") {
-				isPrivate = true
-			} else {
-				for _, zone := range localZones {
-					if strings.HasSuffix(lowerHost, zone) {
-						isPrivate = true
-						break
-					}
-				}
-			}
-		}
-	}
-
 	// select appropriate scraper URL with fallback
 	var scraperURL string
-	if isPrivate {
+	if isPrivateHost(host) {
 		scraperURL = b.scPrvURL
 		if scraperURL == "" {
 			scraperURL = b.scPubURL
@@ -286,18 +882,58 @@ func (b *browser) resolveUrl(targetURL string) (*url.URL, error) {
 	return url.Parse(scraperURL)
 }
 
+// isPrivateHost determines if the target host is in the private network by its address,
+// unresolvable hosts are considered private when they look like local names
+func isPrivateHost(host string) bool {
+	hostIP := parseHostIP(host)
+	if hostIP != nil {
+		return hostIP.IsPrivate() || hostIP.IsLoopback()
+	}
+
+	ip, err := net.ResolveIPAddr("ip", host)
+	if err == nil {
+		return ip.IP.IsPrivate() || ip.IP.IsLoopback()
+	}
+
+	lowerHost := strings.ToLower(host)
+	if strings.Contains(lowerHost, "localhost") || !strings.Contains(lowerHost, ".") {
+		return true
+	}
+
+	for _, zone := range localZones {
+		if strings.HasSuffix(lowerHost, zone) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (b *browser) writeScreenshotToFile(screenshot []byte) (string, error) {
+	flowDir, err := flowArtifactsDir(b.dataDir, b.flowID)
+	if err != nil {
+		return "", err
+	}
+
 	// Write screenshot to file
-	flowDirName := fmt.Sprintf("flow-%d", b.flowID)
-	err := os.MkdirAll(filepath.Join(b.dataDir, "screenshots", flowDirName), os.ModePerm)
+	err = os.MkdirAll(flowDir, os.ModePerm)
 	if err != nil {
 		return "", fmt.Errorf("error creating directory: %w", err)
 	}
 
-	screenshotName := fmt.Sprintf("%s.png", time.Now().Format("2006-01-02-15-04-05"))
-	path := filepath.Join(b.dataDir, "screenshots", flowDirName, screenshotName)
+	if b.dedupScreenshots {
+		return writeDedupScreenshot(flowDir, screenshot)
+	}
 
-	file, err := os.Create(path)
+	// parallel subtasks capture screenshots in the same second, so the name gets the counter
+	// instead of overwriting the screenshot of another call
+	timestamp := time.Now().Format("2006-01-02-15-04-05")
+	screenshotName := timestamp + ".png"
+	file, err := os.OpenFile(filepath.Join(flowDir, screenshotName), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666)
+	for idx := 1; errors.Is(err, os.ErrExist); idx++ {
+		screenshotName = fmt.Sprintf("%s-%d.png", timestamp, idx)
+		file, err = os.OpenFile(filepath.Join(flowDir, screenshotName), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o666)
+	}
 	if err != nil {
 		return "", fmt.Errorf("error creating file: %w", err)
 	}
@@ -313,6 +949,15 @@ func (b *browser) writeScreenshotToFile(screenshot []byte) (string, error) {
 }
 
 func (b *browser) getMD(targetURL string) (string, error) {
+	return b.getMDContext(context.Background(), targetURL)
+}
+
+func (b *browser) getMDContext(ctx context.Context, targetURL string) (string, error) {
+	return b.getMDWithRequest(ctx, FetchRequest{URL: targetURL})
+}
+
+func (b *browser) getMDWithRequest(ctx context.Context, req FetchRequest) (string, error) {
+	targetURL := req.URL
 	scraperURL, err := b.resolveUrl(targetURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve url: %w", err)
@@ -320,21 +965,26 @@ func (b *browser) getMD(targetURL string) (string, error) {
 
 	query := scraperURL.Query()
 	query.Add("url", targetURL)
+	req.addRenderWait(query)
 	scraperURL.Path = "/markdown"
 	scraperURL.RawQuery = query.Encode()
 
-	content, err := b.callScraper(scraperURL.String())
+	content, err := b.callScraperWithRequest(ctx, scraperURL.String(), req)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch content by url '%s': %w", targetURL, err)
+		return "", fmt.Errorf("failed to fetch content by url '%s': %w", redactURL(targetURL), err)
 	}
-	if len(content) < minMdContentSize {
-		return "", fmt.Errorf("content size is less than minimum: %d bytes", minMdContentSize)
+	minSize := b.getMinMdContentSize()
+	if len(content) < minSize {
+		return "", fmt.Errorf("content size is less than minimum: %d bytes", minSize)
 	}
 
-	return string(content), nil
+	return b.truncateContent(string(content), minSize), nil
 }
 
-func (b *browser) getHTML(targetURL string) (string, error) {
+// getText requests the readability extraction from the scraper and falls back to the markdown
+// if the scraper doesn't have the endpoint
+func (b *browser) getText(ctx context.Context, req FetchRequest) (string, error) {
+	targetURL := req.URL
 	scraperURL, err := b.resolveUrl(targetURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve url: %w", err)
@@ -342,21 +992,28 @@ func (b *browser) getHTML(targetURL string) (string, error) {
 
 	query := scraperURL.Query()
 	query.Add("url", targetURL)
-	scraperURL.Path = "/html"
+	req.addRenderWait(query)
+	scraperURL.Path = "/readable"
 	scraperURL.RawQuery = query.Encode()
 
-	content, err := b.callScraper(scraperURL.String())
+	content, err := b.callScraperWithRequest(ctx, scraperURL.String(), req)
+	var statusErr *scraperStatusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound {
+		log.Println("Scraper doesn't support readable text, falling back to markdown for", redactURL(targetURL))
+		return b.getMDWithRequest(ctx, req)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch content by url '%s': %w", targetURL, err)
+		return "", fmt.Errorf("failed to fetch readable text by url '%s': %w", redactURL(targetURL), err)
 	}
-	if len(content) < minMdContentSize {
-		return "", fmt.Errorf("content size is less than minimum: %d bytes", minMdContentSize)
+	if len(content) < minTextContentSize {
+		return "", fmt.Errorf("readable text size is less than minimum: %d bytes", minTextContentSize)
 	}
 
-	return string(content), nil
+	return b.truncateContent(string(content), minTextContentSize), nil
 }
 
-func (b *browser) getLinks(targetURL string) (string, error) {
+func (b *browser) getHTML(ctx context.Context, req FetchRequest) (string, error) {
+	targetURL := req.URL
 	scraperURL, err := b.resolveUrl(targetURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve url: %w", err)
@@ -364,21 +1021,97 @@ func (b *browser) getLinks(targetURL string) (string, error) {
 
 	query := scraperURL.Query()
 	query.Add("url", targetURL)
-	scraperURL.Path = "/links"
+	req.addRenderWait(query)
+	scraperURL.Path = "/html"
+	scraperURL.RawQuery = query.Encode()
+
+	content, err := b.callScraperWithRequest(ctx, scraperURL.String(), req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch content by url '%s': %w", redactURL(targetURL), err)
+	}
+	minSize := b.getMinHtmlContentSize()
+	if len(content) < minSize {
+		return "", fmt.Errorf("content size is less than minimum: %d bytes", minSize)
+	}
+
+	// the scraper doesn't pass the content type of the target page, so it's detected by the content
+	return b.truncateContent(renderBody("", content), minSize), nil
+}
+
+// truncateContent cuts the content above the limit and marks it, the limit never goes below
+// the minimum content size to keep the content meaningful
+func (b *browser) truncateContent(content string, minSize int) string {
+	if b.maxContentBytes <= 0 || len(content) <= b.maxContentBytes {
+		return content
+	}
+
+	limit := max(b.maxContentBytes, minSize)
+	if len(content) <= limit {
+		return content
+	}
+
+	// step back to the rune start to not break multi-byte characters
+	for limit > 0 && !utf8.RuneStart(content[limit]) {
+		limit--
+	}
+
+	return fmt.Sprintf("%s\n\n[content truncated: %d of %d bytes shown]", content[:limit], limit, len(content))
+}
+
+// getMetadata requests title, description, canonical URL and language of the page from the scraper,
+// failures are only logged because the content of the page is useful without the metadata
+// and scrapers without the endpoint answer with not found
+func (b *browser) getMetadata(ctx context.Context, req FetchRequest) PageMetadata {
+	targetURL := req.URL
+	logger := logrus.WithContext(ctx).WithFields(req.logFields())
+
+	scraperURL, err := b.resolveUrl(targetURL)
+	if err != nil {
+		logger.WithError(err).Warn("failed to resolve url for page metadata")
+		return PageMetadata{}
+	}
+
+	query := scraperURL.Query()
+	query.Add("url", targetURL)
+	req.addRenderWait(query)
+	scraperURL.Path = "/metadata"
 	scraperURL.RawQuery = query.Encode()
 
-	content, err := b.callScraper(scraperURL.String())
+	content, err := b.callScraperWithRequest(ctx, scraperURL.String(), req)
+	var statusErr *scraperStatusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusNotFound {
+		return PageMetadata{}
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch links by url '%s': %w", targetURL, err)
+		logger.WithError(err).Warn("failed to fetch page metadata")
+		return PageMetadata{}
 	}
 
-	links := []struct {
-		Title string
-		Link  string
-	}{}
-	err = json.Unmarshal(content, &links)
+	var metadata PageMetadata
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		logger.WithError(err).Warn("failed to unmarshal page metadata")
+		return PageMetadata{}
+	}
+
+	// the fields come from the page, so they are kept on a single line of the block
+	for _, field := range []*string{&metadata.Title, &metadata.Description, &metadata.CanonicalURL, &metadata.Language} {
+		*field = strings.Join(strings.Fields(*field), " ")
+	}
+
+	return metadata
+}
+
+// scraperLink is the link of the page returned by the scraper
+type scraperLink struct {
+	Title string
+	Link  string
+}
+
+func (b *browser) getLinks(ctx context.Context, req FetchRequest) (string, error) {
+	targetURL := req.URL
+	links, err := b.fetchLinks(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal links: %w", err)
+		return "", err
 	}
 
 	var buffer strings.Builder
@@ -398,44 +1131,137 @@ func (b *browser) getLinks(targetURL string) (string, error) {
 	return buffer.String(), nil
 }
 
+func (b *browser) fetchLinks(ctx context.Context, req FetchRequest) ([]scraperLink, error) {
+	targetURL := req.URL
+	scraperURL, err := b.resolveUrl(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve url: %w", err)
+	}
+
+	query := scraperURL.Query()
+	query.Add("url", targetURL)
+	req.addRenderWait(query)
+	scraperURL.Path = "/links"
+	scraperURL.RawQuery = query.Encode()
+
+	content, err := b.callScraperWithRequest(ctx, scraperURL.String(), req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch links by url '%s': %w", redactURL(targetURL), err)
+	}
+
+	var links []scraperLink
+	if err := json.Unmarshal(content, &links); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal links: %w", err)
+	}
+
+	return links, nil
+}
+
 func (b *browser) getScreenshot(targetURL string) (string, error) {
+	return b.getScreenshotWithRequest(context.Background(), FetchRequest{URL: targetURL})
+}
+
+func (b *browser) getScreenshotWithRequest(ctx context.Context, req FetchRequest) (string, error) {
+	screenshot, err := b.fetchScreenshot(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	return b.writeScreenshotToFile(screenshot.Data)
+}
+
+// fetchScreenshot gets the page screenshot from the scraper and validates its size
+func (b *browser) fetchScreenshot(ctx context.Context, req FetchRequest) (Screenshot, error) {
+	targetURL := req.URL
 	scraperURL, err := b.resolveUrl(targetURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve url: %w", err)
+		return Screenshot{}, fmt.Errorf("failed to resolve url: %w", err)
+	}
+
+	if b.scSem != nil {
+		select {
+		case b.scSem <- struct{}{}:
+			defer func() { <-b.scSem }()
+		case <-ctx.Done():
+			return Screenshot{}, fmt.Errorf("failed to wait for screenshot slot: %w", ctx.Err())
+		}
 	}
 
 	query := scraperURL.Query()
 	query.Add("fullPage", "true")
 	query.Add("url", targetURL)
+	req.addRenderWait(query)
 	scraperURL.Path = "/screenshot"
 	scraperURL.RawQuery = query.Encode()
 
-	content, err := b.callScraper(scraperURL.String())
+	content, err := b.callScraperWithRequest(ctx, scraperURL.String(), req)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch screenshot by url '%s': %w", targetURL, err)
+		return Screenshot{}, fmt.Errorf("failed to fetch screenshot by url '%s': %w", redactURL(targetURL), err)
 	}
-	if len(content) < minImgContentSize {
-		return "", fmt.Errorf("image size is less than minimum: %d bytes", minImgContentSize)
+	minSize := b.getMinImgContentSize()
+	if len(content) < minSize {
+		return Screenshot{}, fmt.Errorf("image size is less than minimum: %d bytes", minSize)
 	}
 
-	return b.writeScreenshotToFile(content)
+	return Screenshot{
+		Data:     content,
+		MIMEType: http.DetectContentType(content),
+	}, nil
 }
 
 func (b *browser) callScraper(url string) ([]byte, error) {
+	return b.callScraperContext(context.Background(), url)
+}
+
+func (b *browser) callScraperContext(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scraper request '%s': %w", redactScraperURL(url), withoutRequestURL(err))
+	}
+
+	return b.doScraperRequest(req)
+}
+
+// callScraperWithRequest passes the fetch options to the scraper in POST body,
+// default requests are sent as plain GET to keep compatibility with the scraper API
+func (b *browser) callScraperWithRequest(ctx context.Context, url string, fetch FetchRequest) ([]byte, error) {
+	if fetch.isDefault() {
+		return b.callScraperContext(ctx, url)
+	}
+
+	logrus.WithContext(ctx).WithFields(fetch.logFields()).Debug("passing the request options to the scraper")
+
+	payload, err := json.Marshal(fetch.scraperOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fetch options: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scraper request '%s': %w", redactScraperURL(url), withoutRequestURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return b.doScraperRequest(req)
+}
+
+// doScraperRequest sends the request to the scraper, errors name the scraper URL with the redacted target URL
+func (b *browser) doScraperRequest(req *http.Request) ([]byte, error) {
+	url := redactScraperURL(req.URL.String())
 	client := &http.Client{
-		Timeout: 65 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
+		Timeout:   65 * time.Second,
+		Transport: sharedTransport("", b.scTLS),
 	}
-	resp, err := client.Get(url)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch data by scraper '%s': %w", url, err)
-	} else if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected resp code for scraper '%s': %d", url, resp.StatusCode)
+		return nil, fmt.Errorf("failed to fetch data by scraper '%s': %w", url, withoutRequestURL(err))
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, &scraperStatusError{url: url, code: resp.StatusCode}
+	}
+
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body for scraper '%s': %w", url, err)
@@ -446,6 +1272,124 @@ func (b *browser) callScraper(url string) ([]byte, error) {
 	return content, nil
 }
 
+// writeDedupScreenshot names the screenshot by SHA-256 of the image and returns the name of
+// the existing file for the same image, the temporary file keeps concurrent writers from
+// exposing partially written images
+func writeDedupScreenshot(flowDir string, screenshot []byte) (string, error) {
+	hash := sha256.Sum256(screenshot)
+	screenshotName := hex.EncodeToString(hash[:]) + ".png"
+	path := filepath.Join(flowDir, screenshotName)
+
+	if info, err := os.Lstat(path); err == nil && info.Mode().IsRegular() {
+		return screenshotName, nil
+	}
+
+	file, err := os.CreateTemp(flowDir, "screenshot-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("error creating file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(screenshot); err != nil {
+		file.Close()
+		return "", fmt.Errorf("error writing to file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("error writing to file: %w", err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", fmt.Errorf("error renaming file: %w", err)
+	}
+
+	return screenshotName, nil
+}
+
+// flowArtifactsDir returns the directory of the flow screenshots and makes sure it stays
+// inside the screenshots directory whatever the data dir and the flow ID are
+func flowArtifactsDir(dataDir string, flowID int64) (string, error) {
+	if flowID < 0 {
+		return "", fmt.Errorf("invalid flow id: %d", flowID)
+	}
+
+	rootDir := filepath.Join(dataDir, "screenshots")
+	flowDir := filepath.Join(rootDir, fmt.Sprintf("flow-%d", flowID))
+	if rel, err := filepath.Rel(rootDir, flowDir); err != nil || rel != filepath.Base(flowDir) {
+		return "", fmt.Errorf("flow directory '%s' is outside of '%s'", flowDir, rootDir)
+	}
+
+	return flowDir, nil
+}
+
+// ListArtifacts returns the paths of the flow screenshots relative to the flow directory,
+// the flow without screenshots has no artifacts and symlinks are never followed
+func ListArtifacts(dataDir string, flowID int64) ([]string, error) {
+	flowDir, err := flowArtifactsDir(dataDir, flowID)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(flowDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to stat flow directory '%s': %w", flowDir, err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("flow path '%s' is not a directory", flowDir)
+	}
+
+	var artifacts []string
+	err = filepath.WalkDir(flowDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(flowDir, path)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, filepath.ToSlash(rel))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flow artifacts in '%s': %w", flowDir, err)
+	}
+
+	return artifacts, nil
+}
+
+// CleanupFlow removes the flow screenshots directory to reclaim disk space when the flow ends,
+// a symlink in place of the directory is removed itself without touching its target
+func CleanupFlow(dataDir string, flowID int64) error {
+	flowDir, err := flowArtifactsDir(dataDir, flowID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(flowDir); err != nil {
+		return fmt.Errorf("failed to remove flow directory '%s': %w", flowDir, err)
+	}
+
+	return nil
+}
+
+// scraperStatusError lets callers detect endpoints which aren't supported by the scraper
+type scraperStatusError struct {
+	url  string
+	code int
+}
+
+func (e *scraperStatusError) Error() string {
+	return fmt.Sprintf("unexpected resp code for scraper '%s': %d", e.url, e.code)
+}
+
 func (b *browser) IsAvailable() bool {
-	return b.scPrvURL != "" || b.scPubURL != ""
+	return (b.scPrvURL != "" || b.scPubURL != "") && IsEnabled(BrowserToolName)
+}
+
+func (b *browser) HealthCheck(ctx context.Context) error {
+	return nil
 }
@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrFlowBudgetExceeded is returned for the paid call which would take the spend of the flow over the cap
+var ErrFlowBudgetExceeded = errors.New("flow budget exceeded")
+
+// BudgetEnforcer tracks the estimated spend of the paid tools per flow and rejects the calls over the cap,
+// it's shared by all tools and subtasks of the flows, so the spend is kept under the lock
+type BudgetEnforcer struct {
+	mx    sync.Mutex
+	limit float64
+	spent map[int64]float64
+}
+
+// NewBudgetEnforcer creates the enforcer with the cap of every flow in US dollars
+func NewBudgetEnforcer(limit float64) *BudgetEnforcer {
+	return &BudgetEnforcer{
+		limit: limit,
+		spent: make(map[int64]float64),
+	}
+}
+
+// Reserve adds the cost of the call to the spend of the flow if it fits into the cap,
+// otherwise the spend is kept as is and ErrFlowBudgetExceeded is returned
+func (b *BudgetEnforcer) Reserve(flowID int64, cost float64) error {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	spent := b.spent[flowID]
+	if spent+cost > b.limit {
+		return fmt.Errorf("%w: spent %.4f of %.4f USD, the call would cost %.4f USD",
+			ErrFlowBudgetExceeded, spent, b.limit, cost)
+	}
+	b.spent[flowID] = spent + max(cost, 0)
+
+	return nil
+}
+
+// Spent returns the estimated spend of the flow in US dollars
+func (b *BudgetEnforcer) Spent(flowID int64) float64 {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	return b.spent[flowID]
+}
+
+// Reset forgets the spend of the flow, e.g. when the flow is finished
+func (b *BudgetEnforcer) Reset(flowID int64) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	delete(b.spent, flowID)
+}
+
+var (
+	flowBudgetMx sync.RWMutex
+	flowBudget   *BudgetEnforcer
+)
+
+// InitFlowBudget caps the estimated spend of the paid tools of every flow in US dollars,
+// non-positive limit turns the cap off
+func InitFlowBudget(limit float64) {
+	flowBudgetMx.Lock()
+	defer flowBudgetMx.Unlock()
+
+	flowBudget = nil
+	if limit > 0 {
+		flowBudget = NewBudgetEnforcer(limit)
+	}
+}
+
+func getFlowBudget() *BudgetEnforcer {
+	flowBudgetMx.RLock()
+	defer flowBudgetMx.RUnlock()
+
+	return flowBudget
+}
+
+// ResetFlowBudget forgets the spend of the flow if the cap is set
+func ResetFlowBudget(flowID int64) {
+	if budget := getFlowBudget(); budget != nil {
+		budget.Reset(flowID)
+	}
+}
+
+// withFlowBudget returns the handler of the tool which reserves the estimated cost of the call in the budget
+// of the flow before the call, free tools which don't estimate the cost are returned as is
+func withFlowBudget(flowID int64, tool Tool) ExecutorHandler {
+	estimator, ok := tool.(CostEstimator)
+	if !ok {
+		return tool.Handle
+	}
+
+	return func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		budget := getFlowBudget()
+		if budget == nil {
+			return tool.Handle(ctx, name, args)
+		}
+
+		// malformed arguments are reported by the tool itself
+		estimate, err := estimator.EstimateCost(args)
+		if err != nil {
+			return tool.Handle(ctx, name, args)
+		}
+
+		if err := budget.Reserve(flowID, estimate.USD); err != nil {
+			logrus.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+				"tool":    name,
+				"flow_id": flowID,
+			}).Warn("paid tool call is rejected by the flow budget")
+			return fmt.Sprintf("%v, use the free tools instead of '%s'", err, name), nil
+		}
+
+		return tool.Handle(ctx, name, args)
+	}
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBudgetEnforcerReserve(t *testing.T) {
+	budget := NewBudgetEnforcer(1)
+
+	if err := budget.Reserve(1, 0.6); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := budget.Reserve(1, 0.5); !errors.Is(err, ErrFlowBudgetExceeded) {
+		t.Fatalf("expected the call over the cap to be rejected, got %v", err)
+	}
+	if spent := budget.Spent(1); spent != 0.6 {
+		t.Errorf("expected the rejected call not to be counted, got %v", spent)
+	}
+	if err := budget.Reserve(1, 0.4); err != nil {
+		t.Errorf("expected the call up to the cap to pass, got %v", err)
+	}
+
+	// flows are counted apart and the reset one starts over
+	if err := budget.Reserve(2, 0.9); err != nil {
+		t.Errorf("expected the other flow to have own budget, got %v", err)
+	}
+	budget.Reset(1)
+	if spent := budget.Spent(1); spent != 0 {
+		t.Errorf("expected the reset flow to have no spend, got %v", spent)
+	}
+}
+
+func TestBudgetEnforcerParallel(t *testing.T) {
+	budget := NewBudgetEnforcer(50)
+
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int64
+	)
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if budget.Reserve(1, 1) == nil {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted.Load() != 50 || budget.Spent(1) != 50 {
+		t.Errorf("expected 50 calls within the cap, got %d with the spend %v", accepted.Load(), budget.Spent(1))
+	}
+}
+
+func TestFlowBudget(t *testing.T) {
+	// three tavily searches of 0.016 fit into the cap and the fourth one doesn't
+	InitFlowBudget(0.05)
+	defer InitFlowBudget(0)
+
+	paid := withFlowBudget(1, NewFakeTavilyTool("answer"))
+	free := withFlowBudget(1, NewExploitDBTool(1, nil, nil, exploitdbFixture))
+
+	search, _ := json.Marshal(SearchAction{Query: "log4shell", MaxResults: 5})
+	lookup, _ := json.Marshal(ExploitDBAction{Query: "struts"})
+
+	for i := 0; i < 3; i++ {
+		result, err := paid(context.Background(), TavilyToolName, search)
+		if err != nil || strings.Contains(result, ErrFlowBudgetExceeded.Error()) {
+			t.Fatalf("expected the call %d within the cap to pass, got %q, %v", i+1, result, err)
+		}
+	}
+	if spent := getFlowBudget().Spent(1); math.Abs(spent-0.048) > 1e-9 {
+		t.Errorf("expected the spend of three calls, got %v", spent)
+	}
+
+	result, err := paid(context.Background(), TavilyToolName, search)
+	if err != nil || !strings.Contains(result, ErrFlowBudgetExceeded.Error()) {
+		t.Fatalf("expected the call over the cap to be blocked, got %q, %v", result, err)
+	}
+
+	result, err = free(context.Background(), ExploitDBToolName, lookup)
+	if err != nil || !strings.Contains(result, "EDB-ID") {
+		t.Errorf("expected the free tool to bypass the budget, got %q, %v", result, err)
+	}
+
+	other := withFlowBudget(2, NewFakeTavilyTool("answer"))
+	if result, _ := other(context.Background(), TavilyToolName, search); strings.Contains(result, ErrFlowBudgetExceeded.Error()) {
+		t.Errorf("expected the other flow to have own budget, got %q", result)
+	}
+
+	ResetFlowBudget(1)
+	if result, _ := paid(context.Background(), TavilyToolName, search); strings.Contains(result, ErrFlowBudgetExceeded.Error()) {
+		t.Errorf("expected the reset flow to start over, got %q", result)
+	}
+}
+
+func TestFlowBudgetMetasearch(t *testing.T) {
+	// the metasearch is charged for the tavily search it fans out, the free engine isn't counted
+	InitFlowBudget(0.02)
+	defer InitFlowBudget(0)
+
+	handle := withFlowBudget(1, NewMetasearchTool(1, nil, nil, map[string]Tool{
+		TavilyToolName: NewFakeTavilyTool("answer"),
+		"free": &fakeResultsSearcher{available: true, items: []SearchResultItem{
+			{Title: "Free", URL: "https://example.com/free", Snippet: "free result"},
+		}},
+	}, time.Second, ResultLimits{}, false, nil, nil))
+
+	search, _ := json.Marshal(SearchAction{Query: "log4shell", MaxResults: 5})
+	result, err := handle(context.Background(), MetasearchToolName, search)
+	if err != nil || strings.Contains(result, ErrFlowBudgetExceeded.Error()) {
+		t.Fatalf("expected the call within the cap to pass, got %q, %v", result, err)
+	}
+	if spent := getFlowBudget().Spent(1); math.Abs(spent-0.016) > 1e-9 {
+		t.Errorf("expected the spend of the tavily search, got %v", spent)
+	}
+
+	result, err = handle(context.Background(), MetasearchToolName, search)
+	if err != nil || !strings.Contains(result, ErrFlowBudgetExceeded.Error()) {
+		t.Errorf("expected the call over the cap to be blocked, got %q, %v", result, err)
+	}
+}
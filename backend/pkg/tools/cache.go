@@ -0,0 +1,204 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"pentagi/pkg/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+const searchCacheDirName = "searchcache"
+
+// CacheProvider stores formatted search results between flow runs,
+// the disk implementation can be replaced by any shared storage
+type CacheProvider interface {
+	Get(key string) (string, bool)
+	Put(key, result string) error
+}
+
+// FreshnessCacheProvider is an optional extension of CacheProvider which also tells when the entry
+// was stored, so the agent is warned about cached results which may be outdated, e.g. exploits of the CVE
+type FreshnessCacheProvider interface {
+	GetWithTime(key string) (string, time.Time, bool)
+	IsStale(storedAt time.Time) bool
+}
+
+// searchStaleNoteFormat is prepended to the stale cached result, the time is when the result was fetched
+const searchStaleNoteFormat = "⚠️ results may be stale (as of %s)\n\n"
+
+type searchCacheEntry struct {
+	CreatedAt time.Time `json:"created_at"`
+	Result    string    `json:"result"`
+}
+
+type diskCache struct {
+	dir        string
+	ttl        time.Duration
+	staleAfter time.Duration
+	now        func() time.Time
+}
+
+// SearchCacheConfig configures the disk-backed search cache, zero durations disable the expiry and the staleness
+type SearchCacheConfig struct {
+	Dir        string
+	TTL        time.Duration
+	StaleAfter time.Duration
+}
+
+// NewSearchCache returns disk-backed cache stored under the dir, entries older than ttl are ignored
+func NewSearchCache(dir string, ttl time.Duration) CacheProvider {
+	return NewSearchCacheWithConfig(SearchCacheConfig{Dir: dir, TTL: ttl})
+}
+
+// NewSearchCacheWithConfig returns disk-backed cache, entries older than the stale threshold are still
+// returned but marked as stale until they expire by the ttl
+func NewSearchCacheWithConfig(cfg SearchCacheConfig) CacheProvider {
+	return &diskCache{
+		dir:        cfg.Dir,
+		ttl:        cfg.TTL,
+		staleAfter: cfg.StaleAfter,
+		now:        time.Now,
+	}
+}
+
+func (c *diskCache) Get(key string) (string, bool) {
+	result, _, ok := c.GetWithTime(key)
+	return result, ok
+}
+
+func (c *diskCache) GetWithTime(key string) (string, time.Time, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return "", time.Time{}, false
+	}
+
+	var entry searchCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", time.Time{}, false
+	}
+
+	if c.ttl > 0 && c.now().Sub(entry.CreatedAt) > c.ttl {
+		_ = os.Remove(c.path(key))
+		return "", time.Time{}, false
+	}
+
+	return entry.Result, entry.CreatedAt, true
+}
+
+// IsStale reports whether the entry stored at the time is older than the stale threshold,
+// the entry of exactly the threshold age is still fresh
+func (c *diskCache) IsStale(storedAt time.Time) bool {
+	return c.staleAfter > 0 && c.now().Sub(storedAt) > c.staleAfter
+}
+
+func (c *diskCache) Put(key, result string) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(searchCacheEntry{
+		CreatedAt: c.now(),
+		Result:    result,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	// write to a temporary file and rename it so that concurrent readers
+	// and writers never observe a partially written entry
+	file, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close cache file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("failed to store cache file: %w", err)
+	}
+
+	return nil
+}
+
+func (c *diskCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// searchCacheKey returns stable key for the engine, query and any extra search parameters
+func searchCacheKey(engine database.SearchengineType, query string, params ...any) string {
+	data, err := json.Marshal(append([]any{engine, query}, params...))
+	if err != nil {
+		data = []byte(fmt.Sprint(engine, query, params))
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// withSearchCache returns cached result for the key or calls search and stores its successful result,
+// the time the result was fetched is kept in the stats and the stale cached result is marked by the note
+func withSearchCache(
+	ctx context.Context,
+	cache CacheProvider,
+	key string,
+	stats *SearchStats,
+	search func() (string, error),
+) (string, error) {
+	if cache == nil {
+		return fetchSearchResult(stats, search)
+	}
+
+	if fc, ok := cache.(FreshnessCacheProvider); ok {
+		if result, storedAt, ok := fc.GetWithTime(key); ok {
+			stats.FetchedAt = storedAt
+			if fc.IsStale(storedAt) {
+				result = withStaleNote(result, storedAt)
+			}
+			return result, nil
+		}
+	} else if result, ok := cache.Get(key); ok {
+		return result, nil
+	}
+
+	result, err := fetchSearchResult(stats, search)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cache.Put(key, result); err != nil {
+		logrus.WithContext(ctx).WithError(err).Warn("failed to store search result in cache")
+	}
+
+	return result, nil
+}
+
+// fetchSearchResult calls search and marks the successful result as fetched now
+func fetchSearchResult(stats *SearchStats, search func() (string, error)) (string, error) {
+	result, err := search()
+	if err == nil {
+		stats.FetchedAt = time.Now()
+	}
+
+	return result, err
+}
+
+// withStaleNote prepends the warning with the time the result was fetched
+func withStaleNote(result string, fetchedAt time.Time) string {
+	return fmt.Sprintf(searchStaleNoteFormat, fetchedAt.UTC().Format("2006-01-02 15:04 MST")) + result
+}
@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pentagi/pkg/database"
+)
+
+func TestSearchCacheKey(t *testing.T) {
+	base := searchCacheKey(database.SearchengineTypeGoogle, "query", 10)
+
+	if got := searchCacheKey(database.SearchengineTypeGoogle, "query", 10); got != base {
+		t.Errorf("expected stable key, got %q and %q", base, got)
+	}
+
+	if got := searchCacheKey(database.SearchengineTypeTavily, "query", 10); got == base {
+		t.Error("expected different key for different engine")
+	}
+
+	if got := searchCacheKey(database.SearchengineTypeGoogle, "other query", 10); got == base {
+		t.Error("expected different key for different query")
+	}
+
+	if got := searchCacheKey(database.SearchengineTypeGoogle, "query", 5); got == base {
+		t.Error("expected different key for different parameters")
+	}
+}
+
+func TestDiskCacheGetPut(t *testing.T) {
+	cache := NewSearchCache(t.TempDir(), time.Hour)
+
+	if _, ok := cache.Get("missing"); ok {
+		t.Error("expected cache miss for unknown key")
+	}
+
+	if err := cache.Put("key", "result"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	result, ok := cache.Get("key")
+	if !ok {
+		t.Fatal("expected cache hit after Put()")
+	}
+	if result != "result" {
+		t.Errorf("Get() = %q, want %q", result, "result")
+	}
+}
+
+func TestDiskCacheTTLExpiry(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	cache := &diskCache{
+		dir: dir,
+		ttl: time.Minute,
+		now: func() time.Time { return now },
+	}
+
+	if err := cache.Put("key", "result"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	now = now.Add(30 * time.Second)
+	if _, ok := cache.Get("key"); !ok {
+		t.Error("expected cache hit within TTL")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := cache.Get("key"); ok {
+		t.Error("expected cache miss after TTL expiry")
+	}
+
+	if _, err := os.Stat(cache.path("key")); !os.IsNotExist(err) {
+		t.Error("expected expired entry to be removed from disk")
+	}
+}
+
+func TestSearchCacheStaleness(t *testing.T) {
+	const staleAfter = 24 * time.Hour
+	storedAt := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		age        time.Duration
+		staleAfter time.Duration
+		wantStale  bool
+	}{
+		{"fresh", time.Hour, staleAfter, false},
+		{"borderline", staleAfter, staleAfter, false},
+		{"stale", staleAfter + time.Second, staleAfter, true},
+		{"staleness disabled", 30 * 24 * time.Hour, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := storedAt
+			cache := &diskCache{dir: t.TempDir(), staleAfter: tt.staleAfter, now: func() time.Time { return now }}
+			if err := cache.Put("key", "cached result"); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			now = storedAt.Add(tt.age)
+
+			var stats SearchStats
+			result, err := withSearchCache(context.Background(), cache, "key", &stats, func() (string, error) {
+				t.Error("expected cached result to be used")
+				return "", nil
+			})
+			if err != nil {
+				t.Fatalf("withSearchCache() error = %v", err)
+			}
+
+			if !stats.FetchedAt.Equal(storedAt) {
+				t.Errorf("expected fetch time %v of the cache entry, got %v", storedAt, stats.FetchedAt)
+			}
+			want := "cached result"
+			if tt.wantStale {
+				want = "⚠️ results may be stale (as of 2026-03-01 10:30 UTC)\n\ncached result"
+			}
+			if result != want {
+				t.Errorf("withSearchCache() = %q, want %q", result, want)
+			}
+			if cache.IsStale(storedAt) != tt.wantStale {
+				t.Errorf("IsStale() = %v, want %v", !tt.wantStale, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestSearchCacheFetchedAt(t *testing.T) {
+	cache := NewSearchCacheWithConfig(SearchCacheConfig{Dir: t.TempDir(), TTL: time.Hour, StaleAfter: time.Nanosecond})
+
+	before := time.Now()
+	var stats SearchStats
+	result, err := withSearchCache(context.Background(), cache, "key", &stats, func() (string, error) {
+		return "fresh result", nil
+	})
+	if err != nil || result != "fresh result" {
+		t.Fatalf("withSearchCache() = %q, %v", result, err)
+	}
+	if stats.FetchedAt.Before(before) || stats.FetchedAt.After(time.Now()) {
+		t.Errorf("expected the result to be fetched now, got %v", stats.FetchedAt)
+	}
+
+	// the note is added on the way out and never stored, so it isn't repeated
+	time.Sleep(time.Millisecond)
+	for i := 0; i < 2; i++ {
+		result, _ = withSearchCache(context.Background(), cache, "key", &SearchStats{}, func() (string, error) {
+			return "", nil
+		})
+		if strings.Count(result, "results may be stale") != 1 {
+			t.Errorf("expected single staleness note, got %q", result)
+		}
+	}
+
+	stats = SearchStats{}
+	if _, err := withSearchCache(context.Background(), nil, "key", &stats, func() (string, error) {
+		return "", fmt.Errorf("engine is down")
+	}); err == nil || !stats.FetchedAt.IsZero() {
+		t.Errorf("expected failed search to keep zero fetch time, got %v, %v", err, stats.FetchedAt)
+	}
+}
+
+func TestDiskCacheConcurrentPut(t *testing.T) {
+	cache := NewSearchCache(t.TempDir(), time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := cache.Put("key", fmt.Sprintf("result %d", i)); err != nil {
+				t.Errorf("Put() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, ok := cache.Get("key"); !ok {
+		t.Error("expected readable entry after concurrent writes")
+	}
+}
+
+func TestGoogleSearchCacheHitAvoidsRequest(t *testing.T) {
+	var queries []url.Values
+	server := newGoogleTestServer(t, 10, &queries)
+	defer server.Close()
+
+	g := &google{
+		apiKey:   "test-key",
+		cxKey:    "test-cx",
+		endpoint: server.URL + "/",
+		cache:    NewSearchCache(t.TempDir(), time.Hour),
+	}
+
+	args, _ := json.Marshal(GoogleSearchAction{Query: "pentagi", MaxResults: 3})
+
+	first, err := g.Handle(context.Background(), GoogleToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	second, err := g.Handle(context.Background(), GoogleToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(queries) != 1 {
+		t.Errorf("expected 1 request to the search API, got %d", len(queries))
+	}
+
+	if first != second {
+		t.Error("expected cached result to match the original one")
+	}
+
+	args, _ = json.Marshal(GoogleSearchAction{Query: "pentagi", MaxResults: 5})
+	if _, err := g.Handle(context.Background(), GoogleToolName, args); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if len(queries) != 2 {
+		t.Errorf("expected new request for different parameters, got %d requests", len(queries))
+	}
+}
@@ -283,3 +283,7 @@ func (c *code) Handle(ctx context.Context, name string, args json.RawMessage) (s
 func (c *code) IsAvailable() bool {
 	return c.store != nil
 }
+
+func (c *code) HealthCheck(ctx context.Context) error {
+	return nil
+}
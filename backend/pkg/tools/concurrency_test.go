@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrentCalls is enough to interleave the calls of parallel subtasks under the race detector
+const concurrentCalls = 32
+
+// runConcurrently starts all calls at once and reports the failures of every call
+func runConcurrently(t *testing.T, call func(idx int) error) {
+	t.Helper()
+
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		errs  = make(chan error, concurrentCalls)
+	)
+	for idx := range concurrentCalls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if err := call(idx); err != nil {
+				errs <- fmt.Errorf("call %d: %w", idx, err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func newJSONServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", "100")
+		w.Write([]byte(body))
+	}))
+}
+
+func TestToolsConcurrentHandle(t *testing.T) {
+	perplexityResponse, err := os.ReadFile("testdata/perplexity_response.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	tavilyServer := newJSONServer(t, `{"answer":"tavily answer","results":[{"title":"t","url":"https://example.com"}]}`)
+	defer tavilyServer.Close()
+	traversaalServer := newJSONServer(t, `{"data":{"response_text":"traversaal answer","web_url":["https://example.com"]}}`)
+	defer traversaalServer.Close()
+	perplexityServer := newJSONServer(t, string(perplexityResponse))
+	defer perplexityServer.Close()
+	hibpServer := newJSONServer(t, `[]`)
+	defer hibpServer.Close()
+	ipinfoServer := newJSONServer(t, `{"ip":"8.8.8.8","org":"AS15169 Google LLC"}`)
+	defer ipinfoServer.Close()
+	fetchServer := newJSONServer(t, `{"status":"ok"}`)
+	defer fetchServer.Close()
+
+	tests := []struct {
+		name   string
+		tool   Tool
+		action any
+	}{
+		{
+			name:   TavilyToolName,
+			tool:   &tavily{apiKey: "test-key", apiURL: tavilyServer.URL, tracer: NewNoopTracer()},
+			action: SearchAction{Query: "log4shell", MaxResults: 5},
+		},
+		{
+			name:   TraversaalToolName,
+			tool:   &traversaal{apiKey: "test-key", apiURL: traversaalServer.URL, tracer: NewNoopTracer()},
+			action: SearchAction{Query: "log4shell", MaxResults: 5},
+		},
+		{
+			name:   PerplexityToolName,
+			tool:   &perplexity{apiKey: "test-key", baseURL: perplexityServer.URL, tracer: NewNoopTracer()},
+			action: PerplexitySearchAction{Query: "log4shell"},
+		},
+		{
+			name:   HIBPToolName,
+			tool:   &hibp{apiKey: "test-key", apiURL: hibpServer.URL, tracer: NewNoopTracer()},
+			action: HIBPAction{Account: "example.com"},
+		},
+		{
+			name:   IPInfoToolName,
+			tool:   &ipinfo{enabled: true, apiURL: ipinfoServer.URL, tracer: NewNoopTracer()},
+			action: IPInfoAction{IP: "8.8.8.8"},
+		},
+		{
+			name:   HTTPFetchToolName,
+			tool:   &httpFetch{enabled: true},
+			action: HTTPFetchAction{URL: fetchServer.URL + "/api/status"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(tt.action)
+			runConcurrently(t, func(int) error {
+				result, err := tt.tool.Handle(context.Background(), tt.name, args)
+				if err != nil {
+					return err
+				}
+				if strings.HasPrefix(result, "failed") {
+					return fmt.Errorf("unexpected failure: %s", result)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestBrowserConcurrentScreenshots(t *testing.T) {
+	scraper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/screenshot":
+			w.Write(make([]byte, minImgContentSize))
+		case "/markdown":
+			w.Write([]byte("# Page\n\n" + strings.Repeat("content ", minMdContentSize)))
+		}
+	}))
+	defer scraper.Close()
+
+	dataDir := t.TempDir()
+	b := &browser{flowID: 1, dataDir: dataDir, scPrvURL: scraper.URL, scSem: newScreenshotSemaphore(4)}
+
+	var (
+		mx    sync.Mutex
+		names = make(map[string]struct{})
+	)
+	runConcurrently(t, func(int) error {
+		_, name, err := b.ContentMD("http://127.0.0.1/page")
+		if err != nil {
+			return err
+		}
+
+		mx.Lock()
+		defer mx.Unlock()
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("screenshot %s is overwritten by another call", name)
+		}
+		names[name] = struct{}{}
+
+		return nil
+	})
+
+	files, err := os.ReadDir(filepath.Join(dataDir, "screenshots", "flow-1"))
+	if err != nil {
+		t.Fatalf("failed to read screenshots: %v", err)
+	}
+	if len(files) != concurrentCalls {
+		t.Errorf("expected %d screenshots, got %d", concurrentCalls, len(files))
+	}
+}
+
+func TestHTTPClientConcurrentConstruction(t *testing.T) {
+	InitHTTPTransport(0, 0, 0)
+	defer InitHTTPTransport(0, 0, 0)
+
+	pool, err := NewProxyPool([]string{"http://127.0.0.1:3128", "http://127.0.0.1:3129"}, ProxyRotationRoundRobin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runConcurrently(t, func(idx int) error {
+		// pool limits may be changed while the tools build their clients
+		if idx%8 == 0 {
+			InitHTTPTransport(idx+1, idx+1, time.Minute)
+		}
+
+		client := newHTTPClient(pickProxy(pool, ""), time.Second)
+		if client.Transport == http.DefaultTransport {
+			return fmt.Errorf("expected the client not to use http.DefaultTransport")
+		}
+		(&duckduckgo{proxyPool: pool}).createHTTPClient()
+		(&hibp{}).createHTTPClient()
+
+		return nil
+	})
+}
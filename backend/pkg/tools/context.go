@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"pentagi/pkg/database"
+
+	"github.com/google/uuid"
 )
 
 type AgentContextKey int
@@ -13,6 +15,8 @@ var agentContextKey AgentContextKey
 type agentContext struct {
 	ParentAgentType  database.MsgchainType `json:"parent_agent_type"`
 	CurrentAgentType database.MsgchainType `json:"current_agent_type"`
+	// RequestID correlates the tool call with its logs, trace events and the stored result
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func GetAgentContext(ctx context.Context) (agentContext, bool) {
@@ -21,8 +25,9 @@ func GetAgentContext(ctx context.Context) (agentContext, bool) {
 }
 
 func PutAgentContext(ctx context.Context, agent database.MsgchainType) context.Context {
+	// the context may carry only the request ID without agents
 	agentCtx, ok := GetAgentContext(ctx)
-	if !ok {
+	if !ok || agentCtx.CurrentAgentType == "" {
 		agentCtx.ParentAgentType = agent
 		agentCtx.CurrentAgentType = agent
 	} else {
@@ -32,3 +37,32 @@ func PutAgentContext(ctx context.Context, agent database.MsgchainType) context.C
 
 	return context.WithValue(ctx, agentContextKey, agentCtx)
 }
+
+// PutRequestID binds the correlation ID of the tool call to the agent context, the agents of the context
+// are kept as they are
+func PutRequestID(ctx context.Context, requestID string) context.Context {
+	agentCtx, _ := GetAgentContext(ctx)
+	agentCtx.RequestID = requestID
+
+	return context.WithValue(ctx, agentContextKey, agentCtx)
+}
+
+// GetRequestID returns the correlation ID of the tool call, it's empty outside of the traced call
+func GetRequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	agentCtx, _ := GetAgentContext(ctx)
+	return agentCtx.RequestID
+}
+
+// withRequestID keeps the correlation ID of the context, e.g. the ID of the tool call given by the model,
+// or generates the new one, so nested calls share the ID of the outer call
+func withRequestID(ctx context.Context) context.Context {
+	if GetRequestID(ctx) != "" {
+		return ctx
+	}
+
+	return PutRequestID(ctx, uuid.NewString())
+}
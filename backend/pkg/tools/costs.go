@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// CostEstimate is the approximate price of the single call of the paid tool before it's executed,
+// it's advisory and used for budgeting, the provider bills by its own meters
+type CostEstimate struct {
+	Requests int
+	Results  int
+	Tokens   int
+	USD      float64
+}
+
+// CostEstimator is implemented by tools of paid providers to estimate the call by its arguments without executing it
+type CostEstimator interface {
+	EstimateCost(args json.RawMessage) (CostEstimate, error)
+}
+
+// SearchPricing is the price list of the paid engine in US dollars
+type SearchPricing struct {
+	PerRequest       float64
+	PerResult        float64
+	PerMillionTokens float64
+}
+
+// DefaultSearchPricing are the list prices of the paid engines keyed by their tool names, the request price
+// of perplexity is the one of the low search context size and tavily is priced by the advanced search depth
+var DefaultSearchPricing = map[string]SearchPricing{
+	GoogleToolName:     {PerRequest: 0.005},
+	PerplexityToolName: {PerRequest: 0.005, PerMillionTokens: 1},
+	TavilyToolName:     {PerRequest: 0.016},
+	TraversaalToolName: {PerRequest: 0.01},
+}
+
+var (
+	configuredPricingMx sync.RWMutex
+	configuredRequests  map[string]float64
+	configuredResults   map[string]float64
+	configuredTokens    map[string]float64
+)
+
+// InitSearchPricing sets the configured prices per request, per result and per million tokens of the engines
+// keyed by their tool names, every price overrides the one of DefaultSearchPricing, negative values are dropped
+func InitSearchPricing(requests, results, tokens map[string]float64) {
+	configuredPricingMx.Lock()
+	defer configuredPricingMx.Unlock()
+
+	configuredRequests = nonNegativePrices(requests)
+	configuredResults = nonNegativePrices(results)
+	configuredTokens = nonNegativePrices(tokens)
+}
+
+func nonNegativePrices(prices map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(prices))
+	for engine, price := range prices {
+		if price >= 0 {
+			result[engine] = price
+		}
+	}
+	return result
+}
+
+// resolvePricing returns the price list of the engine with the configured prices over the default ones
+func resolvePricing(engine string) SearchPricing {
+	pricing := DefaultSearchPricing[engine]
+
+	configuredPricingMx.RLock()
+	defer configuredPricingMx.RUnlock()
+
+	if price, ok := configuredRequests[engine]; ok {
+		pricing.PerRequest = price
+	}
+	if price, ok := configuredResults[engine]; ok {
+		pricing.PerResult = price
+	}
+	if price, ok := configuredTokens[engine]; ok {
+		pricing.PerMillionTokens = price
+	}
+
+	return pricing
+}
+
+// estimate prices the call, requestFactor scales the request price for the options which the provider bills higher
+func (p SearchPricing) estimate(requests, results, tokens int, requestFactor float64) CostEstimate {
+	return CostEstimate{
+		Requests: requests,
+		Results:  results,
+		Tokens:   tokens,
+		USD: float64(requests)*p.PerRequest*requestFactor +
+			float64(results)*p.PerResult +
+			float64(tokens)*p.PerMillionTokens/1_000_000,
+	}
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func estimateCost(t *testing.T, estimator CostEstimator, action any) CostEstimate {
+	t.Helper()
+
+	args, _ := json.Marshal(action)
+	estimate, err := estimator.EstimateCost(args)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	return estimate
+}
+
+func TestPerplexityEstimateCost(t *testing.T) {
+	var previous float64
+	for _, contextSize := range []string{"", "medium", "high"} {
+		tool := NewPerplexityToolWithConfig(PerplexityConfig{APIKey: "test-key", ContextSize: contextSize})
+		estimate := estimateCost(t, tool.(CostEstimator), PerplexitySearchAction{Query: "log4shell exploitation"})
+		if estimate.Requests != 1 || estimate.Tokens <= perplexityMaxTokens {
+			t.Errorf("unexpected estimate of the %q context size: %+v", contextSize, estimate)
+		}
+		if estimate.USD <= previous {
+			t.Errorf("expected the %q context size to cost more than %v, got %v", contextSize, previous, estimate.USD)
+		}
+		previous = estimate.USD
+	}
+
+	// the low context size is the default of the provider
+	low := estimateCost(t, NewPerplexityToolWithConfig(PerplexityConfig{APIKey: "test-key", ContextSize: "low"}).(CostEstimator),
+		PerplexitySearchAction{Query: "log4shell exploitation"})
+	unset := estimateCost(t, NewPerplexityToolWithConfig(PerplexityConfig{APIKey: "test-key"}).(CostEstimator),
+		PerplexitySearchAction{Query: "log4shell exploitation"})
+	if low != unset {
+		t.Errorf("expected the low context size by default, got %+v and %+v", low, unset)
+	}
+
+	// the history is the part of the prompt
+	tool := NewPerplexityToolWithConfig(PerplexityConfig{APIKey: "test-key"}).(CostEstimator)
+	history := estimateCost(t, tool, PerplexitySearchAction{
+		Query: "log4shell exploitation",
+		History: []Message{
+			{Role: "user", Content: strings.Repeat("question ", 100)},
+			{Role: "assistant", Content: strings.Repeat("answer ", 100)},
+		},
+	})
+	if history.Tokens <= unset.Tokens || history.USD <= unset.USD {
+		t.Errorf("expected the history to raise the estimate, got %+v and %+v", history, unset)
+	}
+
+	if _, err := tool.EstimateCost(json.RawMessage(`{"query":`)); err == nil {
+		t.Error("expected error on malformed arguments")
+	}
+}
+
+func TestSearchEstimateCostResults(t *testing.T) {
+	InitSearchPricing(nil, map[string]float64{TavilyToolName: 0.001}, nil)
+	defer InitSearchPricing(nil, nil, nil)
+
+	tavilyTool := &tavily{}
+	googleTool := &google{}
+	traversaalTool := &traversaal{}
+
+	tests := []struct {
+		name         string
+		estimator    CostEstimator
+		action       any
+		wantRequests int
+		wantResults  int
+		wantUSD      float64
+	}{
+		{"tavily default", tavilyTool, SearchAction{Query: "q"}, 1, tavilyDefaultResults, 0.016 + 0.005},
+		{"tavily more results", tavilyTool, SearchAction{Query: "q", MaxResults: 10}, 1, 10, 0.016 + 0.01},
+		{"tavily clamped", tavilyTool, SearchAction{Query: "q", MaxResults: 500}, 1, tavilyMaxResults, 0.016 + float64(tavilyMaxResults)*0.001},
+		{"google single page", googleTool, GoogleSearchAction{Query: "q", MaxResults: 10}, 1, 10, 0.005},
+		{"google several pages", googleTool, GoogleSearchAction{Query: "q", MaxResults: 25}, 3, 25, 0.015},
+		{"google clamped", googleTool, GoogleSearchAction{Query: "q", MaxResults: 500}, 10, googleMaxResults, 0.05},
+		{"traversaal", traversaalTool, SearchAction{Query: "q", MaxResults: 10}, 1, 10, 0.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimate := estimateCost(t, tt.estimator, tt.action)
+			if estimate.Requests != tt.wantRequests || estimate.Results != tt.wantResults {
+				t.Errorf("estimate = %+v, want %d requests of %d results", estimate, tt.wantRequests, tt.wantResults)
+			}
+			if math.Abs(estimate.USD-tt.wantUSD) > 1e-9 {
+				t.Errorf("estimate = %v USD, want %v", estimate.USD, tt.wantUSD)
+			}
+		})
+	}
+}
+
+func TestResolvePricing(t *testing.T) {
+	InitSearchPricing(
+		map[string]float64{PerplexityToolName: 0.012, GoogleToolName: -1},
+		nil,
+		map[string]float64{PerplexityToolName: 0},
+	)
+	defer InitSearchPricing(nil, nil, nil)
+
+	if pricing := resolvePricing(PerplexityToolName); pricing != (SearchPricing{PerRequest: 0.012}) {
+		t.Errorf("expected the configured prices, got %+v", pricing)
+	}
+	if pricing := resolvePricing(GoogleToolName); pricing != DefaultSearchPricing[GoogleToolName] {
+		t.Errorf("expected the negative price to be dropped, got %+v", pricing)
+	}
+	if pricing := resolvePricing(DuckDuckGoToolName); pricing != (SearchPricing{}) {
+		t.Errorf("expected the free engine, got %+v", pricing)
+	}
+}
+
+func TestMetasearchEstimateCost(t *testing.T) {
+	tool := NewMetasearchTool(0, nil, nil, map[string]Tool{
+		TavilyToolName:     NewFakeTavilyTool("answer"),
+		GoogleToolName:     &google{apiKey: "key", cxKey: "cx"},
+		DuckDuckGoToolName: &fakeResultsSearcher{available: true},
+	}, time.Second, ResultLimits{}, false, nil, nil).(CostEstimator)
+
+	estimate := estimateCost(t, tool, SearchAction{Query: "q", MaxResults: 5})
+	if estimate.Requests != 2 || estimate.Results != 10 {
+		t.Errorf("expected one request of five results to every paid engine, got %+v", estimate)
+	}
+	if math.Abs(estimate.USD-(0.016+0.005)) > 1e-9 {
+		t.Errorf("expected the sum of the paid engines, got %v", estimate.USD)
+	}
+
+	SetEnabled(GoogleToolName, false)
+	defer SetEnabled(GoogleToolName, true)
+
+	if estimate := estimateCost(t, tool, SearchAction{Query: "q", MaxResults: 5}); math.Abs(estimate.USD-0.016) > 1e-9 {
+		t.Errorf("expected the disabled engine not to be counted, got %v", estimate.USD)
+	}
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	nvdDetailURL       = "https://nvd.nist.gov/vuln/detail/"
+	maxReferencedCVEs  = 10
+	cveEnrichTimeout   = 10 * time.Second
+	referencedCVEsHead = "# Referenced CVEs"
+)
+
+// cvePattern finds CVE identifiers anywhere in the text
+var cvePattern = regexp.MustCompile(`(?i)\bCVE-\d{4}-\d{4,}\b`)
+
+// CVEEnricher returns short summaries of CVEs by their IDs, e.g. from NVD,
+// missing IDs in the result are rendered by the link only
+type CVEEnricher interface {
+	EnrichCVEs(ctx context.Context, ids []string) (map[string]string, error)
+}
+
+// extractCVEIDs returns unique upper-cased CVE IDs in the order of their first mention
+func extractCVEIDs(text string) []string {
+	var ids []string
+	seen := make(map[string]struct{})
+	for _, match := range cvePattern.FindAllString(text, -1) {
+		id := strings.ToUpper(match)
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// withReferencedCVEs appends the section linking CVEs mentioned in the result to their NVD pages
+// if the enricher is set, the enrichment is bounded by the timeout and the result is returned as is
+// when it fails, so the search never fails because of it
+func withReferencedCVEs(ctx context.Context, result string, enricher CVEEnricher) string {
+	if enricher == nil {
+		return result
+	}
+
+	ids := extractCVEIDs(result)
+	if len(ids) == 0 {
+		return result
+	}
+	ids = ids[:min(len(ids), maxReferencedCVEs)]
+
+	ctx, cancel := context.WithTimeout(ctx, cveEnrichTimeout)
+	defer cancel()
+
+	summaries, err := enricher.EnrichCVEs(ctx, ids)
+	if err != nil {
+		logrus.WithContext(ctx).WithError(err).WithField("cves", ids).Warn("failed to enrich referenced CVEs")
+		return result
+	}
+
+	return strings.TrimRight(result, "\n") + "\n\n" + formatReferencedCVEs(ids, summaries)
+}
+
+func formatReferencedCVEs(ids []string, summaries map[string]string) string {
+	var builder strings.Builder
+
+	builder.WriteString(referencedCVEsHead + "\n\n")
+	for _, id := range ids {
+		builder.WriteString(fmt.Sprintf("- [%s](%s%s)", id, nvdDetailURL, id))
+		if summary := strings.TrimSpace(summaries[id]); summary != "" {
+			builder.WriteString(": " + summary)
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
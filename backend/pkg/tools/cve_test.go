@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type stubCVEEnricher struct {
+	summaries map[string]string
+	err       error
+	ids       []string
+}
+
+func (s *stubCVEEnricher) EnrichCVEs(ctx context.Context, ids []string) (map[string]string, error) {
+	s.ids = ids
+	return s.summaries, s.err
+}
+
+func TestExtractCVEIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"none", "nmap smb scripts", nil},
+		{"title and snippet", "# 1. Log4Shell CVE-2021-44228\n\nfixed after CVE-2021-45046.", []string{"CVE-2021-44228", "CVE-2021-45046"}},
+		{"duplicates and case", "cve-2021-44228, CVE-2021-44228 and Cve-2021-44228", []string{"CVE-2021-44228"}},
+		{"long sequence", "CVE-2024-1234567", []string{"CVE-2024-1234567"}},
+		{"markdown link", "[CVE-2014-0160](https://nvd.nist.gov/vuln/detail/CVE-2014-0160)", []string{"CVE-2014-0160"}},
+		{"short sequence", "CVE-2021-442", nil},
+		{"short year", "CVE-21-44228", nil},
+		{"glued prefix", "XCVE-2021-44228 and _CVE-2021-44228", nil},
+		{"glued suffix", "CVE-2021-44228abc", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractCVEIDs(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractCVEIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithReferencedCVEs(t *testing.T) {
+	result := "# 1. Log4Shell\n\n## Snippet\n\nCVE-2021-44228 and CVE-2021-45046\n\n"
+
+	enricher := &stubCVEEnricher{summaries: map[string]string{
+		"CVE-2021-44228": "Apache Log4j2 JNDI remote code execution",
+	}}
+	want := "# 1. Log4Shell\n\n## Snippet\n\nCVE-2021-44228 and CVE-2021-45046\n\n" +
+		"# Referenced CVEs\n\n" +
+		"- [CVE-2021-44228](https://nvd.nist.gov/vuln/detail/CVE-2021-44228): Apache Log4j2 JNDI remote code execution\n" +
+		"- [CVE-2021-45046](https://nvd.nist.gov/vuln/detail/CVE-2021-45046)\n"
+	if got := withReferencedCVEs(context.Background(), result, enricher); got != want {
+		t.Errorf("withReferencedCVEs() = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(enricher.ids, []string{"CVE-2021-44228", "CVE-2021-45046"}) {
+		t.Errorf("unexpected IDs to enrich %v", enricher.ids)
+	}
+
+	// the enrichment is optional and its failure keeps the result as is
+	if got := withReferencedCVEs(context.Background(), result, nil); got != result {
+		t.Errorf("expected result without enricher to be kept, got %q", got)
+	}
+	failing := &stubCVEEnricher{err: errors.New("nvd is down")}
+	if got := withReferencedCVEs(context.Background(), result, failing); got != result {
+		t.Errorf("expected result to be kept on enrichment failure, got %q", got)
+	}
+
+	noCVEs := &stubCVEEnricher{}
+	if got := withReferencedCVEs(context.Background(), "nmap smb scripts", noCVEs); got != "nmap smb scripts" || noCVEs.ids != nil {
+		t.Errorf("expected no enrichment without CVEs, got %q", got)
+	}
+}
@@ -0,0 +1,352 @@
+package tools
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	dnsTimeout = 10 * time.Second
+	dnsPort    = "53"
+	dnsTLSPort = "853"
+)
+
+// dnsRecordTypes are the supported record types in the order of the result sections
+var dnsRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "NS", "TXT"}
+
+// dnsResolver is the part of net.Resolver used by the tool, tests replace it by the stub
+type dnsResolver interface {
+	LookupIP(ctx context.Context, network, host string) ([]net.IP, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// dnsAnswer is the result of the lookup of the single record type
+type dnsAnswer struct {
+	recordType string
+	records    []string
+	err        error
+}
+
+type dns struct {
+	flowID    int64
+	taskID    *int64
+	subtaskID *int64
+	enabled   bool
+	server    string
+	timeout   time.Duration
+	resolver  dnsResolver
+	tracer    Tracer
+}
+
+// NewDNSTool creates the tool which resolves records of domains, empty server means the system resolver,
+// "tls://" prefix of the server enables DNS over TLS
+func NewDNSTool(flowID int64, taskID, subtaskID *int64, enabled bool,
+	server string, timeout time.Duration,
+) Tool {
+	return &dns{
+		flowID:    flowID,
+		taskID:    taskID,
+		subtaskID: subtaskID,
+		enabled:   enabled,
+		server:    server,
+		timeout:   timeout,
+	}
+}
+
+func (d *dns) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action DNSAction
+	ctx, emitter := startTrace(ctx, d.tracer)
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal dns action")
+		return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+	}
+
+	domain, err := normalizeDomain(action.Domain)
+	if err != nil {
+		logger.WithError(err).Error("invalid domain to resolve")
+		return fmt.Sprintf("invalid domain %q: %v", action.Domain, err), nil
+	}
+
+	recordTypes, err := normalizeDNSRecordTypes(action.RecordTypes)
+	if err != nil {
+		logger.WithError(err).Error("invalid dns record types")
+		return err.Error(), nil
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"domain":       domain,
+		"record_types": recordTypes,
+	})
+
+	answers := d.resolve(ctx, domain, recordTypes)
+	switch {
+	case allDNSAnswers(answers, isDNSNotFound):
+		logger.Debug("domain has no dns records")
+		return fmt.Sprintf("domain %s does not exist (NXDOMAIN) or has no %s records",
+			domain, strings.Join(recordTypes, ", ")), nil
+	case allDNSAnswers(answers, func(err error) bool { return err != nil && !isDNSNotFound(err) }):
+		err := errors.Join(dnsAnswerErrors(answers)...)
+		emitter.Emit(TraceEvent{
+			Name:   "dns tool error swallowed",
+			Input:  domain,
+			Status: err.Error(),
+			Level:  TraceLevelWarning,
+			Metadata: map[string]any{
+				"tool_name":    DNSToolName,
+				"domain":       domain,
+				"record_types": recordTypes,
+				"server":       d.server,
+				"error":        err.Error(),
+			},
+		})
+
+		logger.WithError(err).Error("failed to resolve domain")
+		return fmt.Sprintf("failed to resolve domain %s: %s", domain, describeDNSError(answers[0].err)), nil
+	}
+
+	return formatDNSAnswers(domain, answers), nil
+}
+
+// resolve looks up all record types concurrently within the single timeout
+func (d *dns) resolve(ctx context.Context, domain string, recordTypes []string) []dnsAnswer {
+	timeout := resolveTimeout(DNSToolName, d.timeout)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resolver := d.resolver
+	if resolver == nil {
+		resolver = newDNSResolver(d.server)
+	}
+
+	answers := make([]dnsAnswer, len(recordTypes))
+	done := make(chan struct{}, len(recordTypes))
+	for i, recordType := range recordTypes {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			records, err := lookupDNSRecords(ctx, resolver, domain, recordType)
+			answers[i] = dnsAnswer{recordType: recordType, records: records, err: err}
+		}()
+	}
+	for range recordTypes {
+		<-done
+	}
+
+	return answers
+}
+
+func lookupDNSRecords(ctx context.Context, resolver dnsResolver, domain, recordType string) ([]string, error) {
+	var records []string
+
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		// the resolver returns the name itself when it's canonical
+		if cname = strings.TrimSuffix(cname, "."); cname != "" && !strings.EqualFold(cname, domain) {
+			records = append(records, cname)
+		}
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, fmt.Sprintf("%d %s", mx.Pref, strings.TrimSuffix(mx.Host, ".")))
+		}
+	case "NS":
+		nss, err := resolver.LookupNS(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			records = append(records, strings.TrimSuffix(ns.Host, "."))
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, txt := range txts {
+			records = append(records, fmt.Sprintf("%q", txt))
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, &net.DNSError{Err: "no such record", Name: domain, IsNotFound: true}
+	}
+
+	return records, nil
+}
+
+// newDNSResolver creates the resolver which sends queries to the server instead of the system one,
+// the server is "host[:port]" with optional "udp://", "tcp://" or "tls://" scheme
+func newDNSResolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+
+	scheme, addr := parseDNSServer(server)
+	host, _, _ := net.SplitHostPort(addr)
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := &net.Dialer{}
+			switch scheme {
+			case "tls":
+				// the stream connection makes the resolver use TCP framing of messages
+				tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+				return tlsDialer.DialContext(ctx, "tcp", addr)
+			case "tcp":
+				return dialer.DialContext(ctx, "tcp", addr)
+			default:
+				// the resolver retries truncated UDP answers over TCP
+				return dialer.DialContext(ctx, network, addr)
+			}
+		},
+	}
+}
+
+// parseDNSServer splits the server to the scheme and the address with the default port of the scheme
+func parseDNSServer(server string) (string, string) {
+	scheme, addr, ok := strings.Cut(server, "://")
+	if !ok {
+		scheme, addr = "udp", server
+	}
+
+	port := dnsPort
+	if scheme == "tls" {
+		port = dnsTLSPort
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), port)
+	}
+
+	return scheme, addr
+}
+
+func normalizeDNSRecordTypes(recordTypes []string) ([]string, error) {
+	if len(recordTypes) == 0 {
+		return slices.Clone(dnsRecordTypes), nil
+	}
+
+	var normalized []string
+	for _, recordType := range recordTypes {
+		recordType = strings.ToUpper(strings.TrimSpace(recordType))
+		if !slices.Contains(dnsRecordTypes, recordType) {
+			return nil, fmt.Errorf("record type %q is not supported, expected some of: %s",
+				recordType, strings.Join(dnsRecordTypes, ", "))
+		}
+		if !slices.Contains(normalized, recordType) {
+			normalized = append(normalized, recordType)
+		}
+	}
+
+	slices.SortFunc(normalized, func(a, b string) int {
+		return slices.Index(dnsRecordTypes, a) - slices.Index(dnsRecordTypes, b)
+	})
+
+	return normalized, nil
+}
+
+func isDNSNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+func isDNSTimeout(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsTimeout {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// describeDNSError tells the agent whether the record is missing or the resolver failed to answer
+func describeDNSError(err error) string {
+	switch {
+	case isDNSNotFound(err):
+		return "not found"
+	case isDNSTimeout(err):
+		return "timeout, the DNS server didn't answer in time"
+	default:
+		return fmt.Sprintf("error: %v", err)
+	}
+}
+
+func allDNSAnswers(answers []dnsAnswer, match func(error) bool) bool {
+	for _, answer := range answers {
+		if !match(answer.err) {
+			return false
+		}
+	}
+
+	return len(answers) > 0
+}
+
+func dnsAnswerErrors(answers []dnsAnswer) []error {
+	errs := make([]error, 0, len(answers))
+	for _, answer := range answers {
+		errs = append(errs, fmt.Errorf("%s: %w", answer.recordType, answer.err))
+	}
+
+	return errs
+}
+
+func formatDNSAnswers(domain string, answers []dnsAnswer) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("# DNS records of %s\n\n", domain))
+	for _, answer := range answers {
+		builder.WriteString(fmt.Sprintf("## %s\n", answer.recordType))
+		if answer.err != nil {
+			builder.WriteString(fmt.Sprintf("(%s)\n\n", describeDNSError(answer.err)))
+			continue
+		}
+		builder.WriteString(strings.Join(answer.records, "\n"))
+		builder.WriteString("\n\n")
+	}
+
+	return builder.String()
+}
+
+func (d *dns) IsAvailable() bool {
+	return d.enabled && IsEnabled(DNSToolName)
+}
+
+func (d *dns) HealthCheck(ctx context.Context) error {
+	return nil
+}
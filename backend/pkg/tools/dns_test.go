@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubResolver answers from the maps of records and errors by record type
+type stubResolver struct {
+	records map[string][]string
+	errs    map[string]error
+	delay   time.Duration
+}
+
+func (s *stubResolver) lookup(ctx context.Context, recordType string) ([]string, error) {
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return nil, &net.DNSError{Err: ctx.Err().Error(), IsTimeout: true}
+		}
+	}
+	if err, ok := s.errs[recordType]; ok {
+		return nil, err
+	}
+
+	return s.records[recordType], nil
+}
+
+func (s *stubResolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	recordType := "A"
+	if network == "ip6" {
+		recordType = "AAAA"
+	}
+
+	records, err := s.lookup(ctx, recordType)
+	ips := make([]net.IP, 0, len(records))
+	for _, record := range records {
+		ips = append(ips, net.ParseIP(record))
+	}
+
+	return ips, err
+}
+
+func (s *stubResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	records, err := s.lookup(ctx, "CNAME")
+	if err != nil || len(records) == 0 {
+		return host + ".", err
+	}
+
+	return records[0], nil
+}
+
+func (s *stubResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	records, err := s.lookup(ctx, "MX")
+	mxs := make([]*net.MX, 0, len(records))
+	for i, record := range records {
+		mxs = append(mxs, &net.MX{Host: record, Pref: uint16(10 * (i + 1))})
+	}
+
+	return mxs, err
+}
+
+func (s *stubResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	records, err := s.lookup(ctx, "NS")
+	nss := make([]*net.NS, 0, len(records))
+	for _, record := range records {
+		nss = append(nss, &net.NS{Host: record})
+	}
+
+	return nss, err
+}
+
+func (s *stubResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return s.lookup(ctx, "TXT")
+}
+
+func TestDNSHandle(t *testing.T) {
+	notFound := &net.DNSError{Err: "no such host", IsNotFound: true}
+	timeout := &net.DNSError{Err: "i/o timeout", IsTimeout: true}
+
+	tests := []struct {
+		name        string
+		resolver    *stubResolver
+		recordTypes []string
+		want        string
+		wantEvents  int
+	}{
+		{
+			name: "all records",
+			resolver: &stubResolver{records: map[string][]string{
+				"A":     {"93.184.216.34"},
+				"AAAA":  {"2606:2800:220:1:248:1893:25c8:1946"},
+				"CNAME": {"edge.pentagi-example.net."},
+				"MX":    {"mx1.pentagi-example.com.", "mx2.pentagi-example.com."},
+				"NS":    {"a.iana-servers.net.", "b.iana-servers.net."},
+				"TXT":   {"v=spf1 -all"},
+			}},
+			want: "# DNS records of pentagi-example.com\n\n" +
+				"## A\n93.184.216.34\n\n" +
+				"## AAAA\n2606:2800:220:1:248:1893:25c8:1946\n\n" +
+				"## CNAME\nedge.pentagi-example.net\n\n" +
+				"## MX\n10 mx1.pentagi-example.com\n20 mx2.pentagi-example.com\n\n" +
+				"## NS\na.iana-servers.net\nb.iana-servers.net\n\n" +
+				"## TXT\n\"v=spf1 -all\"\n\n",
+		},
+		{
+			name: "mixed answers",
+			resolver: &stubResolver{
+				records: map[string][]string{"A": {"93.184.216.34"}},
+				errs:    map[string]error{"MX": timeout},
+			},
+			recordTypes: []string{"mx", "A", "CNAME", "a"},
+			want: "# DNS records of pentagi-example.com\n\n" +
+				"## A\n93.184.216.34\n\n" +
+				"## CNAME\n(not found)\n\n" +
+				"## MX\n(timeout, the DNS server didn't answer in time)\n\n",
+		},
+		{
+			name:     "nxdomain",
+			resolver: &stubResolver{errs: map[string]error{"A": notFound, "AAAA": notFound, "CNAME": notFound, "MX": notFound, "NS": notFound, "TXT": notFound}},
+			want:     "domain pentagi-example.com does not exist (NXDOMAIN) or has no A, AAAA, CNAME, MX, NS, TXT records",
+		},
+		{
+			name:        "no records",
+			resolver:    &stubResolver{},
+			recordTypes: []string{"TXT"},
+			want:        "domain pentagi-example.com does not exist (NXDOMAIN) or has no TXT records",
+		},
+		{
+			name:        "timeout",
+			resolver:    &stubResolver{errs: map[string]error{"A": timeout, "NS": timeout}},
+			recordTypes: []string{"A", "NS"},
+			want:        "failed to resolve domain pentagi-example.com: timeout, the DNS server didn't answer in time",
+			wantEvents:  1,
+		},
+		{
+			name:        "resolver deadline",
+			resolver:    &stubResolver{delay: time.Minute},
+			recordTypes: []string{"A"},
+			want:        "failed to resolve domain pentagi-example.com: timeout, the DNS server didn't answer in time",
+			wantEvents:  1,
+		},
+		{
+			name:        "server failure",
+			resolver:    &stubResolver{errs: map[string]error{"NS": &net.DNSError{Err: "server misbehaving"}}},
+			recordTypes: []string{"NS"},
+			want:        "failed to resolve domain pentagi-example.com: error: lookup : server misbehaving",
+			wantEvents:  1,
+		},
+		{
+			name:        "unsupported record type",
+			resolver:    &stubResolver{},
+			recordTypes: []string{"A", "SOA"},
+			want:        `record type "SOA" is not supported, expected some of: A, AAAA, CNAME, MX, NS, TXT`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &capturingTracer{}
+			tool := &dns{enabled: true, timeout: 100 * time.Millisecond, resolver: tt.resolver, tracer: tracer}
+
+			args, _ := json.Marshal(DNSAction{Domain: "https://Pentagi-Example.com/", RecordTypes: tt.recordTypes})
+			result, err := tool.Handle(context.Background(), DNSToolName, args)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Handle() = %q, want %q", result, tt.want)
+			}
+
+			// missing domain is the valid answer, only failures of the resolver are reported as errors
+			if len(tracer.events) != tt.wantEvents {
+				t.Errorf("expected %d error events, got %d", tt.wantEvents, len(tracer.events))
+			}
+		})
+	}
+}
+
+func TestDNSHandleInvalidDomain(t *testing.T) {
+	tool := &dns{enabled: true, resolver: &stubResolver{}}
+
+	args, _ := json.Marshal(DNSAction{Domain: "10.0.0.1"})
+	result, err := tool.Handle(context.Background(), DNSToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.HasPrefix(result, `invalid domain "10.0.0.1"`) {
+		t.Errorf("unexpected result %q", result)
+	}
+}
+
+func TestNormalizeDNSRecordTypes(t *testing.T) {
+	got, err := normalizeDNSRecordTypes(nil)
+	if err != nil || !reflect.DeepEqual(got, dnsRecordTypes) {
+		t.Errorf("normalizeDNSRecordTypes(nil) = %v, %v", got, err)
+	}
+
+	got, err = normalizeDNSRecordTypes([]string{" txt", "MX", "a", "Txt"})
+	if err != nil || !reflect.DeepEqual(got, []string{"A", "MX", "TXT"}) {
+		t.Errorf("normalizeDNSRecordTypes() = %v, %v", got, err)
+	}
+
+	if _, err := normalizeDNSRecordTypes([]string{"PTR"}); err == nil {
+		t.Error("expected error for unsupported record type")
+	}
+}
+
+func TestParseDNSServer(t *testing.T) {
+	if newDNSResolver("") != net.DefaultResolver {
+		t.Error("expected system resolver for empty server")
+	}
+
+	tests := []struct {
+		server string
+		scheme string
+		addr   string
+	}{
+		{server: "127.0.0.1", scheme: "udp", addr: "127.0.0.1:53"},
+		{server: "udp://127.0.0.1:5353", scheme: "udp", addr: "127.0.0.1:5353"},
+		{server: "tcp://[::1]", scheme: "tcp", addr: "[::1]:53"},
+		{server: "tls://dns.example.com", scheme: "tls", addr: "dns.example.com:853"},
+		{server: "tls://1.1.1.1:8853", scheme: "tls", addr: "1.1.1.1:8853"},
+	}
+
+	for _, tt := range tests {
+		scheme, addr := parseDNSServer(tt.server)
+		if scheme != tt.scheme || addr != tt.addr {
+			t.Errorf("parseDNSServer(%q) = %q, %q, want %q, %q", tt.server, scheme, addr, tt.scheme, tt.addr)
+		}
+	}
+}
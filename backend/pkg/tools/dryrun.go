@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// redactedHeaders carry credentials and never show up in the dry run output
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "X-Api-Key", "Api-Key", "Cookie"}
+
+// redactedBodyFields carry credentials in JSON bodies, e.g. tavily sends the key in api_key field
+var redactedBodyFields = []string{"api_key", "apikey", "token", "password", "secret"}
+
+// redactedQueryParams carry credentials in URLs, e.g. google sends the key in key parameter
+var redactedQueryParams = []string{"key", "api_key", "apikey", "token"}
+
+// dryRunCache drops the cache in the dry run mode, so descriptions of requests are never
+// returned later instead of real results
+func dryRunCache(cache CacheProvider, dryRun bool) CacheProvider {
+	if dryRun {
+		return nil
+	}
+
+	return cache
+}
+
+// describeRequest formats the outbound request in the dry run mode instead of sending it,
+// credentials in headers and JSON body are redacted
+func describeRequest(req *http.Request) (string, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		body = data
+	}
+
+	var builder strings.Builder
+	builder.WriteString("# Dry Run\n\n")
+	builder.WriteString(fmt.Sprintf("%s %s\n\n", req.Method, redactRequestURL(req.URL)))
+
+	builder.WriteString("## Headers\n")
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		value := strings.Join(req.Header.Values(key), ", ")
+		if isRedactedHeader(key) {
+			value = redactedValue
+		}
+		builder.WriteString(fmt.Sprintf("%s: %s\n", key, value))
+	}
+
+	if len(body) != 0 {
+		builder.WriteString("\n## Body\n")
+		builder.WriteString(redactBody(body))
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+// dryRunResults describes the request as the single structured result, so the metasearch
+// shows what the engine would send instead of its results
+func dryRunResults(req *http.Request) ([]SearchResultItem, error) {
+	description, err := describeRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return []SearchResultItem{{
+		Title:   "Dry Run",
+		URL:     redactRequestURL(req.URL),
+		Snippet: description,
+	}}, nil
+}
+
+// dryRunTransport catches the request of the API library which builds it on its own,
+// the request is never sent and comes back as dryRunError
+type dryRunTransport struct{}
+
+func (dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, &dryRunError{req: req}
+}
+
+// dryRunError carries the request caught by dryRunTransport through the API library
+type dryRunError struct {
+	req *http.Request
+}
+
+func (e *dryRunError) Error() string {
+	return "request isn't sent in the dry run mode"
+}
+
+// caughtRequest returns the request caught by dryRunTransport from the error of the API library
+func caughtRequest(err error) (*http.Request, error) {
+	var dryRunErr *dryRunError
+	if errors.As(err, &dryRunErr) {
+		return dryRunErr.req, nil
+	}
+	if err == nil {
+		return nil, errors.New("request wasn't caught in the dry run mode")
+	}
+
+	return nil, err
+}
+
+// redactRequestURL hides the password and the credentials in query parameters of the URL
+func redactRequestURL(u *url.URL) string {
+	redacted := *u
+	params := strings.Split(redacted.RawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && slices.Contains(redactedQueryParams, strings.ToLower(name)) {
+			params[i] = key + "=" + redactedValue
+		}
+	}
+	redacted.RawQuery = strings.Join(params, "&")
+
+	return redacted.Redacted()
+}
+
+// isRedactedHeader reports whether the header carries credentials, the name is case-insensitive
+func isRedactedHeader(key string) bool {
+	return slices.ContainsFunc(redactedHeaders, func(name string) bool { return strings.EqualFold(name, key) })
+}
+
+// redactBody pretty prints JSON body with redacted credentials, other bodies are kept as is
+func redactBody(body []byte) string {
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+
+	data, err := json.MarshalIndent(redactValue(value), "", "  ")
+	if err != nil {
+		return string(body)
+	}
+
+	return string(data)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if slices.Contains(redactedBodyFields, strings.ToLower(key)) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+
+	return value
+}
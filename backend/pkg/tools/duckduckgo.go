@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,19 +13,18 @@ import (
 	"time"
 
 	"pentagi/pkg/database"
-	obs "pentagi/pkg/observability"
-	"pentagi/pkg/observability/langfuse"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/html"
 )
 
 const (
-	duckduckgoMaxResults = 10
-	duckduckgoMaxRetries = 3
-	duckduckgoSearchURL  = "https://html.duckduckgo.com/html/"
-	duckduckgoTimeout    = 30 * time.Second
-	duckduckgoUserAgent  = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	duckduckgoDefaultResults = 10
+	duckduckgoMaxResults     = 10
+	duckduckgoMaxRetries     = 3
+	duckduckgoSearchURL      = "https://html.duckduckgo.com/html/"
+	duckduckgoTimeout        = 30 * time.Second
+	duckduckgoUserAgent      = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
 )
 
 // Region constants for DuckDuckGo search
@@ -40,9 +40,9 @@ const (
 
 // Safe search levels for DuckDuckGo
 const (
-	DuckDuckGoSafeSearchStrict   = "strict"   // Strict filtering
-	DuckDuckGoSafeSearchModerate = "moderate" // Moderate filtering
-	DuckDuckGoSafeSearchOff      = "off"      // No filtering
+	DuckDuckGoSafeSearchStrict   = SafeSearchStrict   // Strict filtering
+	DuckDuckGoSafeSearchModerate = SafeSearchModerate // Moderate filtering
+	DuckDuckGoSafeSearchOff      = SafeSearchOff      // No filtering
 )
 
 // Time range constants for DuckDuckGo search
@@ -67,37 +67,99 @@ type searchResponse struct {
 }
 
 type duckduckgo struct {
-	flowID     int64
-	taskID     *int64
-	subtaskID  *int64
-	enabled    bool
-	proxyURL   string
-	region     string
-	safeSearch string
-	timeRange  string
-	slp        SearchLogProvider
+	flowID       int64
+	taskID       *int64
+	subtaskID    *int64
+	enabled      bool
+	proxyURL     string
+	proxyPool    *ProxyPool
+	transport    http.RoundTripper
+	retry        RetryPolicy
+	region       string
+	safeSearch   string
+	timeRange    string
+	limits       ResultLimits
+	cache        CacheProvider
+	sourceFooter bool
+	template     *ResultTemplate
+	cveEnricher  CVEEnricher
+	slp          SearchLogProvider
+	summarizer   SummarizeHandler
+	tracer       Tracer
 }
 
+// DuckDuckGoConfig configures the duckduckgo search tool, zero fields keep the defaults of the tool
+type DuckDuckGoConfig struct {
+	FlowID    int64
+	TaskID    *int64
+	SubtaskID *int64
+	Enabled   bool
+
+	ProxyURL  string
+	ProxyPool *ProxyPool
+	Retry     RetryPolicy
+
+	Region     string
+	SafeSearch string
+	TimeRange  string
+	Limits     ResultLimits
+
+	SourceFooter bool
+	Template     *ResultTemplate
+	SearchLog    SearchLogProvider
+	Summarizer   SummarizeHandler
+}
+
+// NewDuckDuckGoTool creates duckduckgo search tool, it's kept for existing callers,
+// NewDuckDuckGoToolWithConfig is easier to read at the call site
 func NewDuckDuckGoTool(flowID int64, taskID, subtaskID *int64, enabled bool,
-	proxyURL, region, safeSearch, timeRange string, slp SearchLogProvider,
+	proxyURL string, proxyPool *ProxyPool, retry RetryPolicy, region, safeSearch, timeRange string, limits ResultLimits,
+	sourceFooter bool, template *ResultTemplate, slp SearchLogProvider,
 ) Tool {
+	return NewDuckDuckGoToolWithConfig(DuckDuckGoConfig{
+		FlowID:       flowID,
+		TaskID:       taskID,
+		SubtaskID:    subtaskID,
+		Enabled:      enabled,
+		ProxyURL:     proxyURL,
+		ProxyPool:    proxyPool,
+		Retry:        retry,
+		Region:       region,
+		SafeSearch:   safeSearch,
+		TimeRange:    timeRange,
+		Limits:       limits,
+		SourceFooter: sourceFooter,
+		Template:     template,
+		SearchLog:    slp,
+	})
+}
+
+// NewDuckDuckGoToolWithConfig creates duckduckgo search tool, the proxy of the pool
+// takes precedence over the single proxy URL
+func NewDuckDuckGoToolWithConfig(cfg DuckDuckGoConfig) Tool {
 	return &duckduckgo{
-		flowID:     flowID,
-		taskID:     taskID,
-		subtaskID:  subtaskID,
-		enabled:    enabled,
-		proxyURL:   proxyURL,
-		region:     region,
-		safeSearch: safeSearch,
-		timeRange:  timeRange,
-		slp:        slp,
+		flowID:       cfg.FlowID,
+		taskID:       cfg.TaskID,
+		subtaskID:    cfg.SubtaskID,
+		enabled:      cfg.Enabled,
+		proxyURL:     cfg.ProxyURL,
+		proxyPool:    cfg.ProxyPool,
+		retry:        cfg.Retry,
+		region:       cfg.Region,
+		safeSearch:   cfg.SafeSearch,
+		timeRange:    cfg.TimeRange,
+		limits:       cfg.Limits,
+		sourceFooter: cfg.SourceFooter,
+		template:     cfg.Template,
+		slp:          cfg.SearchLog,
+		summarizer:   cfg.Summarizer,
 	}
 }
 
 // Handle processes the search request from an AI agent
 func (d *duckduckgo) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
 	var action SearchAction
-	ctx, observation := obs.Observer.NewObservation(ctx)
+	ctx, emitter := startTrace(ctx, d.tracer)
 	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
 		"tool": name,
 		"args": string(args),
@@ -107,72 +169,98 @@ func (d *duckduckgo) Handle(ctx context.Context, name string, args json.RawMessa
 		logger.WithError(err).Error("failed to unmarshal duckduckgo search action")
 		return "", fmt.Errorf("failed to unmarshal %s search action arguments: %w", name, err)
 	}
+	action.Query = sanitizeQuery(action.Query, maxQueryLength)
 
-	// Set default number of results if invalid
-	numResults := int(action.MaxResults)
-	if numResults < 1 || numResults > duckduckgoMaxResults {
-		numResults = duckduckgoMaxResults
-	}
+	numResults := d.limits.resolve(duckduckgoDefaultResults, duckduckgoMaxResults).clamp(int(action.MaxResults))
+	summarize := action.Summarize && d.summarizer != nil
 
 	logger = logger.WithFields(logrus.Fields{
-		"query":       action.Query[:min(len(action.Query), 1000)],
+		"query":       action.Query,
 		"num_results": numResults,
 		"region":      d.region,
+		"summarize":   summarize,
 	})
 
 	// Perform search
-	result, err := d.search(ctx, action.Query, numResults)
+	cacheKey := searchCacheKey(database.SearchengineTypeDuckduckgo, action.Query, numResults,
+		d.region, d.safeSearch, d.timeRange, d.template.cacheKey(), summarize)
+	stats, start := SearchStats{Cached: true}, time.Now()
+	result, err := withSearchCache(ctx, d.cache, cacheKey, &stats, func() (string, error) {
+		result, count, err := d.search(withSearchPriority(ctx, action.Priority), action.Query, numResults, summarize)
+		stats.Cached, stats.ResultCount = false, count
+		return result, err
+	})
+	recordSearchCall(ctx, database.SearchengineTypeDuckduckgo, stats.Cached, time.Since(start), err)
 	if err != nil {
-		observation.Event(
-			langfuse.WithEventName("search engine error swallowed"),
-			langfuse.WithEventInput(action.Query),
-			langfuse.WithEventStatus(err.Error()),
-			langfuse.WithEventLevel(langfuse.ObservationLevelWarning),
-			langfuse.WithEventMetadata(langfuse.Metadata{
-				"tool_name":   DuckDuckGoToolName,
-				"engine":      "duckduckgo",
-				"query":       action.Query,
-				"max_results": numResults,
-				"region":      d.region,
-				"error":       err.Error(),
-			}),
-		)
+		emitter.Emit(searchErrorEvent(action.Query, err, map[string]any{
+			"tool_name":   DuckDuckGoToolName,
+			"engine":      "duckduckgo",
+			"max_results": numResults,
+			"region":      d.region,
+		}))
 
 		logger.WithError(err).Error("failed to search in DuckDuckGo")
 		return fmt.Sprintf("failed to search in DuckDuckGo: %v", err), nil
 	}
 
 	// Log search results if configured
-	if agentCtx, ok := GetAgentContext(ctx); ok {
-		_, _ = d.slp.PutLog(
-			ctx,
-			agentCtx.ParentAgentType,
-			agentCtx.CurrentAgentType,
-			database.SearchengineTypeDuckduckgo,
-			action.Query,
-			result,
-			d.taskID,
-			d.subtaskID,
-		)
-	}
+	stats.Duration = time.Since(start)
+	_, _ = putSearchLog(ctx, d.slp, database.SearchengineTypeDuckduckgo, action.Query, result, stats, d.taskID, d.subtaskID)
 
-	return result, nil
+	result = withReferencedCVEs(ctx, result, d.cveEnricher)
+
+	return withSourceFooter(result, "DuckDuckGo", d.sourceFooter), nil
 }
 
 // search performs a web search using DuckDuckGo
-func (d *duckduckgo) search(ctx context.Context, query string, maxResults int) (string, error) {
+func (d *duckduckgo) search(ctx context.Context, query string, maxResults int, summarize bool) (string, int, error) {
+	results, err := d.fetchResults(ctx, query, maxResults)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if len(results) == 0 {
+		return "No results found", 0, nil
+	}
+
+	// Format results in readable text format
+	result := d.formatSearchResults(results)
+	items := d.getSearchResultItems(results)
+	return withResultsSummary(ctx, d.summarizer, summarize, "DuckDuckGo", query, items, result), len(results), nil
+}
+
+// searchResults returns structured results for the metasearch
+func (d *duckduckgo) searchResults(ctx context.Context, query string, maxResults int) ([]SearchResultItem, error) {
+	results, err := d.fetchResults(ctx, query, maxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.getSearchResultItems(results), nil
+}
+
+// fetchResults requests DuckDuckGo with retries and limits parsed results to the requested number,
+// the search waits for its turn in the rate limit queue by the priority of the context
+func (d *duckduckgo) fetchResults(ctx context.Context, query string, maxResults int) ([]searchResult, error) {
+	if err := waitSearchQueue(ctx, database.SearchengineTypeDuckduckgo, searchPriority(ctx)); err != nil {
+		return nil, err
+	}
+
 	// Build form data for POST request
 	formData := d.buildFormData(query)
 
 	// Create HTTP client with proper configuration
 	client := d.createHTTPClient()
 
-	// Execute request with retry logic
+	// Execute request with retry logic, the scraping is retried on any failure except the unknown host and tls errors
+	policy := d.retry.WithMaxAttempts(duckduckgoMaxRetries)
+	start := time.Now()
+
 	var response *searchResponse
-	for attempt := 0; attempt < duckduckgoMaxRetries; attempt++ {
+	for attempt := 0; ; attempt++ {
 		req, err := http.NewRequestWithContext(ctx, "POST", duckduckgoSearchURL, strings.NewReader(formData))
 		if err != nil {
-			return "", fmt.Errorf("failed to create search request: %w", err)
+			return nil, fmt.Errorf("failed to create search request: %w", err)
 		}
 
 		// Add necessary headers for POST request
@@ -183,26 +271,28 @@ func (d *duckduckgo) search(ctx context.Context, query string, maxResults int) (
 
 		resp, err := client.Do(req)
 		if err != nil {
-			if attempt == duckduckgoMaxRetries-1 {
-				return "", fmt.Errorf("failed to execute search after %d attempts: %w", duckduckgoMaxRetries, err)
+			err = newNetworkError(err, "failed to execute search after %d attempts", attempt+1)
+			if errors.Is(err, ErrDNS) || errors.Is(err, ErrTLSHandshake) {
+				return nil, err
+			}
+			delay, ok := policy.next(ctx, start, attempt, nil)
+			if !ok {
+				return nil, err
 			}
-			select {
-			case <-ctx.Done():
-				return "", ctx.Err()
-			case <-time.After(time.Second):
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
 			}
 			continue
 		}
 
 		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close()
-			if attempt == duckduckgoMaxRetries-1 {
-				return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+			delay, ok := policy.next(ctx, start, attempt, resp)
+			if !ok {
+				return nil, newSearchError(errorKindByStatus(resp.StatusCode), "unexpected status code: %d", resp.StatusCode)
 			}
-			select {
-			case <-ctx.Done():
-				return "", ctx.Err()
-			case <-time.After(time.Second):
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
 			}
 			continue
 		}
@@ -210,19 +300,19 @@ func (d *duckduckgo) search(ctx context.Context, query string, maxResults int) (
 		body, err := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		if err != nil {
-			return "", fmt.Errorf("failed to read response body: %w", err)
+			return nil, fmt.Errorf("failed to read response body: %w", err)
 		}
 
 		response, err = d.parseHTMLResponse(body)
 		if err != nil {
-			return "", fmt.Errorf("failed to parse search response: %w", err)
+			return nil, fmt.Errorf("failed to parse search response: %w", err)
 		}
 
 		break
 	}
 
-	if response == nil || len(response.Results) == 0 {
-		return "No results found", nil
+	if response == nil {
+		return nil, nil
 	}
 
 	// Limit results to requested number
@@ -230,8 +320,7 @@ func (d *duckduckgo) search(ctx context.Context, query string, maxResults int) (
 		response.Results = response.Results[:maxResults]
 	}
 
-	// Format results in readable text format
-	return d.formatSearchResults(response.Results), nil
+	return response.Results, nil
 }
 
 // buildFormData creates form data for DuckDuckGo POST request
@@ -509,35 +598,34 @@ func (d *duckduckgo) cleanText(text string) string {
 
 // formatSearchResults formats search results in a readable text format
 func (d *duckduckgo) formatSearchResults(results []searchResult) string {
-	var builder strings.Builder
-
-	for i, result := range results {
-		builder.WriteString(fmt.Sprintf("# %d. %s\n\n", i+1, result.Title))
-		builder.WriteString(fmt.Sprintf("## URL\n%s\n\n", result.URL))
-		builder.WriteString(fmt.Sprintf("## Description\n\n%s\n\n", result.Description))
+	return FormatResults(d.getSearchResultItems(results), FormatOptions{
+		SnippetTitle: "Description",
+		Separator:    true,
+		Template:     d.template,
+		Engine:       "DuckDuckGo",
+	})
+}
 
-		if i < len(results)-1 {
-			builder.WriteString("---\n\n")
-		}
+func (d *duckduckgo) getSearchResultItems(results []searchResult) []SearchResultItem {
+	items := make([]SearchResultItem, 0, len(results))
+	for _, result := range results {
+		items = append(items, SearchResultItem{
+			Title:   result.Title,
+			URL:     result.URL,
+			Snippet: result.Description,
+		})
 	}
 
-	return builder.String()
+	return items
 }
 
-// createHTTPClient creates an HTTP client with configured proxy and timeout
+// createHTTPClient creates an HTTP client with configured proxy and timeout,
+// the proxy of the pool is picked per client to spread the scraping among egress addresses
+// unless the transport is injected
 func (d *duckduckgo) createHTTPClient() *http.Client {
-	client := &http.Client{
-		Timeout: duckduckgoTimeout,
-	}
-
-	// Configure proxy if specified
-	if d.proxyURL != "" {
-		proxyURL, err := url.Parse(d.proxyURL)
-		if err == nil {
-			client.Transport = &http.Transport{
-				Proxy: http.ProxyURL(proxyURL),
-			}
-		}
+	client := newHTTPClient(pickProxy(d.proxyPool, d.proxyURL), resolveTimeout(DuckDuckGoToolName, 0))
+	if d.transport != nil {
+		client.Transport = d.transport
 	}
 
 	return client
@@ -547,5 +635,11 @@ func (d *duckduckgo) createHTTPClient() *http.Client {
 func (d *duckduckgo) IsAvailable() bool {
 	// DuckDuckGo is a free search engine that doesn't require API keys or additional configuration.
 	// We only need to check if it's enabled in the settings according to the user config.
-	return d.enabled
+	return d.enabled && IsEnabled(DuckDuckGoToolName)
+}
+
+// HealthCheck runs a single result search to verify the engine is reachable through the proxy
+func (d *duckduckgo) HealthCheck(ctx context.Context) error {
+	_, _, err := d.search(ctx, "test", 1, false)
+	return err
 }
@@ -31,9 +31,14 @@ func TestDuckDuckGoBasicSearch(t *testing.T) {
 		&subtaskID,
 		true,
 		"", // no proxy
+		nil,
+		RetryPolicy{},
 		RegionUS,
 		DuckDuckGoSafeSearchModerate,
 		"",
+		ResultLimits{},
+		false,
+		nil, // built-in formatting
 		&MockSearchLogProvider{},
 	)
 
@@ -86,9 +91,14 @@ func TestDuckDuckGoDisabled(t *testing.T) {
 		&subtaskID,
 		false, // disabled
 		"",
+		nil,
+		RetryPolicy{},
 		RegionUS,
 		DuckDuckGoSafeSearchModerate,
 		"",
+		ResultLimits{},
+		false,
+		nil, // built-in formatting
 		&MockSearchLogProvider{},
 	)
 
@@ -111,9 +121,14 @@ func TestDuckDuckGoInvalidJSON(t *testing.T) {
 		&subtaskID,
 		true,
 		"",
+		nil,
+		RetryPolicy{},
 		RegionUS,
 		DuckDuckGoSafeSearchModerate,
 		"",
+		ResultLimits{},
+		false,
+		nil, // built-in formatting
 		&MockSearchLogProvider{},
 	)
 
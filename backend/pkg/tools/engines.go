@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"pentagi/pkg/database"
+)
+
+// knownEngines lists the supported search engines, the names match the tool names of the engines
+var knownEngines = []database.SearchengineType{
+	database.SearchengineTypeGoogle,
+	database.SearchengineTypeDuckduckgo,
+	database.SearchengineTypeTavily,
+	database.SearchengineTypeTraversaal,
+	database.SearchengineTypePerplexity,
+	database.SearchengineTypeSearxng,
+	database.SearchengineTypeGithub,
+	database.SearchengineTypeMetasearch,
+	database.SearchengineTypeAbuseipdb,
+	database.SearchengineTypeHibp,
+	database.SearchengineTypeBrowser,
+}
+
+// engineAliases maps friendly names of the engines to their types, keys are normalized
+var engineAliases = map[string]database.SearchengineType{
+	"ddg":            database.SearchengineTypeDuckduckgo,
+	"duck":           database.SearchengineTypeDuckduckgo,
+	"searx":          database.SearchengineTypeSearxng,
+	"gh":             database.SearchengineTypeGithub,
+	"meta":           database.SearchengineTypeMetasearch,
+	"abuseip":        database.SearchengineTypeAbuseipdb,
+	"haveibeenpwned": database.SearchengineTypeHibp,
+}
+
+// KnownEngines returns the types of all supported search engines
+func KnownEngines() []database.SearchengineType {
+	return slices.Clone(knownEngines)
+}
+
+// ParseEngine maps the engine name or its alias to the engine type, the name is case-insensitive
+// and spaces, dashes and underscores are ignored, so "Duck-Duck-Go" is the same as "duckduckgo"
+func ParseEngine(name string) (database.SearchengineType, error) {
+	key := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+	if engine := database.SearchengineType(key); slices.Contains(knownEngines, engine) {
+		return engine, nil
+	}
+	if engine, ok := engineAliases[key]; ok {
+		return engine, nil
+	}
+
+	names := make([]string, 0, len(knownEngines))
+	for _, engine := range knownEngines {
+		names = append(names, string(engine))
+	}
+
+	return "", fmt.Errorf("unknown search engine %q, use one of: %s", name, strings.Join(names, ", "))
+}
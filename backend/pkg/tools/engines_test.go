@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"pentagi/pkg/database"
+)
+
+func TestParseEngine(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    database.SearchengineType
+		wantErr bool
+	}{
+		{name: "google", want: database.SearchengineTypeGoogle},
+		{name: "Tavily", want: database.SearchengineTypeTavily},
+		{name: "  PERPLEXITY ", want: database.SearchengineTypePerplexity},
+		{name: "Duck-Duck-Go", want: database.SearchengineTypeDuckduckgo},
+		{name: "ddg", want: database.SearchengineTypeDuckduckgo},
+		{name: "SearX", want: database.SearchengineTypeSearxng},
+		{name: "have_i_been_pwned", want: database.SearchengineTypeHibp},
+		{name: "bing", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEngine(tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				if !strings.Contains(err.Error(), "google, duckduckgo") {
+					t.Errorf("expected known engines in the error, got %q", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEngine(%q) error = %v", tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseEngine(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnownEngines(t *testing.T) {
+	engines := KnownEngines()
+	for _, engine := range engines {
+		if got, err := ParseEngine(string(engine)); err != nil || got != engine {
+			t.Errorf("ParseEngine(%q) = %q, %v", engine, got, err)
+		}
+	}
+
+	engines[0] = "bing"
+	if KnownEngines()[0] != database.SearchengineTypeGoogle {
+		t.Error("expected KnownEngines to return a copy")
+	}
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"unicode/utf8"
+)
+
+const (
+	// defaultMaxResponseSize caps response bodies of search engines when the limit isn't set
+	defaultMaxResponseSize = 10 * 1024 * 1024
+	// bodyPreviewSize is the size of the body part quoted in decode errors
+	bodyPreviewSize = 200
+)
+
+// ErrResponseTooLarge is returned when the upstream response body exceeds the size limit
+var ErrResponseTooLarge = errors.New("response too large")
+
+// Error kinds of external search engines, use errors.Is to branch on them
+var (
+	ErrAuth                = errors.New("authentication failed")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrNetwork             = errors.New("network error")
+)
+
+// Network error kinds refine ErrNetwork by the cause of the failed request,
+// errors.Is(err, ErrNetwork) matches all of them
+var (
+	ErrDNS          = fmt.Errorf("%w: dns resolution failed", ErrNetwork)
+	ErrConnRefused  = fmt.Errorf("%w: connection refused", ErrNetwork)
+	ErrTLSHandshake = fmt.Errorf("%w: tls handshake failed", ErrNetwork)
+	ErrTimeout      = fmt.Errorf("%w: timeout", ErrNetwork)
+)
+
+// ErrNoResults is returned when the engine answered successfully but found nothing,
+// so the caller can tell the empty answer from the failure and rephrase the query or try another engine
+var ErrNoResults = errors.New("no results")
+
+// noResultsMessage is the text displayed to the agent instead of the empty answer
+const noResultsMessage = "No results found"
+
+// searchError keeps the human-readable message of the failure and classifies it by the kind
+type searchError struct {
+	kind error
+	err  error
+}
+
+func (e *searchError) Error() string {
+	return e.err.Error()
+}
+
+func (e *searchError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// newSearchError formats the error message and marks it by the kind, nil kind leaves error unclassified
+func newSearchError(kind error, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	if kind == nil {
+		return err
+	}
+
+	return &searchError{kind: kind, err: err}
+}
+
+// newNoResultsError marks the empty answer of the engine by the ErrNoResults sentinel
+func newNoResultsError(format string, args ...any) error {
+	return newSearchError(ErrNoResults, format, args...)
+}
+
+// withNoResultsText turns the empty answer into the human-readable text, so it's displayed
+// and cached like any other result, other errors are returned as is
+func withNoResultsText(result string, count int, err error) (string, int, error) {
+	if errors.Is(err, ErrNoResults) {
+		return noResultsMessage, 0, nil
+	}
+
+	return result, count, err
+}
+
+// newNetworkError marks the failed request by the kind of its cause and names the kind in the message,
+// e.g. "failed to do request: network error: timeout: ...", unknown causes are the plain ErrNetwork
+func newNetworkError(err error, format string, args ...any) error {
+	kind := networkErrorKind(err)
+	if kind == ErrNetwork {
+		return newSearchError(ErrNetwork, "%s: %w", fmt.Sprintf(format, args...), err)
+	}
+
+	return newSearchError(kind, "%s: %v: %w", fmt.Sprintf(format, args...), kind, err)
+}
+
+// networkErrorKind classifies the failure of the request by the net, tls and x509 errors wrapped
+// into *url.Error and *net.OpError, the resolver timeout is the timeout rather than the dns failure
+func networkErrorKind(err error) error {
+	var (
+		dnsErr       *net.DNSError
+		netErr       net.Error
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		certErr      *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+
+	switch {
+	case errors.As(err, &dnsErr) && !dnsErr.IsTimeout:
+		return ErrDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrConnRefused
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &certErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return ErrTLSHandshake
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout
+	default:
+		return ErrNetwork
+	}
+}
+
+// isRetryableNetworkError reports whether repeating the failed request may help, timeouts and
+// temporary resolver failures are transient while NXDOMAIN, refused connections and tls failures aren't
+func isRetryableNetworkError(err error) bool {
+	if errors.Is(err, ErrTimeout) {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsTemporary && !dnsErr.IsNotFound
+}
+
+// errorKindByStatus returns the error kind for an unsuccessful HTTP status code or nil if it's unknown
+func errorKindByStatus(statusCode int) error {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuth
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return ErrUpstreamUnavailable
+	default:
+		return nil
+	}
+}
+
+// readLimitedBody reads the response body up to the limit and fails instead of truncating it,
+// so a misbehaving upstream can't exhaust the memory, zero limit means the default one
+func readLimitedBody(body io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		limit = defaultMaxResponseSize
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: body exceeds %d bytes", ErrResponseTooLarge, limit)
+	}
+
+	return data, nil
+}
+
+// bodyPreview returns the beginning of the body to explain why it can't be decoded,
+// e.g. HTML error page returned with 200 status instead of JSON
+func bodyPreview(body []byte) string {
+	body = bytes.TrimSpace(body)
+	if len(body) <= bodyPreviewSize {
+		return string(body)
+	}
+
+	// step back to the rune start to not break multi-byte characters
+	limit := bodyPreviewSize
+	for limit > 0 && !utf8.RuneStart(body[limit]) {
+		limit--
+	}
+
+	return string(body[:limit]) + "..."
+}
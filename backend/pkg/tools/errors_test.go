@@ -0,0 +1,377 @@
+package tools
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestErrorKindByStatus(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		want       error
+	}{
+		{http.StatusUnauthorized, ErrAuth},
+		{http.StatusForbidden, ErrAuth},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusInternalServerError, ErrUpstreamUnavailable},
+		{http.StatusBadGateway, ErrUpstreamUnavailable},
+		{http.StatusServiceUnavailable, ErrUpstreamUnavailable},
+		{http.StatusGatewayTimeout, ErrUpstreamUnavailable},
+		{http.StatusBadRequest, nil},
+		{http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.statusCode), func(t *testing.T) {
+			if got := errorKindByStatus(tt.statusCode); got != tt.want {
+				t.Errorf("errorKindByStatus(%d) = %v, want %v", tt.statusCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSearchErrorKeepsMessage(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := newSearchError(ErrNetwork, "failed to send request: %w", cause)
+
+	if err.Error() != "failed to send request: connection refused" {
+		t.Errorf("unexpected error message: %q", err.Error())
+	}
+	if !errors.Is(err, ErrNetwork) {
+		t.Error("expected error to match ErrNetwork")
+	}
+	if !errors.Is(err, cause) {
+		t.Error("expected error to wrap the cause")
+	}
+
+	err = newSearchError(nil, "request is invalid")
+	for _, kind := range []error{ErrAuth, ErrRateLimited, ErrUpstreamUnavailable, ErrNetwork} {
+		if errors.Is(err, kind) {
+			t.Errorf("expected unclassified error not to match %v", kind)
+		}
+	}
+}
+
+func TestPerplexityHandleErrorResponseKinds(t *testing.T) {
+	p := &perplexity{}
+
+	tests := []struct {
+		statusCode int
+		kind       error
+		message    string
+	}{
+		{http.StatusUnauthorized, ErrAuth, "API key"},
+		{http.StatusTooManyRequests, ErrRateLimited, "too many results"},
+		{http.StatusServiceUnavailable, ErrUpstreamUnavailable, "temporarily offline"},
+	}
+
+	for _, tt := range tests {
+		err := p.handleErrorResponse(tt.statusCode)
+		if !errors.Is(err, tt.kind) {
+			t.Errorf("status %d: expected %v, got %v", tt.statusCode, tt.kind, err)
+		}
+		if !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("status %d: expected message to contain %q, got %q", tt.statusCode, tt.message, err.Error())
+		}
+	}
+}
+
+func TestTavilyParseHTTPResponseKinds(t *testing.T) {
+	tv := &tavily{}
+
+	tests := []struct {
+		statusCode int
+		kind       error
+	}{
+		{http.StatusUnauthorized, ErrAuth},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusBadGateway, ErrUpstreamUnavailable},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.statusCode, Body: io.NopCloser(strings.NewReader(""))}
+		_, _, err := tv.parseHTTPResponse(context.Background(), resp, "")
+		if !errors.Is(err, tt.kind) {
+			t.Errorf("status %d: expected %v, got %v", tt.statusCode, tt.kind, err)
+		}
+	}
+}
+
+func TestNetworkErrorKind(t *testing.T) {
+	// dialErr wraps the cause like the http client does
+	dialErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: err}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"nxdomain", dialErr(&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}), ErrDNS},
+		{"dns server failure", dialErr(&net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}), ErrDNS},
+		{"dns timeout", dialErr(&net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}), ErrTimeout},
+		{"refused", dialErr(os.NewSyscallError("connect", syscall.ECONNREFUSED)), ErrConnRefused},
+		{"unknown authority", dialErr(x509.UnknownAuthorityError{}), ErrTLSHandshake},
+		{"read deadline", dialErr(os.ErrDeadlineExceeded), ErrTimeout},
+		{"context deadline", fmt.Errorf("request: %w", context.DeadlineExceeded), ErrTimeout},
+		{"reset", dialErr(os.NewSyscallError("read", syscall.ECONNRESET)), ErrNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := networkErrorKind(tt.err); got != tt.want {
+				t.Errorf("networkErrorKind() = %v, want %v", got, tt.want)
+			}
+
+			err := newNetworkError(tt.err, "failed to do request")
+			if !errors.Is(err, tt.want) || !errors.Is(err, ErrNetwork) || !errors.Is(err, tt.err) {
+				t.Errorf("expected error to match %v, ErrNetwork and the cause, got %v", tt.want, err)
+			}
+			// the kind is named in the message unless the cause is unknown
+			prefix := "failed to do request: " + tt.want.Error() + ": "
+			if tt.want == ErrNetwork {
+				prefix = "failed to do request: Get"
+			}
+			if !strings.HasPrefix(err.Error(), prefix) {
+				t.Errorf("expected message to start with %q, got %q", prefix, err.Error())
+			}
+		})
+	}
+}
+
+func TestNetworkErrorKindOfRequests(t *testing.T) {
+	// get sends the request by the client and classifies its failure
+	get := func(t *testing.T, client *http.Client, rawURL string) error {
+		t.Helper()
+
+		req, _ := http.NewRequest(http.MethodGet, rawURL, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			t.Fatal("expected the request to fail")
+		}
+
+		return newNetworkError(err, "failed to do request")
+	}
+
+	t.Run("refused by unroutable proxy", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		proxyURL := &url.URL{Scheme: "http", Host: listener.Addr().String()}
+		listener.Close()
+
+		client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		err = get(t, client, "http://example.com")
+		if !errors.Is(err, ErrConnRefused) || !strings.Contains(err.Error(), "connection refused") {
+			t.Errorf("expected %v, got %v", ErrConnRefused, err)
+		}
+	})
+
+	t.Run("timeout of sink server", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		err := get(t, &http.Client{Timeout: 50 * time.Millisecond}, server.URL)
+		if !errors.Is(err, ErrTimeout) || !strings.Contains(err.Error(), "timeout") {
+			t.Errorf("expected %v, got %v", ErrTimeout, err)
+		}
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.NotFoundHandler())
+		defer server.Close()
+
+		err := get(t, &http.Client{}, server.URL)
+		if !errors.Is(err, ErrTLSHandshake) {
+			t.Errorf("expected %v, got %v", ErrTLSHandshake, err)
+		}
+	})
+}
+
+func TestGoogleSearchErrorKinds(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		kind       error
+	}{
+		{"auth", http.StatusUnauthorized, ErrAuth},
+		{"rate limit", http.StatusTooManyRequests, ErrRateLimited},
+		{"upstream", http.StatusServiceUnavailable, ErrUpstreamUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			g := &google{apiKey: "test-key", cxKey: "test-cx", endpoint: server.URL + "/"}
+			svc, err := g.newSearchService(context.Background())
+			if err != nil {
+				t.Fatalf("newSearchService() error = %v", err)
+			}
+
+			_, err = g.search(context.Background(), svc, GoogleSearchAction{Query: "pentagi"}, 10)
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("expected %v, got %v", tt.kind, err)
+			}
+		})
+	}
+
+	t.Run("network", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		g := &google{apiKey: "test-key", cxKey: "test-cx", endpoint: server.URL + "/"}
+		svc, err := g.newSearchService(context.Background())
+		if err != nil {
+			t.Fatalf("newSearchService() error = %v", err)
+		}
+
+		_, err = g.search(context.Background(), svc, GoogleSearchAction{Query: "pentagi"}, 10)
+		if !errors.Is(err, ErrNetwork) {
+			t.Errorf("expected %v, got %v", ErrNetwork, err)
+		}
+	})
+}
+
+func TestSearchNoResults(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		search func(ctx context.Context, serverURL string) error
+	}{
+		{
+			name: "tavily",
+			body: `{"answer":"","results":[]}`,
+			search: func(ctx context.Context, serverURL string) error {
+				_, _, err := (&tavily{apiKey: "test-key", apiURL: serverURL}).search(ctx, "query", 5)
+				return err
+			},
+		},
+		{
+			name: "tavily results",
+			body: `{"results":[]}`,
+			search: func(ctx context.Context, serverURL string) error {
+				_, err := (&tavily{apiKey: "test-key", apiURL: serverURL}).searchResults(ctx, "query", 5)
+				return err
+			},
+		},
+		{
+			name: "traversaal",
+			body: `{"data":{"response_text":"","web_url":[]}}`,
+			search: func(ctx context.Context, serverURL string) error {
+				_, _, err := (&traversaal{apiKey: "test-key", apiURL: serverURL}).search(ctx, "query", traversaalDefaultResults)
+				return err
+			},
+		},
+		{
+			name: "perplexity",
+			body: `{"id":"1","choices":[]}`,
+			search: func(ctx context.Context, serverURL string) error {
+				_, _, err := (&perplexity{apiKey: "test-key", baseURL: serverURL}).search(ctx, "query", "", nil, nil)
+				return err
+			},
+		},
+		{
+			name: "google",
+			body: `{"items":[],"searchInformation":{"totalResults":"0"}}`,
+			search: func(ctx context.Context, serverURL string) error {
+				_, err := (&google{apiKey: "test-key", cxKey: "test-cx", endpoint: serverURL + "/"}).searchResults(ctx, "query", 5)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			err := tt.search(context.Background(), server.URL)
+			if !errors.Is(err, ErrNoResults) {
+				t.Errorf("expected %v, got %v", ErrNoResults, err)
+			}
+		})
+	}
+}
+
+func TestSearchNoResultsText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":{"response_text":"","web_url":[]}}`)
+	}))
+	defer server.Close()
+
+	tool := &traversaal{apiKey: "test-key", apiURL: server.URL}
+	result, err := tool.Handle(context.Background(), TraversaalToolName, []byte(`{"query":"query"}`))
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if result != noResultsMessage {
+		t.Errorf("expected %q to be displayed, got %q", noResultsMessage, result)
+	}
+
+	result, count, err := withNoResultsText("", 0, fmt.Errorf("wrapped: %w", newNoResultsError("nothing found")))
+	if result != noResultsMessage || count != 0 || err != nil {
+		t.Errorf("withNoResultsText() = %q, %d, %v", result, count, err)
+	}
+}
+
+func TestReadLimitedBody(t *testing.T) {
+	data, err := readLimitedBody(strings.NewReader("12345"), 5)
+	if err != nil || string(data) != "12345" {
+		t.Errorf("expected body within the limit to be read, got %q, %v", data, err)
+	}
+
+	if _, err := readLimitedBody(strings.NewReader("123456"), 5); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected %v, got %v", ErrResponseTooLarge, err)
+	}
+}
+
+func TestSearchResponseSizeLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"data":{"response_text":"`+strings.Repeat("a", 4096)+`","web_url":[]},`)
+		io.WriteString(w, `"answer":"`+strings.Repeat("b", 4096)+`","results":[]}`)
+	}))
+	defer server.Close()
+
+	tv := &tavily{apiKey: "test-key", apiURL: server.URL, maxBody: 1024}
+	if _, _, err := tv.search(context.Background(), "query", 5); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("tavily: expected %v, got %v", ErrResponseTooLarge, err)
+	}
+
+	tr := &traversaal{apiKey: "test-key", apiURL: server.URL, maxBody: 1024}
+	if _, _, err := tr.search(context.Background(), "query", traversaalDefaultResults); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("traversaal: expected %v, got %v", ErrResponseTooLarge, err)
+	}
+
+	tv.maxBody, tr.maxBody = 0, 0
+	if _, _, err := tv.search(context.Background(), "query", 5); err != nil {
+		t.Errorf("tavily: expected default limit to fit the body, got %v", err)
+	}
+	if _, _, err := tr.search(context.Background(), "query", traversaalDefaultResults); err != nil {
+		t.Errorf("traversaal: expected default limit to fit the body, got %v", err)
+	}
+}
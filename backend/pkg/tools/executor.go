@@ -138,6 +138,7 @@ type customExecutor struct {
 	mlp   MsgLogProvider
 	store *pgvector.Store
 	vslp  VectorStoreLogProvider
+	al    AuditLogger
 
 	definitions []llms.FunctionDefinition
 	handlers    map[string]ExecutorHandler
@@ -147,12 +148,26 @@ type customExecutor struct {
 
 func (ce *customExecutor) Tools() []llms.Tool {
 	tools := make([]llms.Tool, 0, len(ce.definitions))
+	paginated := false
 	for idx := range ce.definitions {
+		if !IsEnabled(ce.definitions[idx].Name) {
+			continue
+		}
 		json.MarshalIndent(ce.definitions[idx], "", "  ")
 		tools = append(tools, llms.Tool{
 			Type:     "function",
 			Function: &ce.definitions[idx],
 		})
+		paginated = paginated || isPaginatedTool(ce.definitions[idx].Name)
+	}
+
+	// the pages of long results are read by the generic tool which every executor serves
+	if paginated && getResultPageSize() > 0 {
+		definition := registryDefinitions[ResultPageToolName]
+		tools = append(tools, llms.Tool{
+			Type:     "function",
+			Function: &definition,
+		})
 	}
 
 	return tools
@@ -171,6 +186,9 @@ func (ce *customExecutor) createToolObservation(ctx context.Context, name string
 	if ce.subtaskID != nil {
 		metadata["subtask_id"] = *ce.subtaskID
 	}
+	if requestID := GetRequestID(ctx); requestID != "" {
+		metadata["request_id"] = requestID
+	}
 
 	tool := observation.Tool(
 		langfuse.WithToolName(name),
@@ -246,11 +264,22 @@ func (ce *customExecutor) Execute(
 	args json.RawMessage,
 ) (string, error) {
 	startTime := time.Now()
+	// the ID of the tool call ties the stored result to the logs and the trace events of the tools
+	if id != "" {
+		ctx = PutRequestID(ctx, id)
+	}
 
 	handler, ok := ce.handlers[name]
+	if !ok && name == ResultPageToolName && getResultPageSize() > 0 {
+		handler, ok = getResultPage(ce.flowID), true
+	}
 	if !ok {
 		return fmt.Sprintf("function '%s' not found in available tools list", name), nil
 	}
+	if !IsEnabled(name) {
+		return fmt.Sprintf("function '%s' is disabled by the operator, use other tools", name), nil
+	}
+	handler = withResultPages(ce.flowID, withToolAudit(ce.al, ce.flowID, ce.taskID, ce.subtaskID, handler))
 
 	var raw any
 	if err := json.Unmarshal(args, &raw); err != nil {
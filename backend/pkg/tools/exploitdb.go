@@ -0,0 +1,331 @@
+package tools
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	exploitdbDefaultLimit = 10
+	exploitdbMaxLimit     = 30
+	exploitdbURL          = "https://www.exploit-db.com/exploits/"
+)
+
+// exploitdbRequiredColumns are the columns of files_exploits.csv which the tool can't work without,
+// the type, platform, date and codes columns are optional
+var exploitdbRequiredColumns = []string{"id", "file", "description"}
+
+type exploitdbEntry struct {
+	ID       int
+	File     string
+	Title    string
+	Date     string
+	Type     string
+	Platform string
+	Codes    []string
+}
+
+type exploitdbDataset struct {
+	entries []*exploitdbEntry
+}
+
+type exploitdb struct {
+	flowID    int64
+	taskID    *int64
+	subtaskID *int64
+	dataPath  string
+
+	mx      sync.Mutex
+	dataset *exploitdbDataset
+	modTime time.Time
+}
+
+// NewExploitDBTool creates the tool which searches the local copy of ExploitDB by the files_exploits.csv
+// index at dataPath, it works offline and is available only when the file exists
+func NewExploitDBTool(flowID int64, taskID, subtaskID *int64, dataPath string) Tool {
+	return &exploitdb{
+		flowID:    flowID,
+		taskID:    taskID,
+		subtaskID: subtaskID,
+		dataPath:  dataPath,
+	}
+}
+
+func (e *exploitdb) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action ExploitDBAction
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal exploitdb action")
+		return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+	}
+
+	query := strings.TrimSpace(action.Query)
+	if query == "" {
+		return "keywords to search exploits by are empty", nil
+	}
+
+	dataset, err := e.load(ctx)
+	if err != nil {
+		logger.WithError(err).Error("failed to load ExploitDB index")
+		return "", fmt.Errorf("failed to load ExploitDB index: %w", err)
+	}
+
+	limit := action.Limit.Int()
+	if limit <= 0 {
+		limit = exploitdbDefaultLimit
+	}
+	limit = min(limit, exploitdbMaxLimit)
+
+	platform := strings.ToLower(strings.TrimSpace(action.Platform))
+	exploitType := strings.ToLower(strings.TrimSpace(action.Type))
+
+	entries, total := dataset.search(query, platform, exploitType, limit)
+	if len(entries) == 0 {
+		return fmt.Sprintf("No exploits found in ExploitDB matching %q%s\n", query,
+			formatExploitDBFilters(platform, exploitType)), nil
+	}
+
+	return formatExploitDBSearch(query, platform, exploitType, entries, total), nil
+}
+
+// load returns the index from the file at dataPath and reloads it when the file is modified
+func (e *exploitdb) load(ctx context.Context) (*exploitdbDataset, error) {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	info, err := os.Stat(e.dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat ExploitDB index: %w", err)
+	}
+	if e.dataset != nil && info.ModTime().Equal(e.modTime) {
+		return e.dataset, nil
+	}
+
+	file, err := os.Open(e.dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ExploitDB index: %w", err)
+	}
+	defer file.Close()
+
+	dataset, err := parseExploitDBDataset(file)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"path":    e.dataPath,
+		"entries": len(dataset.entries),
+	}).Debug("loaded ExploitDB index")
+
+	e.dataset, e.modTime = dataset, info.ModTime()
+	return dataset, nil
+}
+
+// parseExploitDBDataset reads files_exploits.csv, columns are found by the header, so the index
+// of the older layout without the codes column is read too, rows without ID are skipped
+func parseExploitDBDataset(r io.Reader) (*exploitdbDataset, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ExploitDB index header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for idx, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = idx
+	}
+	for _, column := range exploitdbRequiredColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("ExploitDB index has no %q column", column)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		idx, ok := columns[column]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	dataset := &exploitdbDataset{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ExploitDB index: %w", err)
+		}
+
+		id, err := strconv.Atoi(field(record, "id"))
+		if err != nil {
+			continue
+		}
+
+		entry := &exploitdbEntry{
+			ID:       id,
+			File:     field(record, "file"),
+			Title:    field(record, "description"),
+			Date:     field(record, "date_published"),
+			Type:     field(record, "type"),
+			Platform: field(record, "platform"),
+		}
+		for _, code := range strings.Split(field(record, "codes"), ";") {
+			if code = strings.TrimSpace(code); code != "" {
+				entry.Codes = append(entry.Codes, code)
+			}
+		}
+		dataset.entries = append(dataset.entries, entry)
+	}
+
+	if len(dataset.entries) == 0 {
+		return nil, errors.New("ExploitDB index has no exploits")
+	}
+
+	return dataset, nil
+}
+
+// search returns exploits of the platform and the type which contain all words of the query in the title,
+// the codes (e.g. CVE) or the file path, matches in the title rank higher and equal scores keep
+// the newest exploits first, total is the number of all matches before the limit
+func (d *exploitdbDataset) search(query, platform, exploitType string, limit int) ([]*exploitdbEntry, int) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, 0
+	}
+
+	type scored struct {
+		entry *exploitdbEntry
+		score int
+	}
+
+	var matches []scored
+	for _, entry := range d.entries {
+		if platform != "" && strings.ToLower(entry.Platform) != platform {
+			continue
+		}
+		if exploitType != "" && strings.ToLower(entry.Type) != exploitType {
+			continue
+		}
+
+		title := strings.ToLower(entry.Title)
+		codes := strings.ToLower(strings.Join(entry.Codes, " "))
+		path := strings.ToLower(entry.File)
+
+		score, matched := 0, true
+		for _, term := range terms {
+			switch {
+			case strings.Contains(title, term):
+				score += 10
+			case strings.Contains(codes, term):
+				score += 5
+			case strings.Contains(path, term):
+				score += 1
+			default:
+				matched = false
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, scored{entry: entry, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].entry.ID > matches[j].entry.ID
+	})
+
+	result := make([]*exploitdbEntry, 0, min(limit, len(matches)))
+	for _, match := range matches[:min(limit, len(matches))] {
+		result = append(result, match.entry)
+	}
+
+	return result, len(matches)
+}
+
+func formatExploitDBFilters(platform, exploitType string) string {
+	var filters []string
+	if platform != "" {
+		filters = append(filters, "platform "+platform)
+	}
+	if exploitType != "" {
+		filters = append(filters, "type "+exploitType)
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(filters, ", ") + ")"
+}
+
+func formatExploitDBSearch(query, platform, exploitType string, entries []*exploitdbEntry, total int) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# ExploitDB exploits matching %q%s\n\n", query,
+		formatExploitDBFilters(platform, exploitType)))
+	if total > len(entries) {
+		builder.WriteString(fmt.Sprintf("Showing %d of %d matches, refine the query or the filters to narrow them\n\n",
+			len(entries), total))
+	}
+
+	for idx, entry := range entries {
+		if idx > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(fmt.Sprintf("## EDB-ID %d: %s\n\n", entry.ID, entry.Title))
+		if entry.Platform != "" {
+			builder.WriteString(fmt.Sprintf("- Platform: %s\n", entry.Platform))
+		}
+		if entry.Type != "" {
+			builder.WriteString(fmt.Sprintf("- Type: %s\n", entry.Type))
+		}
+		if entry.Date != "" {
+			builder.WriteString(fmt.Sprintf("- Published: %s\n", entry.Date))
+		}
+		if len(entry.Codes) > 0 {
+			builder.WriteString(fmt.Sprintf("- Codes: %s\n", strings.Join(entry.Codes, ", ")))
+		}
+		builder.WriteString(fmt.Sprintf("- Path: %s\n", entry.File))
+		builder.WriteString(fmt.Sprintf("- URL: %s%d\n", exploitdbURL, entry.ID))
+	}
+
+	return builder.String()
+}
+
+func (e *exploitdb) IsAvailable() bool {
+	if e.dataPath == "" || !IsEnabled(ExploitDBToolName) {
+		return false
+	}
+
+	info, err := os.Stat(e.dataPath)
+	return err == nil && !info.IsDir()
+}
+
+// HealthCheck verifies that the index can be read and parsed
+func (e *exploitdb) HealthCheck(ctx context.Context) error {
+	_, err := e.load(ctx)
+	return err
+}
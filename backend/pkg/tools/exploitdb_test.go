@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const exploitdbFixture = "testdata/exploitdb_files_exploits.csv"
+
+func loadExploitDBFixture(t *testing.T) *exploitdbDataset {
+	t.Helper()
+
+	file, err := os.Open(exploitdbFixture)
+	if err != nil {
+		t.Fatalf("failed to open fixture: %v", err)
+	}
+	defer file.Close()
+
+	dataset, err := parseExploitDBDataset(file)
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %v", err)
+	}
+
+	return dataset
+}
+
+func TestParseExploitDBDataset(t *testing.T) {
+	dataset := loadExploitDBFixture(t)
+
+	if len(dataset.entries) != 6 {
+		t.Fatalf("expected the row without ID to be skipped, got %d entries", len(dataset.entries))
+	}
+	want := &exploitdbEntry{
+		ID:       50689,
+		File:     "exploits/linux/local/50689.txt",
+		Title:    "PolicyKit-1 0.105-31 - Privilege Escalation",
+		Date:     "2022-01-27",
+		Type:     "local",
+		Platform: "linux",
+		Codes:    []string{"CVE-2021-4034"},
+	}
+	if !reflect.DeepEqual(dataset.entries[0], want) {
+		t.Errorf("unexpected entry %+v, want %+v", dataset.entries[0], want)
+	}
+
+	// the older layout without the codes column is read by the header
+	dataset, err := parseExploitDBDataset(strings.NewReader("id,file,description,date,author,platform,type,port\n" +
+		"1,exploits/linux/local/1.c,Old Exploit,2003-01-01,someone,linux,local,\n"))
+	if err != nil {
+		t.Fatalf("failed to parse the older layout: %v", err)
+	}
+	if entry := dataset.entries[0]; entry.Platform != "linux" || entry.Type != "local" || entry.Codes != nil {
+		t.Errorf("unexpected entry of the older layout %+v", entry)
+	}
+
+	if _, err := parseExploitDBDataset(strings.NewReader("id,title\n1,No File\n")); err == nil {
+		t.Error("expected the index without required columns to fail")
+	}
+}
+
+func TestExploitDBSearch(t *testing.T) {
+	dataset := loadExploitDBFixture(t)
+
+	tests := []struct {
+		name        string
+		query       string
+		platform    string
+		exploitType string
+		limit       int
+		want        []int
+		wantTotal   int
+	}{
+		{"keywords in title", "apache struts", "", "", 10, []int{42315, 41570}, 2},
+		{"all words must match", "struts wordpress", "", "", 10, []int{}, 0},
+		{"cve code", "cve-2021-4034", "", "", 10, []int{50689}, 1},
+		{"title ranks above path", "linux", "", "", 10, []int{45010, 50689, 41570}, 3},
+		{"platform filter", "remote code execution", "windows", "", 10, []int{49757}, 1},
+		{"type filter", "remote code execution", "", "webapps", 10, []int{46731, 41570}, 2},
+		{"platform and type filters", "struts", "linux", "webapps", 10, []int{41570}, 1},
+		{"limit keeps total", "remote code execution", "", "", 2, []int{49757, 46731}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, total := dataset.search(tt.query, tt.platform, tt.exploitType, tt.limit)
+			ids := make([]int, 0, len(entries))
+			for _, entry := range entries {
+				ids = append(ids, entry.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) || total != tt.wantTotal {
+				t.Errorf("search() = %v of %d, want %v of %d", ids, total, tt.want, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestExploitDBHandle(t *testing.T) {
+	e := &exploitdb{dataPath: exploitdbFixture}
+	search := func(action ExploitDBAction) string {
+		args, _ := json.Marshal(action)
+		result, err := e.Handle(context.Background(), ExploitDBToolName, args)
+		if err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+		return result
+	}
+
+	result := search(ExploitDBAction{Query: "struts", Platform: " Linux ", Type: "WebApps"})
+	for _, want := range []string{
+		`# ExploitDB exploits matching "struts" (platform linux, type webapps)`,
+		"## EDB-ID 41570: Apache Struts 2.3.5 < 2.3.31 / 2.5 < 2.5.10 - Remote Code Execution",
+		"- Platform: linux\n- Type: webapps\n- Published: 2017-03-15\n- Codes: CVE-2017-5638\n",
+		"- Path: exploits/linux/webapps/41570.py\n- URL: https://www.exploit-db.com/exploits/41570\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %q in the result:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "42315") || strings.Contains(result, "Showing") {
+		t.Errorf("expected only the filtered exploit:\n%s", result)
+	}
+
+	result = search(ExploitDBAction{Query: "remote code execution", Limit: 1})
+	if !strings.Contains(result, "Showing 1 of 4 matches") {
+		t.Errorf("expected the number of all matches:\n%s", result)
+	}
+
+	result = search(ExploitDBAction{Query: "struts", Platform: "windows"})
+	if result != "No exploits found in ExploitDB matching \"struts\" (platform windows)\n" {
+		t.Errorf("unexpected result without matches %q", result)
+	}
+
+	if result := search(ExploitDBAction{Query: " "}); !strings.Contains(result, "empty") {
+		t.Errorf("expected the empty query to be reported, got %q", result)
+	}
+}
+
+func TestExploitDBReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "files_exploits.csv")
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write index: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("failed to touch index: %v", err)
+		}
+	}
+
+	e := &exploitdb{dataPath: path}
+	if e.IsAvailable() {
+		t.Error("expected the tool without the index to be unavailable")
+	}
+	if err := e.HealthCheck(context.Background()); err == nil {
+		t.Error("expected the health check without the index to fail")
+	}
+
+	write("id,file,description\n1,exploits/linux/local/1.c,First Exploit\n", time.Now())
+	if !e.IsAvailable() {
+		t.Error("expected the tool with the index to be available")
+	}
+	if entries, _ := mustLoadExploitDB(t, e).search("first", "", "", 10); len(entries) != 1 {
+		t.Errorf("expected the first exploit, got %d entries", len(entries))
+	}
+
+	write("id,file,description\n2,exploits/linux/local/2.c,Second Exploit\n", time.Now().Add(time.Minute))
+	if entries, _ := mustLoadExploitDB(t, e).search("second", "", "", 10); len(entries) != 1 {
+		t.Errorf("expected the modified index to be reloaded, got %d entries", len(entries))
+	}
+
+	if (&exploitdb{dataPath: t.TempDir()}).IsAvailable() {
+		t.Error("expected the directory not to be taken for the index")
+	}
+}
+
+func mustLoadExploitDB(t *testing.T, e *exploitdb) *exploitdbDataset {
+	t.Helper()
+
+	dataset, err := e.load(context.Background())
+	if err != nil {
+		t.Fatalf("failed to load index: %v", err)
+	}
+	return dataset
+}
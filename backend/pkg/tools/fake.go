@@ -0,0 +1,276 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"sync"
+
+	"pentagi/pkg/database"
+
+	customsearch "google.golang.org/api/customsearch/v1"
+)
+
+const (
+	fakeAPIKey  = "fake"
+	fakeBaseURL = "http://fake.search.local"
+
+	// fakeSearchAnswer is the canned answer of every engine when the fake search is on
+	fakeSearchAnswer = "This is a canned answer of the fake search engine, real engines are not queried"
+)
+
+// fakeTransport replies to every request with canned bodies in turn,
+// the last body is repeated when all of them were served
+type fakeTransport struct {
+	mx          sync.Mutex
+	bodies      [][]byte
+	next        int
+	contentType string
+}
+
+func newFakeTransport(bodies ...[]byte) *fakeTransport {
+	if len(bodies) == 0 {
+		bodies = [][]byte{[]byte("{}")}
+	}
+
+	return &fakeTransport{bodies: bodies, contentType: "application/json"}
+}
+
+func (f *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	f.mx.Lock()
+	body := f.bodies[min(f.next, len(f.bodies)-1)]
+	f.next++
+	f.mx.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     http.StatusText(http.StatusOK),
+		Header:     http.Header{"Content-Type": {f.contentType}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// fakeResponseBodies marshals API payloads built for every canned response
+func fakeResponseBodies[T any](responses []string, build func(i int, response string) T) [][]byte {
+	bodies := make([][]byte, 0, len(responses))
+	for i, response := range responses {
+		body, err := json.Marshal(build(i, response))
+		if err != nil {
+			continue
+		}
+		bodies = append(bodies, body)
+	}
+
+	return bodies
+}
+
+func fakeLink(engine string, i int) string {
+	return fmt.Sprintf("https://example.com/%s/%d", engine, i+1)
+}
+
+// NewFakePerplexityTool creates perplexity tool which answers with the canned responses
+// in turn without calling the real API, it's intended for offline demos and tests
+func NewFakePerplexityTool(responses ...string) Tool {
+	bodies := fakeResponseBodies(responses, func(i int, response string) CompletionResponse {
+		citations := []string{fakeLink("perplexity", i)}
+		return CompletionResponse{
+			ID:     fmt.Sprintf("fake-%d", i+1),
+			Model:  perplexityModel,
+			Object: "chat.completion",
+			Choices: []Choice{
+				{
+					FinishReason: "stop",
+					Message:      Message{Role: "assistant", Content: response},
+				},
+			},
+			Citations: &citations,
+		}
+	})
+
+	return &perplexity{
+		apiKey:      fakeAPIKey,
+		model:       perplexityModel,
+		temperature: perplexityTemperature,
+		topP:        perplexityTopP,
+		maxTokens:   perplexityMaxTokens,
+		timeout:     perplexityTimeout,
+		transport:   newFakeTransport(bodies...),
+	}
+}
+
+// NewFakeTavilyTool creates tavily tool which answers with the canned responses
+// in turn without calling the real API, it's intended for offline demos and tests
+func NewFakeTavilyTool(responses ...string) Tool {
+	bodies := fakeResponseBodies(responses, func(i int, response string) tavilySearchResult {
+		return tavilySearchResult{
+			Answer: response,
+			Results: []tavilyResult{
+				{
+					Title:   fmt.Sprintf("Fake result %d", i+1),
+					URL:     fakeLink("tavily", i),
+					Content: response,
+					Score:   1,
+				},
+			},
+		}
+	})
+
+	return &tavily{
+		apiKey:    fakeAPIKey,
+		transport: newFakeTransport(bodies...),
+	}
+}
+
+// NewFakeTraversaalTool creates traversaal tool which answers with the canned responses
+// in turn without calling the real API, it's intended for offline demos and tests
+func NewFakeTraversaalTool(responses ...string) Tool {
+	bodies := fakeResponseBodies(responses, func(i int, response string) any {
+		return map[string]traversaalSearchResult{
+			"data": {
+				Response: response,
+				Links:    []string{fakeLink("traversaal", i)},
+			},
+		}
+	})
+
+	return &traversaal{
+		apiKey:    fakeAPIKey,
+		transport: newFakeTransport(bodies...),
+	}
+}
+
+// NewFakeGoogleTool creates google tool which answers with the canned responses
+// in turn without calling the real API, it's intended for offline demos and tests
+func NewFakeGoogleTool(responses ...string) Tool {
+	bodies := fakeResponseBodies(responses, func(i int, response string) customsearch.Search {
+		return customsearch.Search{
+			Items: []*customsearch.Result{
+				{
+					Title:   fmt.Sprintf("Fake result %d", i+1),
+					Link:    fakeLink("google", i),
+					Snippet: response,
+				},
+			},
+			SearchInformation: &customsearch.SearchSearchInformation{TotalResults: "1"},
+		}
+	})
+
+	return &google{
+		apiKey:    fakeAPIKey,
+		cxKey:     fakeAPIKey,
+		endpoint:  fakeBaseURL + "/",
+		transport: newFakeTransport(bodies...),
+	}
+}
+
+// NewFakeDuckDuckGoTool creates duckduckgo tool which answers with the canned responses
+// in turn without scraping the real site, it's intended for offline demos and tests
+func NewFakeDuckDuckGoTool(responses ...string) Tool {
+	bodies := make([][]byte, 0, len(responses))
+	for i, response := range responses {
+		bodies = append(bodies, []byte(fmt.Sprintf(
+			`<div class="result results_links"><a class="result__a" href="%s">Fake result %d</a>`+
+				`<a class="result__snippet">%s</a></div>`,
+			fakeLink("duckduckgo", i), i+1, html.EscapeString(response),
+		)))
+	}
+
+	transport := newFakeTransport(bodies...)
+	transport.contentType = "text/html"
+
+	return &duckduckgo{
+		enabled:   true,
+		transport: transport,
+	}
+}
+
+// NewFakeSearxngTool creates searxng tool which answers with the canned responses
+// in turn without calling the real instance, it's intended for offline demos and tests
+func NewFakeSearxngTool(responses ...string) Tool {
+	bodies := fakeResponseBodies(responses, func(i int, response string) SearxngResponse {
+		return SearxngResponse{
+			Results: []SearxngResult{
+				{
+					Title:   fmt.Sprintf("Fake result %d", i+1),
+					URL:     fakeLink("searxng", i),
+					Content: response,
+					Engine:  "fake",
+				},
+			},
+		}
+	})
+
+	return &SearxngTool{
+		baseURL:   fakeBaseURL,
+		transport: newFakeTransport(bodies...),
+		slp:       fakeSearchLogProvider{},
+	}
+}
+
+// fakeSearchLogProvider drops search logs, searxng tool isn't available without the provider
+type fakeSearchLogProvider struct{}
+
+func (fakeSearchLogProvider) PutLog(
+	ctx context.Context,
+	initiator database.MsgchainType,
+	executor database.MsgchainType,
+	engine database.SearchengineType,
+	query string,
+	result string,
+	taskID *int64,
+	subtaskID *int64,
+) (int64, error) {
+	return 0, nil
+}
+
+// newFakeSearchTool replaces the search engine by its fake with the canned answer, the fake keeps
+// the flow, the search log and the summarizer of the engine, so the searches look like real ones
+// in the flow, tools without the fake are returned as is
+func newFakeSearchTool(tool Tool) Tool {
+	switch t := tool.(type) {
+	case *google:
+		fake := NewFakeGoogleTool(fakeSearchAnswer).(*google)
+		fake.flowID, fake.taskID, fake.subtaskID = t.flowID, t.taskID, t.subtaskID
+		fake.slp, fake.summarizer, fake.sourceFooter = t.slp, t.summarizer, t.sourceFooter
+		return fake
+	case *duckduckgo:
+		fake := NewFakeDuckDuckGoTool(fakeSearchAnswer).(*duckduckgo)
+		fake.flowID, fake.taskID, fake.subtaskID = t.flowID, t.taskID, t.subtaskID
+		fake.slp, fake.summarizer, fake.sourceFooter = t.slp, t.summarizer, t.sourceFooter
+		return fake
+	case *SearxngTool:
+		fake := NewFakeSearxngTool(fakeSearchAnswer).(*SearxngTool)
+		fake.flowID, fake.taskID, fake.subtaskID = t.flowID, t.taskID, t.subtaskID
+		fake.summarizer, fake.sourceFooter = t.summarizer, t.sourceFooter
+		if t.slp != nil {
+			fake.slp = t.slp
+		}
+		return fake
+	case *tavily:
+		fake := NewFakeTavilyTool(fakeSearchAnswer).(*tavily)
+		fake.flowID, fake.taskID, fake.subtaskID = t.flowID, t.taskID, t.subtaskID
+		fake.slp, fake.summarizer, fake.sourceFooter = t.slp, t.summarizer, t.sourceFooter
+		return fake
+	case *traversaal:
+		fake := NewFakeTraversaalTool(fakeSearchAnswer).(*traversaal)
+		fake.flowID, fake.taskID, fake.subtaskID = t.flowID, t.taskID, t.subtaskID
+		fake.slp, fake.sourceFooter = t.slp, t.sourceFooter
+		return fake
+	case *perplexity:
+		fake := NewFakePerplexityTool(fakeSearchAnswer).(*perplexity)
+		fake.flowID, fake.taskID, fake.subtaskID = t.flowID, t.taskID, t.subtaskID
+		fake.slp, fake.summarizer, fake.sourceFooter = t.slp, t.summarizer, t.sourceFooter
+		return fake
+	default:
+		return tool
+	}
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"pentagi/pkg/database"
+)
+
+func TestFakeSearchTools(t *testing.T) {
+	tests := []struct {
+		name    string
+		engine  database.SearchengineType
+		newTool func(responses ...string) Tool
+		withSLP func(tool Tool, slp SearchLogProvider)
+		link    string
+		answer  bool
+	}{
+		{
+			name:    PerplexityToolName,
+			engine:  database.SearchengineTypePerplexity,
+			newTool: NewFakePerplexityTool,
+			withSLP: func(tool Tool, slp SearchLogProvider) { tool.(*perplexity).slp = slp },
+			link:    "https://example.com/perplexity/",
+			answer:  true,
+		},
+		{
+			name:    TavilyToolName,
+			engine:  database.SearchengineTypeTavily,
+			newTool: NewFakeTavilyTool,
+			withSLP: func(tool Tool, slp SearchLogProvider) { tool.(*tavily).slp = slp },
+			link:    "https://example.com/tavily/",
+			answer:  true,
+		},
+		{
+			name:    TraversaalToolName,
+			engine:  database.SearchengineTypeTraversaal,
+			newTool: NewFakeTraversaalTool,
+			withSLP: func(tool Tool, slp SearchLogProvider) { tool.(*traversaal).slp = slp },
+			link:    "https://example.com/traversaal/",
+			answer:  true,
+		},
+		{
+			name:    GoogleToolName,
+			engine:  database.SearchengineTypeGoogle,
+			newTool: NewFakeGoogleTool,
+			withSLP: func(tool Tool, slp SearchLogProvider) { tool.(*google).slp = slp },
+			link:    "https://example.com/google/",
+		},
+		{
+			name:    DuckDuckGoToolName,
+			engine:  database.SearchengineTypeDuckduckgo,
+			newTool: NewFakeDuckDuckGoTool,
+			withSLP: func(tool Tool, slp SearchLogProvider) { tool.(*duckduckgo).slp = slp },
+			link:    "https://example.com/duckduckgo/",
+		},
+		{
+			name:    SearxngToolName,
+			engine:  database.SearchengineTypeSearxng,
+			newTool: NewFakeSearxngTool,
+			withSLP: func(tool Tool, slp SearchLogProvider) { tool.(*SearxngTool).slp = slp },
+			link:    "https://example.com/searxng/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := tt.newTool("first canned answer", "second canned answer")
+			if !tool.IsAvailable() {
+				t.Fatal("expected fake tool to be available")
+			}
+
+			slp := &statsSearchLogProvider{}
+			tt.withSLP(tool, slp)
+
+			ctx := PutAgentContext(context.Background(), database.MsgchainTypeSearcher)
+			args, _ := json.Marshal(SearchAction{Query: "nmap usage", MaxResults: 5})
+
+			for i, want := range []string{"first canned answer", "second canned answer", "second canned answer"} {
+				result, err := tool.Handle(ctx, tt.name, args)
+				if err != nil {
+					t.Fatalf("call %d: Handle() error = %v", i, err)
+				}
+				if tt.answer && !strings.HasPrefix(result, "# Answer\n\n"+want) {
+					t.Errorf("call %d: expected formatted answer %q, got:\n%s", i, want, result)
+				}
+				if !strings.Contains(result, want) {
+					t.Errorf("call %d: expected result to contain %q, got:\n%s", i, want, result)
+				}
+				if !strings.Contains(result, tt.link) {
+					t.Errorf("call %d: expected result to contain link %q, got:\n%s", i, tt.link, result)
+				}
+			}
+
+			if len(slp.logs) != 3 {
+				t.Fatalf("expected 3 search logs, got %d", len(slp.logs))
+			}
+			for _, log := range slp.logs {
+				if log.engine != tt.engine || log.query != "nmap usage" {
+					t.Errorf("unexpected search log %+v", log)
+				}
+				if log.stats.ResultCount != 1 || log.stats.Cached {
+					t.Errorf("unexpected search stats %+v", log.stats)
+				}
+			}
+
+			if err := tool.HealthCheck(context.Background()); err != nil {
+				t.Errorf("HealthCheck() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestFakeTransportWithoutResponses(t *testing.T) {
+	result, count, err := NewFakePerplexityTool().(*perplexity).search(context.Background(), "query", "", nil, nil)
+	if !errors.Is(err, ErrNoResults) {
+		t.Fatalf("expected no results error, got %v", err)
+	}
+	if result != "" || count != 0 {
+		t.Errorf("unexpected result %q with %d items", result, count)
+	}
+}
+
+func TestNewFakeSearchTool(t *testing.T) {
+	taskID, subtaskID := int64(2), int64(3)
+	slp := &statsSearchLogProvider{}
+
+	tools := []Tool{
+		NewGoogleToolWithConfig(GoogleConfig{FlowID: 1, TaskID: &taskID, SubtaskID: &subtaskID, SearchLog: slp}),
+		NewDuckDuckGoToolWithConfig(DuckDuckGoConfig{FlowID: 1, TaskID: &taskID, SubtaskID: &subtaskID, SearchLog: slp}),
+		NewSearxngToolWithConfig(SearxngConfig{FlowID: 1, TaskID: &taskID, SubtaskID: &subtaskID, SearchLog: slp}),
+		NewTavilyToolWithConfig(TavilyConfig{FlowID: 1, TaskID: &taskID, SubtaskID: &subtaskID, SearchLog: slp}),
+		NewTraversaalToolWithConfig(TraversaalConfig{FlowID: 1, TaskID: &taskID, SubtaskID: &subtaskID, SearchLog: slp}),
+		NewPerplexityToolWithConfig(PerplexityConfig{FlowID: 1, TaskID: &taskID, SubtaskID: &subtaskID, SearchLog: slp}),
+	}
+
+	ctx := PutAgentContext(context.Background(), database.MsgchainTypeSearcher)
+	args, _ := json.Marshal(SearchAction{Query: "nmap usage", MaxResults: 5})
+
+	for _, tool := range tools {
+		if tool.IsAvailable() {
+			t.Fatalf("expected unconfigured %T to be unavailable", tool)
+		}
+
+		fake := newFakeSearchTool(tool)
+		if !fake.IsAvailable() {
+			t.Fatalf("expected fake of %T to be available", tool)
+		}
+
+		result, err := fake.Handle(ctx, "search", args)
+		if err != nil {
+			t.Fatalf("%T: Handle() error = %v", tool, err)
+		}
+		if !strings.Contains(result, fakeSearchAnswer) {
+			t.Errorf("%T: expected canned answer, got:\n%s", tool, result)
+		}
+	}
+
+	if len(slp.logs) != len(tools) {
+		t.Fatalf("expected %d search logs, got %d", len(tools), len(slp.logs))
+	}
+
+	exploitdb := &exploitdb{}
+	if tool := newFakeSearchTool(exploitdb); tool != Tool(exploitdb) {
+		t.Error("expected tool without fake to be returned as is")
+	}
+}
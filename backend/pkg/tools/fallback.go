@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	fallbackResults       = 5
+	fallbackEngineTimeout = 30 * time.Second
+)
+
+// SearchRegistry keeps the search engines by their tool names to query them one by one
+type SearchRegistry struct {
+	engines map[string]resultsSearcher
+	timeout time.Duration
+}
+
+// NewSearchRegistry keeps the tools which are able to return structured results,
+// answer based engines (perplexity, traversaal) are dropped like in the metasearch
+func NewSearchRegistry(engines map[string]Tool) *SearchRegistry {
+	registry := &SearchRegistry{
+		engines: make(map[string]resultsSearcher, len(engines)),
+		timeout: fallbackEngineTimeout,
+	}
+	for name, tool := range engines {
+		if searcher, ok := tool.(resultsSearcher); ok {
+			registry.engines[name] = searcher
+		}
+	}
+
+	return registry
+}
+
+// FallbackSearch queries the engines in the order and returns the result of the first one which answered,
+// the next engine is tried only when the engine is unavailable or failed by the auth, rate limit,
+// upstream or network error; empty results (ErrNoResults) and other errors stop the search,
+// empty order means all engines, the order accepts aliases of ParseEngine
+func (r *SearchRegistry) FallbackSearch(ctx context.Context, query string, order []string) (string, error) {
+	if len(order) == 0 {
+		for name := range r.engines {
+			order = append(order, name)
+		}
+		sort.Strings(order)
+	}
+
+	var errs []error
+	for _, name := range order {
+		name = r.engineName(name)
+		searcher, ok := r.engines[name]
+		if !ok || !searcher.IsAvailable() || !IsEnabled(name) {
+			errs = append(errs, fmt.Errorf("%s: engine is not available", name))
+			continue
+		}
+
+		items, err := r.search(ctx, searcher, query)
+		if err == nil || errors.Is(err, ErrNoResults) {
+			result := noResultsMessage
+			if len(items) > 0 {
+				result = FormatResults(items, FormatOptions{Separator: true})
+			}
+			return withSourceFooter(result, name, true), nil
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		if !isFallbackError(err) {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+
+		logrus.WithContext(ctx).WithError(err).WithField("engine", name).
+			Warn("search engine failed, falling back to the next one")
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+
+	if len(errs) == 0 {
+		return "", errors.New("no search engines to fall back to")
+	}
+
+	return "", errors.Join(errs...)
+}
+
+// engineName resolves the friendly name of the engine to its tool name, unknown names are kept as is
+// to be reported as unavailable
+func (r *SearchRegistry) engineName(name string) string {
+	if engine, err := ParseEngine(name); err == nil {
+		return string(engine)
+	}
+
+	return name
+}
+
+func (r *SearchRegistry) search(ctx context.Context, searcher resultsSearcher, query string) ([]SearchResultItem, error) {
+	timeout := r.timeout
+	if timeout <= 0 {
+		timeout = fallbackEngineTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return searcher.searchResults(ctx, query, fallbackResults)
+}
+
+// isFallbackError reports whether the engine failed for a reason another engine doesn't share
+func isFallbackError(err error) bool {
+	return errors.Is(err, ErrAuth) || errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrUpstreamUnavailable) || errors.Is(err, ErrNetwork) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFallbackSearch(t *testing.T) {
+	items := []SearchResultItem{{Title: "Log4Shell", URL: "https://example.com/log4shell", Snippet: "CVE-2021-44228"}}
+
+	tests := []struct {
+		name    string
+		engines map[string]Tool
+		order   []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "first engine rate limited",
+			engines: map[string]Tool{
+				"google": &fakeResultsSearcher{available: true, err: newSearchError(ErrRateLimited, "quota exceeded")},
+				"tavily": &fakeResultsSearcher{available: true, items: items},
+			},
+			order: []string{"google", "tavily"},
+			want:  "_Source: tavily_",
+		},
+		{
+			name: "unavailable engine is skipped",
+			engines: map[string]Tool{
+				"google": &fakeResultsSearcher{available: false},
+				"tavily": &fakeResultsSearcher{available: true, items: items},
+			},
+			order: []string{"google", "tavily"},
+			want:  "_Source: tavily_",
+		},
+		{
+			name: "no results stop the search",
+			engines: map[string]Tool{
+				"google": &fakeResultsSearcher{available: true, items: []SearchResultItem{}},
+				"tavily": &fakeResultsSearcher{available: true, items: items},
+			},
+			order: []string{"google", "tavily"},
+			want:  "No results found\n\n_Source: google_",
+		},
+		{
+			name: "no results sentinel stops the search",
+			engines: map[string]Tool{
+				"google": &fakeResultsSearcher{available: true, err: newNoResultsError("google found no results for the query")},
+				"tavily": &fakeResultsSearcher{available: true, items: items},
+			},
+			order: []string{"google", "tavily"},
+			want:  "No results found\n\n_Source: google_",
+		},
+		{
+			name: "unclassified error isn't retried",
+			engines: map[string]Tool{
+				"google": &fakeResultsSearcher{available: true, err: errors.New("failed to decode response body")},
+				"tavily": &fakeResultsSearcher{available: true, items: items},
+			},
+			order:   []string{"google", "tavily"},
+			wantErr: "google: failed to decode response body",
+		},
+		{
+			name: "all engines failed",
+			engines: map[string]Tool{
+				"google":     &fakeResultsSearcher{available: true, err: newSearchError(ErrAuth, "API key is wrong")},
+				"tavily":     &fakeResultsSearcher{available: true, err: newSearchError(ErrUpstreamUnavailable, "unexpected status code: 503")},
+				"duckduckgo": &fakeResultsSearcher{available: true, err: newSearchError(ErrNetwork, "connection refused")},
+			},
+			wantErr: "duckduckgo: connection refused\ngoogle: API key is wrong\ntavily: unexpected status code: 503",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewSearchRegistry(tt.engines).FallbackSearch(context.Background(), "log4shell", tt.order)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FallbackSearch() error = %v", err)
+			}
+			if !strings.Contains(result, tt.want) {
+				t.Errorf("expected result to contain %q, got:\n%s", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestFallbackSearchEngineTimeout(t *testing.T) {
+	registry := NewSearchRegistry(map[string]Tool{
+		"google": &fakeResultsSearcher{available: true, delay: time.Second},
+		"tavily": &fakeResultsSearcher{available: true, items: []SearchResultItem{{Title: "Answer", URL: "https://example.com"}}},
+	})
+	registry.timeout = 20 * time.Millisecond
+
+	result, err := registry.FallbackSearch(context.Background(), "query", []string{"google", "tavily"})
+	if err != nil {
+		t.Fatalf("FallbackSearch() error = %v", err)
+	}
+	if !strings.HasSuffix(result, "_Source: tavily_\n") {
+		t.Errorf("expected slow engine to be skipped, got:\n%s", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := registry.FallbackSearch(ctx, "query", []string{"google", "tavily"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled search to stop, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"net/http"
+	"strings"
+
+	"pentagi/pkg/config"
+)
+
+// LLMGateway is the shared OpenAI-compatible gateway (LiteLLM, vLLM and so on) which LLM-backed tools
+// send requests to instead of their providers, so the gateway is configured once for all of them
+type LLMGateway struct {
+	BaseURL      string
+	APIKey       string
+	Organization string
+	Headers      map[string]string
+}
+
+// NewLLMGateway returns nil if the gateway isn't configured, tools call their providers directly then
+func NewLLMGateway(cfg *config.Config) *LLMGateway {
+	if cfg == nil || cfg.LLMGatewayURL == "" {
+		return nil
+	}
+
+	return &LLMGateway{
+		BaseURL:      cfg.LLMGatewayURL,
+		APIKey:       cfg.LLMGatewayAPIKey,
+		Organization: cfg.LLMGatewayOrganization,
+		Headers:      cfg.LLMGatewayHeaders,
+	}
+}
+
+func (g *LLMGateway) IsConfigured() bool {
+	return g != nil && g.BaseURL != ""
+}
+
+// URL joins the path of the provider API to the base URL of the gateway
+func (g *LLMGateway) URL(path string) string {
+	return strings.TrimRight(g.BaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// SetHeaders sets the default headers of the gateway to the request and replaces the provider
+// credentials by the gateway key if it's set, otherwise the gateway gets the provider key
+func (g *LLMGateway) SetHeaders(req *http.Request) {
+	for key, value := range g.Headers {
+		req.Header.Set(key, value)
+	}
+
+	if g.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+g.APIKey)
+	}
+
+	if g.Organization != "" {
+		req.Header.Set("OpenAI-Organization", g.Organization)
+	}
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"pentagi/pkg/config"
+)
+
+func TestNewLLMGateway(t *testing.T) {
+	if gateway := NewLLMGateway(&config.Config{LLMGatewayAPIKey: "key"}); gateway != nil {
+		t.Errorf("expected nil gateway without URL, got %+v", gateway)
+	}
+	if NewLLMGateway(nil).IsConfigured() {
+		t.Error("expected nil gateway not to be configured")
+	}
+
+	gateway := NewLLMGateway(&config.Config{
+		LLMGatewayURL:     "http://litellm:4000/v1/",
+		LLMGatewayHeaders: map[string]string{"X-Team": "red"},
+	})
+	if !gateway.IsConfigured() {
+		t.Fatal("expected gateway to be configured")
+	}
+	if got := gateway.URL("/chat/completions"); got != "http://litellm:4000/v1/chat/completions" {
+		t.Errorf("URL() = %q", got)
+	}
+}
+
+func TestPerplexityGateway(t *testing.T) {
+	data, err := os.ReadFile("testdata/perplexity_response.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var requests []*http.Request
+	newServer := func() *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(data)
+		}))
+	}
+
+	gatewayServer, toolServer := newServer(), newServer()
+	defer gatewayServer.Close()
+	defer toolServer.Close()
+
+	tests := []struct {
+		name       string
+		baseURL    string
+		gateway    *LLMGateway
+		wantHost   string
+		wantPath   string
+		wantAuth   string
+		wantHeader map[string]string
+	}{
+		{
+			name: "gateway with own key",
+			gateway: &LLMGateway{
+				BaseURL:      gatewayServer.URL + "/v1",
+				APIKey:       "gateway-key",
+				Organization: "pentest",
+				Headers:      map[string]string{"X-Team": "red"},
+			},
+			wantHost:   gatewayServer.Listener.Addr().String(),
+			wantPath:   "/v1/chat/completions",
+			wantAuth:   "Bearer gateway-key",
+			wantHeader: map[string]string{"X-Team": "red", "OpenAI-Organization": "pentest"},
+		},
+		{
+			name:     "gateway with provider key",
+			gateway:  &LLMGateway{BaseURL: gatewayServer.URL},
+			wantHost: gatewayServer.Listener.Addr().String(),
+			wantPath: "/chat/completions",
+			wantAuth: "Bearer test-key",
+		},
+		{
+			name:       "tool base URL wins",
+			baseURL:    toolServer.URL,
+			gateway:    &LLMGateway{BaseURL: gatewayServer.URL, APIKey: "gateway-key", Headers: map[string]string{"X-Team": "red"}},
+			wantHost:   toolServer.Listener.Addr().String(),
+			wantPath:   "/chat/completions",
+			wantAuth:   "Bearer test-key",
+			wantHeader: map[string]string{"X-Team": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			p := NewPerplexityTool(0, nil, nil, "test-key", tt.baseURL, "", "", "", "",
+				false, false, false, 0, 0, 0, 0, 0, 0, false, false, nil, nil, tt.gateway).(*perplexity)
+
+			if _, _, err := p.search(context.Background(), "log4shell", "", nil, nil); err != nil {
+				t.Fatalf("search() error = %v", err)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("expected one request, got %d", len(requests))
+			}
+
+			req := requests[0]
+			if req.Host != tt.wantHost || req.URL.Path != tt.wantPath {
+				t.Errorf("request sent to %s%s, want %s%s", req.Host, req.URL.Path, tt.wantHost, tt.wantPath)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", got, tt.wantAuth)
+			}
+			for key, want := range tt.wantHeader {
+				if got := req.Header.Get(key); got != want {
+					t.Errorf("header %s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPerplexityWithoutGateway(t *testing.T) {
+	var urls []string
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		urls = append(urls, r.URL.String())
+		return nil, http.ErrHandlerTimeout
+	})
+
+	p := &perplexity{apiKey: "test-key", transport: transport, gateway: NewLLMGateway(&config.Config{})}
+	if _, _, err := p.search(context.Background(), "log4shell", "", nil, nil); err == nil {
+		t.Fatal("expected transport error")
+	}
+	if !reflect.DeepEqual(urls, []string{perplexityBaseURL + "/chat/completions"}) {
+		t.Errorf("expected direct request to the provider, got %v", urls)
+	}
+}
@@ -0,0 +1,368 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"pentagi/pkg/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	githubAPIURL         = "https://api.github.com"
+	githubAPIVersion     = "2022-11-28"
+	githubDefaultResults = 10
+	githubMaxResults     = 30
+	githubTimeout        = 30 * time.Second
+	githubUserAgent      = "PentAGI/1.0"
+)
+
+// Search kinds supported by the github tool
+const (
+	GithubSearchKindCode         = "code"
+	GithubSearchKindRepositories = "repositories"
+	GithubSearchKindAdvisories   = "advisories"
+)
+
+var githubCVEPattern = regexp.MustCompile(`(?i)^CVE-\d{4}-\d{4,}$`)
+
+type githubRepository struct {
+	FullName        string `json:"full_name"`
+	HTMLURL         string `json:"html_url"`
+	Description     string `json:"description"`
+	Language        string `json:"language"`
+	StargazersCount int    `json:"stargazers_count"`
+}
+
+type githubCodeItem struct {
+	Name       string           `json:"name"`
+	Path       string           `json:"path"`
+	HTMLURL    string           `json:"html_url"`
+	Repository githubRepository `json:"repository"`
+}
+
+type githubCodeSearchResult struct {
+	TotalCount int              `json:"total_count"`
+	Items      []githubCodeItem `json:"items"`
+}
+
+type githubRepositorySearchResult struct {
+	TotalCount int                `json:"total_count"`
+	Items      []githubRepository `json:"items"`
+}
+
+type githubAdvisory struct {
+	GHSAID      string `json:"ghsa_id"`
+	CVEID       string `json:"cve_id"`
+	HTMLURL     string `json:"html_url"`
+	Summary     string `json:"summary"`
+	Severity    string `json:"severity"`
+	PublishedAt string `json:"published_at"`
+}
+
+type github struct {
+	flowID       int64
+	taskID       *int64
+	subtaskID    *int64
+	enabled      bool
+	token        string
+	proxyURL     string
+	apiURL       string
+	maxBody      int64
+	limits       ResultLimits
+	cache        CacheProvider
+	sourceFooter bool
+	slp          SearchLogProvider
+	tracer       Tracer
+}
+
+func NewGithubTool(flowID int64, taskID, subtaskID *int64, enabled bool,
+	token, proxyURL string, limits ResultLimits, sourceFooter bool, slp SearchLogProvider,
+) Tool {
+	return &github{
+		flowID:       flowID,
+		taskID:       taskID,
+		subtaskID:    subtaskID,
+		enabled:      enabled,
+		token:        token,
+		proxyURL:     proxyURL,
+		limits:       limits,
+		sourceFooter: sourceFooter,
+		slp:          slp,
+	}
+}
+
+// Handle searches github code, repositories or security advisories by the agent query
+func (g *github) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action GithubSearchAction
+	ctx, emitter := startTrace(ctx, g.tracer)
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal github search action")
+		return "", fmt.Errorf("failed to unmarshal %s search action arguments: %w", name, err)
+	}
+	action.Query = sanitizeQuery(action.Query, maxQueryLength)
+
+	numResults := g.limits.resolve(githubDefaultResults, githubMaxResults).clamp(int(action.MaxResults))
+
+	kind := strings.ToLower(strings.TrimSpace(action.Kind))
+	if kind == "" {
+		kind = GithubSearchKindCode
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"query":       action.Query,
+		"kind":        kind,
+		"num_results": numResults,
+	})
+
+	cacheKey := searchCacheKey(database.SearchengineTypeGithub, action.Query, kind, numResults)
+	stats, start := SearchStats{Cached: true}, time.Now()
+	result, err := withSearchCache(ctx, g.cache, cacheKey, &stats, func() (string, error) {
+		if err := waitSearchQueue(ctx, database.SearchengineTypeGithub, action.Priority); err != nil {
+			stats.Cached = false
+			return "", err
+		}
+		result, count, err := withNoResultsText(g.search(ctx, kind, action.Query, numResults))
+		stats.Cached, stats.ResultCount = false, count
+		return result, err
+	})
+	recordSearchCall(ctx, database.SearchengineTypeGithub, stats.Cached, time.Since(start), err)
+	if err != nil {
+		emitter.Emit(searchErrorEvent(action.Query, err, map[string]any{
+			"tool_name":   GithubToolName,
+			"engine":      "github",
+			"kind":        kind,
+			"max_results": numResults,
+		}))
+
+		logger.WithError(err).Error("failed to search in github")
+		return fmt.Sprintf("failed to search in github: %v", err), nil
+	}
+
+	stats.Duration = time.Since(start)
+	_, _ = putSearchLog(ctx, g.slp, database.SearchengineTypeGithub, action.Query, result, stats, g.taskID, g.subtaskID)
+
+	return withSourceFooter(result, "GitHub", g.sourceFooter), nil
+}
+
+// search formats the items of the search kind, nothing found is ErrNoResults
+func (g *github) search(ctx context.Context, kind, query string, numResults int) (string, int, error) {
+	switch kind {
+	case GithubSearchKindCode:
+		var resp githubCodeSearchResult
+		if err := g.get(ctx, "/search/code", g.searchParams(query, numResults), &resp); err != nil {
+			return "", 0, err
+		}
+		items := resp.Items[:min(len(resp.Items), numResults)]
+		if len(items) == 0 {
+			return "", 0, newNoResultsError("github found no results for the query")
+		}
+		return g.formatCodeResults(items), len(items), nil
+
+	case GithubSearchKindRepositories:
+		var resp githubRepositorySearchResult
+		params := g.searchParams(query, numResults)
+		params.Set("sort", "stars")
+		if err := g.get(ctx, "/search/repositories", params, &resp); err != nil {
+			return "", 0, err
+		}
+		items := resp.Items[:min(len(resp.Items), numResults)]
+		if len(items) == 0 {
+			return "", 0, newNoResultsError("github found no results for the query")
+		}
+		return g.formatRepositoryResults(items), len(items), nil
+
+	case GithubSearchKindAdvisories:
+		var resp []githubAdvisory
+		if err := g.get(ctx, "/advisories", g.advisoryParams(query, numResults), &resp); err != nil {
+			return "", 0, err
+		}
+		items := resp[:min(len(resp), numResults)]
+		if len(items) == 0 {
+			return "", 0, newNoResultsError("github found no results for the query")
+		}
+		return g.formatAdvisoryResults(items), len(items), nil
+
+	default:
+		return "", 0, fmt.Errorf("unknown search kind %q, expected one of: %s, %s, %s", kind,
+			GithubSearchKindCode, GithubSearchKindRepositories, GithubSearchKindAdvisories)
+	}
+}
+
+func (g *github) searchParams(query string, numResults int) url.Values {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("per_page", strconv.Itoa(numResults))
+	return params
+}
+
+// advisoryParams maps the query to the global advisories filters,
+// CVE identifiers are matched exactly and anything else is treated as affected package name
+func (g *github) advisoryParams(query string, numResults int) url.Values {
+	params := url.Values{}
+	query = strings.TrimSpace(query)
+	if githubCVEPattern.MatchString(query) {
+		params.Set("cve_id", strings.ToUpper(query))
+	} else {
+		params.Set("affects", query)
+	}
+	params.Set("per_page", strconv.Itoa(numResults))
+	return params
+}
+
+func (g *github) get(ctx context.Context, path string, params url.Values, result any) error {
+	apiURL := g.apiURL
+	if apiURL == "" {
+		apiURL = githubAPIURL
+	}
+
+	reqURL := strings.TrimRight(apiURL, "/") + path + "?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", githubAPIVersion)
+	req.Header.Set("User-Agent", githubUserAgent)
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.createHTTPClient().Do(req)
+	if err != nil {
+		return newNetworkError(err, "failed to do request")
+	}
+	defer resp.Body.Close()
+
+	if err := g.handleErrorResponse(resp); err != nil {
+		return err
+	}
+
+	body, err := readLimitedBody(resp.Body, g.maxBody)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	return nil
+}
+
+func (g *github) handleErrorResponse(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return newSearchError(ErrAuth, "github token is wrong or required for this search kind")
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		// github reports exhausted rate limit as forbidden with zero remaining requests
+		if resp.StatusCode == http.StatusTooManyRequests || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return newSearchError(ErrRateLimited, "github rate limit exceeded, set the token to increase it")
+		}
+		return newSearchError(ErrAuth, "access to the github search is forbidden")
+	case http.StatusUnprocessableEntity:
+		return fmt.Errorf("github rejected the search query as invalid")
+	default:
+		return newSearchError(errorKindByStatus(resp.StatusCode), "unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+func (g *github) formatCodeResults(items []githubCodeItem) string {
+	var builder strings.Builder
+	for i, item := range items {
+		builder.WriteString(fmt.Sprintf("# %d. %s\n\n", i+1, item.Repository.FullName))
+		builder.WriteString(fmt.Sprintf("## URL\n%s\n\n", item.HTMLURL))
+		builder.WriteString(fmt.Sprintf("## Path\n%s\n\n", item.Path))
+		if item.Repository.StargazersCount > 0 {
+			builder.WriteString(fmt.Sprintf("## Stars\n%d\n\n", item.Repository.StargazersCount))
+		}
+		if item.Repository.Description != "" {
+			builder.WriteString(fmt.Sprintf("## Description\n\n%s\n\n", item.Repository.Description))
+		}
+	}
+
+	return builder.String()
+}
+
+func (g *github) formatRepositoryResults(items []githubRepository) string {
+	var builder strings.Builder
+	for i, item := range items {
+		builder.WriteString(fmt.Sprintf("# %d. %s\n\n", i+1, item.FullName))
+		builder.WriteString(fmt.Sprintf("## URL\n%s\n\n", item.HTMLURL))
+		builder.WriteString(fmt.Sprintf("## Stars\n%d\n\n", item.StargazersCount))
+		if item.Language != "" {
+			builder.WriteString(fmt.Sprintf("## Language\n%s\n\n", item.Language))
+		}
+		if item.Description != "" {
+			builder.WriteString(fmt.Sprintf("## Description\n\n%s\n\n", item.Description))
+		}
+	}
+
+	return builder.String()
+}
+
+func (g *github) formatAdvisoryResults(items []githubAdvisory) string {
+	var builder strings.Builder
+	for i, item := range items {
+		title := item.GHSAID
+		if item.CVEID != "" {
+			title = fmt.Sprintf("%s (%s)", item.GHSAID, item.CVEID)
+		}
+		builder.WriteString(fmt.Sprintf("# %d. %s\n\n", i+1, title))
+		builder.WriteString(fmt.Sprintf("## URL\n%s\n\n", item.HTMLURL))
+		if item.Severity != "" {
+			builder.WriteString(fmt.Sprintf("## Severity\n%s\n\n", item.Severity))
+		}
+		if item.PublishedAt != "" {
+			builder.WriteString(fmt.Sprintf("## Published\n%s\n\n", item.PublishedAt))
+		}
+		builder.WriteString(fmt.Sprintf("## Summary\n\n%s\n\n", item.Summary))
+	}
+
+	return builder.String()
+}
+
+func (g *github) createHTTPClient() *http.Client {
+	return newHTTPClient(g.proxyURL, resolveTimeout(GithubToolName, 0))
+}
+
+// IsAvailable reports whether the tool is enabled, the token is optional but recommended
+// because anonymous requests have low rate limits and code search requires authentication
+func (g *github) IsAvailable() bool {
+	return g.enabled && IsEnabled(GithubToolName)
+}
+
+// HealthCheck requests the rate limit status which isn't counted against the limit itself,
+// it fails on the wrong token and reports the exhausted limit
+func (g *github) HealthCheck(ctx context.Context) error {
+	var resp struct {
+		Resources map[string]struct {
+			Remaining int `json:"remaining"`
+		} `json:"resources"`
+	}
+	if err := g.get(ctx, "/rate_limit", url.Values{}, &resp); err != nil {
+		return err
+	}
+
+	if search, ok := resp.Resources["search"]; ok && search.Remaining == 0 {
+		return newSearchError(ErrRateLimited, "github search rate limit exceeded")
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newGithubTestServer(t *testing.T, fixture string, requests *[]*http.Request) *httptest.Server {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/" + fixture)
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", fixture, err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+}
+
+func TestGithubSearchKinds(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		query    string
+		fixture  string
+		path     string
+		params   url.Values
+		contains []string
+	}{
+		{
+			name:    "code",
+			kind:    GithubSearchKindCode,
+			query:   "CVE-2021-44228 language:python",
+			fixture: "github_code.json",
+			path:    "/search/code",
+			params:  url.Values{"q": {"CVE-2021-44228 language:python"}, "per_page": {"5"}},
+			contains: []string{
+				"# 1. kozmer/log4j-shell-poc\n\n## URL\nhttps://github.com/kozmer/log4j-shell-poc/blob/main/poc/exploit.py\n\n## Path\npoc/exploit.py\n\n",
+				"## Description\n\nA Proof-Of-Concept for the CVE-2021-44228 vulnerability.\n\n",
+				"# 2. fullhunt/log4j-scan\n\n## URL\nhttps://github.com/fullhunt/log4j-scan/blob/master/README.md\n\n## Path\nREADME.md\n\n",
+			},
+		},
+		{
+			name:    "repositories",
+			kind:    GithubSearchKindRepositories,
+			query:   "log4j exploit",
+			fixture: "github_repositories.json",
+			path:    "/search/repositories",
+			params:  url.Values{"q": {"log4j exploit"}, "per_page": {"5"}, "sort": {"stars"}},
+			contains: []string{
+				"# 1. kozmer/log4j-shell-poc\n\n## URL\nhttps://github.com/kozmer/log4j-shell-poc\n\n## Stars\n1830\n\n## Language\nJava\n\n",
+				"# 2. fullhunt/log4j-scan\n\n## URL\nhttps://github.com/fullhunt/log4j-scan\n\n## Stars\n3400\n\n## Language\nPython\n\n",
+			},
+		},
+		{
+			name:    "advisories by cve",
+			kind:    GithubSearchKindAdvisories,
+			query:   "cve-2021-44228",
+			fixture: "github_advisories.json",
+			path:    "/advisories",
+			params:  url.Values{"cve_id": {"CVE-2021-44228"}, "per_page": {"5"}},
+			contains: []string{
+				"# 1. GHSA-jfh8-c2jp-5v3q (CVE-2021-44228)\n\n## URL\nhttps://github.com/advisories/GHSA-jfh8-c2jp-5v3q\n\n",
+				"## Severity\ncritical\n\n## Published\n2021-12-10T00:40:56Z\n\n## Summary\n\nRemote code injection in Log4j\n\n",
+			},
+		},
+		{
+			name:     "advisories by package",
+			kind:     GithubSearchKindAdvisories,
+			query:    "log4j-core",
+			fixture:  "github_advisories.json",
+			path:     "/advisories",
+			params:   url.Values{"affects": {"log4j-core"}, "per_page": {"5"}},
+			contains: []string{"# 1. GHSA-jfh8-c2jp-5v3q (CVE-2021-44228)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []*http.Request
+			server := newGithubTestServer(t, tt.fixture, &requests)
+			defer server.Close()
+
+			g := &github{enabled: true, token: "test-token", apiURL: server.URL}
+			result, count, err := g.search(context.Background(), tt.kind, tt.query, 5)
+			if err != nil {
+				t.Fatalf("search() error = %v", err)
+			}
+
+			if len(requests) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(requests))
+			}
+			req := requests[0]
+			if req.URL.Path != tt.path {
+				t.Errorf("request path = %q, want %q", req.URL.Path, tt.path)
+			}
+			if got := req.URL.Query().Encode(); got != tt.params.Encode() {
+				t.Errorf("request params = %q, want %q", got, tt.params.Encode())
+			}
+			if got := req.Header.Get("Authorization"); got != "Bearer test-token" {
+				t.Errorf("Authorization header = %q", got)
+			}
+
+			if count == 0 {
+				t.Error("expected non-zero result count")
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(result, want) {
+					t.Errorf("expected result to contain %q, got:\n%s", want, result)
+				}
+			}
+		})
+	}
+}
+
+func TestGithubHandleClampsResults(t *testing.T) {
+	var requests []*http.Request
+	server := newGithubTestServer(t, "github_repositories.json", &requests)
+	defer server.Close()
+
+	g := &github{enabled: true, apiURL: server.URL}
+
+	for _, tt := range []struct {
+		maxResults Int64
+		want       string
+	}{
+		{0, "10"},
+		{100, "30"},
+		{7, "7"},
+	} {
+		args, _ := json.Marshal(GithubSearchAction{Query: "nmap", Kind: "repositories", MaxResults: tt.maxResults})
+		if _, err := g.Handle(context.Background(), GithubToolName, args); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+
+		last := requests[len(requests)-1]
+		if got := last.URL.Query().Get("per_page"); got != tt.want {
+			t.Errorf("max_results %d: per_page = %q, want %q", tt.maxResults, got, tt.want)
+		}
+		if got := last.Header.Get("Authorization"); got != "" {
+			t.Errorf("expected no Authorization header without token, got %q", got)
+		}
+	}
+}
+
+func TestGithubHandleCustomLimits(t *testing.T) {
+	var requests []*http.Request
+	server := newGithubTestServer(t, "github_repositories.json", &requests)
+	defer server.Close()
+
+	g := &github{enabled: true, apiURL: server.URL, limits: ResultLimits{Default: 3, Max: 50}}
+
+	for _, tt := range []struct {
+		maxResults Int64
+		want       string
+	}{
+		{0, "3"},
+		{40, "40"},
+		{100, "50"},
+	} {
+		args, _ := json.Marshal(GithubSearchAction{Query: "nmap", Kind: "repositories", MaxResults: tt.maxResults})
+		if _, err := g.Handle(context.Background(), GithubToolName, args); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+
+		if got := requests[len(requests)-1].URL.Query().Get("per_page"); got != tt.want {
+			t.Errorf("max_results %d: per_page = %q, want %q", tt.maxResults, got, tt.want)
+		}
+	}
+}
+
+func TestGithubErrorKinds(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		remaining  string
+		kind       error
+	}{
+		{"unauthorized", http.StatusUnauthorized, "", ErrAuth},
+		{"rate limited", http.StatusForbidden, "0", ErrRateLimited},
+		{"forbidden", http.StatusForbidden, "10", ErrAuth},
+		{"unavailable", http.StatusServiceUnavailable, "", ErrUpstreamUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.remaining != "" {
+					w.Header().Set("X-RateLimit-Remaining", tt.remaining)
+				}
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			g := &github{enabled: true, apiURL: server.URL}
+			_, _, err := g.search(context.Background(), GithubSearchKindCode, "query", 5)
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("expected %v, got %v", tt.kind, err)
+			}
+		})
+	}
+}
+
+func TestGithubEmptyAndLargeResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("q") == "large" {
+			w.Write([]byte(`{"total_count": 1, "items": [{"full_name": "` + strings.Repeat("a", 64) + `"}]}`))
+			return
+		}
+		w.Write([]byte(`{"total_count": 0, "items": []}`))
+	}))
+	defer server.Close()
+
+	g := &github{enabled: true, apiURL: server.URL}
+	if _, _, err := g.search(context.Background(), GithubSearchKindCode, "query", 5); !errors.Is(err, ErrNoResults) {
+		t.Errorf("expected no results error, got %v", err)
+	}
+
+	args, _ := json.Marshal(GithubSearchAction{Query: "query", Kind: GithubSearchKindRepositories})
+	result, err := g.Handle(context.Background(), GithubToolName, args)
+	if err != nil || result != noResultsMessage {
+		t.Errorf("expected no results text, got %q, %v", result, err)
+	}
+
+	g.maxBody = 32
+	if _, _, err := g.search(context.Background(), GithubSearchKindRepositories, "large", 5); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected too large response error, got %v", err)
+	}
+}
+
+func TestGithubUnknownKind(t *testing.T) {
+	g := &github{enabled: true, apiURL: "http://127.0.0.1:0"}
+	if _, _, err := g.search(context.Background(), "issues", "query", 5); err == nil {
+		t.Error("expected error for unknown search kind")
+	}
+}
+
+func TestGithubIsAvailable(t *testing.T) {
+	if (&github{}).IsAvailable() {
+		t.Error("expected disabled tool to be unavailable")
+	}
+	if !(&github{enabled: true}).IsAvailable() {
+		t.Error("expected enabled tool without token to be available")
+	}
+}
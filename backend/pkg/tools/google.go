@@ -3,62 +3,173 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"pentagi/pkg/database"
-	obs "pentagi/pkg/observability"
-	"pentagi/pkg/observability/langfuse"
 
 	"github.com/sirupsen/logrus"
 	customsearch "google.golang.org/api/customsearch/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/googleapi/transport"
 	"google.golang.org/api/option"
 )
 
-const googleMaxResults = 10
+const (
+	googleDefaultResults = 10
+	googlePageSize       = 10
+	googleMaxResults     = 100 // hard ceiling of the custom search API
+)
 
 type google struct {
-	flowID    int64
-	taskID    *int64
-	subtaskID *int64
-	apiKey    string
-	cxKey     string
-	lrKey     string
-	proxyURL  string
-	slp       SearchLogProvider
+	flowID       int64
+	taskID       *int64
+	subtaskID    *int64
+	apiKey       string
+	cxKey        string
+	lrKey        string
+	safeSearch   string
+	proxyURL     string
+	endpoint     string
+	transport    http.RoundTripper
+	limits       ResultLimits
+	dryRun       bool
+	cache        CacheProvider
+	sourceFooter bool
+	highlight    bool
+	template     *ResultTemplate
+	cveEnricher  CVEEnricher
+	slp          SearchLogProvider
+	summarizer   SummarizeHandler
+	tracer       Tracer
+}
+
+// GoogleConfig configures the google custom search tool, zero fields keep the defaults of the tool
+type GoogleConfig struct {
+	FlowID    int64
+	TaskID    *int64
+	SubtaskID *int64
+
+	APIKey     string
+	CXKey      string
+	LRKey      string
+	SafeSearch string
+	ProxyURL   string
+	Endpoint   string
+	Limits     ResultLimits
+
+	DryRun       bool
+	Cache        CacheProvider
+	SourceFooter bool
+	Highlight    bool
+	Template     *ResultTemplate
+	CVEEnricher  CVEEnricher
+	SearchLog    SearchLogProvider
+	Summarizer   SummarizeHandler
+	Tracer       Tracer
 }
 
+// NewGoogleTool creates google custom search tool, it's kept for existing callers,
+// NewGoogleToolWithConfig is easier to read at the call site
 func NewGoogleTool(flowID int64, taskID, subtaskID *int64,
-	apiKey, cxKey, lrKey, proxyURL string, slp SearchLogProvider,
+	apiKey, cxKey, lrKey, safeSearch, proxyURL string, limits ResultLimits, sourceFooter, highlight bool,
+	template *ResultTemplate, slp SearchLogProvider,
 ) Tool {
+	return NewGoogleToolWithConfig(GoogleConfig{
+		FlowID:       flowID,
+		TaskID:       taskID,
+		SubtaskID:    subtaskID,
+		APIKey:       apiKey,
+		CXKey:        cxKey,
+		LRKey:        lrKey,
+		SafeSearch:   safeSearch,
+		ProxyURL:     proxyURL,
+		Limits:       limits,
+		SourceFooter: sourceFooter,
+		Highlight:    highlight,
+		Template:     template,
+		SearchLog:    slp,
+	})
+}
+
+// NewGoogleToolWithConfig creates google custom search tool, safe search is off, moderate or strict
+// and empty level keeps the default of the search engine, highlight bolds the query terms in snippets,
+// nil template keeps the built-in formatting of results, empty endpoint is the public API
+func NewGoogleToolWithConfig(cfg GoogleConfig) Tool {
 	return &google{
-		flowID:    flowID,
-		taskID:    taskID,
-		subtaskID: subtaskID,
-		apiKey:    apiKey,
-		cxKey:     cxKey,
-		lrKey:     lrKey,
-		proxyURL:  proxyURL,
-		slp:       slp,
+		flowID:       cfg.FlowID,
+		taskID:       cfg.TaskID,
+		subtaskID:    cfg.SubtaskID,
+		apiKey:       cfg.APIKey,
+		cxKey:        cfg.CXKey,
+		lrKey:        cfg.LRKey,
+		safeSearch:   cfg.SafeSearch,
+		proxyURL:     cfg.ProxyURL,
+		endpoint:     cfg.Endpoint,
+		limits:       cfg.Limits,
+		dryRun:       cfg.DryRun,
+		cache:        cfg.Cache,
+		sourceFooter: cfg.SourceFooter,
+		highlight:    cfg.Highlight,
+		template:     cfg.Template,
+		cveEnricher:  cfg.CVEEnricher,
+		slp:          cfg.SearchLog,
+		summarizer:   cfg.Summarizer,
+		tracer:       cfg.Tracer,
+	}
+}
+
+func (g *google) parseGoogleSearchResult(res *customsearch.Search, query string) string {
+	opts := FormatOptions{Template: g.template, Engine: "Google"}
+	if g.highlight {
+		opts.Highlight = query
 	}
+	return FormatResults(g.getSearchResultItems(res), opts)
 }
 
-func (g *google) parseGoogleSearchResult(res *customsearch.Search) string {
-	var writer strings.Builder
-	for i, item := range res.Items {
-		writer.WriteString(fmt.Sprintf("# %d. %s\n\n", i+1, item.Title))
-		writer.WriteString(fmt.Sprintf("## URL\n%s\n\n", item.Link))
-		writer.WriteString(fmt.Sprintf("## Snippet\n\n%s\n\n", item.Snippet))
+func (g *google) getSearchResultItems(res *customsearch.Search) []SearchResultItem {
+	items := make([]SearchResultItem, 0, len(res.Items))
+	for _, item := range res.Items {
+		items = append(items, SearchResultItem{
+			Title:   item.Title,
+			URL:     item.Link,
+			Snippet: item.Snippet,
+		})
+	}
+
+	return items
+}
+
+// searchResults returns structured results for the metasearch
+func (g *google) searchResults(ctx context.Context, query string, maxResults int) ([]SearchResultItem, error) {
+	svc, err := g.newSearchService(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if g.dryRun {
+		req, err := g.dryRunRequest(ctx, svc, GoogleSearchAction{Query: query}, int64(maxResults))
+		if err != nil {
+			return nil, err
+		}
+		return dryRunResults(req)
+	}
+
+	resp, err := g.search(ctx, svc, GoogleSearchAction{Query: query}, int64(maxResults))
+	if err != nil {
+		return nil, err
 	}
 
-	return writer.String()
+	return g.getSearchResultItems(resp), nil
 }
 
 func (g *google) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
-	var action SearchAction
-	ctx, observation := obs.Observer.NewObservation(ctx)
+	var action GoogleSearchAction
+	ctx, emitter := startTrace(ctx, g.tracer)
 	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
 		"tool": name,
 		"args": string(args),
@@ -68,15 +179,27 @@ func (g *google) Handle(ctx context.Context, name string, args json.RawMessage)
 		logger.WithError(err).Error("failed to unmarshal google search action")
 		return "", fmt.Errorf("failed to unmarshal %s search action arguments: %w", name, err)
 	}
+	action.Query = sanitizeQuery(action.Query, maxQueryLength)
 
-	numResults := int64(action.MaxResults)
-	if numResults < 1 || numResults > googleMaxResults {
-		numResults = googleMaxResults
+	lang, err := normalizeLanguage(action.Lang)
+	if err != nil {
+		logger.WithError(err).Error("invalid language of google search")
+		return fmt.Sprintf("invalid language of google search: %v", err), nil
 	}
+	action.Lang = lang
+
+	// the configured maximum can't exceed the ceiling of the API
+	limits := g.limits.resolve(googleDefaultResults, googleMaxResults)
+	numResults := int64(min(limits.clamp(int(action.MaxResults)), googleMaxResults))
+	summarize := action.Summarize && g.summarizer != nil
 
-	logger = logger.WithFields(logrus.Fields{
-		"query":       action.Query[:min(len(action.Query), 1000)],
-		"num_results": numResults,
+	logger = withToolFields(logger, "google", action.Query, g.flowID, g.taskID, g.subtaskID, logrus.Fields{
+		"num_results":   numResults,
+		"site":          action.Site,
+		"file_type":     action.FileType,
+		"date_restrict": action.DateRestrict,
+		"lang":          action.Lang,
+		"summarize":     summarize,
 	})
 
 	svc, err := g.newSearchService(ctx)
@@ -85,60 +208,216 @@ func (g *google) Handle(ctx context.Context, name string, args json.RawMessage)
 		return "", err
 	}
 
-	resp, err := svc.Cse.List().Context(ctx).Cx(g.cxKey).Q(action.Query).Lr(g.lrKey).Num(numResults).Do()
+	cacheKey := searchCacheKey(database.SearchengineTypeGoogle, action.Query,
+		g.cxKey, g.lrKey, g.safeSearch, numResults, action.Site, action.FileType, action.DateRestrict, action.Lang, g.highlight,
+		g.template.cacheKey(), summarize)
+	stats, start := SearchStats{Cached: true}, time.Now()
+	result, err := withSearchCache(ctx, dryRunCache(g.cache, g.dryRun), cacheKey, &stats, func() (string, error) {
+		if g.dryRun {
+			stats.Cached = false
+			req, err := g.dryRunRequest(ctx, svc, action, numResults)
+			if err != nil {
+				return "", err
+			}
+			return describeRequest(req)
+		}
+		resp, err := g.search(withSearchPriority(ctx, action.Priority), svc, action, numResults)
+		stats.Cached = false
+		if err != nil {
+			result, _, err := withNoResultsText("", 0, err)
+			return result, err
+		}
+		stats.ResultCount = len(resp.Items)
+		items := g.getSearchResultItems(resp)
+		result := g.parseGoogleSearchResult(resp, action.Query)
+		return withResultsSummary(ctx, g.summarizer, summarize, "Google", action.Query, items, result), nil
+	})
+	recordSearchCall(ctx, database.SearchengineTypeGoogle, stats.Cached, time.Since(start), err)
 	if err != nil {
-		observation.Event(
-			langfuse.WithEventName("search engine error swallowed"),
-			langfuse.WithEventInput(action.Query),
-			langfuse.WithEventStatus(err.Error()),
-			langfuse.WithEventLevel(langfuse.ObservationLevelWarning),
-			langfuse.WithEventMetadata(langfuse.Metadata{
-				"tool_name":   GoogleToolName,
-				"engine":      "google",
-				"query":       action.Query,
-				"max_results": numResults,
-				"error":       err.Error(),
-			}),
-		)
+		emitter.Emit(searchErrorEvent(action.Query, err, map[string]any{
+			"tool_name":   GoogleToolName,
+			"engine":      "google",
+			"max_results": numResults,
+		}))
 
 		logger.WithError(err).Error("failed to call tool to search in google results")
 		return fmt.Sprintf("failed to call tool %s to search in google results: %v", name, err), nil
 	}
 
-	result := g.parseGoogleSearchResult(resp)
+	stats.Duration = time.Since(start)
+	_, _ = putSearchLog(ctx, g.slp, database.SearchengineTypeGoogle, action.Query, result, stats, g.taskID, g.subtaskID)
+
+	result = withReferencedCVEs(ctx, result, g.cveEnricher)
 
-	if agentCtx, ok := GetAgentContext(ctx); ok {
-		_, _ = g.slp.PutLog(
-			ctx,
-			agentCtx.ParentAgentType,
-			agentCtx.CurrentAgentType,
-			database.SearchengineTypeGoogle,
-			action.Query,
-			result,
-			g.taskID,
-			g.subtaskID,
-		)
+	return withSourceFooter(result, "Google", g.sourceFooter), nil
+}
+
+// dryRunRequest builds the request of the first page through the dry run transport of the service
+// without sending it
+func (g *google) dryRunRequest(
+	ctx context.Context,
+	svc *customsearch.Service,
+	action GoogleSearchAction,
+	numResults int64,
+) (*http.Request, error) {
+	_, err := g.newListCall(ctx, svc, action).Start(1).Num(min(googlePageSize, numResults)).Do()
+	return caughtRequest(err)
+}
+
+// search fetches results page by page until numResults items are collected
+// or the engine has nothing more to return, nothing found at all is ErrNoResults,
+// the call waits for its turn in the rate limit queue by the priority of the context
+func (g *google) search(
+	ctx context.Context,
+	svc *customsearch.Service,
+	action GoogleSearchAction,
+	numResults int64,
+) (*customsearch.Search, error) {
+	if err := waitSearchQueue(ctx, database.SearchengineTypeGoogle, searchPriority(ctx)); err != nil {
+		return nil, err
+	}
+
+	result := &customsearch.Search{}
+	call := g.newListCall(ctx, svc, action)
+
+	for start := int64(1); int64(len(result.Items)) < numResults; start += googlePageSize {
+		pageSize := min(googlePageSize, numResults-int64(len(result.Items)))
+		resp, err := call.Start(start).Num(pageSize).Do()
+		if err != nil {
+			if len(result.Items) != 0 {
+				// return what was collected by previous pages
+				break
+			}
+			return nil, g.classifyError(err)
+		}
+
+		result.Items = append(result.Items, resp.Items...)
+		if start == 1 && len(resp.Items) == 0 {
+			g.checkTotalResults(ctx, resp)
+		}
+		if int64(len(resp.Items)) < pageSize || start+pageSize > g.getTotalResults(resp) {
+			break
+		}
+	}
+	if len(result.Items) == 0 {
+		return nil, newNoResultsError("google found no results for the query")
 	}
 
 	return result, nil
 }
 
+// newListCall builds the search call, optional restrictions are set only when provided,
+// the language of the action overrides the configured one and sets the interface language too
+func (g *google) newListCall(
+	ctx context.Context,
+	svc *customsearch.Service,
+	action GoogleSearchAction,
+) *customsearch.CseListCall {
+	call := svc.Cse.List().Context(ctx).Cx(g.cxKey).Q(action.Query)
+
+	if action.Lang != "" {
+		call = call.Lr("lang_" + action.Lang).Hl(action.Lang)
+	} else if g.lrKey != "" {
+		call = call.Lr(g.lrKey)
+	}
+
+	if safe := googleSafeLevel(g.safeSearch); safe != "" {
+		call = call.Safe(safe)
+	}
+
+	if site := strings.TrimSpace(action.Site); site != "" {
+		call = call.SiteSearch(site).SiteSearchFilter("i")
+	}
+
+	if fileType := strings.TrimPrefix(strings.TrimSpace(action.FileType), "."); fileType != "" {
+		call = call.FileType(fileType)
+	}
+
+	if dateRestrict := strings.TrimSpace(action.DateRestrict); dateRestrict != "" {
+		call = call.DateRestrict(dateRestrict)
+	}
+
+	return call
+}
+
+// googleSafeLevel maps the safe search level to the API value, the API filters explicit results
+// or not at all, so moderate and strict levels are the same, unknown level keeps the default
+func googleSafeLevel(safeSearch string) string {
+	switch strings.ToLower(strings.TrimSpace(safeSearch)) {
+	case SafeSearchOff:
+		return "off"
+	case SafeSearchModerate, SafeSearchStrict:
+		return "active"
+	default:
+		return ""
+	}
+}
+
+// classifyError marks API errors by their status code and other failures as network ones
+func (g *google) classifyError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return newSearchError(errorKindByStatus(apiErr.Code), "%w", err)
+	}
+
+	return newNetworkError(err, "failed to do request")
+}
+
+// checkTotalResults reports the drift of the response schema when the first page has no items
+// while the engine counts some results, e.g. the items moved to another field
+func (g *google) checkTotalResults(ctx context.Context, resp *customsearch.Search) {
+	if resp.SearchInformation == nil {
+		return
+	}
+
+	total, err := strconv.ParseInt(resp.SearchInformation.TotalResults, 10, 64)
+	if err == nil && total > 0 {
+		reportResponseDrift(ctx, "google", fmt.Sprintf("no items while total results is %d", total), nil)
+	}
+}
+
+func (g *google) getTotalResults(resp *customsearch.Search) int64 {
+	if resp.SearchInformation == nil {
+		return googleMaxResults
+	}
+
+	total, err := strconv.ParseInt(resp.SearchInformation.TotalResults, 10, 64)
+	if err != nil {
+		return googleMaxResults
+	}
+
+	return min(total, googleMaxResults)
+}
+
 func (g *google) newSearchService(ctx context.Context) (*customsearch.Service, error) {
 	opts := []option.ClientOption{
 		option.WithAPIKey(g.apiKey),
 	}
 
-	if g.proxyURL != "" {
+	if g.proxyURL != "" || g.dryRun || g.transport != nil {
+		var base http.RoundTripper = sharedTransport(g.proxyURL, TLSConfig{})
+		if g.transport != nil {
+			base = g.transport
+		}
+		if g.dryRun {
+			base = dryRunTransport{}
+		}
+
+		// custom HTTP client makes the library skip the API key option, so the key
+		// has to be attached by the transport itself
 		opts = append(opts, option.WithHTTPClient(&http.Client{
-			Transport: &http.Transport{
-				Proxy: func(req *http.Request) (*url.URL, error) {
-					return url.Parse(g.proxyURL)
-				},
+			Transport: &transport.APIKey{
+				Key:       g.apiKey,
+				Transport: base,
 			},
 		}))
 	}
 
-	svc, err := customsearch.NewService(ctx, option.WithAPIKey(g.apiKey))
+	if g.endpoint != "" {
+		opts = append(opts, option.WithEndpoint(g.endpoint))
+	}
+
+	svc, err := customsearch.NewService(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create google search service: %v", err)
 	}
@@ -146,6 +425,34 @@ func (g *google) newSearchService(ctx context.Context) (*customsearch.Service, e
 	return svc, nil
 }
 
+// EstimateCost prices the requests of the pages which the call would fetch, every page holds up to ten results
+func (g *google) EstimateCost(args json.RawMessage) (CostEstimate, error) {
+	var action GoogleSearchAction
+	if err := json.Unmarshal(args, &action); err != nil {
+		return CostEstimate{}, fmt.Errorf("failed to unmarshal %s search action arguments: %w", GoogleToolName, err)
+	}
+
+	limits := g.limits.resolve(googleDefaultResults, googleMaxResults)
+	numResults := min(limits.clamp(int(action.MaxResults)), googleMaxResults)
+	pages := (numResults + googlePageSize - 1) / googlePageSize
+
+	return resolvePricing(GoogleToolName).estimate(pages, numResults, 0, 1), nil
+}
+
 func (g *google) IsAvailable() bool {
-	return g.apiKey != "" && g.cxKey != ""
+	return g.apiKey != "" && g.cxKey != "" && IsEnabled(GoogleToolName)
+}
+
+// HealthCheck requests a single result to verify the API key, search engine id and proxy
+func (g *google) HealthCheck(ctx context.Context) error {
+	svc, err := g.newSearchService(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := g.newListCall(ctx, svc, GoogleSearchAction{Query: "test"}).Num(1).Do(); err != nil {
+		return g.classifyError(err)
+	}
+
+	return nil
 }
@@ -0,0 +1,351 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// newGoogleTestServer emulates the custom search API with total available items
+// and records query parameters of every received request
+func newGoogleTestServer(t *testing.T, total int, queries *[]url.Values) *httptest.Server {
+	t.Helper()
+
+	var mx sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		num, _ := strconv.Atoi(r.URL.Query().Get("num"))
+
+		mx.Lock()
+		*queries = append(*queries, r.URL.Query())
+		mx.Unlock()
+
+		items := []map[string]string{}
+		for i := start; i < start+num && i <= total; i++ {
+			items = append(items, map[string]string{
+				"title":   fmt.Sprintf("Result %d", i),
+				"link":    fmt.Sprintf("https://example.com/%d", i),
+				"snippet": fmt.Sprintf("Snippet %d", i),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"items": items,
+			"searchInformation": map[string]string{
+				"totalResults": strconv.Itoa(total),
+			},
+		})
+	}))
+}
+
+func TestGoogleNewSearchServiceWithProxy(t *testing.T) {
+	var proxyHits atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyHits.Add(1)
+		// refuse to tunnel so the test never reaches the real google API
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer proxy.Close()
+
+	g := &google{
+		apiKey:   "test-key",
+		cxKey:    "test-cx",
+		proxyURL: proxy.URL,
+	}
+
+	svc, err := g.newSearchService(context.Background())
+	if err != nil {
+		t.Fatalf("newSearchService() error = %v", err)
+	}
+
+	if _, err := svc.Cse.List().Cx(g.cxKey).Q("pentagi").Do(); err == nil {
+		t.Fatal("expected error when proxy refuses the connection")
+	}
+
+	if proxyHits.Load() == 0 {
+		t.Error("expected outbound request to be routed through the proxy")
+	}
+}
+
+func TestGoogleNewSearchServiceWithoutProxy(t *testing.T) {
+	g := &google{
+		apiKey: "test-key",
+		cxKey:  "test-cx",
+	}
+
+	svc, err := g.newSearchService(context.Background())
+	if err != nil {
+		t.Fatalf("newSearchService() error = %v", err)
+	}
+	if svc == nil {
+		t.Fatal("expected non-nil search service")
+	}
+}
+
+func TestGoogleSearchPagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      int
+		maxResults int
+		wantStarts []int
+		wantItems  int
+	}{
+		{
+			name:       "single page",
+			total:      100,
+			maxResults: 5,
+			wantStarts: []int{1},
+			wantItems:  5,
+		},
+		{
+			name:       "two pages",
+			total:      100,
+			maxResults: 15,
+			wantStarts: []int{1, 11},
+			wantItems:  15,
+		},
+		{
+			name:       "stops at available total",
+			total:      12,
+			maxResults: 30,
+			wantStarts: []int{1, 11},
+			wantItems:  12,
+		},
+		{
+			name:       "clamped to api ceiling",
+			total:      1000,
+			maxResults: 500,
+			wantStarts: []int{1, 11, 21, 31, 41, 51, 61, 71, 81, 91},
+			wantItems:  100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []url.Values
+			server := newGoogleTestServer(t, tt.total, &queries)
+			defer server.Close()
+
+			g := &google{
+				apiKey:   "test-key",
+				cxKey:    "test-cx",
+				endpoint: server.URL + "/",
+			}
+
+			args, _ := json.Marshal(GoogleSearchAction{
+				Query:      "pentagi",
+				MaxResults: Int64(tt.maxResults),
+			})
+
+			result, err := g.Handle(context.Background(), GoogleToolName, args)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			starts := make([]int, 0, len(queries))
+			for _, query := range queries {
+				start, _ := strconv.Atoi(query.Get("start"))
+				starts = append(starts, start)
+			}
+
+			if fmt.Sprint(starts) != fmt.Sprint(tt.wantStarts) {
+				t.Errorf("requested start offsets = %v, want %v", starts, tt.wantStarts)
+			}
+
+			if got := strings.Count(result, "## URL"); got != tt.wantItems {
+				t.Errorf("got %d items, want %d", got, tt.wantItems)
+			}
+
+			last := fmt.Sprintf("# %d. Result %d", tt.wantItems, tt.wantItems)
+			if !strings.Contains(result, last) {
+				t.Errorf("expected result to contain %q", last)
+			}
+		})
+	}
+}
+
+func TestGoogleSearchRestrictions(t *testing.T) {
+	tests := []struct {
+		name   string
+		action GoogleSearchAction
+		want   map[string]string
+	}{
+		{
+			name:   "no restrictions",
+			action: GoogleSearchAction{Query: "pentagi", MaxResults: 1},
+			want: map[string]string{
+				"siteSearch":       "",
+				"siteSearchFilter": "",
+				"fileType":         "",
+				"dateRestrict":     "",
+				"lr":               "",
+				"hl":               "",
+			},
+		},
+		{
+			name: "all restrictions",
+			action: GoogleSearchAction{
+				Query:        "pentagi",
+				MaxResults:   1,
+				Site:         "example.com",
+				FileType:     "pdf",
+				DateRestrict: "m6",
+				Lang:         "DE",
+			},
+			want: map[string]string{
+				"siteSearch":       "example.com",
+				"siteSearchFilter": "i",
+				"fileType":         "pdf",
+				"dateRestrict":     "m6",
+				"lr":               "lang_de",
+				"hl":               "de",
+			},
+		},
+		{
+			name: "file type with leading dot",
+			action: GoogleSearchAction{
+				Query:      "pentagi",
+				MaxResults: 1,
+				FileType:   ".xls",
+			},
+			want: map[string]string{
+				"siteSearch":   "",
+				"fileType":     "xls",
+				"dateRestrict": "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []url.Values
+			server := newGoogleTestServer(t, 10, &queries)
+			defer server.Close()
+
+			g := &google{
+				apiKey:   "test-key",
+				cxKey:    "test-cx",
+				endpoint: server.URL + "/",
+			}
+
+			args, _ := json.Marshal(tt.action)
+			if _, err := g.Handle(context.Background(), GoogleToolName, args); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if len(queries) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(queries))
+			}
+
+			for key, want := range tt.want {
+				if want == "" && queries[0].Has(key) {
+					t.Errorf("expected %q to be omitted, got %q", key, queries[0].Get(key))
+				} else if got := queries[0].Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGoogleSafeSearch(t *testing.T) {
+	tests := []struct {
+		safeSearch string
+		want       string
+	}{
+		{"", ""},
+		{"off", "off"},
+		{"moderate", "active"},
+		{"strict", "active"},
+		{" Strict ", "active"},
+		{"unknown", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.safeSearch, func(t *testing.T) {
+			var queries []url.Values
+			server := newGoogleTestServer(t, 10, &queries)
+			defer server.Close()
+
+			g := &google{
+				apiKey:     "test-key",
+				cxKey:      "test-cx",
+				safeSearch: tt.safeSearch,
+				endpoint:   server.URL + "/",
+			}
+
+			args, _ := json.Marshal(GoogleSearchAction{Query: "pentagi", MaxResults: 1})
+			if _, err := g.Handle(context.Background(), GoogleToolName, args); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if len(queries) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(queries))
+			}
+			if tt.want == "" && queries[0].Has("safe") {
+				t.Errorf("expected safe to be omitted, got %q", queries[0].Get("safe"))
+			} else if got := queries[0].Get("safe"); got != tt.want {
+				t.Errorf("safe = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoogleSearchLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		lrKey  string
+		lang   string
+		wantLr string
+		wantHl string
+	}{
+		{"provider default", "", "", "", ""},
+		{"configured language", "lang_en", "", "lang_en", ""},
+		{"action overrides configured language", "lang_en", "zh_tw", "lang_zh-TW", "zh-TW"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []url.Values
+			server := newGoogleTestServer(t, 10, &queries)
+			defer server.Close()
+
+			g := &google{apiKey: "test-key", cxKey: "test-cx", lrKey: tt.lrKey, endpoint: server.URL + "/"}
+
+			args, _ := json.Marshal(GoogleSearchAction{Query: "pentagi", MaxResults: 1, Lang: tt.lang})
+			if _, err := g.Handle(context.Background(), GoogleToolName, args); err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if len(queries) != 1 {
+				t.Fatalf("expected 1 request, got %d", len(queries))
+			}
+			for key, want := range map[string]string{"lr": tt.wantLr, "hl": tt.wantHl} {
+				if want == "" && queries[0].Has(key) {
+					t.Errorf("expected %q to be omitted, got %q", key, queries[0].Get(key))
+				} else if got := queries[0].Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+
+	g := &google{apiKey: "test-key", cxKey: "test-cx", endpoint: "http://127.0.0.1:0/"}
+	args, _ := json.Marshal(GoogleSearchAction{Query: "pentagi", MaxResults: 1, Lang: "german"})
+	result, err := g.Handle(context.Background(), GoogleToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.HasPrefix(result, "invalid language of google search") {
+		t.Errorf("unexpected result for invalid language %q", result)
+	}
+}
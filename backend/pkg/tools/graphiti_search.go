@@ -76,7 +76,12 @@ func NewGraphitiSearchTool(
 
 // IsAvailable checks if the tool is available
 func (t *GraphitiSearchTool) IsAvailable() bool {
-	return t.graphitiClient != nil && t.graphitiClient.IsEnabled()
+	return t.graphitiClient != nil && t.graphitiClient.IsEnabled() && IsEnabled(GraphitiSearchToolName)
+}
+
+// HealthCheck is a no-op, graphiti client availability is checked on its creation
+func (t *GraphitiSearchTool) HealthCheck(ctx context.Context) error {
+	return nil
 }
 
 // Handle executes the search based on search_type
@@ -277,3 +277,7 @@ func (g *guide) Handle(ctx context.Context, name string, args json.RawMessage) (
 func (g *guide) IsAvailable() bool {
 	return g.store != nil
 }
+
+func (g *guide) HealthCheck(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+	"sync"
+)
+
+// CheckToolsHealth runs health checks of the available tools concurrently
+// and returns failed checks by the tool name, unavailable tools are skipped
+func CheckToolsHealth(ctx context.Context, tools map[string]Tool) map[string]error {
+	var (
+		mx     sync.Mutex
+		wg     sync.WaitGroup
+		failed = make(map[string]error)
+	)
+
+	for name, tool := range tools {
+		if tool == nil || !tool.IsAvailable() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, tool Tool) {
+			defer wg.Done()
+
+			if err := tool.HealthCheck(ctx); err != nil {
+				mx.Lock()
+				failed[name] = err
+				mx.Unlock()
+			}
+		}(name, tool)
+	}
+
+	wg.Wait()
+
+	return failed
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestToolsHealthCheck(t *testing.T) {
+	tools := map[string]func(serverURL string) Tool{
+		"perplexity": func(serverURL string) Tool {
+			return &perplexity{apiKey: "test-key", model: perplexityModel, baseURL: serverURL}
+		},
+		"google": func(serverURL string) Tool {
+			return &google{apiKey: "test-key", cxKey: "test-cx", endpoint: serverURL + "/"}
+		},
+		"tavily": func(serverURL string) Tool {
+			return &tavily{apiKey: "test-key", apiURL: serverURL}
+		},
+		"traversaal": func(serverURL string) Tool {
+			return &traversaal{apiKey: "test-key", apiURL: serverURL}
+		},
+		"github": func(serverURL string) Tool {
+			return &github{enabled: true, token: "test-token", apiURL: serverURL}
+		},
+		"searxng": func(serverURL string) Tool {
+			return NewSearxngTool(0, nil, nil, serverURL, "", "", "", "", "", 0, false, &MockSearchLogProvider{}, nil)
+		},
+	}
+
+	for name, newTool := range tools {
+		t.Run(name+" success", func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+
+			if err := newTool(server.URL).HealthCheck(context.Background()); err != nil {
+				t.Errorf("HealthCheck() error = %v", err)
+			}
+		})
+
+		t.Run(name+" auth failure", func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			}))
+			defer server.Close()
+
+			err := newTool(server.URL).HealthCheck(context.Background())
+			if !errors.Is(err, ErrAuth) {
+				t.Errorf("expected %v, got %v", ErrAuth, err)
+			}
+		})
+	}
+}
+
+func TestToolsHealthCheckUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	tool := &tavily{apiKey: "test-key", apiURL: serverURL}
+	if err := tool.HealthCheck(context.Background()); !errors.Is(err, ErrNetwork) {
+		t.Errorf("expected %v, got %v", ErrNetwork, err)
+	}
+}
+
+func TestGithubHealthCheckRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rate_limit" {
+			t.Errorf("unexpected request path %q", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"resources": map[string]any{
+				"search": map[string]int{"limit": 10, "remaining": 0},
+			},
+		})
+	}))
+	defer server.Close()
+
+	g := &github{enabled: true, apiURL: server.URL}
+	if err := g.HealthCheck(context.Background()); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected %v, got %v", ErrRateLimited, err)
+	}
+}
+
+type healthCheckTool struct {
+	available bool
+	err       error
+	calls     int
+}
+
+func (h *healthCheckTool) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	return "", nil
+}
+
+func (h *healthCheckTool) IsAvailable() bool {
+	return h.available
+}
+
+func (h *healthCheckTool) HealthCheck(ctx context.Context) error {
+	h.calls++
+	return h.err
+}
+
+func TestCheckToolsHealth(t *testing.T) {
+	healthy := &healthCheckTool{available: true}
+	broken := &healthCheckTool{available: true, err: newSearchError(ErrAuth, "API key is wrong")}
+	disabled := &healthCheckTool{available: false, err: errors.New("should not be called")}
+
+	failed := CheckToolsHealth(context.Background(), map[string]Tool{
+		"healthy":  healthy,
+		"broken":   broken,
+		"disabled": disabled,
+		"missing":  nil,
+	})
+
+	if len(failed) != 1 {
+		t.Fatalf("expected 1 failed check, got %v", failed)
+	}
+	if !errors.Is(failed["broken"], ErrAuth) {
+		t.Errorf("expected broken tool to fail with %v, got %v", ErrAuth, failed["broken"])
+	}
+	if healthy.calls != 1 || broken.calls != 1 {
+		t.Errorf("expected available tools to be checked once, got %d and %d", healthy.calls, broken.calls)
+	}
+	if disabled.calls != 0 {
+		t.Errorf("expected unavailable tool to be skipped, got %d calls", disabled.calls)
+	}
+}
@@ -0,0 +1,297 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"pentagi/pkg/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	hibpAPIURL    = "https://haveibeenpwned.com/api/v3"
+	hibpUserAgent = "PentAGI"
+	hibpTimeout   = 30 * time.Second
+	// requests over the per-minute limit of the subscription are retried after the Retry-After delay,
+	// the delays and the deadline come from the retry policy of the tool
+	hibpRetries = 2
+)
+
+type hibpBreach struct {
+	Name        string   `json:"Name"`
+	Title       string   `json:"Title"`
+	Domain      string   `json:"Domain"`
+	BreachDate  string   `json:"BreachDate"`
+	AddedDate   string   `json:"AddedDate"`
+	PwnCount    int      `json:"PwnCount"`
+	DataClasses []string `json:"DataClasses"`
+	IsVerified  bool     `json:"IsVerified"`
+	IsSensitive bool     `json:"IsSensitive"`
+	IsSpamList  bool     `json:"IsSpamList"`
+}
+
+type hibp struct {
+	flowID    int64
+	taskID    *int64
+	subtaskID *int64
+	apiKey    string
+	proxyURL  string
+	apiURL    string
+	retry     RetryPolicy
+	cache     CacheProvider
+	slp       SearchLogProvider
+	tracer    Tracer
+	quota     quotaTracker
+}
+
+func NewHIBPTool(flowID int64, taskID, subtaskID *int64,
+	apiKey, proxyURL string, retry RetryPolicy, slp SearchLogProvider,
+) Tool {
+	return &hibp{
+		flowID:    flowID,
+		taskID:    taskID,
+		subtaskID: subtaskID,
+		apiKey:    apiKey,
+		proxyURL:  proxyURL,
+		retry:     retry,
+		slp:       slp,
+	}
+}
+
+// Handle looks up the breaches of the email address or the domain in Have I Been Pwned
+func (h *hibp) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action HIBPAction
+	ctx, emitter := startTrace(ctx, h.tracer)
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal hibp action")
+		return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+	}
+
+	account, isEmail, err := normalizeHIBPAccount(action.Account)
+	logger = logger.WithField("account", account)
+	if err != nil {
+		logger.WithError(err).Error("invalid account to check in hibp")
+		return fmt.Sprintf("invalid account %q to check: %v", action.Account, err), nil
+	}
+
+	cacheKey := searchCacheKey(database.SearchengineTypeHibp, account)
+	stats, start := SearchStats{Cached: true}, time.Now()
+	result, err := withSearchCache(ctx, h.cache, cacheKey, &stats, func() (string, error) {
+		breaches, err := h.lookup(ctx, account, isEmail)
+		if err != nil {
+			stats.Cached = false
+			return "", err
+		}
+		stats.Cached, stats.ResultCount = false, len(breaches)
+		return formatHIBPBreaches(account, breaches), nil
+	})
+	recordSearchCall(ctx, database.SearchengineTypeHibp, stats.Cached, time.Since(start), err)
+	if event, ok := quotaEvent("hibp", h); ok && !stats.Cached {
+		emitter.Emit(event)
+	}
+	if err != nil {
+		emitter.Emit(searchErrorEvent(account, err, map[string]any{
+			"tool_name": HIBPToolName,
+			"engine":    "hibp",
+		}))
+
+		logger.WithError(err).Error("failed to check account in hibp")
+		return fmt.Sprintf("failed to check account in hibp: %v", err), nil
+	}
+
+	stats.Duration = time.Since(start)
+	_, _ = putSearchLog(ctx, h.slp, database.SearchengineTypeHibp, account, result, stats, h.taskID, h.subtaskID)
+
+	return result, nil
+}
+
+// lookup returns the breaches of the email address or the breaches of the site with the domain,
+// no breaches is an empty list
+func (h *hibp) lookup(ctx context.Context, account string, isEmail bool) ([]hibpBreach, error) {
+	apiURL := h.apiURL
+	if apiURL == "" {
+		apiURL = hibpAPIURL
+	}
+	apiURL = strings.TrimRight(apiURL, "/")
+
+	var reqURL string
+	if isEmail {
+		reqURL = apiURL + "/breachedaccount/" + url.PathEscape(account) + "?truncateResponse=false"
+	} else {
+		reqURL = apiURL + "/breaches?" + url.Values{"domain": {account}}.Encode()
+	}
+
+	body, err := h.get(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, nil
+	}
+
+	var breaches []hibpBreach
+	if err := json.Unmarshal(body, &breaches); err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	return breaches, nil
+}
+
+// get returns nil body for the not found response which means the account is not in any breach
+func (h *hibp) get(ctx context.Context, reqURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", hibpUserAgent)
+	req.Header.Set("hibp-api-key", h.apiKey)
+
+	resp, err := doWithRetry(h.createHTTPClient(), req, h.retry.WithMaxAttempts(hibpRetries+1))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	h.quota.update(resp.Header)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := h.handleErrorResponse(resp); err != nil {
+		return nil, err
+	}
+
+	return readLimitedBody(resp.Body, defaultMaxResponseSize)
+}
+
+// LastQuota returns the requests left in the current window of the subscription from the last response
+func (h *hibp) LastQuota() (int, time.Time, bool) {
+	return h.quota.LastQuota()
+}
+
+func (h *hibp) handleErrorResponse(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return newSearchError(ErrAuth, "hibp API key is wrong or the request is forbidden")
+	case http.StatusTooManyRequests:
+		if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+			return newSearchError(ErrRateLimited, "hibp rate limit exceeded, retry after %s seconds", retryAfter)
+		}
+		return newSearchError(ErrRateLimited, "hibp rate limit exceeded")
+	case http.StatusBadRequest:
+		return fmt.Errorf("hibp rejected the account as invalid")
+	default:
+		return newSearchError(errorKindByStatus(resp.StatusCode), "unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+// normalizeHIBPAccount accepts the email address or the domain and reports whether it's an email address
+func normalizeHIBPAccount(value string) (string, bool, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "@") {
+		addr, err := mail.ParseAddress(value)
+		if err != nil {
+			return value, true, fmt.Errorf("email address is malformed: %w", err)
+		}
+		return strings.ToLower(addr.Address), true, nil
+	}
+
+	domain, err := normalizeDomain(value)
+	if err != nil {
+		return value, false, err
+	}
+
+	return domain, false, nil
+}
+
+// formatHIBPBreaches renders the breaches from the most recent one
+func formatHIBPBreaches(account string, breaches []hibpBreach) string {
+	if len(breaches) == 0 {
+		return fmt.Sprintf("No breaches of %s found in Have I Been Pwned\n", account)
+	}
+
+	breaches = slices.Clone(breaches)
+	slices.SortStableFunc(breaches, func(a, b hibpBreach) int {
+		return strings.Compare(b.BreachDate, a.BreachDate)
+	})
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# Breaches of %s\n\n", account))
+	builder.WriteString(fmt.Sprintf("Found in %d breaches\n\n", len(breaches)))
+
+	for _, breach := range breaches {
+		title := breach.Title
+		if title == "" {
+			title = breach.Name
+		}
+		if breach.Domain != "" {
+			title = fmt.Sprintf("%s (%s)", title, breach.Domain)
+		}
+		builder.WriteString(fmt.Sprintf("## %s\n", title))
+
+		if breach.BreachDate != "" {
+			builder.WriteString(fmt.Sprintf("- Breach date: %s\n", breach.BreachDate))
+		}
+		if breach.AddedDate != "" {
+			builder.WriteString(fmt.Sprintf("- Added: %s\n", breach.AddedDate))
+		}
+		if breach.PwnCount > 0 {
+			builder.WriteString(fmt.Sprintf("- Affected accounts: %d\n", breach.PwnCount))
+		}
+		if len(breach.DataClasses) > 0 {
+			builder.WriteString(fmt.Sprintf("- Data classes: %s\n", strings.Join(breach.DataClasses, ", ")))
+		}
+
+		var flags []string
+		if !breach.IsVerified {
+			flags = append(flags, "unverified")
+		}
+		if breach.IsSensitive {
+			flags = append(flags, "sensitive")
+		}
+		if breach.IsSpamList {
+			flags = append(flags, "spam list")
+		}
+		if len(flags) > 0 {
+			builder.WriteString(fmt.Sprintf("- Flags: %s\n", strings.Join(flags, ", ")))
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+func (h *hibp) createHTTPClient() *http.Client {
+	return newHTTPClient(h.proxyURL, resolveTimeout(HIBPToolName, 0))
+}
+
+func (h *hibp) IsAvailable() bool {
+	return h.apiKey != "" && IsEnabled(HIBPToolName)
+}
+
+// HealthCheck verifies the API key by the subscription status which isn't counted against the rate limit
+func (h *hibp) HealthCheck(ctx context.Context) error {
+	apiURL := h.apiURL
+	if apiURL == "" {
+		apiURL = hibpAPIURL
+	}
+
+	_, err := h.get(ctx, strings.TrimRight(apiURL, "/")+"/subscription/status")
+	return err
+}
@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"pentagi/pkg/database"
+)
+
+func TestHIBPFormatBreaches(t *testing.T) {
+	data, err := os.ReadFile("testdata/hibp_breachedaccount.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var breaches []hibpBreach
+	if err := json.Unmarshal(data, &breaches); err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+
+	want := "# Breaches of admin@example.com\n\n" +
+		"Found in 3 breaches\n\n" +
+		"## ExampleForum\n" +
+		"- Breach date: 2021-03-18\n" +
+		"- Added: 2021-04-02T08:11:00Z\n" +
+		"- Affected accounts: 20310\n" +
+		"- Data classes: Email addresses, IP addresses\n" +
+		"- Flags: unverified, sensitive\n\n" +
+		"## Adobe (adobe.com)\n" +
+		"- Breach date: 2013-10-04\n" +
+		"- Added: 2013-12-04T00:00:00Z\n" +
+		"- Affected accounts: 152445165\n" +
+		"- Data classes: Email addresses, Password hints, Passwords, Usernames\n\n" +
+		"## LinkedIn (linkedin.com)\n" +
+		"- Breach date: 2012-05-05\n" +
+		"- Added: 2016-05-21T21:35:40Z\n" +
+		"- Affected accounts: 164611595\n" +
+		"- Data classes: Email addresses, Passwords\n\n"
+	if got := formatHIBPBreaches("admin@example.com", breaches); got != want {
+		t.Errorf("unexpected breaches:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := formatHIBPBreaches("example.com", nil); got != "No breaches of example.com found in Have I Been Pwned\n" {
+		t.Errorf("unexpected result without breaches %q", got)
+	}
+}
+
+func TestHIBPHandle(t *testing.T) {
+	data, err := os.ReadFile("testdata/hibp_breachedaccount.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	slp := &statsSearchLogProvider{}
+	h := &hibp{apiKey: "key", apiURL: server.URL, slp: slp}
+
+	for _, tt := range []struct {
+		account string
+		path    string
+		query   string
+	}{
+		{" Admin@Example.com ", "/breachedaccount/admin@example.com", "truncateResponse=false"},
+		{"https://Example.com/login", "/breaches", "domain=example.com"},
+	} {
+		args, _ := json.Marshal(HIBPAction{Account: tt.account})
+		result, err := h.Handle(context.Background(), HIBPToolName, args)
+		if err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+		if !strings.Contains(result, "Found in 3 breaches") {
+			t.Errorf("unexpected result:\n%s", result)
+		}
+
+		last := requests[len(requests)-1]
+		if last.URL.Path != tt.path || last.URL.RawQuery != tt.query {
+			t.Errorf("unexpected request %s?%s, want %s?%s", last.URL.Path, last.URL.RawQuery, tt.path, tt.query)
+		}
+		if last.Header.Get("hibp-api-key") != "key" || last.Header.Get("User-Agent") != hibpUserAgent {
+			t.Errorf("unexpected headers %v", last.Header)
+		}
+	}
+
+	if len(slp.logs) != 2 {
+		t.Fatalf("expected 2 search logs, got %d", len(slp.logs))
+	}
+	if log := slp.logs[0]; log.engine != database.SearchengineTypeHibp || log.stats.ResultCount != 3 {
+		t.Errorf("unexpected search log %+v", log)
+	}
+}
+
+func TestHIBPNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	slp := &statsSearchLogProvider{}
+	h := &hibp{apiKey: "key", apiURL: server.URL, slp: slp}
+
+	args, _ := json.Marshal(HIBPAction{Account: "nobody@example.com"})
+	result, err := h.Handle(context.Background(), HIBPToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if result != "No breaches of nobody@example.com found in Have I Been Pwned\n" {
+		t.Errorf("unexpected result %q", result)
+	}
+	if len(slp.logs) != 1 || slp.logs[0].stats.ResultCount != 0 {
+		t.Errorf("unexpected search logs %+v", slp.logs)
+	}
+}
+
+func TestHIBPRateLimit(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	h := &hibp{apiKey: "key", apiURL: server.URL}
+	_, err := h.lookup(context.Background(), "admin@example.com", true)
+	if !errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "retry after 0 seconds") {
+		t.Errorf("expected rate limited error with retry delay, got %v", err)
+	}
+	if calls != hibpRetries+1 {
+		t.Errorf("expected %d requests, got %d", hibpRetries+1, calls)
+	}
+}
+
+func TestHIBPRateLimitRecovers(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	h := &hibp{apiKey: "key", apiURL: server.URL}
+	breaches, err := h.lookup(context.Background(), "example.com", false)
+	if err != nil {
+		t.Fatalf("lookup() error = %v", err)
+	}
+	if len(breaches) != 0 || calls != 2 {
+		t.Errorf("expected no breaches after 2 requests, got %d after %d", len(breaches), calls)
+	}
+	if time.Since(start) < time.Second {
+		t.Error("expected the retry to wait for Retry-After delay")
+	}
+}
+
+func TestHIBPInvalidAccount(t *testing.T) {
+	h := &hibp{apiKey: "key", apiURL: "http://127.0.0.1:0"}
+
+	for _, account := range []string{"admin@", "localhost", "10.0.0.1"} {
+		args, _ := json.Marshal(HIBPAction{Account: account})
+		result, err := h.Handle(context.Background(), HIBPToolName, args)
+		if err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+		if !strings.HasPrefix(result, "invalid account") {
+			t.Errorf("%s: unexpected result %q", account, result)
+		}
+	}
+}
+
+func TestHIBPIsAvailable(t *testing.T) {
+	if (&hibp{}).IsAvailable() {
+		t.Error("expected tool without API key to be unavailable")
+	}
+	if !(&hibp{apiKey: "key"}).IsAvailable() {
+		t.Error("expected tool with API key to be available")
+	}
+}
@@ -0,0 +1,270 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	httpFetchTimeout     = 30 * time.Second
+	httpFetchMaxBodySize = 64 * 1024
+)
+
+var httpFetchMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+type httpFetch struct {
+	flowID      int64
+	taskID      *int64
+	subtaskID   *int64
+	enabled     bool
+	proxyURL    string
+	proxyPool   *ProxyPool
+	timeout     time.Duration
+	maxBodySize int
+	tls         TLSConfig
+	policy      *URLPolicy
+	scope       *DomainScope
+	transport   http.RoundTripper
+	tracer      Tracer
+}
+
+// NewHTTPFetchTool creates the tool which sends arbitrary HTTP requests to probe APIs of the target,
+// zero timeout and max body size fall back to the defaults, certificates of the targets are verified
+// unless the TLS config skips the verification or trusts the custom CA of the lab, the targets are
+// restricted by the same policy and scope as the targets of the browser, nil ones allow any target
+func NewHTTPFetchTool(flowID int64, taskID, subtaskID *int64, enabled bool,
+	proxyURL string, proxyPool *ProxyPool, timeout time.Duration, maxBodySize int, tlsConfig TLSConfig,
+	policy *URLPolicy, scope *DomainScope,
+) Tool {
+	return &httpFetch{
+		flowID:      flowID,
+		taskID:      taskID,
+		subtaskID:   subtaskID,
+		enabled:     enabled,
+		proxyURL:    proxyURL,
+		proxyPool:   proxyPool,
+		timeout:     timeout,
+		maxBodySize: maxBodySize,
+		tls:         tlsConfig,
+		policy:      policy,
+		scope:       scope,
+	}
+}
+
+func (h *httpFetch) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action HTTPFetchAction
+	ctx, emitter := startTrace(ctx, h.tracer)
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal http fetch action")
+		return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(action.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"method": method,
+		"url":    action.URL,
+	})
+
+	result, err := h.fetch(ctx, method, action.URL, action.Headers, action.Body)
+	if err != nil {
+		emitter.Emit(TraceEvent{
+			Name:   "http fetch tool error swallowed",
+			Input:  action.URL,
+			Status: err.Error(),
+			Level:  TraceLevelWarning,
+			Metadata: map[string]any{
+				"tool_name": HTTPFetchToolName,
+				"method":    method,
+				"url":       action.URL,
+				"error":     err.Error(),
+			},
+		})
+
+		logger.WithError(err).Error("failed to fetch url")
+		return fmt.Sprintf("failed to fetch url '%s': %v", action.URL, err), nil
+	}
+
+	return result, nil
+}
+
+func (h *httpFetch) fetch(ctx context.Context, method, targetURL string, headers map[string]string, body string) (string, error) {
+	u, err := h.validateURL(ctx, targetURL)
+	if err != nil {
+		return "", err
+	}
+
+	if !slices.Contains(httpFetchMethods, method) {
+		return "", fmt.Errorf("method %s is not supported, expected one of: %s",
+			method, strings.Join(httpFetchMethods, ", "))
+	}
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+
+	for key, value := range headers {
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+
+	resp, err := h.createHTTPClient(u).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// the whole body is read to render it properly, the rest above the limit is only counted
+	// to report the full size and it's capped to not hang on endless streams
+	data, err := io.ReadAll(io.LimitReader(resp.Body, defaultMaxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	rest, _ := io.Copy(io.Discard, io.LimitReader(resp.Body, defaultMaxResponseSize))
+
+	return h.formatResponse(resp, data, rest), nil
+}
+
+// validateURL accepts only absolute http and https URLs to refuse local files and other dangerous schemes,
+// the host is checked by the scope and the policy before any request the same way as the browser does
+func (h *httpFetch) validateURL(ctx context.Context, targetURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(targetURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	if scheme := strings.ToLower(u.Scheme); scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("scheme %q is not allowed, only http and https urls can be fetched", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("url must contain the host")
+	}
+
+	if err := h.scope.Check(u.Hostname()); err != nil {
+		return nil, err
+	}
+	if err := h.policy.Check(ctx, u.Hostname()); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// createHTTPClient picks the egress the same way as the browser picks the scraper:
+// private targets are requested directly and public ones go through the proxy if it's set
+// or the next proxy of the pool, redirects aren't followed to show them to the agent as is
+func (h *httpFetch) createHTTPClient(target *url.URL) *http.Client {
+	timeout := resolveTimeout(HTTPFetchToolName, h.timeout)
+
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	if h.transport != nil {
+		client.Transport = h.transport
+		return client
+	}
+
+	// targets of the penetration testing often use self-signed certificates, it's up to the TLS config
+	proxyURL := ""
+	if !isPrivateHost(target.Hostname()) {
+		proxyURL = pickProxy(h.proxyPool, h.proxyURL)
+	}
+	client.Transport = sharedTransport(proxyURL, h.tls)
+
+	return client
+}
+
+// formatResponse renders the body according to its content type and truncates the rendered body,
+// skipped is the size of the raw body which wasn't read at all
+func (h *httpFetch) formatResponse(resp *http.Response, body []byte, skipped int64) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("# Status\n%s %s\n\n", resp.Proto, resp.Status))
+
+	keys := make([]string, 0, len(resp.Header))
+	for key := range resp.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	builder.WriteString("# Headers\n")
+	for _, key := range keys {
+		for _, value := range resp.Header[key] {
+			builder.WriteString(fmt.Sprintf("%s: %s\n", key, value))
+		}
+	}
+	builder.WriteString("\n")
+
+	builder.WriteString("# Body\n")
+	if len(body) == 0 {
+		builder.WriteString("(empty)\n")
+		return builder.String()
+	}
+
+	maxBodySize := h.maxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = httpFetchMaxBodySize
+	}
+
+	rendered := renderBody(resp.Header.Get("Content-Type"), body)
+	if total := int64(len(rendered)) + skipped; total > int64(maxBodySize) {
+		limit := min(len(rendered), maxBodySize)
+		for limit > 0 && limit < len(rendered) && !utf8.RuneStart(rendered[limit]) {
+			limit--
+		}
+		rendered = fmt.Sprintf("%s\n\n[body truncated: %d of %d bytes shown]", rendered[:limit], limit, total)
+	}
+	builder.WriteString(rendered)
+	builder.WriteString("\n")
+
+	return builder.String()
+}
+
+func (h *httpFetch) IsAvailable() bool {
+	return h.enabled && IsEnabled(HTTPFetchToolName)
+}
+
+func (h *httpFetch) HealthCheck(ctx context.Context) error {
+	return nil
+}
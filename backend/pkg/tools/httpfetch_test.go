@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHTTPFetchHandle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/users":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Api-Version", "2")
+			w.Write([]byte(`{"users":["admin"]}`))
+		case "/api/login":
+			body, _ := io.ReadAll(r.Body)
+			if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("received: " + string(body)))
+		case "/large":
+			w.Write([]byte(strings.Repeat("a", 1000)))
+		case "/redirect":
+			http.Redirect(w, r, "/api/users", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		action   HTTPFetchAction
+		contains []string
+		excludes []string
+	}{
+		{
+			name:   "get",
+			action: HTTPFetchAction{URL: server.URL + "/api/users"},
+			contains: []string{
+				"# Status\nHTTP/1.1 200 OK\n\n",
+				"Content-Type: application/json\n",
+				"X-Api-Version: 2\n",
+				"# Body\n{\n  \"users\": [\n    \"admin\"\n  ]\n}\n",
+			},
+		},
+		{
+			name: "post with body",
+			action: HTTPFetchAction{
+				Method:  "post",
+				URL:     server.URL + "/api/login",
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    `{"user":"admin","password":"admin"}`,
+			},
+			contains: []string{
+				"# Status\nHTTP/1.1 201 Created\n\n",
+				"# Body\nreceived: {\"user\":\"admin\",\"password\":\"admin\"}\n",
+			},
+		},
+		{
+			name:   "large response is truncated",
+			action: HTTPFetchAction{URL: server.URL + "/large"},
+			contains: []string{
+				"# Body\n" + strings.Repeat("a", 100) + "\n\n[body truncated: 100 of 1000 bytes shown]\n",
+			},
+		},
+		{
+			name:     "redirect is not followed",
+			action:   HTTPFetchAction{URL: server.URL + "/redirect"},
+			contains: []string{"HTTP/1.1 302 Found", "Location: /api/users\n"},
+			excludes: []string{"admin"},
+		},
+	}
+
+	h := &httpFetch{enabled: true, maxBodySize: 100}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(tt.action)
+			result, err := h.Handle(context.Background(), HTTPFetchToolName, args)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			for _, want := range tt.contains {
+				if !strings.Contains(result, want) {
+					t.Errorf("expected result to contain %q, got:\n%s", want, result)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(result, unwanted) {
+					t.Errorf("expected result not to contain %q, got:\n%s", unwanted, result)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPFetchRefusesRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		want   string
+	}{
+		{"file scheme", "", "file:///etc/passwd", `scheme "file" is not allowed`},
+		{"gopher scheme", "", "gopher://127.0.0.1:6379/_INFO", `scheme "gopher" is not allowed`},
+		{"relative url", "", "/etc/passwd", `scheme "" is not allowed`},
+		{"unknown method", "TRACE", "http://127.0.0.1/", "method TRACE is not supported"},
+	}
+
+	h := &httpFetch{enabled: true}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.fetch(context.Background(), tt.method, tt.url, nil, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestHTTPFetchPolicyAndScope(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	policy, err := NewURLPolicy(true, nil, []string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("NewURLPolicy() error = %v", err)
+	}
+	policy.lookup = func(ctx context.Context, host string) ([]net.IP, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	scope, err := NewDomainScope(nil, []string{"admin.example.com"})
+	if err != nil {
+		t.Fatalf("NewDomainScope() error = %v", err)
+	}
+
+	h := NewHTTPFetchTool(1, nil, nil, true, "", nil, 0, 0, TLSConfig{}, policy, scope).(*httpFetch)
+	for _, tt := range []struct {
+		url  string
+		want error
+	}{
+		{"http://169.254.169.254/latest/meta-data/", ErrBlockedByPolicy},
+		{"http://0xA9FEA9FE/latest/meta-data/", ErrBlockedByPolicy},
+		{"http://10.1.2.3:8080/", ErrBlockedByPolicy},
+		{"http://unknown.invalid/", ErrBlockedByPolicy},
+		{"https://admin.example.com/", ErrOutOfScope},
+	} {
+		if _, err := h.fetch(context.Background(), http.MethodGet, tt.url, nil, ""); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.url, tt.want, err)
+		}
+	}
+
+	result, err := h.Handle(context.Background(), HTTPFetchToolName, json.RawMessage(`{"url":"http://169.254.169.254/"}`))
+	if err != nil || !strings.Contains(result, "blocked by policy") {
+		t.Errorf("expected the blocked target to be reported to the agent, got %q, %v", result, err)
+	}
+
+	if _, err := h.fetch(context.Background(), http.MethodGet, server.URL, nil, ""); err != nil {
+		t.Fatalf("expected the allowed target to be fetched, got %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected only the allowed target to be requested, got %d requests", requests.Load())
+	}
+}
+
+func TestHTTPFetchTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal api"))
+	}))
+	defer server.Close()
+
+	args, _ := json.Marshal(HTTPFetchAction{URL: server.URL})
+
+	h := NewHTTPFetchTool(1, nil, nil, true, "", nil, 0, 0, TLSConfig{}, nil, nil)
+	result, err := h.Handle(context.Background(), HTTPFetchToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.Contains(result, "failed to fetch url") || !strings.Contains(result, "certificate") {
+		t.Errorf("expected untrusted certificate to fail by default, got:\n%s", result)
+	}
+
+	h = NewHTTPFetchTool(1, nil, nil, true, "", nil, 0, 0, TLSConfig{InsecureSkipVerify: true}, nil, nil)
+	result, err = h.Handle(context.Background(), HTTPFetchToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.Contains(result, "200 OK") || !strings.Contains(result, "internal api") {
+		t.Errorf("expected insecure mode to accept the certificate, got:\n%s", result)
+	}
+}
+
+func TestHTTPFetchIsAvailable(t *testing.T) {
+	if (&httpFetch{}).IsAvailable() {
+		t.Error("expected disabled tool to be unavailable")
+	}
+	if !(&httpFetch{enabled: true}).IsAvailable() {
+		t.Error("expected enabled tool to be available")
+	}
+}
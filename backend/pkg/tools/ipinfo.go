@@ -0,0 +1,310 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ipinfoAPIURL        = "https://ipinfo.io"
+	ipinfoTimeout       = 30 * time.Second
+	ipinfoHealthCheckIP = "8.8.8.8"
+)
+
+// ipinfoDetails is the answer of the IPInfo API, asn, company, privacy and abuse objects
+// are returned only for tokens of the plans including them, bogon addresses have only the ip and bogon fields
+type ipinfoDetails struct {
+	IP       string         `json:"ip"`
+	Bogon    bool           `json:"bogon"`
+	Hostname string         `json:"hostname"`
+	Anycast  bool           `json:"anycast"`
+	City     string         `json:"city"`
+	Region   string         `json:"region"`
+	Country  string         `json:"country"`
+	Loc      string         `json:"loc"`
+	Org      string         `json:"org"`
+	Postal   string         `json:"postal"`
+	Timezone string         `json:"timezone"`
+	ASN      *ipinfoASN     `json:"asn"`
+	Company  *ipinfoCompany `json:"company"`
+	Privacy  *ipinfoPrivacy `json:"privacy"`
+	Abuse    *ipinfoAbuse   `json:"abuse"`
+}
+
+type ipinfoASN struct {
+	ASN    string `json:"asn"`
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	Route  string `json:"route"`
+	Type   string `json:"type"`
+}
+
+type ipinfoCompany struct {
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	Type   string `json:"type"`
+}
+
+type ipinfoPrivacy struct {
+	VPN     bool   `json:"vpn"`
+	Proxy   bool   `json:"proxy"`
+	Tor     bool   `json:"tor"`
+	Relay   bool   `json:"relay"`
+	Hosting bool   `json:"hosting"`
+	Service string `json:"service"`
+}
+
+type ipinfoAbuse struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Network string `json:"network"`
+}
+
+type ipinfo struct {
+	flowID    int64
+	taskID    *int64
+	subtaskID *int64
+	enabled   bool
+	token     string
+	proxyURL  string
+	apiURL    string
+	timeout   time.Duration
+	tracer    Tracer
+}
+
+// NewIPInfoTool creates the tool which maps IP addresses to ASN, organization and geolocation,
+// the token is optional and unlocks the company, privacy and abuse contact details
+func NewIPInfoTool(flowID int64, taskID, subtaskID *int64, enabled bool,
+	token, proxyURL string, timeout time.Duration,
+) Tool {
+	return &ipinfo{
+		flowID:    flowID,
+		taskID:    taskID,
+		subtaskID: subtaskID,
+		enabled:   enabled,
+		token:     token,
+		proxyURL:  proxyURL,
+		timeout:   timeout,
+	}
+}
+
+func (i *ipinfo) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action IPInfoAction
+	ctx, emitter := startTrace(ctx, i.tracer)
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal ipinfo action")
+		return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+	}
+
+	ip := strings.TrimSpace(action.IP)
+	logger = logger.WithField("ip", ip)
+
+	if net.ParseIP(ip) == nil {
+		logger.Error("invalid IP address to look up in ipinfo")
+		return fmt.Sprintf("invalid IP address %q, expected IPv4 or IPv6 address", ip), nil
+	}
+
+	details, err := i.lookup(ctx, ip)
+	if err != nil {
+		emitter.Emit(searchErrorEvent(ip, err, map[string]any{
+			"tool_name": IPInfoToolName,
+			"engine":    "ipinfo",
+		}))
+
+		logger.WithError(err).Error("failed to look up IP address in ipinfo")
+		return fmt.Sprintf("failed to look up IP address %s in ipinfo: %v", ip, err), nil
+	}
+
+	return formatIPInfo(details, i.token != ""), nil
+}
+
+func (i *ipinfo) lookup(ctx context.Context, ip string) (*ipinfoDetails, error) {
+	ctx, cancel := context.WithTimeout(ctx, i.getTimeout())
+	defer cancel()
+
+	reqURL := strings.TrimRight(orDefault(i.apiURL, ipinfoAPIURL), "/") + "/" + url.PathEscape(ip) + "/json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if i.token != "" {
+		req.Header.Set("Authorization", "Bearer "+i.token)
+	}
+
+	resp, err := newHTTPClient(i.proxyURL, i.getTimeout()).Do(req)
+	if err != nil {
+		return nil, newNetworkError(err, "failed to do request")
+	}
+	defer resp.Body.Close()
+
+	if err := i.handleErrorResponse(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := readLimitedBody(resp.Body, defaultMaxResponseSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var details ipinfoDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	if details.IP == "" {
+		details.IP = ip
+	}
+
+	return &details, nil
+}
+
+func (i *ipinfo) handleErrorResponse(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return newSearchError(ErrAuth, "ipinfo token is wrong or has no access to the lookup")
+	case http.StatusTooManyRequests:
+		// the quota is counted per month, so retrying right away is useless
+		return newSearchError(ErrRateLimited, "ipinfo request quota exceeded")
+	case http.StatusNotFound:
+		return fmt.Errorf("ipinfo rejected the IP address as invalid")
+	default:
+		return newSearchError(errorKindByStatus(resp.StatusCode), "unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+func (i *ipinfo) getTimeout() time.Duration {
+	return resolveTimeout(IPInfoToolName, i.timeout)
+}
+
+// formatIPInfo renders the details of the address, the ASN and the organization are taken
+// from the org field like "AS15169 Google LLC" when the asn object isn't in the answer
+func formatIPInfo(details *ipinfoDetails, hasToken bool) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# IP information of %s\n\n", details.IP))
+
+	if details.Bogon {
+		builder.WriteString("The address is a bogon (private, reserved or not allocated), " +
+			"it has no public ASN, organization or location\n")
+		return builder.String()
+	}
+
+	asn, org := ipinfoParseOrg(details.Org)
+	if details.ASN != nil {
+		asn, org = orDefault(details.ASN.ASN, asn), orDefault(details.ASN.Name, org)
+	}
+
+	var location []string
+	for _, part := range []string{details.City, details.Region, details.Country} {
+		if part != "" {
+			location = append(location, part)
+		}
+	}
+
+	writeField := func(name, value string) {
+		if value != "" {
+			builder.WriteString(fmt.Sprintf("- %s: %s\n", name, value))
+		}
+	}
+	writeField("Hostname", details.Hostname)
+	writeField("ASN", asn)
+	writeField("Organization", org)
+	if details.ASN != nil {
+		writeField("Network", details.ASN.Route)
+		writeField("Network Type", details.ASN.Type)
+		writeField("Network Domain", details.ASN.Domain)
+	}
+	writeField("Location", strings.Join(location, ", "))
+	writeField("Postal Code", details.Postal)
+	writeField("Coordinates", details.Loc)
+	writeField("Timezone", details.Timezone)
+	if details.Anycast {
+		writeField("Anycast", "yes")
+	}
+
+	if details.Company != nil && details.Company.Name != "" {
+		builder.WriteString("\n## Company\n\n")
+		writeField("Name", details.Company.Name)
+		writeField("Domain", details.Company.Domain)
+		writeField("Type", details.Company.Type)
+	}
+
+	if details.Privacy != nil {
+		var flags []string
+		for _, flag := range []struct {
+			set  bool
+			name string
+		}{
+			{details.Privacy.VPN, "VPN"},
+			{details.Privacy.Proxy, "proxy"},
+			{details.Privacy.Tor, "Tor"},
+			{details.Privacy.Relay, "relay"},
+			{details.Privacy.Hosting, "hosting"},
+		} {
+			if flag.set {
+				flags = append(flags, flag.name)
+			}
+		}
+
+		builder.WriteString("\n## Privacy\n\n")
+		writeField("Detected", orDefault(strings.Join(flags, ", "), "none"))
+		writeField("Service", details.Privacy.Service)
+	}
+
+	if details.Abuse != nil && (details.Abuse.Email != "" || details.Abuse.Name != "") {
+		builder.WriteString("\n## Abuse Contact\n\n")
+		writeField("Name", details.Abuse.Name)
+		writeField("Email", details.Abuse.Email)
+		writeField("Phone", details.Abuse.Phone)
+		writeField("Network", details.Abuse.Network)
+	}
+
+	if !hasToken {
+		builder.WriteString("\nCompany, privacy and abuse contact details are not available without the IPInfo token\n")
+	}
+
+	return builder.String()
+}
+
+// ipinfoParseOrg splits the org field into the AS number and the name of the organization
+func ipinfoParseOrg(org string) (string, string) {
+	asn, name, found := strings.Cut(strings.TrimSpace(org), " ")
+	if !strings.HasPrefix(asn, "AS") {
+		return "", org
+	}
+	if !found {
+		return asn, ""
+	}
+	return asn, strings.TrimSpace(name)
+}
+
+func (i *ipinfo) IsAvailable() bool {
+	return i.enabled && IsEnabled(IPInfoToolName)
+}
+
+// HealthCheck verifies the token by the lookup of a well-known address, the lookup
+// without token isn't checked to save the shared quota
+func (i *ipinfo) HealthCheck(ctx context.Context) error {
+	if i.token == "" {
+		return nil
+	}
+
+	_, err := i.lookup(ctx, ipinfoHealthCheckIP)
+	return err
+}
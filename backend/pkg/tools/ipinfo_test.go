@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func loadIPInfoFixture(t *testing.T, name string) *ipinfoDetails {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+
+	var details ipinfoDetails
+	if err := json.Unmarshal(data, &details); err != nil {
+		t.Fatalf("failed to unmarshal fixture %s: %v", name, err)
+	}
+
+	return &details
+}
+
+func TestFormatIPInfo(t *testing.T) {
+	want := "# IP information of 8.8.8.8\n\n" +
+		"- Hostname: dns.google\n" +
+		"- ASN: AS15169\n" +
+		"- Organization: Google LLC\n" +
+		"- Network: 8.8.8.0/24\n" +
+		"- Network Type: hosting\n" +
+		"- Network Domain: google.com\n" +
+		"- Location: Mountain View, California, US\n" +
+		"- Postal Code: 94043\n" +
+		"- Coordinates: 37.4056,-122.0775\n" +
+		"- Timezone: America/Los_Angeles\n" +
+		"- Anycast: yes\n" +
+		"\n## Company\n\n" +
+		"- Name: Google LLC\n" +
+		"- Domain: google.com\n" +
+		"- Type: hosting\n" +
+		"\n## Privacy\n\n" +
+		"- Detected: hosting\n" +
+		"\n## Abuse Contact\n\n" +
+		"- Name: Abuse\n" +
+		"- Email: network-abuse@google.com\n" +
+		"- Phone: +1-650-253-0000\n" +
+		"- Network: 8.8.8.0/24\n"
+
+	if got := formatIPInfo(loadIPInfoFixture(t, "ipinfo_full.json"), true); got != want {
+		t.Errorf("formatIPInfo() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatIPInfoWithoutToken(t *testing.T) {
+	want := "# IP information of 1.1.1.1\n\n" +
+		"- Hostname: one.one.one.one\n" +
+		"- ASN: AS13335\n" +
+		"- Organization: Cloudflare, Inc.\n" +
+		"- Location: Brisbane, Queensland, AU\n" +
+		"- Postal Code: 4101\n" +
+		"- Coordinates: -27.4816,153.0175\n" +
+		"- Timezone: Australia/Brisbane\n" +
+		"- Anycast: yes\n" +
+		"\nCompany, privacy and abuse contact details are not available without the IPInfo token\n"
+
+	if got := formatIPInfo(loadIPInfoFixture(t, "ipinfo_basic.json"), false); got != want {
+		t.Errorf("formatIPInfo() = %q, want %q", got, want)
+	}
+
+	bogon := formatIPInfo(&ipinfoDetails{IP: "10.0.0.1", Bogon: true}, false)
+	if !strings.Contains(bogon, "is a bogon") || strings.Contains(bogon, "- ASN") {
+		t.Errorf("expected bogon address without details:\n%s", bogon)
+	}
+}
+
+func TestIPInfoParseOrg(t *testing.T) {
+	for _, tt := range []struct {
+		org, asn, name string
+	}{
+		{"AS15169 Google LLC", "AS15169", "Google LLC"},
+		{"AS64496", "AS64496", ""},
+		{"Example Corp", "", "Example Corp"},
+		{"", "", ""},
+	} {
+		if asn, name := ipinfoParseOrg(tt.org); asn != tt.asn || name != tt.name {
+			t.Errorf("ipinfoParseOrg(%q) = %q, %q, want %q, %q", tt.org, asn, name, tt.asn, tt.name)
+		}
+	}
+}
+
+func TestIPInfoHandle(t *testing.T) {
+	full, err := os.ReadFile("testdata/ipinfo_full.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	basic, err := os.ReadFile("testdata/ipinfo_basic.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var authHeader, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeader, path = r.Header.Get("Authorization"), r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case authHeader == "Bearer wrong-token":
+			w.WriteHeader(http.StatusForbidden)
+		case authHeader != "":
+			w.Write(full)
+		default:
+			w.Write(basic)
+		}
+	}))
+	defer server.Close()
+
+	lookup := func(t *testing.T, token, ip string) string {
+		t.Helper()
+		i := &ipinfo{enabled: true, token: token, apiURL: server.URL}
+		args, _ := json.Marshal(IPInfoAction{IP: ip})
+		result, err := i.Handle(context.Background(), IPInfoToolName, args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	t.Run("with token", func(t *testing.T) {
+		result := lookup(t, "test-token", " 8.8.8.8 ")
+		if authHeader != "Bearer test-token" || path != "/8.8.8.8/json" {
+			t.Errorf("unexpected request %q with auth %q", path, authHeader)
+		}
+		if !strings.Contains(result, "## Privacy") || strings.Contains(result, "without the IPInfo token") {
+			t.Errorf("expected full details:\n%s", result)
+		}
+	})
+
+	t.Run("without token", func(t *testing.T) {
+		result := lookup(t, "", "1.1.1.1")
+		if authHeader != "" {
+			t.Errorf("expected no auth header, got %q", authHeader)
+		}
+		if !strings.Contains(result, "- ASN: AS13335") || !strings.Contains(result, "without the IPInfo token") {
+			t.Errorf("expected degraded details:\n%s", result)
+		}
+	})
+
+	t.Run("wrong token", func(t *testing.T) {
+		if result := lookup(t, "wrong-token", "8.8.8.8"); !strings.Contains(result, "ipinfo token is wrong") {
+			t.Errorf("expected auth error:\n%s", result)
+		}
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		path = ""
+		if result := lookup(t, "", "example.com"); !strings.Contains(result, "invalid IP address") || path != "" {
+			t.Errorf("expected the address to be rejected before the request:\n%s", result)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package tools
+
+// ResultLimits sets the default and the maximum number of results returned by the search tool,
+// zero values keep the limits of the tool
+type ResultLimits struct {
+	Default int
+	Max     int
+}
+
+// resolve fills unset limits by the tool ones and keeps the default within the maximum
+func (l ResultLimits) resolve(defaultResults, maxResults int) ResultLimits {
+	if l.Max <= 0 {
+		l.Max = maxResults
+	}
+	if l.Default <= 0 {
+		l.Default = defaultResults
+	}
+
+	l.Default = min(l.Default, l.Max)
+
+	return l
+}
+
+// clamp returns the number of results to request, non-positive requested number means the default
+func (l ResultLimits) clamp(requested int) int {
+	if requested < 1 {
+		return l.Default
+	}
+
+	return min(requested, l.Max)
+}
@@ -0,0 +1,31 @@
+package tools
+
+import "testing"
+
+func TestResultLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		limits    ResultLimits
+		requested int
+		want      int
+	}{
+		{"unset uses tool default", ResultLimits{}, 0, 5},
+		{"unset clamps to tool max", ResultLimits{}, 50, 10},
+		{"unset keeps requested", ResultLimits{}, 7, 7},
+		{"custom default", ResultLimits{Default: 3}, 0, 3},
+		{"custom default above tool max", ResultLimits{Default: 15}, 0, 10},
+		{"custom max", ResultLimits{Max: 20}, 50, 20},
+		{"custom max keeps tool default", ResultLimits{Max: 20}, -1, 5},
+		{"custom max below tool default", ResultLimits{Max: 2}, 0, 2},
+		{"custom min and max", ResultLimits{Default: 8, Max: 30}, 25, 25},
+		{"custom default above custom max", ResultLimits{Default: 40, Max: 30}, 0, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.limits.resolve(5, 10).clamp(tt.requested); got != tt.want {
+				t.Errorf("clamp(%d) = %d, want %d", tt.requested, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// maxLogQueryLength caps the query in log fields, the full query is still in the args field
+const maxLogQueryLength = 200
+
+// withToolFields attaches the base fields shared by all tools: the engine, the truncated query,
+// IDs of the flow, the task and the subtask and the correlation ID of the call from the logger context,
+// so logs of different tools are aggregated uniformly, params are the specific fields of the tool
+func withToolFields(logger *logrus.Entry, engine, query string,
+	flowID int64, taskID, subtaskID *int64, params logrus.Fields,
+) *logrus.Entry {
+	fields := make(logrus.Fields, len(params)+6)
+	for key, value := range params {
+		fields[key] = value
+	}
+
+	fields["engine"] = engine
+	fields["query"] = truncateLogValue(query, maxLogQueryLength)
+	fields["flow_id"] = flowID
+	if taskID != nil {
+		fields["task_id"] = *taskID
+	}
+	if subtaskID != nil {
+		fields["subtask_id"] = *subtaskID
+	}
+	if requestID := GetRequestID(logger.Context); requestID != "" {
+		fields["request_id"] = requestID
+	}
+
+	return logger.WithFields(fields)
+}
+
+// truncateLogValue cuts the value to max characters and marks the cut by the ellipsis
+func truncateLogValue(value string, max int) string {
+	runes := []rune(value)
+	if len(runes) <= max {
+		return value
+	}
+
+	return string(runes[:max]) + "..."
+}
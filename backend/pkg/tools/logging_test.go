@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithToolFields(t *testing.T) {
+	taskID, subtaskID := int64(7), int64(42)
+	logger := logrus.NewEntry(logrus.New()).WithField("tool", "tavily")
+
+	entry := withToolFields(logger, "tavily", "log4j exploit", 1, &taskID, &subtaskID, logrus.Fields{
+		"max_results": 5,
+		"query":       "overridden by the base field",
+	})
+
+	want := logrus.Fields{
+		"tool":        "tavily",
+		"engine":      "tavily",
+		"query":       "log4j exploit",
+		"flow_id":     int64(1),
+		"task_id":     int64(7),
+		"subtask_id":  int64(42),
+		"max_results": 5,
+	}
+	if len(entry.Data) != len(want) {
+		t.Errorf("unexpected fields %v", entry.Data)
+	}
+	for key, value := range want {
+		if entry.Data[key] != value {
+			t.Errorf("field %s = %v, want %v", key, entry.Data[key], value)
+		}
+	}
+
+	// IDs of the flow level tools are omitted
+	entry = withToolFields(logger, "browser", "https://example.com", 1, nil, nil, nil)
+	if _, ok := entry.Data["task_id"]; ok {
+		t.Errorf("unexpected task_id field %v", entry.Data)
+	}
+	if _, ok := entry.Data["subtask_id"]; ok {
+		t.Errorf("unexpected subtask_id field %v", entry.Data)
+	}
+}
+
+func TestWithToolFieldsTruncation(t *testing.T) {
+	logger := logrus.NewEntry(logrus.New())
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"short", "nmap", "nmap"},
+		{"limit", strings.Repeat("a", maxLogQueryLength), strings.Repeat("a", maxLogQueryLength)},
+		{"long", strings.Repeat("a", maxLogQueryLength+1), strings.Repeat("a", maxLogQueryLength) + "..."},
+		{"multibyte", strings.Repeat("ж", maxLogQueryLength+10), strings.Repeat("ж", maxLogQueryLength) + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := withToolFields(logger, "google", tt.query, 0, nil, nil, nil)
+			if got := entry.Data["query"]; got != tt.want {
+				t.Errorf("query field = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
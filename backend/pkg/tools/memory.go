@@ -229,6 +229,10 @@ func (m *memory) IsAvailable() bool {
 	return m.store != nil
 }
 
+func (m *memory) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
 func getGlobalFilters(filters map[string]any) (bool, map[string]any) {
 	globalFilters := maps.Clone(filters)
 	delete(globalFilters, "task_id")
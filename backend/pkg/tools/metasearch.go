@@ -0,0 +1,326 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pentagi/pkg/database"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	metasearchDefaultResults = 5
+	metasearchMaxResults     = 10
+	metasearchTimeout        = 60 * time.Second
+)
+
+// resultsSearcher is a search engine which returns structured results,
+// answer based engines (perplexity, traversaal) don't fit the metasearch
+type resultsSearcher interface {
+	IsAvailable() bool
+	searchResults(ctx context.Context, query string, maxResults int) ([]SearchResultItem, error)
+}
+
+type metasearchEngine struct {
+	name     string
+	searcher resultsSearcher
+}
+
+type metasearchResult struct {
+	engine string
+	items  []SearchResultItem
+	err    error
+}
+
+type metasearch struct {
+	flowID       int64
+	taskID       *int64
+	subtaskID    *int64
+	engines      []metasearchEngine
+	timeout      time.Duration
+	limits       ResultLimits
+	dryRun       bool
+	cache        CacheProvider
+	sourceFooter bool
+	template     *ResultTemplate
+	slp          SearchLogProvider
+	summarizer   SummarizeHandler
+	tracer       Tracer
+}
+
+// NewMetasearchTool creates the tool which queries all available engines concurrently
+// and merges their results, engines are keyed by their tool names
+func NewMetasearchTool(flowID int64, taskID, subtaskID *int64,
+	engines map[string]Tool, timeout time.Duration, limits ResultLimits, sourceFooter bool,
+	template *ResultTemplate, slp SearchLogProvider,
+) Tool {
+	return &metasearch{
+		flowID:       flowID,
+		taskID:       taskID,
+		subtaskID:    subtaskID,
+		engines:      newMetasearchEngines(engines),
+		timeout:      resolveTimeout(MetasearchToolName, timeout),
+		limits:       limits,
+		sourceFooter: sourceFooter,
+		template:     template,
+		slp:          slp,
+	}
+}
+
+// newMetasearchEngines keeps available tools which are able to return structured results,
+// engines are sorted by name to get the stable order of merged results
+func newMetasearchEngines(tools map[string]Tool) []metasearchEngine {
+	engines := make([]metasearchEngine, 0, len(tools))
+	for name, tool := range tools {
+		searcher, ok := tool.(resultsSearcher)
+		if !ok || !searcher.IsAvailable() {
+			continue
+		}
+
+		engines = append(engines, metasearchEngine{name: name, searcher: searcher})
+	}
+
+	sort.Slice(engines, func(i, j int) bool {
+		return engines[i].name < engines[j].name
+	})
+
+	return engines
+}
+
+func (m *metasearch) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action SearchAction
+	ctx, emitter := startTrace(ctx, m.tracer)
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal metasearch action")
+		return "", fmt.Errorf("failed to unmarshal %s search action arguments: %w", name, err)
+	}
+	action.Query = sanitizeQuery(action.Query, maxQueryLength)
+
+	numResults := m.limits.resolve(metasearchDefaultResults, metasearchMaxResults).clamp(action.MaxResults.Int())
+
+	engines := make([]string, 0, len(m.engines))
+	for _, engine := range m.engines {
+		engines = append(engines, engine.name)
+	}
+
+	// the summary is produced only when the summarizer is set, so the cache key depends on both
+	summarize := action.Summarize && m.summarizer != nil
+	logger = logger.WithFields(logrus.Fields{
+		"query":       action.Query,
+		"num_results": numResults,
+		"engines":     engines,
+		"summarize":   summarize,
+	})
+
+	cacheKey := searchCacheKey(database.SearchengineTypeMetasearch, action.Query, numResults,
+		strings.Join(engines, ","), m.template.cacheKey(), summarize)
+	stats, start := SearchStats{Cached: true}, time.Now()
+	result, err := withSearchCache(ctx, dryRunCache(m.cache, m.dryRun), cacheKey, &stats, func() (string, error) {
+		result, count, err := m.search(withSearchPriority(ctx, action.Priority), action.Query, numResults, summarize)
+		stats.Cached, stats.ResultCount = false, count
+		return result, err
+	})
+	recordSearchCall(ctx, database.SearchengineTypeMetasearch, stats.Cached, time.Since(start), err)
+	if err != nil {
+		emitter.Emit(searchErrorEvent(action.Query, err, map[string]any{
+			"tool_name":   MetasearchToolName,
+			"engine":      "metasearch",
+			"engines":     engines,
+			"max_results": numResults,
+		}))
+
+		logger.WithError(err).Error("failed to search in all engines")
+		return fmt.Sprintf("failed to search in all engines: %v", err), nil
+	}
+
+	stats.Duration = time.Since(start)
+	_, _ = putSearchLog(ctx, m.slp, database.SearchengineTypeMetasearch, action.Query, result, stats, m.taskID, m.subtaskID)
+
+	return withSourceFooter(result, "Metasearch", m.sourceFooter), nil
+}
+
+// search queries engines concurrently with the shared deadline, failed engines are skipped
+// and the call fails only when no engine has succeeded, an engine which found nothing isn't failed,
+// the merged results are cut to numResults and summarize replaces them by their summary
+func (m *metasearch) search(ctx context.Context, query string, numResults int, summarize bool) (string, int, error) {
+	if len(m.engines) == 0 {
+		return "", 0, errors.New("no search engines available")
+	}
+
+	timeout := resolveTimeout(MetasearchToolName, m.timeout)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	engines := m.enabledEngines()
+	if len(engines) == 0 {
+		return "", 0, errors.New("all engines are disabled")
+	}
+
+	var wg sync.WaitGroup
+	results := make([]metasearchResult, len(engines))
+	for i, engine := range engines {
+		wg.Add(1)
+		go func(i int, engine metasearchEngine) {
+			defer wg.Done()
+
+			items, err := engine.searcher.searchResults(ctx, query, numResults)
+			results[i] = metasearchResult{engine: engine.name, items: items, err: err}
+		}(i, engine)
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.err != nil && !errors.Is(result.err, ErrNoResults) {
+			logrus.WithContext(ctx).WithError(result.err).WithField("engine", result.engine).
+				Warn("metasearch engine failed, its results are skipped")
+			errs = append(errs, fmt.Errorf("%s: %w", result.engine, result.err))
+		}
+	}
+	if len(errs) == len(results) {
+		return "", 0, errors.Join(errs...)
+	}
+
+	// results are capped after merging so the duplicates still add their engines to the sources
+	items := mergeSearchResults(preferredEngineFirst(results, query))
+	items = items[:min(len(items), numResults)]
+	if len(items) == 0 {
+		return noResultsMessage, 0, nil
+	}
+
+	result := FormatResults(items, FormatOptions{Separator: true, Template: m.template, Engine: "Metasearch"})
+	return withResultsSummary(ctx, m.summarizer, summarize, "Metasearch", query, items, result), len(items), nil
+}
+
+// enabledEngines skips the engines turned off at runtime
+func (m *metasearch) enabledEngines() []metasearchEngine {
+	return slices.DeleteFunc(slices.Clone(m.engines), func(engine metasearchEngine) bool {
+		return !IsEnabled(engine.name)
+	})
+}
+
+// preferredEngineFirst moves results of the engine selected for the query intent by SelectEngine
+// to the front, so its results lead every rank of the merge and keep the duplicates of other engines
+func preferredEngineFirst(results []metasearchResult, query string) []metasearchResult {
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		if result.err == nil {
+			names = append(names, result.engine)
+		}
+	}
+
+	preferred := SelectEngine(query, names)
+	if idx := slices.IndexFunc(results, func(r metasearchResult) bool { return r.engine == preferred }); idx > 0 {
+		lead := results[idx]
+		copy(results[1:idx+1], results[:idx])
+		results[0] = lead
+	}
+
+	return results
+}
+
+// mergeSearchResults interleaves results of engines by their rank and deduplicates them by URL,
+// the duplicate adds its engine to the sources of the first occurrence
+func mergeSearchResults(results []metasearchResult) []SearchResultItem {
+	var (
+		merged []SearchResultItem
+		seen   = make(map[string]int)
+	)
+
+	for rank := 0; ; rank++ {
+		added := false
+		for _, result := range results {
+			if rank >= len(result.items) {
+				continue
+			}
+			added = true
+
+			item := result.items[rank]
+			key := normalizeResultURL(item.URL)
+			if idx, ok := seen[key]; ok {
+				merged[idx].Source += ", " + result.engine
+				continue
+			}
+
+			item.Source = result.engine
+			seen[key] = len(merged)
+			merged = append(merged, item)
+		}
+
+		if !added {
+			return merged
+		}
+	}
+}
+
+// normalizeResultURL makes the same page found by different engines comparable
+func normalizeResultURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(strings.ToLower(rawURL), "/")
+	}
+
+	u.Host = strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	u.Path = strings.TrimRight(u.Path, "/")
+
+	return u.Host + u.Path + "?" + u.RawQuery
+}
+
+// EstimateCost sums the estimates of the paid engines which the call would query with the same arguments,
+// free engines cost nothing
+func (m *metasearch) EstimateCost(args json.RawMessage) (CostEstimate, error) {
+	var action SearchAction
+	if err := json.Unmarshal(args, &action); err != nil {
+		return CostEstimate{}, fmt.Errorf("failed to unmarshal %s search action arguments: %w", MetasearchToolName, err)
+	}
+	numResults := m.limits.resolve(metasearchDefaultResults, metasearchMaxResults).clamp(action.MaxResults.Int())
+
+	engineArgs, err := json.Marshal(SearchAction{Query: action.Query, MaxResults: Int64(numResults)})
+	if err != nil {
+		return CostEstimate{}, err
+	}
+
+	var total CostEstimate
+	for _, engine := range m.enabledEngines() {
+		estimator, ok := engine.searcher.(CostEstimator)
+		if !ok {
+			continue
+		}
+
+		estimate, err := estimator.EstimateCost(engineArgs)
+		if err != nil {
+			return CostEstimate{}, fmt.Errorf("failed to estimate the cost of %s: %w", engine.name, err)
+		}
+		total.Requests += estimate.Requests
+		total.Results += estimate.Results
+		total.Tokens += estimate.Tokens
+		total.USD += estimate.USD
+	}
+
+	return total, nil
+}
+
+// IsAvailable reports whether at least two engines can be queried, a single one is better used directly
+func (m *metasearch) IsAvailable() bool {
+	return len(m.engines) > 1 && IsEnabled(MetasearchToolName)
+}
+
+func (m *metasearch) HealthCheck(ctx context.Context) error {
+	return nil
+}
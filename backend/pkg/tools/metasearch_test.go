@@ -0,0 +1,230 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"pentagi/pkg/database"
+)
+
+// fakeResultsSearcher is a search engine which returns canned results or the error
+type fakeResultsSearcher struct {
+	available bool
+	items     []SearchResultItem
+	err       error
+	delay     time.Duration
+}
+
+func (f *fakeResultsSearcher) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	return "", nil
+}
+
+func (f *fakeResultsSearcher) IsAvailable() bool {
+	return f.available
+}
+
+func (f *fakeResultsSearcher) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeResultsSearcher) searchResults(ctx context.Context, query string, maxResults int) ([]SearchResultItem, error) {
+	if f.delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(f.delay):
+		}
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return f.items[:min(len(f.items), maxResults)], nil
+}
+
+func TestMetasearchPartialFailure(t *testing.T) {
+	slp := &statsSearchLogProvider{}
+	tool := NewMetasearchTool(0, nil, nil, map[string]Tool{
+		"alpha": &fakeResultsSearcher{available: true, items: []SearchResultItem{
+			{Title: "Alpha 1", URL: "https://example.com/page", Snippet: "alpha first"},
+			{Title: "Alpha 2", URL: "https://alpha.example.com/2", Snippet: "alpha second"},
+		}},
+		"beta": &fakeResultsSearcher{available: true, err: newSearchError(ErrAuth, "API key is wrong")},
+		"gamma": &fakeResultsSearcher{available: true, items: []SearchResultItem{
+			{Title: "Gamma 1", URL: "https://gamma.example.com/1", Snippet: "gamma first"},
+			{Title: "Gamma dup", URL: "https://www.example.com/page/", Snippet: "duplicate"},
+		}},
+	}, time.Second, ResultLimits{}, false, nil, slp)
+
+	if !tool.IsAvailable() {
+		t.Fatal("expected metasearch with several engines to be available")
+	}
+
+	ctx := PutAgentContext(context.Background(), database.MsgchainTypeSearcher)
+	args, _ := json.Marshal(SearchAction{Query: "nmap", MaxResults: 5})
+	result, err := tool.Handle(ctx, MetasearchToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	for i, title := range []string{"# 1. Alpha 1", "# 2. Gamma 1", "# 3. Alpha 2"} {
+		if !strings.Contains(result, title) {
+			t.Errorf("expected result %d %q, got:\n%s", i+1, title, result)
+		}
+	}
+	if !strings.Contains(result, "## Source\nalpha, gamma\n") {
+		t.Errorf("expected duplicate to be merged into the first result sources, got:\n%s", result)
+	}
+	if strings.Contains(result, "Gamma dup") || strings.Contains(result, "# 4.") {
+		t.Errorf("expected duplicate URL to be dropped, got:\n%s", result)
+	}
+
+	if len(slp.logs) != 1 {
+		t.Fatalf("expected 1 search log, got %d", len(slp.logs))
+	}
+	if log := slp.logs[0]; log.engine != database.SearchengineTypeMetasearch || log.stats.ResultCount != 3 {
+		t.Errorf("unexpected search log %+v", log)
+	}
+}
+
+func TestMetasearchAllEnginesFailed(t *testing.T) {
+	m := &metasearch{
+		engines: newMetasearchEngines(map[string]Tool{
+			"alpha": &fakeResultsSearcher{available: true, err: newSearchError(ErrRateLimited, "too many requests")},
+			"beta":  &fakeResultsSearcher{available: true, err: errors.New("broken")},
+		}),
+		timeout: time.Second,
+	}
+
+	_, _, err := m.search(context.Background(), "nmap", 5, false)
+	if err == nil {
+		t.Fatal("expected error when all engines failed")
+	}
+	if !errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "beta: broken") {
+		t.Errorf("expected joined engine errors, got %v", err)
+	}
+}
+
+func TestMetasearchSharedDeadline(t *testing.T) {
+	m := &metasearch{
+		engines: newMetasearchEngines(map[string]Tool{
+			"fast": &fakeResultsSearcher{available: true, items: []SearchResultItem{
+				{Title: "Fast", URL: "https://fast.example.com"},
+			}},
+			"slow": &fakeResultsSearcher{available: true, delay: time.Minute},
+		}),
+		timeout: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	result, count, err := m.search(context.Background(), "nmap", 5, false)
+	if err != nil {
+		t.Fatalf("search() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected slow engine to be cut by the deadline, took %v", elapsed)
+	}
+	if count != 1 || !strings.Contains(result, "# 1. Fast") {
+		t.Errorf("unexpected result with %d items:\n%s", count, result)
+	}
+}
+
+func TestMetasearchNumResults(t *testing.T) {
+	newItems := func(engine string) []SearchResultItem {
+		var items []SearchResultItem
+		for i := range 3 {
+			items = append(items, SearchResultItem{
+				Title: fmt.Sprintf("%s %d", engine, i+1),
+				URL:   fmt.Sprintf("https://%s.example.com/%d", engine, i+1),
+			})
+		}
+		return items
+	}
+
+	m := &metasearch{
+		engines: newMetasearchEngines(map[string]Tool{
+			"alpha": &fakeResultsSearcher{available: true, items: append(newItems("alpha"),
+				SearchResultItem{Title: "shared", URL: "https://shared.example.com"})},
+			"beta": &fakeResultsSearcher{available: true, items: append(newItems("beta"),
+				SearchResultItem{Title: "shared", URL: "https://shared.example.com"})},
+		}),
+		timeout: time.Second,
+	}
+
+	result, count, err := m.search(context.Background(), "nmap", 3, false)
+	if err != nil {
+		t.Fatalf("search() error = %v", err)
+	}
+	if count != 3 || strings.Contains(result, "# 4.") {
+		t.Errorf("expected 3 merged results, got %d:\n%s", count, result)
+	}
+	for _, title := range []string{"# 1. alpha 1", "# 2. beta 1", "# 3. alpha 2"} {
+		if !strings.Contains(result, title) {
+			t.Errorf("expected top ranked result %q, got:\n%s", title, result)
+		}
+	}
+}
+
+func TestMetasearchEngines(t *testing.T) {
+	engines := newMetasearchEngines(map[string]Tool{
+		"zeta":        &fakeResultsSearcher{available: true},
+		"alpha":       &fakeResultsSearcher{available: true},
+		"disabled":    &fakeResultsSearcher{available: false},
+		"answer-only": &traversaal{apiKey: "test-key"},
+		"missing":     &google{},
+	})
+
+	if len(engines) != 2 || engines[0].name != "alpha" || engines[1].name != "zeta" {
+		t.Errorf("expected available structured engines sorted by name, got %+v", engines)
+	}
+
+	if (&metasearch{engines: engines[:1]}).IsAvailable() {
+		t.Error("expected metasearch with single engine to be unavailable")
+	}
+}
+
+func TestMetasearchPreferredEngineFirst(t *testing.T) {
+	newEngines := func() []metasearchEngine {
+		return []metasearchEngine{
+			{name: DuckDuckGoToolName, searcher: &fakeResultsSearcher{available: true, items: []SearchResultItem{
+				{Title: "ddg", URL: "https://example.com/shared"},
+			}}},
+			{name: GoogleToolName, searcher: &fakeResultsSearcher{available: true, items: []SearchResultItem{
+				{Title: "google", URL: "https://example.com/shared"},
+			}}},
+			{name: TavilyToolName, searcher: &fakeResultsSearcher{available: true, items: []SearchResultItem{
+				{Title: "tavily", URL: "https://example.com/shared"},
+			}}},
+		}
+	}
+
+	tests := []struct {
+		query  string
+		source string
+	}{
+		{"nmap service detection", "google, duckduckgo, tavily"},
+		{"how does log4shell work", "tavily, duckduckgo, google"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			engines := newEngines()
+			results := make([]metasearchResult, 0, len(engines))
+			for _, engine := range engines {
+				items, err := engine.searcher.searchResults(context.Background(), tt.query, 5)
+				results = append(results, metasearchResult{engine: engine.name, items: items, err: err})
+			}
+
+			items := mergeSearchResults(preferredEngineFirst(results, tt.query))
+			if len(items) != 1 || items[0].Source != tt.source {
+				t.Errorf("expected single result from %q, got %+v", tt.source, items)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"pentagi/pkg/database"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// Outcomes of search engine calls used as the metrics label
+const (
+	SearchOutcomeSuccess             = "success"
+	SearchOutcomeAuthError           = "auth_error"
+	SearchOutcomeRateLimited         = "rate_limited"
+	SearchOutcomeUpstreamUnavailable = "upstream_unavailable"
+	SearchOutcomeNetwork             = "network"
+	SearchOutcomeError               = "error"
+)
+
+const (
+	searchCallsMetricName    = "tools_search_calls_total"
+	searchDurationMetricName = "tools_search_duration_seconds"
+)
+
+// MetricsProvider creates metric instruments, the global observer implements it
+// and exports metrics to the configured collector which can be scraped by Prometheus
+type MetricsProvider interface {
+	NewInt64Counter(string, ...otelmetric.Int64CounterOption) (otelmetric.Int64Counter, error)
+	NewFloat64Histogram(string, ...otelmetric.Float64HistogramOption) (otelmetric.Float64Histogram, error)
+}
+
+type searchMetrics struct {
+	calls    otelmetric.Int64Counter
+	duration otelmetric.Float64Histogram
+}
+
+var (
+	metricsMx sync.RWMutex
+	metrics   *searchMetrics
+)
+
+// InitMetrics registers instruments of search engine calls in the provider,
+// calls aren't recorded until it's done and nil provider turns recording off
+func InitMetrics(provider MetricsProvider) error {
+	if provider == nil {
+		metricsMx.Lock()
+		metrics = nil
+		metricsMx.Unlock()
+		return nil
+	}
+
+	calls, err := provider.NewInt64Counter(searchCallsMetricName,
+		otelmetric.WithDescription("Number of search engine calls by engine and outcome"),
+	)
+	if err != nil {
+		return err
+	}
+
+	duration, err := provider.NewFloat64Histogram(searchDurationMetricName,
+		otelmetric.WithDescription("Duration of search engine calls by engine and outcome"),
+		otelmetric.WithUnit("s"),
+	)
+	if err != nil {
+		return err
+	}
+
+	metricsMx.Lock()
+	metrics = &searchMetrics{calls: calls, duration: duration}
+	metricsMx.Unlock()
+
+	return nil
+}
+
+// recordSearchCall counts the call of the search engine and its duration, cached results are
+// counted separately to not skew latencies of the engine
+func recordSearchCall(
+	ctx context.Context,
+	engine database.SearchengineType,
+	cached bool,
+	duration time.Duration,
+	err error,
+) {
+	metricsMx.RLock()
+	m := metrics
+	metricsMx.RUnlock()
+
+	if m == nil {
+		return
+	}
+
+	attrs := otelmetric.WithAttributes(
+		attribute.String("engine", string(engine)),
+		attribute.String("outcome", searchOutcome(err)),
+		attribute.Bool("cached", cached),
+	)
+	m.calls.Add(ctx, 1, attrs)
+	m.duration.Record(ctx, duration.Seconds(), attrs)
+}
+
+func searchOutcome(err error) string {
+	switch {
+	case err == nil:
+		return SearchOutcomeSuccess
+	case errors.Is(err, ErrAuth):
+		return SearchOutcomeAuthError
+	case errors.Is(err, ErrRateLimited):
+		return SearchOutcomeRateLimited
+	case errors.Is(err, ErrUpstreamUnavailable):
+		return SearchOutcomeUpstreamUnavailable
+	case errors.Is(err, ErrNetwork):
+		return SearchOutcomeNetwork
+	default:
+		return SearchOutcomeError
+	}
+}
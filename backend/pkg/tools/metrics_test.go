@@ -0,0 +1,204 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// meterMetricsProvider adapts the plain meter to the provider interface implemented by the observer
+type meterMetricsProvider struct {
+	meter otelmetric.Meter
+}
+
+func (m meterMetricsProvider) NewInt64Counter(
+	name string, options ...otelmetric.Int64CounterOption,
+) (otelmetric.Int64Counter, error) {
+	return m.meter.Int64Counter(name, options...)
+}
+
+func (m meterMetricsProvider) NewFloat64Histogram(
+	name string, options ...otelmetric.Float64HistogramOption,
+) (otelmetric.Float64Histogram, error) {
+	return m.meter.Float64Histogram(name, options...)
+}
+
+func initTestMetrics(t *testing.T) *sdkmetric.ManualReader {
+	t.Helper()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	if err := InitMetrics(meterMetricsProvider{meter: provider.Meter("tools")}); err != nil {
+		t.Fatalf("InitMetrics() error = %v", err)
+	}
+	t.Cleanup(func() { _ = InitMetrics(nil) })
+
+	return reader
+}
+
+// collectSearchCalls returns counters of search calls keyed by engine/outcome/cached
+// and the number of recorded durations in the same keys
+func collectSearchCalls(t *testing.T, reader *sdkmetric.ManualReader) (map[string]int64, map[string]uint64) {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect metrics: %v", err)
+	}
+
+	key := func(set attribute.Set) string {
+		engine, _ := set.Value("engine")
+		outcome, _ := set.Value("outcome")
+		cached, _ := set.Value("cached")
+		return fmt.Sprintf("%s/%s/%t", engine.AsString(), outcome.AsString(), cached.AsBool())
+	}
+
+	calls, durations := map[string]int64{}, map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				if m.Name != searchCallsMetricName {
+					continue
+				}
+				for _, dp := range data.DataPoints {
+					calls[key(dp.Attributes)] += dp.Value
+				}
+			case metricdata.Histogram[float64]:
+				if m.Name != searchDurationMetricName {
+					continue
+				}
+				for _, dp := range data.DataPoints {
+					durations[key(dp.Attributes)] += dp.Count
+				}
+			}
+		}
+	}
+
+	return calls, durations
+}
+
+func TestSearchMetrics(t *testing.T) {
+	reader := initTestMetrics(t)
+
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"data":{"ipAddress":"185.220.101.1","isPublic":true}}`))
+	}))
+	defer server.Close()
+
+	a := &abuseipdb{apiKey: "key", apiURL: server.URL, cache: NewSearchCache(t.TempDir(), time.Hour)}
+	handle := func(ip string) {
+		args, _ := json.Marshal(AbuseIPDBAction{IP: ip})
+		if _, err := a.Handle(context.Background(), AbuseIPDBToolName, args); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	handle("185.220.101.1")
+	handle("185.220.101.1")
+	status = http.StatusUnauthorized
+	handle("185.220.101.2")
+	status = http.StatusTooManyRequests
+	handle("185.220.101.3")
+	handle("185.220.101.4")
+
+	calls, durations := collectSearchCalls(t, reader)
+	want := map[string]int64{
+		"abuseipdb/success/false":      1,
+		"abuseipdb/success/true":       1,
+		"abuseipdb/auth_error/false":   1,
+		"abuseipdb/rate_limited/false": 2,
+	}
+	if len(calls) != len(want) {
+		t.Errorf("unexpected counters %v", calls)
+	}
+	for key, count := range want {
+		if calls[key] != count {
+			t.Errorf("counter %s = %d, want %d", key, calls[key], count)
+		}
+		if durations[key] != uint64(count) {
+			t.Errorf("durations %s = %d, want %d", key, durations[key], count)
+		}
+	}
+}
+
+func TestHIBPSearchMetrics(t *testing.T) {
+	reader := initTestMetrics(t)
+
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	h := &hibp{apiKey: "key", apiURL: server.URL, cache: NewSearchCache(t.TempDir(), time.Hour)}
+	handle := func(account string) {
+		args, _ := json.Marshal(HIBPAction{Account: account})
+		if _, err := h.Handle(context.Background(), HIBPToolName, args); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	handle("example.com")
+	handle("example.com")
+	status = http.StatusUnauthorized
+	handle("example.org")
+
+	calls, _ := collectSearchCalls(t, reader)
+	want := map[string]int64{
+		"hibp/success/false":    1,
+		"hibp/success/true":     1,
+		"hibp/auth_error/false": 1,
+	}
+	if len(calls) != len(want) {
+		t.Errorf("unexpected counters %v", calls)
+	}
+	for key, count := range want {
+		if calls[key] != count {
+			t.Errorf("counter %s = %d, want %d", key, calls[key], count)
+		}
+	}
+}
+
+func TestSearchMetricsNoop(t *testing.T) {
+	if err := InitMetrics(nil); err != nil {
+		t.Fatalf("InitMetrics(nil) error = %v", err)
+	}
+
+	args, _ := json.Marshal(SearchAction{Query: "query"})
+	if _, err := newFailingMetasearch(NewNoopTracer()).Handle(context.Background(), MetasearchToolName, args); err != nil {
+		t.Errorf("Handle() error = %v", err)
+	}
+}
+
+func TestSearchOutcome(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want string
+	}{
+		{nil, SearchOutcomeSuccess},
+		{newSearchError(ErrAuth, "bad key"), SearchOutcomeAuthError},
+		{newSearchError(ErrRateLimited, "slow down"), SearchOutcomeRateLimited},
+		{newSearchError(ErrUpstreamUnavailable, "down"), SearchOutcomeUpstreamUnavailable},
+		{fmt.Errorf("wrapped: %w", newSearchError(ErrNetwork, "reset")), SearchOutcomeNetwork},
+		{errors.New("broken body"), SearchOutcomeError},
+	} {
+		if got := searchOutcome(tt.err); got != tt.want {
+			t.Errorf("searchOutcome(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	nvdAPIURL           = "https://services.nvd.nist.gov/rest/json/cves/2.0"
+	nvdUserAgent        = "PentAGI"
+	nvdMaxSummaryLength = 200
+)
+
+type nvdResponse struct {
+	Vulnerabilities []struct {
+		CVE struct {
+			ID           string `json:"id"`
+			Descriptions []struct {
+				Lang  string `json:"lang"`
+				Value string `json:"value"`
+			} `json:"descriptions"`
+		} `json:"cve"`
+	} `json:"vulnerabilities"`
+}
+
+// nvdEnricher summarizes CVEs by their English descriptions from the CVE API of NVD,
+// summaries are kept in memory because the same CVEs are referenced by many searches of the flow
+type nvdEnricher struct {
+	apiKey   string
+	apiURL   string
+	proxyURL string
+
+	mx        sync.Mutex
+	summaries map[string]string
+}
+
+// NewNVDEnricher creates the enricher of referenced CVEs backed by NVD, the API key is optional
+// and raises the rate limit of the public API
+func NewNVDEnricher(apiKey, proxyURL string) CVEEnricher {
+	return &nvdEnricher{
+		apiKey:    apiKey,
+		proxyURL:  proxyURL,
+		summaries: make(map[string]string),
+	}
+}
+
+// EnrichCVEs looks up CVEs one by one because the API accepts a single ID per request,
+// CVEs which failed are left without the summary and the error is returned only if all of them failed
+func (n *nvdEnricher) EnrichCVEs(ctx context.Context, ids []string) (map[string]string, error) {
+	result := make(map[string]string, len(ids))
+	var errs []error
+	for _, id := range ids {
+		if summary, ok := n.cached(id); ok {
+			result[id] = summary
+			continue
+		}
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+
+		summary, err := n.lookup(ctx, id)
+		if err != nil {
+			logrus.WithContext(ctx).WithError(err).WithField("cve", id).Debug("failed to look up CVE in nvd")
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+
+		n.store(id, summary)
+		result[id] = summary
+	}
+
+	if len(result) == 0 && len(errs) != 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return result, nil
+}
+
+func (n *nvdEnricher) cached(id string) (string, bool) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	summary, ok := n.summaries[id]
+	return summary, ok
+}
+
+func (n *nvdEnricher) store(id, summary string) {
+	n.mx.Lock()
+	defer n.mx.Unlock()
+
+	n.summaries[id] = summary
+}
+
+// lookup returns the shortened English description of the CVE, unknown CVE has the empty summary
+func (n *nvdEnricher) lookup(ctx context.Context, id string) (string, error) {
+	apiURL := n.apiURL
+	if apiURL == "" {
+		apiURL = nvdAPIURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"?"+url.Values{"cveId": {id}}.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", nvdUserAgent)
+	if n.apiKey != "" {
+		req.Header.Set("apiKey", n.apiKey)
+	}
+
+	resp, err := newHTTPClient(n.proxyURL, cveEnrichTimeout).Do(req)
+	if err != nil {
+		return "", newNetworkError(err, "failed to request nvd")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", newSearchError(errorKindByStatus(resp.StatusCode), "unexpected status code: %d", resp.StatusCode)
+	}
+
+	var body nvdResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode nvd response: %w", err)
+	}
+
+	for _, vulnerability := range body.Vulnerabilities {
+		if !strings.EqualFold(vulnerability.CVE.ID, id) {
+			continue
+		}
+		for _, description := range vulnerability.CVE.Descriptions {
+			if description.Lang == "en" {
+				return shortenCVESummary(description.Value), nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// shortenCVESummary keeps the first sentence of the description within the length limit
+func shortenCVESummary(description string) string {
+	summary := strings.Join(strings.Fields(description), " ")
+	if idx := strings.Index(summary, ". "); idx > 0 {
+		summary = summary[:idx+1]
+	}
+	if runes := []rune(summary); len(runes) > nvdMaxSummaryLength {
+		summary = strings.TrimSpace(string(runes[:nvdMaxSummaryLength])) + "..."
+	}
+
+	return summary
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const nvdLog4ShellResponse = `{"vulnerabilities":[{"cve":{"id":"CVE-2021-44228","descriptions":[
+	{"lang":"es","value":"Apache Log4j2 ... (spanish)"},
+	{"lang":"en","value":"Apache Log4j2 2.0-beta9 through 2.15.0 JNDI features do not protect against attacker controlled LDAP endpoints. An attacker can execute arbitrary code."}
+]}}]}`
+
+func TestNVDEnricher(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if got := r.Header.Get("apiKey"); got != "nvd-key" {
+			t.Errorf("apiKey = %q", got)
+		}
+
+		switch r.URL.Query().Get("cveId") {
+		case "CVE-2021-44228":
+			w.Write([]byte(nvdLog4ShellResponse))
+		case "CVE-2021-45046":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"vulnerabilities":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	enricher := NewNVDEnricher("nvd-key", "").(*nvdEnricher)
+	enricher.apiURL = server.URL
+
+	result := withReferencedCVEs(context.Background(), "CVE-2021-44228, CVE-2021-45046 and CVE-2099-0001", enricher)
+	want := "# Referenced CVEs\n\n" +
+		"- [CVE-2021-44228](https://nvd.nist.gov/vuln/detail/CVE-2021-44228): Apache Log4j2 2.0-beta9 through 2.15.0 JNDI features do not protect against attacker controlled LDAP endpoints.\n" +
+		"- [CVE-2021-45046](https://nvd.nist.gov/vuln/detail/CVE-2021-45046)\n" +
+		"- [CVE-2099-0001](https://nvd.nist.gov/vuln/detail/CVE-2099-0001)\n"
+	if !strings.HasSuffix(result, want) {
+		t.Errorf("unexpected result:\n%s", result)
+	}
+
+	// the found CVEs are looked up once, the failed one is retried
+	calls.Store(0)
+	withReferencedCVEs(context.Background(), "CVE-2021-44228 and CVE-2021-45046", enricher)
+	if calls.Load() != 1 {
+		t.Errorf("expected only the failed CVE to be requested again, got %d requests", calls.Load())
+	}
+}
+
+func TestNVDEnricherUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	enricher := NewNVDEnricher("", "").(*nvdEnricher)
+	enricher.apiURL = server.URL
+
+	// the search result is kept as is when nvd can't be used at all
+	if got := withReferencedCVEs(context.Background(), "CVE-2021-44228", enricher); got != "CVE-2021-44228" {
+		t.Errorf("expected the result to be kept, got %q", got)
+	}
+}
+
+func TestShortenCVESummary(t *testing.T) {
+	if got := shortenCVESummary("First  sentence\nof the CVE. Second one."); got != "First sentence of the CVE." {
+		t.Errorf("shortenCVESummary() = %q", got)
+	}
+
+	long := shortenCVESummary(strings.Repeat("word ", 100))
+	if len([]rune(long)) > nvdMaxSummaryLength+3 || !strings.HasSuffix(long, "...") {
+		t.Errorf("expected the long summary to be cut, got %q", long)
+	}
+}
@@ -0,0 +1,317 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// resultPageMinSize keeps the pages large enough to hold at least a few results
+	resultPageMinSize = 1024
+	// resultPagesTTL is the time the rest pages of the result wait for the follow-up calls
+	resultPagesTTL = time.Hour
+	// resultPagesMaxEntries limits the number of the paginated results kept in memory
+	resultPagesMaxEntries = 256
+)
+
+// errResultPageToken is returned for the token which is malformed, expired or belongs to another flow
+var errResultPageToken = errors.New("page token is invalid or expired")
+
+// resultPages keeps the pages of the single result of the tool until they are read or expire
+type resultPages struct {
+	flowID  int64
+	tool    string
+	pages   []string
+	expires time.Time
+}
+
+var (
+	resultPagesMx    sync.Mutex
+	resultPagesStore = make(map[string]*resultPages)
+	resultPageSize   int
+)
+
+// InitResultPages turns on the pagination of the tool results longer than pageSize bytes,
+// the first page is returned by the tool and the rest ones by the result_page tool with
+// the continuation token, non-positive pageSize turns the pagination off
+func InitResultPages(pageSize int) {
+	resultPagesMx.Lock()
+	defer resultPagesMx.Unlock()
+
+	resultPagesStore = make(map[string]*resultPages)
+	resultPageSize = 0
+	if pageSize > 0 {
+		resultPageSize = max(pageSize, resultPageMinSize)
+	}
+}
+
+func getResultPageSize() int {
+	resultPagesMx.Lock()
+	defer resultPagesMx.Unlock()
+
+	return resultPageSize
+}
+
+// isPaginatedTool reports whether results of the tool are split into pages, results of agents
+// and barriers are kept whole because they are read by the other agents and the flow itself
+func isPaginatedTool(name string) bool {
+	if name == ResultPageToolName {
+		return false
+	}
+
+	switch GetToolType(name) {
+	case EnvironmentToolType, SearchNetworkToolType:
+		return true
+	default:
+		return false
+	}
+}
+
+// withResultPages wraps the handler to return the first page of the long result with the token
+// of the next page, the rest pages are kept in memory for the result_page tool
+func withResultPages(flowID int64, handler ExecutorHandler) ExecutorHandler {
+	return func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		result, err := handler(ctx, name, args)
+		if err != nil {
+			return result, err
+		}
+
+		size := getResultPageSize()
+		if size <= 0 || len(result) <= size || !isPaginatedTool(name) {
+			return result, nil
+		}
+
+		pages := splitResultPages(result, size)
+		if len(pages) < 2 {
+			return result, nil
+		}
+
+		id, err := putResultPages(flowID, name, pages)
+		if err != nil {
+			logrus.WithContext(ctx).WithError(err).WithField("tool", name).
+				Warn("failed to store result pages, returning the result as is")
+			return result, nil
+		}
+
+		return formatResultPage(name, pages[0], 1, len(pages), encodeResultPageToken(id, 2)), nil
+	}
+}
+
+// getResultPage is the handler of the result_page tool, it returns the page of the token
+// with the token of the following page
+func getResultPage(flowID int64) ExecutorHandler {
+	return func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		var action ResultPageAction
+		if err := json.Unmarshal(args, &action); err != nil {
+			logrus.WithContext(ctx).WithError(err).Error("failed to unmarshal result page action")
+			return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+		}
+
+		id, page, err := parseResultPageToken(strings.TrimSpace(action.Token))
+		if err != nil {
+			return fmt.Sprintf("%v, call the original tool again to get the result", err), nil
+		}
+
+		stored, ok := getResultPages(flowID, id)
+		if !ok || page > len(stored.pages) {
+			return fmt.Sprintf("%v, call the original tool again to get the result", errResultPageToken), nil
+		}
+
+		var next string
+		if page < len(stored.pages) {
+			next = encodeResultPageToken(id, page+1)
+		}
+
+		return formatResultPage(stored.tool, stored.pages[page-1], page, len(stored.pages), next), nil
+	}
+}
+
+func putResultPages(flowID int64, tool string, pages []string) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate page token: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	resultPagesMx.Lock()
+	defer resultPagesMx.Unlock()
+
+	now := time.Now()
+	for key, stored := range resultPagesStore {
+		if now.After(stored.expires) {
+			delete(resultPagesStore, key)
+		}
+	}
+
+	// the oldest results are dropped first when the store is full
+	if over := len(resultPagesStore) - resultPagesMaxEntries + 1; over > 0 {
+		keys := make([]string, 0, len(resultPagesStore))
+		for key := range resultPagesStore {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return resultPagesStore[keys[i]].expires.Before(resultPagesStore[keys[j]].expires)
+		})
+		for _, key := range keys[:over] {
+			delete(resultPagesStore, key)
+		}
+	}
+
+	resultPagesStore[id] = &resultPages{
+		flowID:  flowID,
+		tool:    tool,
+		pages:   pages,
+		expires: now.Add(resultPagesTTL),
+	}
+
+	return id, nil
+}
+
+func getResultPages(flowID int64, id string) (*resultPages, bool) {
+	resultPagesMx.Lock()
+	defer resultPagesMx.Unlock()
+
+	stored, ok := resultPagesStore[id]
+	if !ok || stored.flowID != flowID || time.Now().After(stored.expires) {
+		return nil, false
+	}
+
+	return stored, true
+}
+
+// encodeResultPageToken builds the continuation token from the ID of the stored result and the page number
+func encodeResultPageToken(id string, page int) string {
+	return fmt.Sprintf("%s.%d", id, page)
+}
+
+func parseResultPageToken(token string) (string, int, error) {
+	id, pageStr, ok := strings.Cut(token, ".")
+	if !ok || id == "" {
+		return "", 0, errResultPageToken
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return "", 0, errResultPageToken
+	}
+
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 1 {
+		return "", 0, errResultPageToken
+	}
+
+	return id, page, nil
+}
+
+func formatResultPage(tool, page string, number, total int, next string) string {
+	var builder strings.Builder
+	builder.WriteString(strings.TrimRight(page, "\n"))
+	builder.WriteString(fmt.Sprintf("\n\n---\n\nPage %d of %d of the %s result", number, total, tool))
+	if next != "" {
+		builder.WriteString(fmt.Sprintf(", call `%s` with the token %q to get the next page\n", ResultPageToolName, next))
+	} else {
+		builder.WriteString(", it's the last page\n")
+	}
+
+	return builder.String()
+}
+
+// splitResultPages cuts the result into pages of at most size bytes, the pages end at the result
+// separators (lines of dashes) when possible, the longer blocks are cut at line breaks and
+// the longer lines at the rune boundary, so the same result is always cut the same way
+func splitResultPages(result string, size int) []string {
+	var pages []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			pages = append(pages, current.String())
+			current.Reset()
+		}
+	}
+
+	add := func(piece string) bool {
+		if current.Len()+len(piece) <= size {
+			current.WriteString(piece)
+			return true
+		}
+		flush()
+		if len(piece) <= size {
+			current.WriteString(piece)
+			return true
+		}
+		return false
+	}
+
+	for _, block := range splitResultBlocks(result) {
+		if add(block) {
+			continue
+		}
+		for _, line := range strings.SplitAfter(block, "\n") {
+			if add(line) {
+				continue
+			}
+			for _, part := range splitRunes(line, size) {
+				add(part)
+			}
+		}
+	}
+	flush()
+
+	return pages
+}
+
+// splitResultBlocks splits the result after the separator lines which consist of three or more dashes
+func splitResultBlocks(result string) []string {
+	var blocks []string
+	var current strings.Builder
+
+	for _, line := range strings.SplitAfter(result, "\n") {
+		current.WriteString(line)
+		if isResultSeparator(line) {
+			blocks = append(blocks, current.String())
+			current.Reset()
+		}
+	}
+	if current.Len() > 0 {
+		blocks = append(blocks, current.String())
+	}
+
+	return blocks
+}
+
+func isResultSeparator(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) >= 3 && strings.Trim(line, "-") == ""
+}
+
+// splitRunes cuts the text into parts of at most size bytes without breaking multi-byte runes
+func splitRunes(text string, size int) []string {
+	var parts []string
+	for len(text) > size {
+		cut := size
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			cut = size
+		}
+		parts = append(parts, text[:cut])
+		text = text[cut:]
+	}
+	if text != "" {
+		parts = append(parts, text)
+	}
+
+	return parts
+}
@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitResultPages(t *testing.T) {
+	var builder strings.Builder
+	for i := 1; i <= 6; i++ {
+		builder.WriteString(fmt.Sprintf("## Result %d\n\n%s\n\n---\n\n", i, strings.Repeat("snippet ", 20)))
+	}
+	result := builder.String()
+
+	pages := splitResultPages(result, 400)
+	if len(pages) < 2 {
+		t.Fatalf("expected several pages, got %d", len(pages))
+	}
+	if strings.Join(pages, "") != result {
+		t.Error("expected the pages to make up the whole result")
+	}
+	for idx, page := range pages {
+		if len(page) > 400 {
+			t.Errorf("page %d is longer than the page size: %d", idx+1, len(page))
+		}
+		// every page but the last one ends right after the separator of results
+		if idx < len(pages)-1 && !strings.HasSuffix(page, "---\n") {
+			t.Errorf("expected page %d to end at the separator, got %q", idx+1, page[max(0, len(page)-20):])
+		}
+		if !strings.HasPrefix(strings.TrimLeft(page, "\n"), "## Result") {
+			t.Errorf("expected page %d to start with the result, got %q", idx+1, page[:min(len(page), 20)])
+		}
+	}
+
+	// the same result is always cut the same way
+	if again := splitResultPages(result, 400); strings.Join(again, "|") != strings.Join(pages, "|") {
+		t.Error("expected stable page boundaries")
+	}
+}
+
+func TestSplitResultPagesLongBlocks(t *testing.T) {
+	// the block without separators is cut at the line breaks
+	lines := strings.Repeat("0123456789012345678\n", 10)
+	pages := splitResultPages(lines, 50)
+	for idx, page := range pages {
+		if len(page) > 50 || !strings.HasSuffix(page, "\n") {
+			t.Errorf("expected page %d to end at the line break within the size, got %q", idx+1, page)
+		}
+	}
+	if strings.Join(pages, "") != lines {
+		t.Error("expected the pages to make up the whole block")
+	}
+
+	// the line longer than the page is cut on the rune boundary
+	text := strings.Repeat("привет ", 20)
+	pages = splitResultPages(text, 25)
+	for idx, page := range pages {
+		if len(page) > 25 || !utf8.ValidString(page) {
+			t.Errorf("expected page %d to be valid UTF-8 within the size, got %q", idx+1, page)
+		}
+	}
+	if strings.Join(pages, "") != text {
+		t.Error("expected the pages to make up the whole line")
+	}
+
+	if pages := splitResultPages("short", 25); len(pages) != 1 || pages[0] != "short" {
+		t.Errorf("expected the short result as the single page, got %q", pages)
+	}
+}
+
+func TestResultPageToken(t *testing.T) {
+	id, page, err := parseResultPageToken(encodeResultPageToken("0123456789abcdef", 3))
+	if err != nil || id != "0123456789abcdef" || page != 3 {
+		t.Errorf("expected the token to round trip, got %q, %d, %v", id, page, err)
+	}
+
+	for _, token := range []string{"", "0123456789abcdef", "0123456789abcdef.0", "0123456789abcdef.x", "nothex.2", ".2"} {
+		if _, _, err := parseResultPageToken(token); err != errResultPageToken {
+			t.Errorf("expected token %q to be rejected, got %v", token, err)
+		}
+	}
+}
+
+var resultPageTokenPattern = regexp.MustCompile("`result_page` with the token \"([^\"]+)\"")
+
+func TestResultPages(t *testing.T) {
+	InitResultPages(resultPageMinSize)
+	defer InitResultPages(0)
+
+	var builder strings.Builder
+	for i := 1; i <= 10; i++ {
+		builder.WriteString(fmt.Sprintf("## Result %d\n\n%s\n\n---\n\n", i, strings.Repeat("x", 300)))
+	}
+	result := builder.String()
+
+	handler := withResultPages(1, func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		return result, nil
+	})
+
+	page, err := handler(context.Background(), BrowserToolName, nil)
+	if err != nil {
+		t.Fatalf("handler() error = %v", err)
+	}
+
+	var read strings.Builder
+	for number := 1; ; number++ {
+		if !strings.Contains(page, fmt.Sprintf("Page %d of ", number)) {
+			t.Fatalf("expected page %d, got %q", number, page)
+		}
+		content, _, _ := strings.Cut(page, "\n\n---\n\nPage ")
+		read.WriteString(content)
+
+		match := resultPageTokenPattern.FindStringSubmatch(page)
+		if match == nil {
+			if !strings.Contains(page, "it's the last page") {
+				t.Errorf("expected the last page to be marked, got %q", page)
+			}
+			break
+		}
+
+		// the token of another flow is rejected
+		args, _ := json.Marshal(ResultPageAction{Token: match[1]})
+		if other, _ := getResultPage(2)(context.Background(), ResultPageToolName, args); !strings.Contains(other, "invalid or expired") {
+			t.Errorf("expected the token of another flow to be rejected, got %q", other)
+		}
+
+		if page, err = getResultPage(1)(context.Background(), ResultPageToolName, args); err != nil {
+			t.Fatalf("getResultPage() error = %v", err)
+		}
+	}
+
+	for i := 1; i <= 10; i++ {
+		if !strings.Contains(read.String(), fmt.Sprintf("## Result %d\n", i)) {
+			t.Errorf("expected result %d to be read from the pages", i)
+		}
+	}
+
+	// agents, short results and disabled pagination keep the result whole
+	if whole, _ := withResultPages(1, func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+		return result, nil
+	})(context.Background(), SearchToolName, nil); whole != result {
+		t.Error("expected the result of the agent to be kept whole")
+	}
+
+	InitResultPages(0)
+	if whole, _ := handler(context.Background(), BrowserToolName, nil); whole != result {
+		t.Error("expected the result to be kept whole while the pagination is off")
+	}
+}
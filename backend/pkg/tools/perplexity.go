@@ -3,66 +3,180 @@ package tools
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
 
 	"pentagi/pkg/database"
-	obs "pentagi/pkg/observability"
-	"pentagi/pkg/observability/langfuse"
 
 	"github.com/sirupsen/logrus"
 )
 
 // Constants for Perplexity API
 const (
-	perplexityURL         = "https://api.perplexity.ai/chat/completions"
-	perplexityTimeout     = 60 * time.Second
-	perplexityModel       = "sonar"
-	perplexityTemperature = 0.5
-	perplexityTopP        = 0.9
-	perplexityMaxTokens   = 4000
+	perplexityBaseURL        = "https://api.perplexity.ai"
+	perplexityTimeout        = 60 * time.Second
+	perplexityConnectTimeout = 10 * time.Second
+	perplexityModel          = "sonar"
+	perplexityTemperature    = 0.5
+	perplexityTopP           = 0.9
+	perplexityMaxTokens      = 4000
+	perplexityMaxHistory     = 10
+	perplexityMaxImages      = 5
+	perplexityMaxPenalty     = 2.0
 )
 
-// Message - structure for Perplexity API message
+// perplexityImageModels accept images in the content parts of the messages
+var perplexityImageModels = []string{"sonar", "sonar-pro", "sonar-reasoning", "sonar-reasoning-pro"}
+
+// perplexityThinkPattern matches reasoning traces which reasoning models put before the answer
+var perplexityThinkPattern = regexp.MustCompile(`(?s)<think>(.*?)</think>`)
+
+// perplexityContextSizes are the values accepted by the search_context_size option
+var perplexityContextSizes = []string{"low", "medium", "high"}
+
+// perplexityContextSizeFactors scale the request price by the search context size as the list prices do
+var perplexityContextSizeFactors = map[string]float64{"low": 1, "medium": 1.6, "high": 2.4}
+
+// perplexitySearchMode is the request parameters which bias the web search of the model to the sources
+type perplexitySearchMode struct {
+	searchMode    string
+	recencyFilter string
+}
+
+// perplexitySearchModes maps the modes of the action to the request parameters, the web mode
+// keeps the request of the general web search as is
+var perplexitySearchModes = map[string]perplexitySearchMode{
+	"web":      {},
+	"academic": {searchMode: "academic"},
+	"news":     {recencyFilter: "week"},
+}
+
+// Message - structure for Perplexity API message, parts replace the text content in the request
+// when the message carries images
 type Message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string        `json:"role"`
+	Content string        `json:"content"`
+	Parts   []ContentPart `json:"-"`
+}
+
+// ContentPart - text or image part of the multimodal message content
+type ContentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+}
+
+// ImageURL - public URL of the image or base64 data URI
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+// MarshalJSON keeps the text content as is and sends the content parts array for multimodal messages
+func (m Message) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{Role: m.Role, Content: m.Content})
+	}
+
+	return json.Marshal(struct {
+		Role    string        `json:"role"`
+		Content []ContentPart `json:"content"`
+	}{Role: m.Role, Content: m.Parts})
 }
 
 // CompletionRequest - request to Perplexity API
 type CompletionRequest struct {
-	Messages               []Message `json:"messages"`
-	Model                  string    `json:"model"`
-	MaxTokens              int       `json:"max_tokens"`
-	Temperature            float64   `json:"temperature"`
-	TopP                   float64   `json:"top_p"`
-	SearchContextSize      string    `json:"search_context_size"`
-	SearchDomainFilter     []string  `json:"search_domain_filter,omitempty"`
-	ReturnImages           bool      `json:"return_images"`
-	ReturnRelatedQuestions bool      `json:"return_related_questions"`
-	SearchRecencyFilter    string    `json:"search_recency_filter,omitempty"`
-	TopK                   int       `json:"top_k,omitempty"`
-	Stream                 bool      `json:"stream"`
-	PresencePenalty        float64   `json:"presence_penalty,omitempty"`
-	FrequencyPenalty       float64   `json:"frequency_penalty,omitempty"`
+	Messages               []Message         `json:"messages"`
+	Model                  string            `json:"model"`
+	MaxTokens              int               `json:"max_tokens"`
+	Temperature            float64           `json:"temperature"`
+	TopP                   float64           `json:"top_p"`
+	WebSearchOptions       *WebSearchOptions `json:"web_search_options,omitempty"`
+	SearchDomainFilter     []string          `json:"search_domain_filter,omitempty"`
+	ReturnImages           bool              `json:"return_images"`
+	ReturnRelatedQuestions bool              `json:"return_related_questions"`
+	SearchRecencyFilter    string            `json:"search_recency_filter,omitempty"`
+	SearchMode             string            `json:"search_mode,omitempty"`
+	TopK                   int               `json:"top_k,omitempty"`
+	Stream                 bool              `json:"stream"`
+	PresencePenalty        float64           `json:"presence_penalty,omitempty"`
+	FrequencyPenalty       float64           `json:"frequency_penalty,omitempty"`
+}
+
+// WebSearchOptions - options of the web search performed by Perplexity API
+type WebSearchOptions struct {
+	SearchContextSize string `json:"search_context_size,omitempty"`
 }
 
 // CompletionResponse - response from Perplexity API
 type CompletionResponse struct {
-	ID        string    `json:"id"`
-	Model     string    `json:"model"`
-	Created   int       `json:"created"`
-	Object    string    `json:"object"`
-	Choices   []Choice  `json:"choices"`
-	Usage     Usage     `json:"usage"`
-	Citations *[]string `json:"citations,omitempty"`
+	ID               string    `json:"id"`
+	Model            string    `json:"model"`
+	Created          int       `json:"created"`
+	Object           string    `json:"object"`
+	Choices          []Choice  `json:"choices"`
+	Usage            Usage     `json:"usage"`
+	Citations        *[]string `json:"citations,omitempty"`
+	RelatedQuestions []string  `json:"related_questions,omitempty"`
+
+	SearchResults []PerplexitySearchResult `json:"search_results,omitempty"`
+}
+
+// PerplexitySearchResult - source of the answer with its title and snippet, newer API responses list them
+// in search_results next to the bare URLs of citations
+type PerplexitySearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Date    string `json:"date,omitempty"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Citation - source of the answer, the title and the snippet are empty when the API returns only the URL
+type Citation struct {
+	URL     string
+	Title   string
+	Snippet string
+}
+
+// getCitations keeps the order of citations because the answer refers to them by numbers and takes titles
+// and snippets from search_results by URL, search_results are the citations when the response has no URLs
+func (r *CompletionResponse) getCitations() []Citation {
+	results := make(map[string]PerplexitySearchResult, len(r.SearchResults))
+	for _, result := range r.SearchResults {
+		if _, ok := results[result.URL]; !ok {
+			results[result.URL] = result
+		}
+	}
+
+	var citations []Citation
+	if r.Citations != nil && len(*r.Citations) > 0 {
+		for _, link := range *r.Citations {
+			result := results[link]
+			citations = append(citations, Citation{URL: link, Title: result.Title, Snippet: result.Snippet})
+		}
+		return citations
+	}
+
+	for _, result := range r.SearchResults {
+		if strings.TrimSpace(result.URL) == "" {
+			continue
+		}
+		citations = append(citations, Citation{URL: result.URL, Title: result.Title, Snippet: result.Snippet})
+	}
+
+	return citations
 }
 
 // Choice - choice from Perplexity API response
@@ -81,66 +195,175 @@ type Usage struct {
 
 // perplexity - structure for working with Perplexity API
 type perplexity struct {
-	flowID      int64
-	taskID      *int64
-	subtaskID   *int64
-	apiKey      string
-	proxyURL    string
-	model       string
-	contextSize string
-	temperature float64
-	topP        float64
-	maxTokens   int
-	timeout     time.Duration
-	slp         SearchLogProvider
-	summarizer  SummarizeHandler
+	flowID           int64
+	taskID           *int64
+	subtaskID        *int64
+	apiKey           string
+	proxyURL         string
+	baseURL          string
+	transport        http.RoundTripper
+	model            string
+	contextSize      string
+	systemPrompt     string
+	relatedQuestions bool
+	summarize        bool
+	keepReasoning    bool
+	temperature      float64
+	topP             float64
+	presencePenalty  float64
+	frequencyPenalty float64
+	maxTokens        int
+	timeout          time.Duration
+	connTimeouts     ConnTimeouts
+	dryRun           bool
+	cache            CacheProvider
+	sourceFooter     bool
+	slp              SearchLogProvider
+	summarizer       SummarizeHandler
+	gateway          *LLMGateway
+	tracer           Tracer
 }
 
+// PerplexityConfig configures the perplexity search tool, zero fields keep the defaults of the tool
+type PerplexityConfig struct {
+	FlowID    int64
+	TaskID    *int64
+	SubtaskID *int64
+
+	APIKey    string
+	BaseURL   string
+	ProxyURL  string
+	Transport http.RoundTripper
+	Timeout   time.Duration
+
+	// ConnectTimeout and ResponseHeaderTimeout bound the stages of the request within the timeout,
+	// zero connect timeout is the default one and zero response header timeout isn't limited
+	ConnectTimeout        time.Duration
+	ResponseHeaderTimeout time.Duration
+
+	Model            string
+	ContextSize      string
+	SystemPrompt     string
+	RelatedQuestions bool
+	DisableSummarize bool
+	KeepReasoning    bool
+	Temperature      float64
+	TopP             float64
+	PresencePenalty  float64
+	FrequencyPenalty float64
+	MaxTokens        int
+
+	DryRun       bool
+	Cache        CacheProvider
+	SourceFooter bool
+	SearchLog    SearchLogProvider
+	Summarizer   SummarizeHandler
+	Gateway      *LLMGateway
+	Tracer       Tracer
+}
+
+// NewPerplexityTool creates perplexity search tool, it's kept for existing callers,
+// NewPerplexityToolWithConfig is easier to read at the call site
 func NewPerplexityTool(flowID int64, taskID, subtaskID *int64,
-	apiKey, proxyURL, model, contextSize string, temperature, topP float64,
-	maxTokens int, timeout time.Duration, slp SearchLogProvider, summarizer SummarizeHandler,
+	apiKey, baseURL, proxyURL, model, contextSize, systemPrompt string, relatedQuestions, summarize, keepReasoning bool,
+	temperature, topP, presencePenalty, frequencyPenalty float64,
+	maxTokens int, timeout time.Duration, dryRun bool, sourceFooter bool, slp SearchLogProvider, summarizer SummarizeHandler, gateway *LLMGateway,
 ) Tool {
-	if model == "" {
-		model = perplexityModel
+	return NewPerplexityToolWithConfig(PerplexityConfig{
+		FlowID:           flowID,
+		TaskID:           taskID,
+		SubtaskID:        subtaskID,
+		APIKey:           apiKey,
+		BaseURL:          baseURL,
+		ProxyURL:         proxyURL,
+		Timeout:          timeout,
+		Model:            model,
+		ContextSize:      contextSize,
+		SystemPrompt:     systemPrompt,
+		RelatedQuestions: relatedQuestions,
+		DisableSummarize: !summarize,
+		KeepReasoning:    keepReasoning,
+		Temperature:      temperature,
+		TopP:             topP,
+		PresencePenalty:  presencePenalty,
+		FrequencyPenalty: frequencyPenalty,
+		MaxTokens:        maxTokens,
+		DryRun:           dryRun,
+		SourceFooter:     sourceFooter,
+		SearchLog:        slp,
+		Summarizer:       summarizer,
+		Gateway:          gateway,
+	})
+}
+
+// NewPerplexityToolWithConfig creates perplexity search tool, long answers are summarized by the summarizer
+// unless DisableSummarize is set, then the answer with citations is returned as is, keepReasoning moves
+// reasoning traces of reasoning models into the collapsible section instead of dropping them,
+// empty system prompt sends no system message and empty base URL falls back to the gateway if it's set
+// or to the public API otherwise, zero penalties are omitted from the request to keep the provider defaults
+func NewPerplexityToolWithConfig(cfg PerplexityConfig) Tool {
+	if cfg.Model == "" {
+		cfg.Model = perplexityModel
 	}
 
-	if temperature == 0 {
-		temperature = perplexityTemperature
+	if cfg.Temperature == 0 {
+		cfg.Temperature = perplexityTemperature
 	}
 
-	if topP == 0 {
-		topP = perplexityTopP
+	if cfg.TopP == 0 {
+		cfg.TopP = perplexityTopP
 	}
 
-	if maxTokens == 0 {
-		maxTokens = perplexityMaxTokens
+	if cfg.MaxTokens == 0 {
+		cfg.MaxTokens = perplexityMaxTokens
+	}
+
+	cfg.Timeout = resolveTimeout(PerplexityToolName, cfg.Timeout)
+
+	if cfg.ConnectTimeout == 0 {
+		cfg.ConnectTimeout = perplexityConnectTimeout
 	}
 
-	if timeout == 0 {
-		timeout = perplexityTimeout
+	if cfg.ContextSize != "" && perplexityWebSearchOptions(cfg.ContextSize) == nil {
+		logrus.WithField("context_size", cfg.ContextSize).Warn("unknown perplexity search context size, using the provider default")
+		cfg.ContextSize = ""
 	}
 
 	return &perplexity{
-		flowID:      flowID,
-		taskID:      taskID,
-		subtaskID:   subtaskID,
-		apiKey:      apiKey,
-		proxyURL:    proxyURL,
-		model:       model,
-		contextSize: contextSize,
-		temperature: temperature,
-		topP:        topP,
-		maxTokens:   maxTokens,
-		timeout:     timeout,
-		slp:         slp,
-		summarizer:  summarizer,
+		flowID:           cfg.FlowID,
+		taskID:           cfg.TaskID,
+		subtaskID:        cfg.SubtaskID,
+		apiKey:           cfg.APIKey,
+		baseURL:          cfg.BaseURL,
+		proxyURL:         cfg.ProxyURL,
+		transport:        cfg.Transport,
+		model:            cfg.Model,
+		contextSize:      cfg.ContextSize,
+		systemPrompt:     cfg.SystemPrompt,
+		relatedQuestions: cfg.RelatedQuestions,
+		summarize:        !cfg.DisableSummarize,
+		keepReasoning:    cfg.KeepReasoning,
+		temperature:      cfg.Temperature,
+		topP:             cfg.TopP,
+		presencePenalty:  clampPerplexityPenalty("presence_penalty", cfg.PresencePenalty),
+		frequencyPenalty: clampPerplexityPenalty("frequency_penalty", cfg.FrequencyPenalty),
+		maxTokens:        cfg.MaxTokens,
+		timeout:          cfg.Timeout,
+		connTimeouts:     ConnTimeouts{Connect: cfg.ConnectTimeout, ResponseHeader: cfg.ResponseHeaderTimeout},
+		dryRun:           cfg.DryRun,
+		cache:            cfg.Cache,
+		sourceFooter:     cfg.SourceFooter,
+		slp:              cfg.SearchLog,
+		summarizer:       cfg.Summarizer,
+		gateway:          cfg.Gateway,
+		tracer:           cfg.Tracer,
 	}
 }
 
 // Handle processes a search request through Perplexity API
 func (t *perplexity) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
-	var action SearchAction
-	ctx, observation := obs.Observer.NewObservation(ctx)
+	var action PerplexitySearchAction
+	ctx, emitter := startTrace(ctx, t.tracer)
 	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
 		"tool": name,
 		"args": string(args),
@@ -150,129 +373,376 @@ func (t *perplexity) Handle(ctx context.Context, name string, args json.RawMessa
 		logger.WithError(err).Error("failed to unmarshal perplexity search action")
 		return "", fmt.Errorf("failed to unmarshal %s search action arguments: %w", name, err)
 	}
+	action.Query = sanitizeQuery(action.Query, maxQueryLength)
+
+	lang, err := normalizeLanguage(action.Lang)
+	if err != nil {
+		logger.WithError(err).Error("invalid language of perplexity search")
+		return fmt.Sprintf("invalid language of perplexity search: %v", err), nil
+	}
+	query := withLanguageHint(action.Query, lang)
+
+	mode, err := normalizePerplexityMode(action.Mode)
+	if err != nil {
+		logger.WithError(err).Error("invalid mode of perplexity search")
+		return fmt.Sprintf("invalid mode of perplexity search: %v", err), nil
+	}
 
-	logger = logger.WithFields(logrus.Fields{
-		"query":       action.Query[:min(len(action.Query), 1000)],
+	history := t.limitHistory(action.History)
+	logger = withToolFields(logger, "perplexity", action.Query, t.flowID, t.taskID, t.subtaskID, logrus.Fields{
 		"max_results": action.MaxResults,
+		"history":     len(history),
+		"images":      len(action.Images),
+		"lang":        lang,
+		"mode":        mode,
 	})
 
-	result, err := t.search(ctx, action.Query)
+	images, err := t.normalizeImages(action.Images)
 	if err != nil {
-		observation.Event(
-			langfuse.WithEventName("search engine error swallowed"),
-			langfuse.WithEventInput(action.Query),
-			langfuse.WithEventStatus(err.Error()),
-			langfuse.WithEventLevel(langfuse.ObservationLevelWarning),
-			langfuse.WithEventMetadata(langfuse.Metadata{
-				"tool_name":   PerplexityToolName,
-				"engine":      "perplexity",
-				"query":       action.Query,
-				"model":       t.model,
-				"max_results": action.MaxResults.Int(),
-				"error":       err.Error(),
-			}),
-		)
+		logger.WithError(err).Error("invalid images for perplexity search")
+		return fmt.Sprintf("invalid images for perplexity search: %v", err), nil
+	}
+
+	// the prompt is the part of the key only when it's configured, so keys without it stay the same
+	keyParams := []any{t.model, t.contextSize, t.relatedQuestions, t.summarize, history, images, mode}
+	if t.systemPrompt != "" {
+		keyParams = append(keyParams, t.systemPrompt)
+	}
+	cacheKey := searchCacheKey(database.SearchengineTypePerplexity, query, keyParams...)
+	stats, start := SearchStats{Cached: true}, time.Now()
+	result, err := withSearchCache(ctx, dryRunCache(t.cache, t.dryRun), cacheKey, &stats, func() (string, error) {
+		if err := waitSearchQueue(ctx, database.SearchengineTypePerplexity, action.Priority); err != nil {
+			stats.Cached = false
+			return "", err
+		}
+		result, count, err := withNoResultsText(t.search(ctx, query, mode, history, images))
+		stats.Cached, stats.ResultCount = false, count
+		return result, err
+	})
+	recordSearchCall(ctx, database.SearchengineTypePerplexity, stats.Cached, time.Since(start), err)
+	if err != nil {
+		emitter.Emit(searchErrorEvent(action.Query, err, map[string]any{
+			"tool_name":   PerplexityToolName,
+			"engine":      "perplexity",
+			"model":       t.model,
+			"mode":        mode,
+			"max_results": action.MaxResults.Int(),
+		}))
 
 		logger.WithError(err).Error("failed to search in perplexity")
 		return fmt.Sprintf("failed to search in perplexity: %v", err), nil
 	}
 
-	if agentCtx, ok := GetAgentContext(ctx); ok {
-		_, _ = t.slp.PutLog(
-			ctx,
-			agentCtx.ParentAgentType,
-			agentCtx.CurrentAgentType,
-			database.SearchengineTypePerplexity,
-			action.Query,
-			result,
-			t.taskID,
-			t.subtaskID,
-		)
+	stats.Duration = time.Since(start)
+	_, _ = putSearchLog(ctx, t.slp, database.SearchengineTypePerplexity, action.Query, result, stats, t.taskID, t.subtaskID)
+
+	return withSourceFooter(result, "Perplexity", t.sourceFooter), nil
+}
+
+// withLanguageHint asks to research sources in the language since the API has no language filter,
+// the answer is still expected in English like without the hint
+func withLanguageHint(query, lang string) string {
+	if lang == "" {
+		return query
 	}
 
-	return result, nil
+	return fmt.Sprintf("%s\n\nSearch for sources written in the language with ISO 639-1 code %s, answer in English.", query, lang)
 }
 
-// search performs a request to Perplexity API
-func (t *perplexity) search(ctx context.Context, query string) (string, error) {
-	// Setting up HTTP client with timeout
-	httpClient := &http.Client{
-		Timeout: t.timeout,
+// normalizePerplexityMode validates the search mode of the action, empty mode is the web search
+func normalizePerplexityMode(mode string) (string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		return "web", nil
+	}
+	if _, ok := perplexitySearchModes[mode]; !ok {
+		return "", fmt.Errorf("unknown mode %q, expected web, academic or news", mode)
 	}
 
-	// Setting up proxy if specified
-	if t.proxyURL != "" {
-		httpClient.Transport = &http.Transport{
-			Proxy: func(req *http.Request) (*url.URL, error) {
-				return url.Parse(t.proxyURL)
-			},
-		}
+	return mode, nil
+}
+
+// perplexityWebSearchOptions returns the web search options for the context size,
+// empty or unknown size returns nil to leave the provider default
+func perplexityWebSearchOptions(contextSize string) *WebSearchOptions {
+	contextSize = strings.ToLower(strings.TrimSpace(contextSize))
+	if !slices.Contains(perplexityContextSizes, contextSize) {
+		return nil
 	}
 
-	// Creating message for the request
-	messages := []Message{
-		{
-			Role:    "user",
-			Content: query,
-		},
+	return &WebSearchOptions{SearchContextSize: contextSize}
+}
+
+// clampPerplexityPenalty keeps the penalty within the range accepted by the API
+func clampPerplexityPenalty(name string, value float64) float64 {
+	clamped := min(max(value, -perplexityMaxPenalty), perplexityMaxPenalty)
+	if clamped != value {
+		logrus.WithFields(logrus.Fields{
+			"penalty": name,
+			"value":   value,
+			"clamped": clamped,
+		}).Warn("perplexity penalty is out of range, clamped to the limit")
 	}
 
+	return clamped
+}
+
+// search performs a request to Perplexity API, the history of previous questions and answers
+// is sent before the query to keep the context of follow-up questions, images are attached to the query,
+// the mode sets the search parameters of the request and empty or unknown one keeps the web search
+func (t *perplexity) search(ctx context.Context, query, mode string, history []Message, images []string) (string, int, error) {
+	searchMode := perplexitySearchModes[mode]
+
 	// Forming the request
 	reqPayload := CompletionRequest{
-		Messages:               messages,
+		Messages:               t.buildMessages(query, history, images),
 		Model:                  t.model,
-		SearchContextSize:      t.contextSize,
+		WebSearchOptions:       perplexityWebSearchOptions(t.contextSize),
 		MaxTokens:              t.maxTokens,
 		Temperature:            t.temperature,
 		TopP:                   t.topP,
+		PresencePenalty:        t.presencePenalty,
+		FrequencyPenalty:       t.frequencyPenalty,
 		ReturnImages:           false,
-		ReturnRelatedQuestions: false,
+		ReturnRelatedQuestions: t.relatedQuestions,
+		SearchMode:             searchMode.searchMode,
+		SearchRecencyFilter:    searchMode.recencyFilter,
 		Stream:                 false,
 	}
 
-	// Serializing the request
-	reqBody, err := json.Marshal(reqPayload)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request body: %w", err)
+	if t.dryRun {
+		req, err := t.newRequest(ctx, reqPayload)
+		if err != nil {
+			return "", 0, err
+		}
+		result, err := describeRequest(req)
+		return result, 0, err
 	}
 
-	// Creating HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, perplexityURL, bytes.NewBuffer(reqBody))
+	response, err := t.complete(ctx, reqPayload)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", 0, err
+	}
+	if len(response.Choices) == 0 || strings.TrimSpace(response.Choices[0].Message.Content) == "" {
+		return "", 0, newNoResultsError("perplexity returned no answer for the query")
 	}
 
-	// Setting request headers
-	req.Header.Set("Authorization", "Bearer "+t.apiKey)
-	req.Header.Set("Content-Type", "application/json")
+	// Counting citations as result items
+	count := len(response.getCitations())
+
+	// Forming the result
+	result := t.formatResponse(ctx, response, query)
+	return result, count, nil
+}
+
+// buildMessages puts the configured system prompt and the history before the current query,
+// no system message is sent without the prompt, the query with images is sent as the content parts,
+// text-only query stays the plain string
+func (t *perplexity) buildMessages(query string, history []Message, images []string) []Message {
+	messages := make([]Message, 0, len(history)+2)
+	if t.systemPrompt != "" {
+		messages = append(messages, Message{Role: "system", Content: t.systemPrompt})
+	}
+	messages = append(messages, history...)
+
+	msg := Message{Role: "user", Content: query}
+	if len(images) > 0 {
+		msg.Parts = append(msg.Parts, ContentPart{Type: "text", Text: query})
+		for _, image := range images {
+			msg.Parts = append(msg.Parts, ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: image}})
+		}
+	}
+
+	return append(messages, msg)
+}
+
+// normalizeImages checks that the model accepts images and turns every image to the public URL
+// or the data URI, raw base64 data gets the data URI prefix with the detected image type
+func (t *perplexity) normalizeImages(images []string) ([]string, error) {
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	if !perplexitySupportsImages(t.model) {
+		return nil, fmt.Errorf("model %s doesn't accept images, use some of: %s",
+			t.model, strings.Join(perplexityImageModels, ", "))
+	}
+
+	if len(images) > perplexityMaxImages {
+		return nil, fmt.Errorf("too many images %d, maximum is %d", len(images), perplexityMaxImages)
+	}
+
+	normalized := make([]string, 0, len(images))
+	for i, image := range images {
+		image = strings.TrimSpace(image)
+		switch {
+		case strings.HasPrefix(image, "http://") || strings.HasPrefix(image, "https://"):
+			if u, err := url.Parse(image); err != nil || u.Host == "" {
+				return nil, fmt.Errorf("image %d has invalid URL %q", i+1, image)
+			}
+		case strings.HasPrefix(image, "data:"):
+			header, data, ok := strings.Cut(image, ",")
+			if !ok || !strings.HasPrefix(header, "data:image/") || !strings.HasSuffix(header, ";base64") {
+				return nil, fmt.Errorf("image %d must be the base64 data URI of the image", i+1)
+			}
+			if _, err := base64.StdEncoding.DecodeString(data); err != nil {
+				return nil, fmt.Errorf("image %d has invalid base64 data: %w", i+1, err)
+			}
+		default:
+			data, err := base64.StdEncoding.DecodeString(image)
+			if err != nil {
+				return nil, fmt.Errorf("image %d must be the http(s) URL or base64 encoded image", i+1)
+			}
+			contentType := http.DetectContentType(data)
+			if !strings.HasPrefix(contentType, "image/") {
+				return nil, fmt.Errorf("image %d has unsupported content type %s", i+1, contentType)
+			}
+			image = "data:" + contentType + ";base64," + image
+		}
+		normalized = append(normalized, image)
+	}
+
+	return normalized, nil
+}
+
+// perplexitySupportsImages checks the model name without the provider prefix of the gateway aliases
+func perplexitySupportsImages(model string) bool {
+	model = strings.ToLower(model)
+	if i := strings.LastIndex(model, "/"); i != -1 {
+		model = model[i+1:]
+	}
+
+	return slices.Contains(perplexityImageModels, model)
+}
+
+// limitHistory keeps the latest question and answer pairs of the history which fit into the tokens budget,
+// it roughly estimates 4 characters per token and drops messages with unsupported roles, the API requires
+// the history to alternate the user and assistant roles, so a question without the answer, an answer without
+// the question and the earlier of repeated questions or answers are dropped, pairs are never split
+func (t *perplexity) limitHistory(history []Message) []Message {
+	maxTokens := t.maxTokens
+	if maxTokens <= 0 {
+		maxTokens = perplexityMaxTokens
+	}
+
+	var (
+		pairs    [][2]Message
+		question *Message
+	)
+	for _, msg := range history {
+		if strings.TrimSpace(msg.Content) == "" {
+			continue
+		}
+
+		switch msg.Role {
+		case "user":
+			question = &msg
+		case "assistant":
+			if question != nil {
+				pairs = append(pairs, [2]Message{*question, msg})
+				question = nil
+			} else if len(pairs) > 0 {
+				pairs[len(pairs)-1][1] = msg
+			}
+		}
+	}
+
+	var (
+		kept   int
+		tokens int
+	)
+	for i := len(pairs) - 1; i >= 0 && 2*(kept+1) <= perplexityMaxHistory; i-- {
+		tokens += len(pairs[i][0].Content)/4 + len(pairs[i][1].Content)/4 + 2
+		if tokens > maxTokens {
+			break
+		}
+		kept++
+	}
+
+	var limited []Message
+	for _, pair := range pairs[len(pairs)-kept:] {
+		limited = append(limited, pair[0], pair[1])
+	}
+
+	return limited
+}
+
+// complete sends the completion request to Perplexity API and decodes the response
+func (t *perplexity) complete(ctx context.Context, reqPayload CompletionRequest) (*CompletionResponse, error) {
+	// Setting up HTTP client with timeout
+	httpClient := newHTTPClientWithTimeouts(t.proxyURL, resolveTimeout(PerplexityToolName, t.timeout), t.connTimeouts)
+
+	// Setting up injected transport if specified
+	if t.transport != nil {
+		httpClient.Transport = t.transport
+	}
+
+	req, err := t.newRequest(ctx, reqPayload)
+	if err != nil {
+		return nil, err
+	}
 
 	// Sending the request
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, newNetworkError(err, "failed to send request")
 	}
 	defer resp.Body.Close()
 
 	// Handling the response
 	if resp.StatusCode != http.StatusOK {
-		return "", t.handleErrorResponse(resp.StatusCode)
+		return nil, t.handleErrorResponse(resp.StatusCode)
 	}
 
 	// Reading the response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Deserializing the response
 	var response CompletionResponse
 	if err := json.Unmarshal(body, &response); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	// Forming the result
-	result := t.formatResponse(ctx, &response, query)
-	return result, nil
+	return &response, nil
+}
+
+// newRequest builds the request to the completions API or the gateway compatible with it
+func (t *perplexity) newRequest(ctx context.Context, reqPayload CompletionRequest) (*http.Request, error) {
+	// Serializing the request
+	reqBody, err := json.Marshal(reqPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	// base URL can point to the gateway compatible with Perplexity API,
+	// the own base URL of the tool wins over the shared gateway
+	useGateway := t.baseURL == "" && t.gateway.IsConfigured()
+	baseURL := t.baseURL
+	if baseURL == "" {
+		baseURL = perplexityBaseURL
+	}
+	apiURL := strings.TrimRight(baseURL, "/") + "/chat/completions"
+	if useGateway {
+		apiURL = t.gateway.URL("chat/completions")
+	}
+
+	// Creating HTTP request
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Setting request headers
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if useGateway {
+		t.gateway.SetHeaders(req)
+	}
+
+	return req, nil
 }
 
 // handleErrorResponse handles erroneous HTTP statuses
@@ -281,25 +751,25 @@ func (t *perplexity) handleErrorResponse(statusCode int) error {
 	case http.StatusBadRequest:
 		return errors.New("request is invalid")
 	case http.StatusUnauthorized:
-		return errors.New("API key is wrong")
+		return newSearchError(ErrAuth, "API key is wrong")
 	case http.StatusForbidden:
-		return errors.New("the endpoint requested is hidden for administrators only")
+		return newSearchError(ErrAuth, "the endpoint requested is hidden for administrators only")
 	case http.StatusNotFound:
 		return errors.New("the specified endpoint could not be found")
 	case http.StatusMethodNotAllowed:
 		return errors.New("there need to try to access an endpoint with an invalid method")
 	case http.StatusTooManyRequests:
-		return errors.New("there are requesting too many results")
+		return newSearchError(ErrRateLimited, "there are requesting too many results")
 	case http.StatusInternalServerError:
-		return errors.New("there had a problem with our server. try again later")
+		return newSearchError(ErrUpstreamUnavailable, "there had a problem with our server. try again later")
 	case http.StatusBadGateway:
-		return errors.New("there was a problem with the server. Please try again later")
+		return newSearchError(ErrUpstreamUnavailable, "there was a problem with the server. Please try again later")
 	case http.StatusServiceUnavailable:
-		return errors.New("there are temporarily offline for maintenance. please try again later")
+		return newSearchError(ErrUpstreamUnavailable, "there are temporarily offline for maintenance. please try again later")
 	case http.StatusGatewayTimeout:
-		return errors.New("there are temporarily offline for maintenance. please try again later")
+		return newSearchError(ErrUpstreamUnavailable, "there are temporarily offline for maintenance. please try again later")
 	default:
-		return fmt.Errorf("unexpected status code: %d", statusCode)
+		return newSearchError(errorKindByStatus(statusCode), "unexpected status code: %d", statusCode)
 	}
 }
 
@@ -313,23 +783,46 @@ func (t *perplexity) formatResponse(ctx context.Context, response *CompletionRes
 	}
 
 	// Getting the response content
-	content := response.Choices[0].Message.Content
+	content, reasoning := response.Choices[0].Message.Content, ""
+	if isPerplexityReasoningModel(t.model) {
+		content, reasoning = splitPerplexityReasoning(content)
+	}
 	builder.WriteString("# Answer\n\n")
 	builder.WriteString(content)
 
-	// Adding citations if available and within maxResults limit
-	if response.Citations != nil && len(*response.Citations) > 0 {
+	if t.keepReasoning && reasoning != "" {
+		builder.WriteString("\n\n# Reasoning\n\n<details>\n<summary>Reasoning of the model</summary>\n\n")
+		builder.WriteString(reasoning)
+		builder.WriteString("\n\n</details>")
+	}
+
+	// Adding citations if available, titled ones are rendered as links with the snippets
+	citations := response.getCitations()
+	if len(citations) > 0 {
 		builder.WriteString("\n\n# Citations\n\n")
-		for i, citation := range *response.Citations {
-			builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, citation))
+		for i, citation := range citations {
+			builder.WriteString(formatPerplexityCitation(i+1, citation))
+		}
+	}
+
+	// Adding follow-up questions to expand the research
+	if len(response.RelatedQuestions) > 0 {
+		builder.WriteString("\n\n# Related Questions\n\n")
+		for i, question := range response.RelatedQuestions {
+			builder.WriteString(fmt.Sprintf("%d. %s\n", i+1, question))
 		}
 	}
 
 	rawContent := builder.String()
+	if !t.summarize {
+		// raw answer with citations is requested, skip the extra model pass
+		return rawContent
+	}
+
 	if len(rawContent) > maxRawContentLength {
 		// Check if summarizer is available
 		if t.summarizer != nil {
-			summarizePrompt, err := t.getSummarizePrompt(query, rawContent, response.Citations)
+			summarizePrompt, err := t.getSummarizePrompt(query, rawContent, citations)
 			if err == nil {
 				if summarizedContent, err := t.summarizer(ctx, summarizePrompt); err == nil {
 					return summarizedContent
@@ -343,8 +836,51 @@ func (t *perplexity) formatResponse(ctx context.Context, response *CompletionRes
 	return rawContent
 }
 
+// formatPerplexityCitation renders the bare URL as is and the titled citation as the link
+// with the snippet on the next line, the snippet is kept on a single line of the list item
+func formatPerplexityCitation(number int, citation Citation) string {
+	title := strings.Join(strings.Fields(citation.Title), " ")
+	if title == "" {
+		return fmt.Sprintf("%d. %s\n", number, citation.URL)
+	}
+
+	line := fmt.Sprintf("%d. [%s](%s)\n", number, title, citation.URL)
+	if snippet := strings.Join(strings.Fields(citation.Snippet), " "); snippet != "" {
+		line += fmt.Sprintf("   %s\n", snippet)
+	}
+
+	return line
+}
+
+// isPerplexityReasoningModel reports whether the model puts its reasoning into think tags
+func isPerplexityReasoningModel(model string) bool {
+	model = strings.ToLower(model)
+	return strings.Contains(model, "reasoning") || strings.Contains(model, "deep-research")
+}
+
+// splitPerplexityReasoning cuts think blocks out of the content and returns them separately,
+// the unclosed block of the answer stopped by the token limit takes the rest of the content
+func splitPerplexityReasoning(content string) (string, string) {
+	var traces []string
+	content = perplexityThinkPattern.ReplaceAllStringFunc(content, func(block string) string {
+		if trace := strings.TrimSpace(perplexityThinkPattern.FindStringSubmatch(block)[1]); trace != "" {
+			traces = append(traces, trace)
+		}
+		return ""
+	})
+
+	if idx := strings.Index(content, "<think>"); idx >= 0 {
+		if trace := strings.TrimSpace(content[idx+len("<think>"):]); trace != "" {
+			traces = append(traces, trace)
+		}
+		content = content[:idx]
+	}
+
+	return strings.TrimSpace(content), strings.Join(traces, "\n\n")
+}
+
 // getSummarizePrompt creates a prompt for summarizing Perplexity search results
-func (t *perplexity) getSummarizePrompt(query string, content string, citations *[]string) (string, error) {
+func (t *perplexity) getSummarizePrompt(query string, content string, citations []Citation) (string, error) {
 	templateText := `<instructions>
 TASK: Summarize Perplexity search results for the following user query:
 
@@ -379,7 +915,7 @@ The summary MUST provide complete answers to the user's query, preserving all re
 
 {{if .HasCitations}}
 <citations>
-{{range $index, $citation := .Citations}}{{$index | inc}}. {{$citation}}
+{{range $index, $citation := .Citations}}{{$index | inc}}. {{if $citation.Title}}{{$citation.Title}}: {{end}}{{$citation.URL}}
 {{end}}</citations>
 {{end}}`
 
@@ -393,11 +929,11 @@ The summary MUST provide complete answers to the user's query, preserving all re
 		"Query":        query,
 		"MaxLength":    maxRawContentLength,
 		"Content":      content,
-		"HasCitations": citations != nil && len(*citations) > 0,
+		"HasCitations": len(citations) > 0,
 	}
 
-	if citations != nil && len(*citations) > 0 {
-		templateContext["Citations"] = *citations
+	if len(citations) > 0 {
+		templateContext["Citations"] = citations
 	}
 
 	tmpl, err := template.New("summarize").Funcs(funcMap).Parse(templateText)
@@ -413,7 +949,44 @@ The summary MUST provide complete answers to the user's query, preserving all re
 	return buf.String(), nil
 }
 
+// EstimateCost prices the request by the search context size and the tokens of the question, the history
+// and the system prompt with the whole completion budget, so it's the upper bound of the call
+func (t *perplexity) EstimateCost(args json.RawMessage) (CostEstimate, error) {
+	var action PerplexitySearchAction
+	if err := json.Unmarshal(args, &action); err != nil {
+		return CostEstimate{}, fmt.Errorf("failed to unmarshal %s search action arguments: %w", PerplexityToolName, err)
+	}
+
+	// the provider applies the low context size when the option isn't sent
+	factor := perplexityContextSizeFactors["low"]
+	if options := perplexityWebSearchOptions(t.contextSize); options != nil {
+		factor = perplexityContextSizeFactors[options.SearchContextSize]
+	}
+
+	maxTokens := t.maxTokens
+	if maxTokens <= 0 {
+		maxTokens = perplexityMaxTokens
+	}
+
+	tokens := len(sanitizeQuery(action.Query, maxQueryLength))/4 + len(t.systemPrompt)/4 + 2 + maxTokens
+	for _, msg := range t.limitHistory(action.History) {
+		tokens += len(msg.Content)/4 + 1
+	}
+
+	return resolvePricing(PerplexityToolName).estimate(1, 0, tokens, factor), nil
+}
+
 // isAvailable checks the availability of the API
 func (t *perplexity) IsAvailable() bool {
-	return t.apiKey != ""
+	return t.apiKey != "" && IsEnabled(PerplexityToolName)
+}
+
+// HealthCheck asks a tiny question limited to a single token to verify the API key and proxy
+func (t *perplexity) HealthCheck(ctx context.Context) error {
+	_, err := t.complete(ctx, CompletionRequest{
+		Messages:  []Message{{Role: "user", Content: "ping"}},
+		Model:     t.model,
+		MaxTokens: 1,
+	})
+	return err
 }
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Safe search levels of the web search tools, empty level keeps the provider default
+const (
+	SafeSearchOff      = "off"
+	SafeSearchModerate = "moderate"
+	SafeSearchStrict   = "strict"
+)
+
+// maxQueryLength caps the query sent to search engines, longer queries are mostly
+// pasted command output which engines reject or answer with noise
+const maxQueryLength = 1000
+
+// questionWords start natural-language questions which are better answered by the synthesized answer
+var questionWords = []string{
+	"what", "how", "why", "which", "who", "when", "where", "whether",
+	"is", "are", "can", "could", "does", "do", "should", "explain", "compare",
+}
+
+// engine preferences by the query intent, the first available engine wins
+var (
+	vulnerabilityEngines = []string{GithubToolName, TavilyToolName, GoogleToolName, PerplexityToolName}
+	questionEngines      = []string{PerplexityToolName, TavilyToolName, TraversaalToolName, GoogleToolName}
+	keywordEngines       = []string{GoogleToolName, DuckDuckGoToolName, SearxngToolName, TavilyToolName}
+)
+
+// languagePattern matches ISO 639-1 language code with the optional region (e.g. de, pt-BR)
+var languagePattern = regexp.MustCompile(`^([a-z]{2})(?:[-_]([a-z]{2}))?$`)
+
+// normalizeLanguage returns the language code in the form of de or zh-TW, empty value means provider default
+func normalizeLanguage(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return "", nil
+	}
+
+	match := languagePattern.FindStringSubmatch(value)
+	if match == nil {
+		return "", fmt.Errorf("invalid language %q, expected ISO 639-1 code (e.g. de or zh-TW)", value)
+	}
+	if match[2] != "" {
+		return match[1] + "-" + strings.ToUpper(match[2]), nil
+	}
+
+	return match[1], nil
+}
+
+// sanitizeQuery prepares the query of the agent to be sent upstream and logged:
+// control characters and invalid UTF-8 are stripped, whitespace runs are collapsed into single spaces,
+// and the result is capped to max characters, non-positive max disables the cap
+func sanitizeQuery(q string, max int) string {
+	var builder strings.Builder
+	builder.Grow(len(q))
+
+	count, space := 0, false
+	for _, r := range q {
+		switch {
+		case unicode.IsSpace(r):
+			space = builder.Len() > 0
+			continue
+		case unicode.IsControl(r) || r == utf8.RuneError:
+			continue
+		}
+
+		// the separator is written only before the next word to not leave trailing space
+		if space {
+			if max > 0 && count+2 > max {
+				break
+			}
+			builder.WriteByte(' ')
+			count++
+			space = false
+		}
+
+		if max > 0 && count >= max {
+			break
+		}
+		builder.WriteRune(r)
+		count++
+	}
+
+	return builder.String()
+}
+
+// SelectEngine picks the search engine for the query by simple deterministic rules:
+// CVE identifiers go to the engines with advisories and exploits, questions go to the engines
+// which synthesize answers and keywords go to the general web search, the first available
+// engine is returned when none of the preferred ones is available and empty string without engines
+func SelectEngine(query string, available []string) string {
+	if len(available) == 0 {
+		return ""
+	}
+
+	preferred := keywordEngines
+	switch {
+	case cvePattern.MatchString(query):
+		preferred = vulnerabilityEngines
+	case isQuestionQuery(query):
+		preferred = questionEngines
+	}
+
+	for _, engine := range preferred {
+		if slices.Contains(available, engine) {
+			return engine
+		}
+	}
+
+	return available[0]
+}
+
+// isQuestionQuery detects natural-language questions by the question mark or the leading question word
+func isQuestionQuery(query string) bool {
+	query = strings.TrimSpace(query)
+	if strings.HasSuffix(query, "?") {
+		return true
+	}
+
+	fields := strings.Fields(strings.ToLower(query))
+	// single word is the keyword even if it's the question word
+	if len(fields) < 2 {
+		return false
+	}
+
+	return slices.Contains(questionWords, strings.Trim(fields[0], ",.:;!"))
+}
@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		max   int
+		want  string
+	}{
+		{"plain", "log4j exploit", 100, "log4j exploit"},
+		{"trim", "  \t log4j exploit \n", 100, "log4j exploit"},
+		{"collapse whitespace", "log4j\n\n  exploit\t\tpoc", 100, "log4j exploit poc"},
+		{"control chars", "log4j\x00\x1b[31m exploit\x7f", 100, "log4j[31m exploit"},
+		{"invalid utf-8", "log4j \xff\xfeexploit", 100, "log4j exploit"},
+		{"unicode kept", "уязвимость  nginx", 100, "уязвимость nginx"},
+		{"cap", "abcdef", 3, "abc"},
+		{"cap in runes", "уязвимость", 4, "уязв"},
+		{"cap without trailing space", "abc def", 4, "abc"},
+		{"cap with space", "abc def", 5, "abc d"},
+		{"no cap", strings.Repeat("a", 2000), 0, strings.Repeat("a", 2000)},
+		{"empty", " \x00\n ", 100, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeQuery(tt.query, tt.max); got != tt.want {
+				t.Errorf("sanitizeQuery(%q, %d) = %q, want %q", tt.query, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeQueryMaxLength(t *testing.T) {
+	query := strings.Repeat("exploit ", maxQueryLength)
+
+	got := sanitizeQuery(query, maxQueryLength)
+	if n := utf8.RuneCountInString(got); n > maxQueryLength {
+		t.Errorf("expected at most %d characters, got %d", maxQueryLength, n)
+	}
+	if strings.HasSuffix(got, " ") {
+		t.Error("expected no trailing space after capping")
+	}
+}
+
+func TestSelectEngine(t *testing.T) {
+	all := []string{GoogleToolName, DuckDuckGoToolName, TavilyToolName, PerplexityToolName, GithubToolName}
+
+	tests := []struct {
+		name      string
+		query     string
+		available []string
+		want      string
+	}{
+		{"cve id", "CVE-2021-44228", all, GithubToolName},
+		{"cve in keywords", "apache log4j cve-2021-44228 poc", all, GithubToolName},
+		{"cve question", "How to exploit CVE-2021-44228?", all, GithubToolName},
+		{"cve without github", "CVE-2021-44228", []string{GoogleToolName, TavilyToolName}, TavilyToolName},
+		{"question word", "how does kerberoasting work", all, PerplexityToolName},
+		{"question mark", "nginx 1.18 still supported?", all, PerplexityToolName},
+		{"question with punctuation", "What, exactly, is SSRF", all, PerplexityToolName},
+		{"question without perplexity", "what is ssrf", []string{GoogleToolName, TavilyToolName}, TavilyToolName},
+		{"keywords", "nmap smb scripts", all, GoogleToolName},
+		{"single question word", "what", all, GoogleToolName},
+		{"cve-like version", "openssl 3.0.2-cve fixes", all, GoogleToolName},
+		{"keywords without google", "nmap smb scripts", []string{PerplexityToolName, DuckDuckGoToolName}, DuckDuckGoToolName},
+		{"no preferred engine", "nmap smb scripts", []string{WhoisToolName, DNSToolName}, WhoisToolName},
+		{"no engines", "nmap smb scripts", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectEngine(tt.query, tt.available); got != tt.want {
+				t.Errorf("SelectEngine(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{" DE ", "de", false},
+		{"pt-br", "pt-BR", false},
+		{"zh_TW", "zh-TW", false},
+		{"german", "", true},
+		{"de-", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeLanguage(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeLanguage(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"pentagi/pkg/database"
+)
+
+// SearchPriority orders searches waiting for the rate limit of the provider
+type SearchPriority string
+
+const (
+	SearchPriorityLow    SearchPriority = "low"
+	SearchPriorityNormal SearchPriority = "normal"
+	SearchPriorityHigh   SearchPriority = "high"
+)
+
+// searchQueueAging raises the priority of the pending search by one level per the period of waiting,
+// so low priority searches don't starve behind the stream of interactive ones
+const searchQueueAging = 10 * time.Second
+
+// level returns the order of the priority, unknown and empty priorities are normal
+func (p SearchPriority) level() int {
+	switch p {
+	case SearchPriorityLow:
+		return 0
+	case SearchPriorityHigh:
+		return 2
+	default:
+		return 1
+	}
+}
+
+type queuedSearch struct {
+	level    int
+	seq      uint64
+	enqueued time.Time
+	ready    chan struct{}
+}
+
+// searchQueue dispatches searches of the single provider not faster than once per the interval,
+// the pending search of the highest priority is dispatched first when the next token frees up
+// and the earliest one wins among searches of the same priority
+type searchQueue struct {
+	mx       sync.Mutex
+	interval time.Duration
+	aging    time.Duration
+	next     time.Time
+	seq      uint64
+	pending  []*queuedSearch
+	timer    *time.Timer
+}
+
+func newSearchQueue(interval time.Duration) *searchQueue {
+	return &searchQueue{interval: interval, aging: searchQueueAging}
+}
+
+// Wait blocks until the search is dispatched or the context is done
+func (q *searchQueue) Wait(ctx context.Context, priority SearchPriority) error {
+	q.mx.Lock()
+	q.seq++
+	search := &queuedSearch{
+		level:    priority.level(),
+		seq:      q.seq,
+		enqueued: time.Now(),
+		ready:    make(chan struct{}),
+	}
+	q.pending = append(q.pending, search)
+	q.schedule()
+	q.mx.Unlock()
+
+	select {
+	case <-search.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	select {
+	case <-search.ready:
+		// the token is already spent on the search, so the caller is let to fail by the context itself
+		return nil
+	default:
+		q.pending = slices.DeleteFunc(q.pending, func(s *queuedSearch) bool { return s == search })
+		return ctx.Err()
+	}
+}
+
+// schedule dispatches pending searches while tokens are free and arms the timer
+// to the time when the next token frees up, it must be called under the lock
+func (q *searchQueue) schedule() {
+	for q.timer == nil && len(q.pending) > 0 {
+		now := time.Now()
+		if wait := q.next.Sub(now); wait > 0 {
+			q.timer = time.AfterFunc(wait, q.dispatch)
+			return
+		}
+
+		search := q.pop(now)
+		close(search.ready)
+		q.next = now.Add(q.interval)
+	}
+}
+
+func (q *searchQueue) dispatch() {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	q.timer = nil
+	q.schedule()
+}
+
+// pop removes the pending search of the highest priority raised by the time of waiting
+func (q *searchQueue) pop(now time.Time) *queuedSearch {
+	effective := func(s *queuedSearch) int {
+		if q.aging <= 0 {
+			return s.level
+		}
+		return s.level + int(now.Sub(s.enqueued)/q.aging)
+	}
+
+	best := 0
+	for i, search := range q.pending[1:] {
+		level, bestLevel := effective(search), effective(q.pending[best])
+		if level > bestLevel || (level == bestLevel && search.seq < q.pending[best].seq) {
+			best = i + 1
+		}
+	}
+
+	search := q.pending[best]
+	q.pending = slices.Delete(q.pending, best, best+1)
+
+	return search
+}
+
+var (
+	searchQueuesMx       sync.Mutex
+	searchQueues         map[database.SearchengineType]*searchQueue
+	searchQueuesInterval time.Duration
+)
+
+// InitSearchRateLimit limits calls of every search provider to rps requests per second
+// shared by all flows, searches over the limit wait in the queue ordered by their priority,
+// non-positive rps turns the limit off
+func InitSearchRateLimit(rps float64) {
+	searchQueuesMx.Lock()
+	defer searchQueuesMx.Unlock()
+
+	searchQueues = make(map[database.SearchengineType]*searchQueue)
+	searchQueuesInterval = 0
+	if rps > 0 {
+		searchQueuesInterval = time.Duration(float64(time.Second) / rps)
+	}
+}
+
+type searchPriorityContextKey struct{}
+
+// withSearchPriority passes the priority of the search down to the requests of the engine,
+// e.g. from the metasearch to the engines it queries
+func withSearchPriority(ctx context.Context, priority SearchPriority) context.Context {
+	return context.WithValue(ctx, searchPriorityContextKey{}, priority)
+}
+
+// searchPriority returns the priority of the search passed by the context, it's normal by default
+func searchPriority(ctx context.Context) SearchPriority {
+	if priority, ok := ctx.Value(searchPriorityContextKey{}).(SearchPriority); ok && priority != "" {
+		return priority
+	}
+	return SearchPriorityNormal
+}
+
+// waitSearchQueue waits for the turn of the search in the queue of the engine if the rate limit is set
+func waitSearchQueue(ctx context.Context, engine database.SearchengineType, priority SearchPriority) error {
+	searchQueuesMx.Lock()
+	if searchQueuesInterval <= 0 {
+		searchQueuesMx.Unlock()
+		return nil
+	}
+	queue, ok := searchQueues[engine]
+	if !ok {
+		queue = newSearchQueue(searchQueuesInterval)
+		searchQueues[engine] = queue
+	}
+	searchQueuesMx.Unlock()
+
+	return queue.Wait(ctx, priority)
+}
@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pentagi/pkg/database"
+)
+
+// waitPending polls the queue until the number of pending searches is reached
+func waitPending(t *testing.T, q *searchQueue, count int) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		q.mx.Lock()
+		pending := len(q.pending)
+		q.mx.Unlock()
+		if pending == count {
+			return
+		}
+	}
+
+	t.Fatalf("expected %d pending searches", count)
+}
+
+func TestSearchQueuePriority(t *testing.T) {
+	q := newSearchQueue(time.Second)
+
+	// the first search takes the free token right away
+	start := time.Now()
+	if err := q.Wait(context.Background(), SearchPriorityNormal); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("expected the first search to be dispatched right away, waited %v", elapsed)
+	}
+
+	dispatched := make(chan SearchPriority, 2)
+	wait := func(priority SearchPriority) {
+		if err := q.Wait(context.Background(), priority); err != nil {
+			t.Errorf("Wait() error = %v", err)
+		}
+		dispatched <- priority
+	}
+
+	go wait(SearchPriorityLow)
+	waitPending(t, q, 1)
+	go wait(SearchPriorityHigh)
+	waitPending(t, q, 2)
+
+	for _, want := range []SearchPriority{SearchPriorityHigh, SearchPriorityLow} {
+		select {
+		case got := <-dispatched:
+			if got != want {
+				t.Errorf("dispatched %q search, want %q", got, want)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("%q search wasn't dispatched", want)
+		}
+	}
+
+	// the rate is kept, the second and the third searches are dispatched a second apart
+	if elapsed := time.Since(start); elapsed < 2*time.Second-50*time.Millisecond {
+		t.Errorf("expected 1 rps, 3 searches took %v", elapsed)
+	}
+}
+
+func TestSearchQueueAging(t *testing.T) {
+	q := newSearchQueue(time.Second)
+	now := time.Now()
+
+	q.pending = []*queuedSearch{
+		{level: SearchPriorityHigh.level(), seq: 2, enqueued: now},
+		{level: SearchPriorityLow.level(), seq: 1, enqueued: now.Add(-3 * searchQueueAging)},
+		{level: SearchPriorityHigh.level(), seq: 3, enqueued: now},
+	}
+
+	// the low priority search waited long enough to go ahead of the fresh high priority ones
+	for _, want := range []uint64{1, 2, 3} {
+		if got := q.pop(now); got.seq != want {
+			t.Errorf("pop() = search %d, want %d", got.seq, want)
+		}
+	}
+}
+
+func TestSearchQueueCancel(t *testing.T) {
+	q := newSearchQueue(time.Hour)
+	if err := q.Wait(context.Background(), SearchPriorityNormal); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := q.Wait(ctx, SearchPriorityHigh); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+	if len(q.pending) != 0 {
+		t.Errorf("expected cancelled search to leave the queue, got %d pending", len(q.pending))
+	}
+}
+
+func TestWaitSearchQueueDisabled(t *testing.T) {
+	InitSearchRateLimit(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// without the limit searches never wait, even with the done context
+	if err := waitSearchQueue(ctx, database.SearchengineTypeTavily, SearchPriorityLow); err != nil {
+		t.Errorf("waitSearchQueue() error = %v", err)
+	}
+}
+
+func TestSearchResultsWaitQueue(t *testing.T) {
+	// the second search waits an hour for its turn, so it's cut by the deadline
+	InitSearchRateLimit(1.0 / 3600)
+	defer InitSearchRateLimit(0)
+
+	tool := NewFakeTavilyTool("answer").(*tavily)
+	if _, err := tool.searchResults(context.Background(), "log4shell", 5); err != nil {
+		t.Fatalf("searchResults() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := tool.searchResults(ctx, "log4shell", 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the metasearch path to wait in the queue, got %v", err)
+	}
+}
+
+func TestSearchPriorityContext(t *testing.T) {
+	if got := searchPriority(context.Background()); got != SearchPriorityNormal {
+		t.Errorf("expected normal priority by default, got %q", got)
+	}
+	if got := searchPriority(withSearchPriority(context.Background(), "")); got != SearchPriorityNormal {
+		t.Errorf("expected normal priority for the empty one, got %q", got)
+	}
+	if got := searchPriority(withSearchPriority(context.Background(), SearchPriorityHigh)); got != SearchPriorityHigh {
+		t.Errorf("expected the passed priority, got %q", got)
+	}
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resetEpochThreshold separates reset headers with the unix time from ones with seconds left,
+// providers use both formats and no quota window is longer than the years the threshold gives
+const resetEpochThreshold = 1_000_000_000
+
+// QuotaReporter is implemented by tools of providers which report the request quota in response headers,
+// it lets operators notice the quota running out before the provider starts to reject requests
+type QuotaReporter interface {
+	// LastQuota returns the quota from the last response, ok is false if the provider didn't report it
+	LastQuota() (remaining int, reset time.Time, ok bool)
+}
+
+// quotaTracker keeps the latest quota parsed from the rate limit headers, it's best-effort,
+// so responses without the headers don't drop the quota known before
+type quotaTracker struct {
+	mx        sync.Mutex
+	remaining int
+	reset     time.Time
+	known     bool
+}
+
+// update parses X-RateLimit-Remaining and X-RateLimit-Reset headers or their RateLimit-* variants
+func (q *quotaTracker) update(header http.Header) {
+	remaining, ok := parseQuotaRemaining(firstHeader(header, "X-RateLimit-Remaining", "RateLimit-Remaining"))
+	if !ok {
+		return
+	}
+	reset, _ := parseQuotaReset(firstHeader(header, "X-RateLimit-Reset", "RateLimit-Reset"), time.Now())
+
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	q.remaining, q.reset, q.known = remaining, reset, true
+}
+
+func (q *quotaTracker) LastQuota() (int, time.Time, bool) {
+	q.mx.Lock()
+	defer q.mx.Unlock()
+
+	return q.remaining, q.reset, q.known
+}
+
+func firstHeader(header http.Header, names ...string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(header.Get(name)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseQuotaRemaining takes the first value because some providers list the quota of every window
+func parseQuotaRemaining(value string) (int, bool) {
+	value, _, _ = strings.Cut(value, ",")
+	remaining, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || remaining < 0 {
+		return 0, false
+	}
+	return remaining, true
+}
+
+// parseQuotaReset supports the unix time, the seconds left and the HTTP date formats of the header
+func parseQuotaReset(value string, now time.Time) (time.Time, bool) {
+	value, _, _ = strings.Cut(value, ",")
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		if seconds >= resetEpochThreshold {
+			return time.Unix(int64(seconds), 0), true
+		}
+		return now.Add(time.Duration(seconds * float64(time.Second))), true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return date, true
+	}
+
+	return time.Time{}, false
+}
+
+// quotaEvent reports the known quota of the provider, the exhausted one is a warning
+func quotaEvent(engine string, reporter QuotaReporter) (TraceEvent, bool) {
+	remaining, reset, ok := reporter.LastQuota()
+	if !ok {
+		return TraceEvent{}, false
+	}
+
+	metadata := map[string]any{
+		"engine":    engine,
+		"remaining": remaining,
+	}
+	if !reset.IsZero() {
+		metadata["reset"] = reset.UTC().Format(time.RFC3339)
+	}
+
+	level := TraceLevelDefault
+	if remaining == 0 {
+		level = TraceLevelWarning
+	}
+
+	return TraceEvent{
+		Name:     "search engine quota",
+		Input:    engine,
+		Status:   strconv.Itoa(remaining) + " requests remaining",
+		Level:    level,
+		Metadata: metadata,
+	}, true
+}
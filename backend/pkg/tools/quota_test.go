@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuotaTrackerUpdate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name          string
+		headers       map[string]string
+		wantRemaining int
+		wantReset     time.Time
+		wantOK        bool
+	}{
+		{
+			name:          "epoch reset",
+			headers:       map[string]string{"X-RateLimit-Remaining": "42", "X-RateLimit-Reset": "1893456000"},
+			wantRemaining: 42,
+			wantReset:     time.Unix(1893456000, 0),
+			wantOK:        true,
+		},
+		{
+			name:          "seconds left",
+			headers:       map[string]string{"RateLimit-Remaining": "7", "RateLimit-Reset": "60"},
+			wantRemaining: 7,
+			wantReset:     now.Add(time.Minute),
+			wantOK:        true,
+		},
+		{
+			name:          "several windows",
+			headers:       map[string]string{"X-RateLimit-Remaining": "0, 950"},
+			wantRemaining: 0,
+			wantOK:        true,
+		},
+		{
+			name:    "malformed",
+			headers: map[string]string{"X-RateLimit-Remaining": "unknown", "X-RateLimit-Reset": "60"},
+		},
+		{
+			name: "absent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for name, value := range tt.headers {
+				header.Set(name, value)
+			}
+
+			var q quotaTracker
+			q.update(header)
+
+			remaining, reset, ok := q.LastQuota()
+			if ok != tt.wantOK || remaining != tt.wantRemaining {
+				t.Fatalf("LastQuota() = %d, %v, want %d, %v", remaining, ok, tt.wantRemaining, tt.wantOK)
+			}
+			if diff := reset.Sub(tt.wantReset); diff < -time.Second || diff > time.Second {
+				t.Errorf("expected reset %v, got %v", tt.wantReset, reset)
+			}
+		})
+	}
+}
+
+func TestQuotaTrackerKeepsLastKnown(t *testing.T) {
+	var q quotaTracker
+	q.update(http.Header{"X-Ratelimit-Remaining": {"10"}})
+	q.update(http.Header{})
+
+	if remaining, _, ok := q.LastQuota(); !ok || remaining != 10 {
+		t.Errorf("expected quota to survive response without headers, got %d, %v", remaining, ok)
+	}
+
+	if _, ok := quotaEvent("hibp", &q); !ok {
+		t.Error("expected quota event for the known quota")
+	}
+	if event, _ := quotaEvent("hibp", &quotaTracker{known: true}); event.Level != TraceLevelWarning {
+		t.Errorf("expected warning for exhausted quota, got %v", event.Level)
+	}
+}
+
+func newQuotaServer(t *testing.T, remaining, body string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", remaining)
+		w.Header().Set("X-RateLimit-Reset", "30")
+		w.Write([]byte(body))
+	}))
+}
+
+func TestHIBPLastQuota(t *testing.T) {
+	server := newQuotaServer(t, "9", `[]`)
+	defer server.Close()
+
+	h := &hibp{apiKey: "key", apiURL: server.URL, tracer: NewNoopTracer()}
+	if _, _, ok := h.LastQuota(); ok {
+		t.Error("expected unknown quota before the first request")
+	}
+
+	args, _ := json.Marshal(HIBPAction{Account: "example.com"})
+	if _, err := h.Handle(context.Background(), HIBPToolName, args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remaining, reset, ok := h.LastQuota()
+	if !ok || remaining != 9 || time.Until(reset) <= 0 {
+		t.Errorf("expected parsed quota, got %d, %v, %v", remaining, reset, ok)
+	}
+}
+
+func TestTavilyLastQuota(t *testing.T) {
+	server := newQuotaServer(t, "120", `{"answer":"answer","results":[]}`)
+	defer server.Close()
+
+	tool := &tavily{apiKey: "test-key", apiURL: server.URL}
+	if _, _, err := tool.search(context.Background(), "query", 5); err != nil {
+		t.Fatalf("search() error = %v", err)
+	}
+
+	var reporter QuotaReporter = tool
+	if remaining, _, ok := reporter.LastQuota(); !ok || remaining != 120 {
+		t.Errorf("expected parsed quota, got %d, %v", remaining, ok)
+	}
+}
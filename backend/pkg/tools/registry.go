@@ -1,8 +1,12 @@
 package tools
 
 import (
+	"errors"
+	"fmt"
 	"maps"
 	"pentagi/pkg/database"
+	"strings"
+	"sync"
 
 	"github.com/invopop/jsonschema"
 	"github.com/vxcontrol/langchaingo/llms"
@@ -21,12 +25,23 @@ const (
 	MemoristToolName          = "memorist"
 	MemoristResultToolName    = "memorist_result"
 	BrowserToolName           = "browser"
+	HTTPFetchToolName         = "httpfetch"
 	GoogleToolName            = "google"
 	DuckDuckGoToolName        = "duckduckgo"
 	TavilyToolName            = "tavily"
 	TraversaalToolName        = "traversaal"
 	PerplexityToolName        = "perplexity"
 	SearxngToolName           = "searxng"
+	GithubToolName            = "github"
+	MetasearchToolName        = "metasearch"
+	AbuseIPDBToolName         = "abuseipdb"
+	HIBPToolName              = "hibp"
+	WhoisToolName             = "whois"
+	DNSToolName               = "dns"
+	WaybackToolName           = "wayback"
+	AttackToolName            = "attack"
+	IPInfoToolName            = "ipinfo"
+	ExploitDBToolName         = "exploitdb"
 	SearchToolName            = "search"
 	SearchResultToolName      = "search_result"
 	EnricherResultToolName    = "enricher_result"
@@ -41,6 +56,7 @@ const (
 	ReportResultToolName      = "report_result"
 	SubtaskListToolName       = "subtask_list"
 	SubtaskPatchToolName      = "subtask_patch"
+	ResultPageToolName        = "result_page"
 	TerminalToolName          = "terminal"
 	FileToolName              = "file"
 )
@@ -100,12 +116,23 @@ var toolsTypeMapping = map[string]ToolType{
 	MemoristToolName:          AgentToolType,
 	MemoristResultToolName:    StoreAgentResultToolType,
 	BrowserToolName:           SearchNetworkToolType,
+	HTTPFetchToolName:         SearchNetworkToolType,
 	GoogleToolName:            SearchNetworkToolType,
 	DuckDuckGoToolName:        SearchNetworkToolType,
 	TavilyToolName:            SearchNetworkToolType,
 	TraversaalToolName:        SearchNetworkToolType,
 	PerplexityToolName:        SearchNetworkToolType,
 	SearxngToolName:           SearchNetworkToolType,
+	GithubToolName:            SearchNetworkToolType,
+	MetasearchToolName:        SearchNetworkToolType,
+	AbuseIPDBToolName:         SearchNetworkToolType,
+	HIBPToolName:              SearchNetworkToolType,
+	WhoisToolName:             SearchNetworkToolType,
+	DNSToolName:               SearchNetworkToolType,
+	WaybackToolName:           SearchNetworkToolType,
+	AttackToolName:            SearchNetworkToolType,
+	IPInfoToolName:            SearchNetworkToolType,
+	ExploitDBToolName:         SearchNetworkToolType,
 	SearchToolName:            AgentToolType,
 	SearchResultToolName:      StoreAgentResultToolType,
 	EnricherResultToolName:    StoreAgentResultToolType,
@@ -122,6 +149,7 @@ var toolsTypeMapping = map[string]ToolType{
 	SubtaskPatchToolName:      StoreAgentResultToolType,
 	TerminalToolName:          EnvironmentToolType,
 	FileToolName:              EnvironmentToolType,
+	ResultPageToolName:        EnvironmentToolType,
 }
 
 var reflector = &jsonschema.Reflector{
@@ -144,6 +172,16 @@ var allowedStoringInMemoryTools = []string{
 	TraversaalToolName,
 	PerplexityToolName,
 	SearxngToolName,
+	GithubToolName,
+	MetasearchToolName,
+	AbuseIPDBToolName,
+	HIBPToolName,
+	WhoisToolName,
+	DNSToolName,
+	WaybackToolName,
+	AttackToolName,
+	IPInfoToolName,
+	ExploitDBToolName,
 	MaintenanceToolName,
 	CoderToolName,
 	PentesterToolName,
@@ -179,6 +217,12 @@ var registryDefinitions = map[string]llms.FunctionDefinition{
 			"and reorder (move to different position) operations. Use empty operations array if no changes needed.",
 		Parameters: reflector.Reflect(&SubtaskPatch{}),
 	},
+	ResultPageToolName: {
+		Name: ResultPageToolName,
+		Description: "Get the next page of the long tool result which was cut into pages, " +
+			"use the token from the end of the previous page to continue reading the result",
+		Parameters: reflector.Reflect(&ResultPageAction{}),
+	},
 	SearchToolName: {
 		Name: SearchToolName,
 		Description: "Search in a different search engines in the internet and long-term memory " +
@@ -197,11 +241,17 @@ var registryDefinitions = map[string]llms.FunctionDefinition{
 		Description: "Opens a browser to look for additional information from the web site",
 		Parameters:  reflector.Reflect(&Browser{}),
 	},
+	HTTPFetchToolName: {
+		Name: HTTPFetchToolName,
+		Description: "Send the raw HTTP request with any method, headers and body to probe APIs and webhooks, " +
+			"returns status, headers and body of the response as is without rendering the page",
+		Parameters: reflector.Reflect(&HTTPFetchAction{}),
+	},
 	GoogleToolName: {
 		Name: GoogleToolName,
 		Description: "Search in the google search engine, it's a fast query and the shortest content " +
 			"to check some information or collect public links by short query",
-		Parameters: reflector.Reflect(&SearchAction{}),
+		Parameters: reflector.Reflect(&GoogleSearchAction{}),
 	},
 	DuckDuckGoToolName: {
 		Name: DuckDuckGoToolName,
@@ -224,8 +274,9 @@ var registryDefinitions = map[string]llms.FunctionDefinition{
 	PerplexityToolName: {
 		Name: PerplexityToolName,
 		Description: "Search in the perplexity search engine, it's a fully complex query and detailed research report " +
-			"with answer by query and detailed information from the web sites and other sources augmented by the LLM",
-		Parameters: reflector.Reflect(&SearchAction{}),
+			"with answer by query and detailed information from the web sites and other sources augmented by the LLM, " +
+			"pass previous questions and answers as history to ask the follow-up question",
+		Parameters: reflector.Reflect(&PerplexitySearchAction{}),
 	},
 	SearxngToolName: {
 		Name: SearxngToolName,
@@ -234,6 +285,66 @@ var registryDefinitions = map[string]llms.FunctionDefinition{
 			"language settings, and safety filters",
 		Parameters: reflector.Reflect(&SearchAction{}),
 	},
+	GithubToolName: {
+		Name: GithubToolName,
+		Description: "Search in github for exploit PoCs and tools in source code and repositories " +
+			"or for security advisories by CVE identifier or affected package name",
+		Parameters: reflector.Reflect(&GithubSearchAction{}),
+	},
+	MetasearchToolName: {
+		Name: MetasearchToolName,
+		Description: "Search in all available web search engines at once and get their results merged " +
+			"and deduplicated by URL, use it when any engine fits and the broad coverage is needed",
+		Parameters: reflector.Reflect(&SearchAction{}),
+	},
+	AbuseIPDBToolName: {
+		Name: AbuseIPDBToolName,
+		Description: "Check the reputation of the IP address in AbuseIPDB to get its abuse confidence score, " +
+			"number of reports, country, ISP and usage type, use it to triage suspicious traffic or target infrastructure",
+		Parameters: reflector.Reflect(&AbuseIPDBAction{}),
+	},
+	HIBPToolName: {
+		Name: HIBPToolName,
+		Description: "Check the email address or the domain in Have I Been Pwned to get the breaches it was exposed in " +
+			"with their dates and leaked data classes, use it to assess the exposure of credentials of the target",
+		Parameters: reflector.Reflect(&HIBPAction{}),
+	},
+	WhoisToolName: {
+		Name: WhoisToolName,
+		Description: "Look up WHOIS registration data of the domain to get its registrar, registrant organization, " +
+			"creation and expiry dates, name servers and status, use it for OSINT recon of the target domains",
+		Parameters: reflector.Reflect(&WhoisAction{}),
+	},
+	DNSToolName: {
+		Name: DNSToolName,
+		Description: "Resolve A, AAAA, CNAME, MX, NS and TXT records of the domain without running tools in the container, " +
+			"use it for DNS recon of the target domains and their mail and name servers",
+		Parameters: reflector.Reflect(&DNSAction{}),
+	},
+	WaybackToolName: {
+		Name: WaybackToolName,
+		Description: "Look up archived snapshots of the URL in the Internet Archive Wayback Machine and list its recent captures, " +
+			"use it to find removed pages, old versions of the target site and endpoints which are not linked anymore",
+		Parameters: reflector.Reflect(&WaybackAction{}),
+	},
+	AttackToolName: {
+		Name: AttackToolName,
+		Description: "Look up the MITRE ATT&CK technique by its ID (e.g. T1059 or T1003.001) to get its tactics, platforms, " +
+			"detection and mitigations, or search techniques by keywords to map the findings and planned actions to ATT&CK",
+		Parameters: reflector.Reflect(&AttackAction{}),
+	},
+	IPInfoToolName: {
+		Name: IPInfoToolName,
+		Description: "Get the ASN, organization, hostname and geolocation of the IP address, " +
+			"use it to scope the targets and to tell hosting and cloud providers from networks of the target itself",
+		Parameters: reflector.Reflect(&IPInfoAction{}),
+	},
+	ExploitDBToolName: {
+		Name: ExploitDBToolName,
+		Description: "Search the local offline copy of ExploitDB by keywords or CVE code to find public exploits " +
+			"with their EDB-IDs, platforms, types and file paths, optionally filtered by the platform and the type",
+		Parameters: reflector.Reflect(&ExploitDBAction{}),
+	},
 	EnricherResultToolName: {
 		Name:        EnricherResultToolName,
 		Description: "Send the enriched user's question with additional information to the user",
@@ -361,11 +472,12 @@ func getMessageType(name string) database.MsglogType {
 		return database.MsglogTypeTerminal
 	case FileToolName:
 		return database.MsglogTypeFile
-	case BrowserToolName:
+	case BrowserToolName, HTTPFetchToolName:
 		return database.MsglogTypeBrowser
 	case MemoristToolName, SearchToolName, GoogleToolName, DuckDuckGoToolName, TavilyToolName, TraversaalToolName,
-		PerplexityToolName, SearxngToolName, SearchGuideToolName, SearchAnswerToolName, SearchCodeToolName, SearchInMemoryToolName,
-		GraphitiSearchToolName:
+		PerplexityToolName, SearxngToolName, GithubToolName, MetasearchToolName, AbuseIPDBToolName, HIBPToolName, WhoisToolName,
+		DNSToolName, WaybackToolName, AttackToolName, IPInfoToolName, ExploitDBToolName, SearchGuideToolName,
+		SearchAnswerToolName, SearchCodeToolName, SearchInMemoryToolName, GraphitiSearchToolName:
 		return database.MsglogTypeSearch
 	case AdviceToolName:
 		return database.MsglogTypeAdvice
@@ -413,3 +525,77 @@ func GetToolsByType() map[ToolType][]string {
 
 	return result
 }
+
+var (
+	disabledToolsMx sync.RWMutex
+	disabledTools   = make(map[string]struct{})
+)
+
+// SetEnabled turns the tool on or off at runtime for all flows without the restart, disabled tools
+// are hidden from agents and their calls are rejected, all tools are enabled by default,
+// only optional search tools can be turned off, see checkDisable
+func SetEnabled(name string, enabled bool) error {
+	if !enabled {
+		if err := checkDisable(name); err != nil {
+			return err
+		}
+	}
+
+	disabledToolsMx.Lock()
+	defer disabledToolsMx.Unlock()
+
+	if enabled {
+		delete(disabledTools, name)
+	} else {
+		disabledTools[name] = struct{}{}
+	}
+
+	return nil
+}
+
+// InitDisabledTools turns off the tools listed by the operator and turns the rest back on,
+// unknown and required tools are reported by the error while the rest listed ones are still turned off
+func InitDisabledTools(names []string) error {
+	disabled := make(map[string]struct{}, len(names))
+	var errs []error
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if err := checkDisable(name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		disabled[name] = struct{}{}
+	}
+
+	disabledToolsMx.Lock()
+	defer disabledToolsMx.Unlock()
+
+	disabledTools = disabled
+
+	return errors.Join(errs...)
+}
+
+// checkDisable allows to turn off only the search tools, barriers, agents, results of agents
+// and environment tools are required by flows, e.g. without the done barrier flows never finish
+func checkDisable(name string) error {
+	switch toolType, ok := toolsTypeMapping[name]; {
+	case !ok:
+		return fmt.Errorf("unknown tool %s", name)
+	case toolType != SearchNetworkToolType && toolType != SearchVectorDbToolType:
+		return fmt.Errorf("tool %s of type %s can't be disabled", name, toolType)
+	}
+
+	return nil
+}
+
+// IsEnabled reports whether the tool isn't turned off at runtime
+func IsEnabled(name string) bool {
+	disabledToolsMx.RLock()
+	defer disabledToolsMx.RUnlock()
+
+	_, disabled := disabledTools[name]
+	return !disabled
+}
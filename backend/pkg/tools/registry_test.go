@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vxcontrol/langchaingo/llms"
+)
+
+func TestSetEnabled(t *testing.T) {
+	t.Cleanup(func() { SetEnabled(DNSToolName, true) })
+
+	called := 0
+	ce := &customExecutor{
+		definitions: []llms.FunctionDefinition{registryDefinitions[DNSToolName], registryDefinitions[WhoisToolName]},
+		handlers: map[string]ExecutorHandler{
+			DNSToolName: func(ctx context.Context, name string, args json.RawMessage) (string, error) {
+				called++
+				return "resolved", nil
+			},
+		},
+	}
+	tool := &dns{enabled: true}
+	args := json.RawMessage(`{"domain":"example.com"}`)
+
+	if !IsEnabled(DNSToolName) || !tool.IsAvailable() || len(ce.Tools()) != 2 {
+		t.Fatal("expected the tool to be enabled by default")
+	}
+
+	SetEnabled(DNSToolName, false)
+
+	if IsEnabled(DNSToolName) || tool.IsAvailable() {
+		t.Error("expected the tool to be unavailable while disabled")
+	}
+	if !IsEnabled(WhoisToolName) {
+		t.Error("expected other tools to stay enabled")
+	}
+	if tools := ce.Tools(); len(tools) != 1 || tools[0].Function.Name != WhoisToolName {
+		t.Errorf("expected disabled tool to be hidden, got %d tools", len(tools))
+	}
+
+	result, err := ce.Execute(context.Background(), 0, "call-1", DNSToolName, "", args)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if called != 0 || !strings.Contains(result, "function 'dns' is disabled") {
+		t.Errorf("expected the call of disabled tool to be rejected, got %q", result)
+	}
+
+	SetEnabled(DNSToolName, true)
+
+	if !tool.IsAvailable() || len(ce.Tools()) != 2 {
+		t.Error("expected the tool to be available after enabling it back")
+	}
+}
+
+func TestMetasearchSkipsDisabledEngines(t *testing.T) {
+	t.Cleanup(func() {
+		SetEnabled(GoogleToolName, true)
+		SetEnabled(TavilyToolName, true)
+	})
+
+	m := &metasearch{
+		engines: newMetasearchEngines(map[string]Tool{
+			GoogleToolName: &fakeResultsSearcher{available: true, items: []SearchResultItem{
+				{Title: "Google 1", URL: "https://google.example.com/1", Snippet: "google"},
+			}},
+			TavilyToolName: &fakeResultsSearcher{available: true, items: []SearchResultItem{
+				{Title: "Tavily 1", URL: "https://tavily.example.com/1", Snippet: "tavily"},
+			}},
+		}),
+		timeout: time.Second,
+	}
+
+	SetEnabled(GoogleToolName, false)
+	result, count, err := m.search(context.Background(), "nmap", 5, false)
+	if err != nil {
+		t.Fatalf("search() error = %v", err)
+	}
+	if count != 1 || strings.Contains(result, "Google 1") {
+		t.Errorf("expected results of the enabled engine only, got:\n%s", result)
+	}
+
+	SetEnabled(TavilyToolName, false)
+	if _, _, err := m.search(context.Background(), "nmap", 5, false); err == nil {
+		t.Error("expected error when all engines are disabled")
+	}
+}
+
+func TestInitDisabledTools(t *testing.T) {
+	t.Cleanup(func() { InitDisabledTools(nil) })
+
+	SetEnabled(WhoisToolName, false)
+	err := InitDisabledTools([]string{DNSToolName, " " + GoogleToolName, "", "unknown"})
+	if err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("expected error about the unknown tool, got %v", err)
+	}
+	if IsEnabled(DNSToolName) || IsEnabled(GoogleToolName) {
+		t.Error("expected the listed tools to be disabled")
+	}
+	if !IsEnabled(WhoisToolName) {
+		t.Error("expected the tools which aren't listed to be enabled back")
+	}
+
+	if err := InitDisabledTools(nil); err != nil {
+		t.Fatalf("InitDisabledTools() error = %v", err)
+	}
+	if !IsEnabled(DNSToolName) || !IsEnabled(GoogleToolName) {
+		t.Error("expected all tools to be enabled with the empty list")
+	}
+}
+
+func TestDisableRequiredTools(t *testing.T) {
+	t.Cleanup(func() { InitDisabledTools(nil) })
+
+	required := []string{FinalyToolName, AskUserToolName, HackResultToolName, PentesterToolName, TerminalToolName, FileToolName}
+	for _, name := range required {
+		if err := SetEnabled(name, false); err == nil || !strings.Contains(err.Error(), "can't be disabled") {
+			t.Errorf("SetEnabled(%s, false): expected error, got %v", name, err)
+		}
+		if !IsEnabled(name) {
+			t.Errorf("expected %s to stay enabled", name)
+		}
+	}
+
+	err := InitDisabledTools(append([]string{GoogleToolName}, required...))
+	if err == nil || !strings.Contains(err.Error(), "tool done of type barrier can't be disabled") {
+		t.Errorf("expected error about the barrier, got %v", err)
+	}
+	for _, name := range required {
+		if !IsEnabled(name) {
+			t.Errorf("expected %s to stay enabled", name)
+		}
+	}
+	if IsEnabled(GoogleToolName) {
+		t.Error("expected the search tool to be disabled along with the rejected ones")
+	}
+	if err := SetEnabled(SearchInMemoryToolName, false); err != nil {
+		t.Errorf("expected the vector store search to be disabled, got %v", err)
+	}
+}
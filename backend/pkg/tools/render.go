@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// renderHexDumpSize is the size of binary content prefix shown to the agent
+const renderHexDumpSize = 64
+
+var (
+	htmlTitlePattern  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlFormPattern   = regexp.MustCompile(`(?i)<form[\s>]`)
+	htmlInputPattern  = regexp.MustCompile(`(?i)<input[\s>/]`)
+	htmlLinkPattern   = regexp.MustCompile(`(?i)<a\s[^>]*href=`)
+	htmlScriptPattern = regexp.MustCompile(`(?i)<script[\s>]`)
+)
+
+// renderBody makes fetched content readable for the agent: JSON is pretty-printed,
+// HTML gets the short summary before the markup, binary content is replaced by its size
+// and hex dump of the prefix, and any other text is passed through as is
+func renderBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	switch {
+	case strings.Contains(mediaType, "json"):
+		return renderJSON(body)
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return renderHTML(body)
+	case mediaType == "text/plain" && json.Valid(body):
+		// APIs often send JSON without the proper content type
+		return renderJSON(body)
+	case isTextMediaType(mediaType) || (utf8.Valid(body) && bytes.IndexByte(body, 0) == -1):
+		return string(body)
+	default:
+		return renderBinary(mediaType, body)
+	}
+}
+
+func isTextMediaType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.Contains(mediaType, "xml") ||
+		strings.Contains(mediaType, "javascript") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// renderJSON indents valid JSON and keeps the invalid one as is to not hide the server output
+func renderJSON(body []byte) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(body), "", "  "); err != nil {
+		return string(body)
+	}
+
+	return buf.String()
+}
+
+func renderHTML(body []byte) string {
+	summary := []string{fmt.Sprintf("%d bytes", len(body))}
+	for _, counter := range []struct {
+		name    string
+		pattern *regexp.Regexp
+	}{
+		{"forms", htmlFormPattern},
+		{"inputs", htmlInputPattern},
+		{"links", htmlLinkPattern},
+		{"scripts", htmlScriptPattern},
+	} {
+		if count := len(counter.pattern.FindAllIndex(body, -1)); count > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", count, counter.name))
+		}
+	}
+
+	title := "HTML document"
+	if match := htmlTitlePattern.FindSubmatch(body); match != nil {
+		if text := strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " "); text != "" {
+			title = fmt.Sprintf("HTML document %q", text)
+		}
+	}
+
+	return fmt.Sprintf("[%s: %s]\n\n%s", title, strings.Join(summary, ", "), body)
+}
+
+func renderBinary(mediaType string, body []byte) string {
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+
+	prefix := body[:min(len(body), renderHexDumpSize)]
+	return fmt.Sprintf("[binary content: %s, %d bytes, first %d bytes are shown]\n\n%s",
+		mediaType, len(body), len(prefix), hex.Dump(prefix))
+}
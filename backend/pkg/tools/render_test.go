@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderBody(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+		contains    []string
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"user":{"id":1,"roles":["admin"]}}`,
+			want:        "{\n  \"user\": {\n    \"id\": 1,\n    \"roles\": [\n      \"admin\"\n    ]\n  }\n}",
+		},
+		{
+			name:        "json with vendor content type",
+			contentType: "application/vnd.api+json",
+			body:        `[1,2]`,
+			want:        "[\n  1,\n  2\n]",
+		},
+		{
+			name:        "json without content type",
+			contentType: "",
+			body:        ` {"ok":true} `,
+			want:        "{\n  \"ok\": true\n}",
+		},
+		{
+			name:        "invalid json",
+			contentType: "application/json",
+			body:        `{"truncated":`,
+			want:        `{"truncated":`,
+		},
+		{
+			name:        "html",
+			contentType: "text/html",
+			body: `<html><head><title>Admin &amp; Login</title></head><body>` +
+				`<form action="/login"><input name="user"><input type="password" name="pass"></form>` +
+				`<a href="/reset">reset</a><script src="/app.js"></script></body></html>`,
+			contains: []string{
+				`[HTML document "Admin & Login": 211 bytes, 1 forms, 2 inputs, 1 links, 1 scripts]` + "\n\n<html>",
+				`<input type="password" name="pass">`,
+			},
+		},
+		{
+			name:        "html without title",
+			contentType: "",
+			body:        "<!DOCTYPE html><html><body>It works!</body></html>",
+			want:        "[HTML document: 50 bytes]\n\n<!DOCTYPE html><html><body>It works!</body></html>",
+		},
+		{
+			name:        "plain text",
+			contentType: "text/plain",
+			body:        "User-agent: *\nDisallow: /admin\n",
+			want:        "User-agent: *\nDisallow: /admin\n",
+		},
+		{
+			name:        "text with unknown content type",
+			contentType: "application/x-custom",
+			body:        "key=value",
+			want:        "key=value",
+		},
+		{
+			name:        "binary png",
+			contentType: "image/png",
+			body:        string(png),
+			contains: []string{
+				"[binary content: image/png, 33 bytes, first 33 bytes are shown]\n\n",
+				"00000000  89 50 4e 47 0d 0a 1a 0a  00 00 00 0d 49 48 44 52  |.PNG........IHDR|\n",
+			},
+		},
+		{
+			name:        "binary png without content type",
+			contentType: "application/octet-stream",
+			body:        string(png),
+			contains:    []string{"[binary content: image/png, 33 bytes"},
+		},
+		{
+			name:        "empty",
+			contentType: "application/json",
+			body:        "",
+			want:        "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderBody(tt.contentType, []byte(tt.body))
+			if tt.contains == nil && got != tt.want {
+				t.Errorf("renderBody() = %q, want %q", got, tt.want)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("expected rendered body to contain %q, got:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderBodyLargeBinary(t *testing.T) {
+	body := make([]byte, 1<<20)
+	got := renderBody("application/zip", body)
+
+	if !strings.HasPrefix(got, "[binary content: application/zip, 1048576 bytes, first 64 bytes are shown]") {
+		t.Errorf("unexpected binary summary %q", got[:min(len(got), 100)])
+	}
+	if len(got) > 1024 {
+		t.Errorf("expected short rendering of binary content, got %d bytes", len(got))
+	}
+}
@@ -0,0 +1,273 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSnippetTitle = "Snippet"
+	minHighlightTermLen = 2
+)
+
+var (
+	// highlightProtectedPattern matches markdown which must stay intact: code spans, bold text,
+	// link targets and bare URLs, terms inside them aren't highlighted
+	highlightProtectedPattern = regexp.MustCompile("`[^`]*`|\\*\\*[^*]+\\*\\*|\\]\\([^)]*\\)|https?://\\S+")
+	highlightOperatorPattern  = regexp.MustCompile(`^[a-zA-Z]+:`)
+	highlightStopWords        = map[string]struct{}{
+		"an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "by": {}, "for": {}, "from": {}, "how": {}, "in": {},
+		"is": {}, "of": {}, "on": {}, "or": {}, "the": {}, "to": {}, "what": {}, "with": {},
+	}
+)
+
+// SearchResultItem is an engine-agnostic search result which tools convert their provider responses to
+type SearchResultItem struct {
+	Title     string  `json:"title"`
+	URL       string  `json:"url"`
+	Snippet   string  `json:"snippet"`
+	Source    string  `json:"source,omitempty"`
+	Published string  `json:"published,omitempty"`
+	Score     float64 `json:"score,omitempty"`
+}
+
+// FormatOptions customizes markdown rendering of search results
+type FormatOptions struct {
+	// SnippetTitle is a heading of the snippet section, "Snippet" by default
+	SnippetTitle string
+	// Separator puts horizontal rule between results
+	Separator bool
+	// Highlight is the query whose terms are bolded in snippets, empty disables highlighting
+	Highlight string
+	// Template replaces the built-in markdown when it's set, Engine is passed to it
+	Template *ResultTemplate
+	Engine   string
+}
+
+// ResultTemplateData is the data which the result template is executed with
+type ResultTemplateData struct {
+	Engine  string
+	Results []SearchResultItem
+}
+
+// ResultTemplate renders search results by the text/template supplied by the operator
+type ResultTemplate struct {
+	tmpl *template.Template
+	hash string
+}
+
+// NewResultTemplate parses the template and executes it with sample results, so unknown fields
+// and functions are reported right away instead of at the first search
+func NewResultTemplate(text string) (*ResultTemplate, error) {
+	tmpl, err := template.New("results").Funcs(template.FuncMap{
+		"inc": func(i int) int {
+			return i + 1
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse result template: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(text))
+	rt := &ResultTemplate{tmpl: tmpl, hash: hex.EncodeToString(sum[:8])}
+	if _, err := rt.Render("Sample", []SearchResultItem{{
+		Title:     "Sample",
+		URL:       "https://example.com",
+		Snippet:   "sample snippet",
+		Source:    "example.com",
+		Published: "2024-01-02",
+		Score:     1,
+	}}); err != nil {
+		return nil, err
+	}
+
+	return rt, nil
+}
+
+// LoadResultTemplate reads the template from the file, empty path means the built-in formatting
+func LoadResultTemplate(path string) (*ResultTemplate, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read result template: %w", err)
+	}
+
+	return NewResultTemplate(string(text))
+}
+
+// Render executes the template with the results of the engine
+func (rt *ResultTemplate) Render(engine string, results []SearchResultItem) (string, error) {
+	var buf bytes.Buffer
+	if err := rt.tmpl.Execute(&buf, ResultTemplateData{Engine: engine, Results: results}); err != nil {
+		return "", fmt.Errorf("failed to execute result template: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// cacheKey tells apart results cached with different templates, it's empty for the built-in formatting
+func (rt *ResultTemplate) cacheKey() string {
+	if rt == nil {
+		return ""
+	}
+	return rt.hash
+}
+
+// withSourceFooter appends the line naming the engine which produced the result,
+// so the agent and reviewers can tell apart results of different engines
+func withSourceFooter(result, engine string, enabled bool) string {
+	if !enabled || result == "" {
+		return result
+	}
+
+	return strings.TrimRight(result, "\n") + "\n\n_Source: " + engine + "_\n"
+}
+
+// FormatResults renders search results as markdown document with a section per result,
+// optional fields are rendered only when they are set
+func FormatResults(results []SearchResultItem, opts FormatOptions) string {
+	if opts.Highlight != "" {
+		highlighted := make([]SearchResultItem, len(results))
+		for i, result := range results {
+			result.Snippet = highlightTerms(result.Snippet, opts.Highlight)
+			highlighted[i] = result
+		}
+		results = highlighted
+	}
+
+	if opts.Template != nil {
+		result, err := opts.Template.Render(opts.Engine, results)
+		if err == nil {
+			return result
+		}
+		// the template was checked on load, so it fails only on unusual data
+		logrus.WithError(err).WithField("engine", opts.Engine).
+			Warn("failed to render search results by the template, using the built-in formatting")
+	}
+
+	snippetTitle := opts.SnippetTitle
+	if snippetTitle == "" {
+		snippetTitle = defaultSnippetTitle
+	}
+
+	var builder strings.Builder
+	for i, result := range results {
+		builder.WriteString(fmt.Sprintf("# %d. %s\n\n", i+1, result.Title))
+		builder.WriteString(fmt.Sprintf("## URL\n%s\n\n", result.URL))
+
+		if result.Source != "" {
+			builder.WriteString(fmt.Sprintf("## Source\n%s\n\n", result.Source))
+		}
+		if result.Published != "" {
+			builder.WriteString(fmt.Sprintf("## Published\n%s\n\n", result.Published))
+		}
+		if result.Score != 0 {
+			builder.WriteString(fmt.Sprintf("## Score\n%.3f\n\n", result.Score))
+		}
+
+		builder.WriteString(fmt.Sprintf("## %s\n\n%s\n\n", snippetTitle, result.Snippet))
+
+		if opts.Separator && i < len(results)-1 {
+			builder.WriteString("---\n\n")
+		}
+	}
+
+	return builder.String()
+}
+
+// highlightTerms wraps case-insensitive matches of the query terms in the text with **,
+// overlapping and adjacent matches are merged into one bold span so the markdown stays valid
+func highlightTerms(text, query string) string {
+	terms := highlightQueryTerms(query)
+	if text == "" || len(terms) == 0 {
+		return text
+	}
+
+	protected := highlightProtectedPattern.FindAllStringIndex(text, -1)
+	isProtected := func(start, end int) bool {
+		for _, span := range protected {
+			if start < span[1] && end > span[0] {
+				return true
+			}
+		}
+		return false
+	}
+
+	var spans [][2]int
+	for _, term := range terms {
+		pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+		for _, match := range pattern.FindAllStringIndex(text, -1) {
+			if !isProtected(match[0], match[1]) {
+				spans = append(spans, [2]int{match[0], match[1]})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return text
+	}
+
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i][0] < spans[j][0]
+	})
+	merged := spans[:1]
+	for _, span := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if span[0] <= last[1] {
+			last[1] = max(last[1], span[1])
+			continue
+		}
+		merged = append(merged, span)
+	}
+
+	var builder strings.Builder
+	prev := 0
+	for _, span := range merged {
+		builder.WriteString(text[prev:span[0]])
+		builder.WriteString("**")
+		builder.WriteString(text[span[0]:span[1]])
+		builder.WriteString("**")
+		prev = span[1]
+	}
+	builder.WriteString(text[prev:])
+
+	return builder.String()
+}
+
+// highlightQueryTerms splits the query into distinct lower case terms, search operators
+// like site: and excluded terms are dropped as well as stop words and too short terms
+func highlightQueryTerms(query string) []string {
+	seen := make(map[string]struct{})
+	var terms []string
+	for _, field := range strings.Fields(strings.ToLower(query)) {
+		if strings.HasPrefix(field, "-") || highlightOperatorPattern.MatchString(field) {
+			continue
+		}
+		term := strings.Trim(field, "+\"'`()[]{}<>,.;:!?*")
+		if utf8.RuneCountInString(term) < minHighlightTermLen {
+			continue
+		}
+		if _, ok := highlightStopWords[term]; ok {
+			continue
+		}
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+		terms = append(terms, term)
+	}
+
+	return terms
+}
@@ -0,0 +1,334 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	customsearch "google.golang.org/api/customsearch/v1"
+)
+
+func TestFormatResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []SearchResultItem
+		opts    FormatOptions
+		want    string
+	}{
+		{
+			name: "empty",
+			want: "",
+		},
+		{
+			name: "default options",
+			results: []SearchResultItem{
+				{Title: "First", URL: "https://example.com/1", Snippet: "first snippet"},
+				{Title: "Second", URL: "https://example.com/2", Snippet: "second snippet"},
+			},
+			want: "# 1. First\n\n## URL\nhttps://example.com/1\n\n## Snippet\n\nfirst snippet\n\n" +
+				"# 2. Second\n\n## URL\nhttps://example.com/2\n\n## Snippet\n\nsecond snippet\n\n",
+		},
+		{
+			name: "snippet title and separator",
+			results: []SearchResultItem{
+				{Title: "First", URL: "https://example.com/1", Snippet: "first"},
+				{Title: "Second", URL: "https://example.com/2", Snippet: "second"},
+			},
+			opts: FormatOptions{SnippetTitle: "Description", Separator: true},
+			want: "# 1. First\n\n## URL\nhttps://example.com/1\n\n## Description\n\nfirst\n\n" +
+				"---\n\n" +
+				"# 2. Second\n\n## URL\nhttps://example.com/2\n\n## Description\n\nsecond\n\n",
+		},
+		{
+			name: "optional fields",
+			results: []SearchResultItem{
+				{
+					Title:     "Advisory",
+					URL:       "https://example.com/a",
+					Snippet:   "details",
+					Source:    "example.com",
+					Published: "2024-01-02",
+					Score:     0.5,
+				},
+			},
+			want: "# 1. Advisory\n\n## URL\nhttps://example.com/a\n\n## Source\nexample.com\n\n" +
+				"## Published\n2024-01-02\n\n## Score\n0.500\n\n## Snippet\n\ndetails\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatResults(tt.results, tt.opts); got != tt.want {
+				t.Errorf("FormatResults() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoogleParseSearchResultGolden(t *testing.T) {
+	g := &google{}
+	res := &customsearch.Search{
+		Items: []*customsearch.Result{
+			{Title: "PentAGI", Link: "https://github.com/vxcontrol/pentagi", Snippet: "Autonomous penetration testing"},
+			{Title: "Docs", Link: "https://pentagi.com", Snippet: "Documentation"},
+		},
+	}
+
+	want := "# 1. PentAGI\n\n## URL\nhttps://github.com/vxcontrol/pentagi\n\n## Snippet\n\nAutonomous penetration testing\n\n" +
+		"# 2. Docs\n\n## URL\nhttps://pentagi.com\n\n## Snippet\n\nDocumentation\n\n"
+
+	if got := g.parseGoogleSearchResult(res, "pentagi"); got != want {
+		t.Errorf("parseGoogleSearchResult() = %q, want %q", got, want)
+	}
+}
+
+func TestDuckDuckGoFormatSearchResultsGolden(t *testing.T) {
+	d := &duckduckgo{}
+	results := []searchResult{
+		{Title: "First", URL: "https://example.com/1", Description: "first description"},
+		{Title: "Second", URL: "https://example.com/2", Description: "second description"},
+	}
+
+	want := "# 1. First\n\n## URL\nhttps://example.com/1\n\n## Description\n\nfirst description\n\n" +
+		"---\n\n" +
+		"# 2. Second\n\n## URL\nhttps://example.com/2\n\n## Description\n\nsecond description\n\n"
+
+	if got := d.formatSearchResults(results); got != want {
+		t.Errorf("formatSearchResults() = %q, want %q", got, want)
+	}
+}
+
+func TestSourceFooter(t *testing.T) {
+	var requests []CompletionRequest
+	perplexityServer := newPerplexityRequestRecorder(t, &requests)
+	defer perplexityServer.Close()
+
+	var githubRequests []*http.Request
+	githubServer := newGithubTestServer(t, "github_repositories.json", &githubRequests)
+	defer githubServer.Close()
+
+	engines := map[string]Tool{
+		"alpha": &fakeResultsSearcher{available: true, items: []SearchResultItem{
+			{Title: "Alpha 1", URL: "https://example.com/page", Snippet: "alpha first"},
+		}},
+		"beta": &fakeResultsSearcher{available: true},
+	}
+
+	tests := []struct {
+		name   string
+		engine string
+		tool   func(sourceFooter bool) Tool
+		args   any
+	}{
+		{
+			name:   "perplexity",
+			engine: "Perplexity",
+			tool: func(sourceFooter bool) Tool {
+				return &perplexity{apiKey: "test-key", model: perplexityModel, baseURL: perplexityServer.URL, sourceFooter: sourceFooter}
+			},
+			args: PerplexitySearchAction{Query: "log4shell", MaxResults: 5},
+		},
+		{
+			name:   "github",
+			engine: "GitHub",
+			tool: func(sourceFooter bool) Tool {
+				return &github{enabled: true, apiURL: githubServer.URL, sourceFooter: sourceFooter}
+			},
+			args: GithubSearchAction{Query: "nmap", Kind: "repositories", MaxResults: 5},
+		},
+		{
+			name:   "metasearch",
+			engine: "Metasearch",
+			tool: func(sourceFooter bool) Tool {
+				return NewMetasearchTool(0, nil, nil, engines, time.Second, ResultLimits{}, sourceFooter, nil, nil)
+			},
+			args: SearchAction{Query: "nmap", MaxResults: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(tt.args)
+			footer := "\n\n_Source: " + tt.engine + "_\n"
+
+			result, err := tt.tool(true).Handle(context.Background(), tt.name, args)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if !strings.HasSuffix(result, footer) || strings.Count(result, "_Source: ") != 1 {
+				t.Errorf("expected the footer %q at the end of result:\n%s", footer, result)
+			}
+
+			result, err = tt.tool(false).Handle(context.Background(), tt.name, args)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if strings.Contains(result, "_Source: ") {
+				t.Errorf("expected no footer when it's disabled:\n%s", result)
+			}
+		})
+	}
+}
+
+func TestHighlightTerms(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		query string
+		want  string
+	}{
+		{
+			name:  "multiple terms in any case",
+			text:  "Apache Log4j remote code execution via JNDI lookups in log4j 2.x",
+			query: "log4j RCE jndi",
+			want:  "Apache **Log4j** remote code execution via **JNDI** lookups in **log4j** 2.x",
+		},
+		{
+			name:  "overlapping terms are merged",
+			text:  "Reflected cross-site-scripting payloads",
+			query: "cross-site site-scripting",
+			want:  "Reflected **cross-site-scripting** payloads",
+		},
+		{
+			name:  "adjacent terms are merged",
+			text:  "Apache log4j and log4net",
+			query: "\"log 4j\"",
+			want:  "Apache **log4j** and **log**4net",
+		},
+		{
+			name:  "duplicate and excluded terms",
+			text:  "Exploit for CVE-2021-44228 in Log4j",
+			query: "cve-2021 CVE-2021 -log4j",
+			want:  "Exploit for **CVE-2021**-44228 in Log4j",
+		},
+		{
+			name:  "no match",
+			text:  "Autonomous penetration testing",
+			query: "nmap scanner",
+			want:  "Autonomous penetration testing",
+		},
+		{
+			name:  "operators, stop words and short terms are ignored",
+			text:  "Nmap is the tool for network discovery on example.com",
+			query: "site:example.com filetype:pdf nmap for a discovery",
+			want:  "**Nmap** is the tool for network **discovery** on example.com",
+		},
+		{
+			name:  "markdown is not broken",
+			text:  "Run `nmap -sV` or see **nmap** docs at https://nmap.org/book and [nmap guide](https://example.com/nmap)",
+			query: "nmap",
+			want:  "Run `nmap -sV` or see **nmap** docs at https://nmap.org/book and [**nmap** guide](https://example.com/nmap)",
+		},
+		{
+			name:  "empty query",
+			text:  "Autonomous penetration testing",
+			query: " ",
+			want:  "Autonomous penetration testing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightTerms(tt.text, tt.query); got != tt.want {
+				t.Errorf("highlightTerms() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchResultsHighlighting(t *testing.T) {
+	res := &customsearch.Search{
+		Items: []*customsearch.Result{
+			{Title: "PentAGI", Link: "https://github.com/vxcontrol/pentagi", Snippet: "Autonomous penetration testing"},
+		},
+	}
+
+	if got := (&google{highlight: true}).parseGoogleSearchResult(res, "penetration testing"); !strings.Contains(got,
+		"# 1. PentAGI\n\n## URL\nhttps://github.com/vxcontrol/pentagi\n\n## Snippet\n\nAutonomous **penetration** **testing**\n\n") {
+		t.Errorf("expected highlighted snippet only:\n%s", got)
+	}
+	if got := (&google{}).parseGoogleSearchResult(res, "penetration testing"); strings.Contains(got, "**") {
+		t.Errorf("expected no highlighting when it's disabled:\n%s", got)
+	}
+
+	result := &tavilySearchResult{
+		Answer:  "Penetration testing is an authorized simulated attack",
+		Results: []tavilyResult{{Title: "Penetration test", URL: "https://example.com", Content: "A penetration test is a simulated attack"}},
+	}
+	got := (&tavily{highlight: true}).buildTavilyResult(context.Background(), result, "penetration")
+	if !strings.Contains(got, "### Short content\n\nA **penetration** test is a simulated attack") ||
+		strings.Count(got, "**") != 2 {
+		t.Errorf("expected highlighted short content only:\n%s", got)
+	}
+}
+
+func TestResultTemplate(t *testing.T) {
+	results := []SearchResultItem{
+		{Title: "First", URL: "https://example.com/1", Snippet: "nmap scan"},
+		{Title: "Second", URL: "https://example.com/2", Snippet: "second"},
+	}
+
+	tmpl, err := NewResultTemplate("{{.Engine}}:\n{{range $i, $r := .Results}}{{inc $i}}. [{{$r.Title}}]({{$r.URL}}) {{$r.Snippet}}\n{{end}}")
+	if err != nil {
+		t.Fatalf("NewResultTemplate() error = %v", err)
+	}
+
+	want := "Google:\n1. [First](https://example.com/1) **nmap** scan\n2. [Second](https://example.com/2) second\n"
+	if got := FormatResults(results, FormatOptions{Template: tmpl, Engine: "Google", Highlight: "nmap"}); got != want {
+		t.Errorf("FormatResults() = %q, want %q", got, want)
+	}
+
+	// the built-in formatting is kept without the template
+	if got := FormatResults(results[:1], FormatOptions{}); got != "# 1. First\n\n## URL\nhttps://example.com/1\n\n## Snippet\n\nnmap scan\n\n" {
+		t.Errorf("unexpected built-in formatting %q", got)
+	}
+	if results[0].Snippet != "nmap scan" {
+		t.Errorf("expected results not to be modified by highlighting, got %q", results[0].Snippet)
+	}
+
+	res := &customsearch.Search{Items: []*customsearch.Result{{Title: "First", Link: "https://example.com/1", Snippet: "nmap scan"}}}
+	if got := (&google{template: tmpl}).parseGoogleSearchResult(res, "nmap"); got != "Google:\n1. [First](https://example.com/1) nmap scan\n" {
+		t.Errorf("unexpected google result by the template %q", got)
+	}
+}
+
+func TestResultTemplateValidation(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		text string
+	}{
+		{name: "syntax error", text: "{{range .Results}}{{.Title}}"},
+		{name: "unknown field", text: "{{range .Results}}{{.Rank}}{{end}}"},
+		{name: "unknown function", text: "{{upper .Engine}}"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewResultTemplate(tt.text); err == nil {
+				t.Error("expected the template to be rejected")
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "results.tmpl")
+	if err := os.WriteFile(path, []byte("{{len .Results}} results"), 0o600); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	tmpl, err := LoadResultTemplate(path)
+	if err != nil || tmpl == nil {
+		t.Fatalf("LoadResultTemplate() = %v, %v", tmpl, err)
+	}
+	if got := FormatResults([]SearchResultItem{{Title: "First"}}, FormatOptions{Template: tmpl}); got != "1 results" {
+		t.Errorf("unexpected result by the loaded template %q", got)
+	}
+
+	if tmpl, err := LoadResultTemplate(""); tmpl != nil || err != nil {
+		t.Errorf("expected the built-in formatting for empty path, got %v, %v", tmpl, err)
+	}
+	if _, err := LoadResultTemplate(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("expected an error for the missing file")
+	}
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// default delays of retries for transient errors of search engines, variables to shorten them in tests
+var (
+	searchRetryBaseDelay = 500 * time.Millisecond
+	searchRetryMaxDelay  = 10 * time.Second
+)
+
+// RetryPolicy bounds retries of transient errors, zero delays mean the default ones;
+// the deadline caps the time of all attempts with the delays between them whatever
+// attempts are left, the deadline of the request context bounds them as well
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt too, so values below 2 disable retries
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Deadline of all attempts, zero means no limit besides the context one
+	Deadline time.Duration
+}
+
+// NewRetryPolicy creates the policy which repeats the request up to retries times with default base delay
+func NewRetryPolicy(retries int, maxDelay, deadline time.Duration) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: retries + 1,
+		MaxDelay:    maxDelay,
+		Deadline:    deadline,
+	}
+}
+
+// WithMaxAttempts returns copy of the policy for tools having own number of attempts
+func (p RetryPolicy) WithMaxAttempts(attempts int) RetryPolicy {
+	p.MaxAttempts = attempts
+	return p
+}
+
+func (p RetryPolicy) baseDelay() time.Duration {
+	if p.BaseDelay <= 0 {
+		return searchRetryBaseDelay
+	}
+	return p.BaseDelay
+}
+
+func (p RetryPolicy) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return searchRetryMaxDelay
+	}
+	return p.MaxDelay
+}
+
+// next returns the delay before the attempt following the failed one, false means attempts
+// are exhausted or the delay would overrun the deadline of the policy or the context
+func (p RetryPolicy) next(ctx context.Context, start time.Time, attempt int, resp *http.Response) (time.Duration, bool) {
+	if attempt+1 >= p.MaxAttempts {
+		return 0, false
+	}
+
+	delay := p.delay(attempt, resp)
+	if p.Deadline > 0 && time.Since(start)+delay >= p.Deadline {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+// doWithRetry sends the request and repeats it by the policy on rate limit and server errors
+// with exponential backoff and jitter, Retry-After header of rate limited response is honored,
+// failed requests are repeated on timeouts only, e.g. the unknown host won't appear on the next attempt;
+// the last response is returned as is to keep the status classification by the caller
+func doWithRetry(client *http.Client, req *http.Request, policy RetryPolicy) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, newSearchError(ErrNetwork, "failed to rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			err = newNetworkError(err, "failed to do request")
+			if ctx.Err() != nil || !isRetryableNetworkError(err) {
+				return nil, err
+			}
+
+			delay, ok := policy.next(ctx, start, attempt, nil)
+			if !ok {
+				return nil, err
+			}
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay, ok := policy.next(ctx, start, attempt, resp)
+		if !ok {
+			return resp, nil
+		}
+
+		// drain the body to reuse the connection
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepContext waits for the delay and returns the error of the context if it's done earlier
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// delay returns Retry-After delay for rate limited response when it's set,
+// otherwise exponential backoff with jitter in the upper half of the current step,
+// both are capped by the max delay of the policy
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(delay, p.maxDelay())
+		}
+	}
+
+	backoff := min(p.baseDelay()<<min(attempt, 30), p.maxDelay())
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+
+	return half + time.Duration(rand.Int64N(int64(half)))
+}
+
+// parseRetryAfter supports both delay in seconds and HTTP date formats of the header
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,292 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func shortenSearchRetryDelays(t *testing.T) {
+	t.Helper()
+
+	base, maxDelay := searchRetryBaseDelay, searchRetryMaxDelay
+	searchRetryBaseDelay, searchRetryMaxDelay = time.Millisecond, 10*time.Millisecond
+	t.Cleanup(func() {
+		searchRetryBaseDelay, searchRetryMaxDelay = base, maxDelay
+	})
+}
+
+// newFlakyServer fails with the given statuses first and then replies with the body,
+// it checks the request body is sent again on every attempt
+func newFlakyServer(t *testing.T, body string, calls *int32, statuses ...int) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if data, _ := io.ReadAll(r.Body); len(data) == 0 {
+			t.Error("expected request body on every attempt")
+		}
+
+		call := int(atomic.AddInt32(calls, 1))
+		if call <= len(statuses) {
+			if statuses[call-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(statuses[call-1])
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+}
+
+func TestSearchRetryEventualSuccess(t *testing.T) {
+	shortenSearchRetryDelays(t)
+
+	t.Run("tavily", func(t *testing.T) {
+		var calls int32
+		server := newFlakyServer(t, `{"answer":"tavily answer","results":[]}`, &calls,
+			http.StatusServiceUnavailable, http.StatusTooManyRequests)
+		defer server.Close()
+
+		tool := &tavily{apiKey: "test-key", apiURL: server.URL, retry: RetryPolicy{MaxAttempts: 3}}
+		result, _, err := tool.search(context.Background(), "query", 5)
+		if err != nil {
+			t.Fatalf("search() error = %v", err)
+		}
+		if !strings.Contains(result, "tavily answer") {
+			t.Errorf("unexpected result:\n%s", result)
+		}
+		if atomic.LoadInt32(&calls) != 3 {
+			t.Errorf("expected 3 requests, got %d", atomic.LoadInt32(&calls))
+		}
+	})
+
+	t.Run("traversaal", func(t *testing.T) {
+		var calls int32
+		server := newFlakyServer(t, `{"data":{"response_text":"traversaal answer","web_url":[]}}`, &calls,
+			http.StatusBadGateway, http.StatusTooManyRequests)
+		defer server.Close()
+
+		tool := &traversaal{apiKey: "test-key", apiURL: server.URL, retry: RetryPolicy{MaxAttempts: 3}}
+		result, _, err := tool.search(context.Background(), "query", traversaalDefaultResults)
+		if err != nil {
+			t.Fatalf("search() error = %v", err)
+		}
+		if !strings.Contains(result, "traversaal answer") {
+			t.Errorf("unexpected result:\n%s", result)
+		}
+		if atomic.LoadInt32(&calls) != 3 {
+			t.Errorf("expected 3 requests, got %d", atomic.LoadInt32(&calls))
+		}
+	})
+}
+
+func TestSearchRetryStopsOnNonRetryableStatus(t *testing.T) {
+	shortenSearchRetryDelays(t)
+
+	for _, status := range []int{
+		http.StatusBadRequest,
+		http.StatusUnauthorized,
+		http.StatusForbidden,
+		http.StatusNotFound,
+	} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var calls int32
+			server := newFlakyServer(t, "{}", &calls, status, status, status)
+			defer server.Close()
+
+			tool := &tavily{apiKey: "test-key", apiURL: server.URL, retry: RetryPolicy{MaxAttempts: 3}}
+			if _, _, err := tool.search(context.Background(), "query", 5); err == nil {
+				t.Error("expected error")
+			}
+			if atomic.LoadInt32(&calls) != 1 {
+				t.Errorf("expected single request, got %d", atomic.LoadInt32(&calls))
+			}
+		})
+	}
+}
+
+func TestSearchRetryExhausted(t *testing.T) {
+	shortenSearchRetryDelays(t)
+
+	var calls int32
+	server := newFlakyServer(t, "{}", &calls,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	tool := &traversaal{apiKey: "test-key", apiURL: server.URL, retry: RetryPolicy{MaxAttempts: 3}}
+	_, _, err := tool.search(context.Background(), "query", traversaalDefaultResults)
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("expected %v, got %v", ErrUpstreamUnavailable, err)
+	}
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("expected 3 requests, got %d", atomic.LoadInt32(&calls))
+	}
+}
+
+// dialFailure fails every request by the dial error of the cause and counts the attempts
+func dialFailure(calls *int32, cause error) http.RoundTripper {
+	return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(calls, 1)
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: cause}
+	})
+}
+
+func TestSearchRetryNetworkErrors(t *testing.T) {
+	shortenSearchRetryDelays(t)
+
+	t.Run("timeout is retried", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		client := &http.Client{Timeout: 50 * time.Millisecond}
+		_, err := doWithRetry(client, req, RetryPolicy{MaxAttempts: 3})
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("expected %v, got %v", ErrTimeout, err)
+		}
+		if got := atomic.LoadInt32(&calls); got != 3 {
+			t.Errorf("expected 3 requests, got %d", got)
+		}
+	})
+
+	for _, tt := range []struct {
+		name  string
+		cause error
+		kind  error
+	}{
+		{"nxdomain", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, ErrDNS},
+		{"refused", os.NewSyscallError("connect", syscall.ECONNREFUSED), ErrConnRefused},
+	} {
+		t.Run(tt.name+" isn't retried", func(t *testing.T) {
+			var calls int32
+			req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+			_, err := doWithRetry(&http.Client{Transport: dialFailure(&calls, tt.cause)}, req, RetryPolicy{MaxAttempts: 3})
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("expected %v, got %v", tt.kind, err)
+			}
+			if got := atomic.LoadInt32(&calls); got != 1 {
+				t.Errorf("expected single request, got %d", got)
+			}
+		})
+	}
+
+	t.Run("temporary dns failure is retried", func(t *testing.T) {
+		var calls int32
+		cause := &net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}
+		req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		_, err := doWithRetry(&http.Client{Transport: dialFailure(&calls, cause)}, req, RetryPolicy{MaxAttempts: 3})
+		if !errors.Is(err, ErrDNS) {
+			t.Errorf("expected %v, got %v", ErrDNS, err)
+		}
+		if got := atomic.LoadInt32(&calls); got != 3 {
+			t.Errorf("expected 3 requests, got %d", got)
+		}
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	rateLimited := func(retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+
+	var policy RetryPolicy
+	if got := policy.delay(0, rateLimited("3")); got != 3*time.Second {
+		t.Errorf("expected Retry-After seconds to be honored, got %v", got)
+	}
+	if got := policy.delay(0, rateLimited("3600")); got != searchRetryMaxDelay {
+		t.Errorf("expected Retry-After to be capped by %v, got %v", searchRetryMaxDelay, got)
+	}
+	if got := policy.delay(0, rateLimited(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))); got != 0 {
+		t.Errorf("expected past Retry-After date to give zero delay, got %v", got)
+	}
+
+	unavailable := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+	for attempt := 0; attempt < 3; attempt++ {
+		step := searchRetryBaseDelay << attempt
+		if got := policy.delay(attempt, unavailable); got < step/2 || got >= step {
+			t.Errorf("attempt %d: expected jittered delay in [%v, %v), got %v", attempt, step/2, step, got)
+		}
+	}
+}
+
+func TestRetryPolicyDeadline(t *testing.T) {
+	var calls int32
+	server := newFlakyServer(t, "{}", &calls,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	defer server.Close()
+
+	// the delays are capped by 20ms, so only a few of the attempts fit into the deadline of 50ms
+	policy := RetryPolicy{MaxAttempts: 6, BaseDelay: 40 * time.Millisecond, MaxDelay: 20 * time.Millisecond, Deadline: 50 * time.Millisecond}
+	tool := &traversaal{apiKey: "test-key", apiURL: server.URL, retry: policy}
+
+	start := time.Now()
+	_, _, err := tool.search(context.Background(), "query", traversaalDefaultResults)
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("expected %v of the last attempt, got %v", ErrUpstreamUnavailable, err)
+	}
+	if got := atomic.LoadInt32(&calls); got < 2 || got >= int32(policy.MaxAttempts) {
+		t.Errorf("expected retries to stop at the deadline before %d attempts, got %d", policy.MaxAttempts, got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected retries to be capped by the deadline, took %v", elapsed)
+	}
+}
+
+func TestRetryPolicyContextDeadline(t *testing.T) {
+	var calls int32
+	server := newFlakyServer(t, "{}", &calls, http.StatusBadGateway, http.StatusBadGateway)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// the delay is longer than the time left for the call, so it isn't retried at all
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Minute}
+	tool := &traversaal{apiKey: "test-key", apiURL: server.URL, retry: policy}
+	_, _, err := tool.search(ctx, "query", traversaalDefaultResults)
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("expected %v instead of waiting for the context, got %v", ErrUpstreamUnavailable, err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected single request, got %d", got)
+	}
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy := NewRetryPolicy(2, 3*time.Second, time.Minute)
+	if policy.MaxAttempts != 3 || policy.maxDelay() != 3*time.Second || policy.Deadline != time.Minute {
+		t.Errorf("unexpected policy %+v", policy)
+	}
+	if policy.baseDelay() != searchRetryBaseDelay {
+		t.Errorf("expected default base delay, got %v", policy.baseDelay())
+	}
+
+	own := policy.WithMaxAttempts(5)
+	if own.MaxAttempts != 5 || own.Deadline != policy.Deadline || policy.MaxAttempts != 3 {
+		t.Errorf("expected copy with own attempts, got %+v of %+v", own, policy)
+	}
+
+	if _, ok := (RetryPolicy{}).next(context.Background(), time.Now(), 0, nil); ok {
+		t.Error("expected zero policy not to retry")
+	}
+}
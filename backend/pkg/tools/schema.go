@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// responseDriftEvent describes the response of the engine which doesn't look like the expected one,
+// e.g. the upstream renamed the fields and decoding silently dropped the data
+func responseDriftEvent(engine, reason string, body []byte) TraceEvent {
+	metadata := map[string]any{
+		"engine": engine,
+		"reason": reason,
+	}
+	if len(body) != 0 {
+		metadata["body_preview"] = bodyPreview(body)
+	}
+
+	return TraceEvent{
+		Name:     "search engine response drift",
+		Input:    engine,
+		Status:   reason,
+		Level:    TraceLevelWarning,
+		Metadata: metadata,
+	}
+}
+
+// reportResponseDrift warns about the unexpected response shape through the trace of the tool call
+// bound to the context and the log, the call itself goes on with what was decoded
+func reportResponseDrift(ctx context.Context, engine, reason string, body []byte) {
+	logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"engine": engine,
+		"reason": reason,
+	}).Warn("search engine response doesn't match the expected schema")
+
+	emitterFromContext(ctx).Emit(responseDriftEvent(engine, reason, body))
+}
+
+// checkResponseKeys reports the drift when the JSON object of the response misses any of the keys,
+// nested keys are joined by dots, it returns whether all keys are present
+func checkResponseKeys(ctx context.Context, engine string, body []byte, keys ...string) bool {
+	var missing []string
+	for _, key := range keys {
+		if !hasJSONPath(body, strings.Split(key, ".")) {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return true
+	}
+
+	reportResponseDrift(ctx, engine, "missing keys: "+strings.Join(missing, ", "), body)
+	return false
+}
+
+// lookupJSONPath returns the value of the decoded JSON data at the path of keys of the nested objects
+func lookupJSONPath(data any, path []string) (any, bool) {
+	for _, key := range path {
+		object, ok := data.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if data, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return data, true
+}
+
+// hasJSONPath reports whether the nested objects of the JSON data contain the path of keys
+func hasJSONPath(data []byte, path []string) bool {
+	for _, key := range path {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return false
+		}
+
+		value, ok := object[key]
+		if !ok {
+			return false
+		}
+		data = value
+	}
+
+	return true
+}
@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckResponseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		keys []string
+		want bool
+	}{
+		{"all keys", `{"results":[],"query":"q"}`, []string{"results", "query"}, true},
+		{"nested keys", `{"data":{"response_text":"a","web_url":[]}}`, []string{"data.response_text", "data.web_url"}, true},
+		{"renamed key", `{"items":[]}`, []string{"results"}, false},
+		{"renamed nested key", `{"data":{"response_text":"a","urls":[]}}`, []string{"data.response_text", "data.web_url"}, false},
+		{"null object", `{"data":null}`, []string{"data.web_url"}, false},
+		{"not an object", `[]`, []string{"results"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &capturingTracer{}
+			ctx, _ := startTrace(context.Background(), tracer)
+
+			if got := checkResponseKeys(ctx, "engine", []byte(tt.body), tt.keys...); got != tt.want {
+				t.Errorf("checkResponseKeys() = %v, want %v", got, tt.want)
+			}
+			if wantEvents := map[bool]int{true: 0, false: 1}[tt.want]; len(tracer.events) != wantEvents {
+				t.Errorf("expected %d events, got %d", wantEvents, len(tracer.events))
+			}
+		})
+	}
+}
+
+// handleWithDriftCheck runs the tool on the drifted response and returns the result with the drift events
+func handleWithDriftCheck(t *testing.T, body string, handle func(serverURL string, tracer Tracer) (string, error)) (string, []TraceEvent) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	tracer := &capturingTracer{}
+	result, err := handle(server.URL, tracer)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	var events []TraceEvent
+	for _, event := range tracer.events {
+		if event.Name == "search engine response drift" {
+			events = append(events, event)
+		}
+	}
+
+	return result, events
+}
+
+func TestResponseDriftIsReported(t *testing.T) {
+	args, _ := json.Marshal(SearchAction{Query: "log4shell"})
+
+	tests := []struct {
+		name       string
+		body       string
+		handle     func(serverURL string, tracer Tracer) (string, error)
+		wantResult string
+		wantReason string
+	}{
+		{
+			name: "tavily",
+			body: `{"answer":"tavily answer","items":[{"title":"t","url":"https://example.com"}]}`,
+			handle: func(serverURL string, tracer Tracer) (string, error) {
+				tool := &tavily{apiKey: "test-key", apiURL: serverURL, tracer: tracer}
+				return tool.Handle(context.Background(), TavilyToolName, args)
+			},
+			wantResult: "tavily answer",
+			wantReason: "missing keys: results",
+		},
+		{
+			name: "traversaal",
+			body: `{"data":{"response_text":"traversaal answer","urls":["https://example.com"]}}`,
+			handle: func(serverURL string, tracer Tracer) (string, error) {
+				tool := &traversaal{apiKey: "test-key", apiURL: serverURL, tracer: tracer}
+				return tool.Handle(context.Background(), TraversaalToolName, args)
+			},
+			wantResult: "traversaal answer",
+			wantReason: "missing keys: data.web_url",
+		},
+		{
+			name: "google",
+			body: `{"results":[{"title":"t"}],"searchInformation":{"totalResults":"42"}}`,
+			handle: func(serverURL string, tracer Tracer) (string, error) {
+				tool := &google{apiKey: "test-key", cxKey: "test-cx", endpoint: serverURL + "/", tracer: tracer}
+				return tool.Handle(context.Background(), GoogleToolName, args)
+			},
+			wantResult: noResultsMessage,
+			wantReason: "no items while total results is 42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, events := handleWithDriftCheck(t, tt.body, tt.handle)
+			if !strings.Contains(result, tt.wantResult) {
+				t.Errorf("expected the call to return %q, got %q", tt.wantResult, result)
+			}
+			if len(events) != 1 {
+				t.Fatalf("expected one drift event, got %d", len(events))
+			}
+			if events[0].Level != TraceLevelWarning || events[0].Status != tt.wantReason {
+				t.Errorf("unexpected drift event %+v", events[0])
+			}
+		})
+	}
+}
+
+func TestResponseDriftNotReportedForExpectedShape(t *testing.T) {
+	body := `{"data":{"response_text":"answer","web_url":["https://example.com"]}}`
+	_, events := handleWithDriftCheck(t, body, func(serverURL string, tracer Tracer) (string, error) {
+		tool := &traversaal{apiKey: "test-key", apiURL: serverURL, tracer: tracer}
+		args, _ := json.Marshal(SearchAction{Query: "log4shell"})
+		return tool.Handle(context.Background(), TraversaalToolName, args)
+	})
+	if len(events) != 0 {
+		t.Errorf("expected no drift events, got %+v", events)
+	}
+}
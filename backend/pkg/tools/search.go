@@ -266,3 +266,7 @@ func (s *search) Handle(ctx context.Context, name string, args json.RawMessage)
 func (s *search) IsAvailable() bool {
 	return s.store != nil
 }
+
+func (s *search) HealthCheck(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"pentagi/pkg/database"
+)
+
+// SearchStats describes a single search call for monitoring dashboards
+type SearchStats struct {
+	// Duration is wall-clock time spent to get the result including cache lookup
+	Duration time.Duration
+	// ResultCount is a number of items returned by the engine, zero for cached results
+	ResultCount int
+	// Cached is set when the result was served from the search cache
+	Cached bool
+	// FetchedAt is when the result was received from the engine, it's the time of the cache entry
+	// for cached results and zero when the cache doesn't keep it
+	FetchedAt time.Time
+}
+
+// SearchStatsLogProvider is an optional extension of SearchLogProvider which also
+// receives latency and result count of the search, it's used instead of PutLog if implemented
+type SearchStatsLogProvider interface {
+	PutLogWithStats(
+		ctx context.Context,
+		initiator database.MsgchainType,
+		executor database.MsgchainType,
+		engine database.SearchengineType,
+		query string,
+		result string,
+		stats SearchStats,
+		taskID *int64,
+		subtaskID *int64,
+	) (int64, error)
+}
+
+// putSearchLog stores the search result with its stats into the log provider for the current agent
+func putSearchLog(
+	ctx context.Context,
+	slp SearchLogProvider,
+	engine database.SearchengineType,
+	query string,
+	result string,
+	stats SearchStats,
+	taskID *int64,
+	subtaskID *int64,
+) (int64, error) {
+	agentCtx, ok := GetAgentContext(ctx)
+	if !ok || slp == nil {
+		return 0, nil
+	}
+
+	if sslp, ok := slp.(SearchStatsLogProvider); ok {
+		return sslp.PutLogWithStats(
+			ctx,
+			agentCtx.ParentAgentType,
+			agentCtx.CurrentAgentType,
+			engine,
+			query,
+			result,
+			stats,
+			taskID,
+			subtaskID,
+		)
+	}
+
+	return slp.PutLog(
+		ctx,
+		agentCtx.ParentAgentType,
+		agentCtx.CurrentAgentType,
+		engine,
+		query,
+		result,
+		taskID,
+		subtaskID,
+	)
+}
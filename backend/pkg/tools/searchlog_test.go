@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"pentagi/pkg/database"
+)
+
+type statsSearchLog struct {
+	engine database.SearchengineType
+	query  string
+	stats  SearchStats
+}
+
+// statsSearchLogProvider captures search stats passed by tools
+type statsSearchLogProvider struct {
+	MockSearchLogProvider
+	logs []statsSearchLog
+}
+
+func (p *statsSearchLogProvider) PutLogWithStats(
+	ctx context.Context,
+	initiator database.MsgchainType,
+	executor database.MsgchainType,
+	engine database.SearchengineType,
+	query string,
+	result string,
+	stats SearchStats,
+	taskID *int64,
+	subtaskID *int64,
+) (int64, error) {
+	p.logs = append(p.logs, statsSearchLog{engine: engine, query: query, stats: stats})
+	return int64(len(p.logs)), nil
+}
+
+func TestSearchLogStats(t *testing.T) {
+	var queries []url.Values
+	server := newGoogleTestServer(t, 20, &queries)
+	defer server.Close()
+
+	slp := &statsSearchLogProvider{}
+	g := &google{
+		apiKey:   "test-key",
+		cxKey:    "test-cx",
+		endpoint: server.URL + "/",
+		cache:    NewSearchCache(t.TempDir(), time.Hour),
+		slp:      slp,
+	}
+
+	ctx := PutAgentContext(context.Background(), database.MsgchainTypeSearcher)
+	args, _ := json.Marshal(GoogleSearchAction{Query: "pentagi", MaxResults: 15})
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.Handle(ctx, GoogleToolName, args); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+	}
+
+	if len(slp.logs) != 2 {
+		t.Fatalf("expected 2 search logs, got %d", len(slp.logs))
+	}
+
+	live := slp.logs[0]
+	if live.engine != database.SearchengineTypeGoogle || live.query != "pentagi" {
+		t.Errorf("unexpected log engine %q and query %q", live.engine, live.query)
+	}
+	if live.stats.ResultCount != 15 {
+		t.Errorf("expected result count 15, got %d", live.stats.ResultCount)
+	}
+	if live.stats.Duration <= 0 {
+		t.Error("expected positive search duration")
+	}
+	if live.stats.Cached {
+		t.Error("expected first search not to be cached")
+	}
+
+	cached := slp.logs[1]
+	if !cached.stats.Cached {
+		t.Error("expected second search to be served from cache")
+	}
+	if cached.stats.ResultCount != 0 {
+		t.Errorf("expected zero result count for cached search, got %d", cached.stats.ResultCount)
+	}
+}
+
+func TestPutSearchLogFallback(t *testing.T) {
+	ctx := PutAgentContext(context.Background(), database.MsgchainTypeSearcher)
+
+	id, err := putSearchLog(ctx, &MockSearchLogProvider{}, database.SearchengineTypeGoogle,
+		"query", "result", SearchStats{ResultCount: 1}, nil, nil)
+	if err != nil {
+		t.Fatalf("putSearchLog() error = %v", err)
+	}
+	if id != 1 {
+		t.Errorf("expected plain PutLog to be used, got id %d", id)
+	}
+
+	id, err = putSearchLog(context.Background(), &MockSearchLogProvider{}, database.SearchengineTypeGoogle,
+		"query", "result", SearchStats{}, nil, nil)
+	if err != nil || id != 0 {
+		t.Errorf("expected no log without agent context, got id %d and error %v", id, err)
+	}
+}
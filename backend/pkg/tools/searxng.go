@@ -21,55 +21,99 @@ const (
 
 // SearxngTool represents the Searxng search tool
 type SearxngTool struct {
-	flowID     int64
-	taskID     *int64
-	subtaskID  *int64
-	baseURL    string
-	categories string
-	language   string
-	safeSearch string
-	timeRange  string
-	proxyURL   string
-	timeout    time.Duration
-	slp        SearchLogProvider
-	summarizer SummarizeHandler
+	flowID       int64
+	taskID       *int64
+	subtaskID    *int64
+	baseURL      string
+	categories   string
+	language     string
+	safeSearch   string
+	timeRange    string
+	proxyURL     string
+	transport    http.RoundTripper
+	timeout      time.Duration
+	sourceFooter bool
+	slp          SearchLogProvider
+	summarizer   SummarizeHandler
 }
 
-// NewSearxngTool creates a new Searxng tool instance
+// SearxngConfig configures the searxng search tool, zero fields keep the defaults of the tool
+type SearxngConfig struct {
+	FlowID    int64
+	TaskID    *int64
+	SubtaskID *int64
+
+	BaseURL    string
+	Categories string
+	Language   string
+	SafeSearch string
+	TimeRange  string
+	ProxyURL   string
+	Timeout    time.Duration
+
+	SourceFooter bool
+	SearchLog    SearchLogProvider
+	Summarizer   SummarizeHandler
+}
+
+// NewSearxngTool creates a new Searxng tool instance, timeout is in seconds,
+// it's kept for existing callers, NewSearxngToolWithConfig is easier to read at the call site
 func NewSearxngTool(
 	flowID int64,
 	taskID, subtaskID *int64,
 	baseURL, categories, language, safeSearch, timeRange, proxyURL string,
 	timeout int,
+	sourceFooter bool,
 	slp SearchLogProvider,
 	summarizer SummarizeHandler,
 ) *SearxngTool {
-	tool := &SearxngTool{
-		flowID:     flowID,
-		taskID:     taskID,
-		subtaskID:  subtaskID,
-		baseURL:    baseURL,
-		categories: categories,
-		language:   language,
-		safeSearch: safeSearch,
-		timeRange:  timeRange,
-		proxyURL:   proxyURL,
-		slp:        slp,
-		summarizer: summarizer,
-	}
+	return NewSearxngToolWithConfig(SearxngConfig{
+		FlowID:       flowID,
+		TaskID:       taskID,
+		SubtaskID:    subtaskID,
+		BaseURL:      baseURL,
+		Categories:   categories,
+		Language:     language,
+		SafeSearch:   safeSearch,
+		TimeRange:    timeRange,
+		ProxyURL:     proxyURL,
+		Timeout:      time.Duration(timeout) * time.Second,
+		SourceFooter: sourceFooter,
+		SearchLog:    slp,
+		Summarizer:   summarizer,
+	})
+}
 
-	if timeout > 0 {
-		tool.timeout = time.Duration(timeout) * time.Second
-	} else {
-		tool.timeout = defaultSearxngTimeout
+// NewSearxngToolWithConfig creates a new Searxng tool instance, zero timeout means the default one
+func NewSearxngToolWithConfig(cfg SearxngConfig) *SearxngTool {
+	cfg.Timeout = resolveTimeout(SearxngToolName, cfg.Timeout)
+
+	return &SearxngTool{
+		flowID:       cfg.FlowID,
+		taskID:       cfg.TaskID,
+		subtaskID:    cfg.SubtaskID,
+		baseURL:      cfg.BaseURL,
+		categories:   cfg.Categories,
+		language:     cfg.Language,
+		safeSearch:   cfg.SafeSearch,
+		timeRange:    cfg.TimeRange,
+		proxyURL:     cfg.ProxyURL,
+		timeout:      cfg.Timeout,
+		sourceFooter: cfg.SourceFooter,
+		slp:          cfg.SearchLog,
+		summarizer:   cfg.Summarizer,
 	}
-
-	return tool
 }
 
 // IsAvailable checks if the Searxng tool is available
 func (s *SearxngTool) IsAvailable() bool {
-	return s.baseURL != "" && s.slp != nil
+	return s.baseURL != "" && s.slp != nil && IsEnabled(SearxngToolName)
+}
+
+// HealthCheck runs a single result search to verify the instance and proxy are reachable
+func (s *SearxngTool) HealthCheck(ctx context.Context) error {
+	_, err := s.performSearxngSearch(ctx, "test", 1)
+	return err
 }
 
 // Handle handles the Searxng search tool execution
@@ -82,6 +126,7 @@ func (s *SearxngTool) Handle(ctx context.Context, name string, args json.RawMess
 	if err := json.Unmarshal(args, &searchArgs); err != nil {
 		return "", fmt.Errorf("error unmarshaling search arguments: %w", err)
 	}
+	searchArgs.Query = sanitizeQuery(searchArgs.Query, maxQueryLength)
 
 	// Validate required parameters
 	if searchArgs.Query == "" {
@@ -108,7 +153,9 @@ func (s *SearxngTool) Handle(ctx context.Context, name string, args json.RawMess
 	}
 
 	// Perform the search
+	start := time.Now()
 	results, err := s.performSearxngSearch(ctx, searchArgs.Query, searchArgs.MaxResults.Int())
+	recordSearchCall(ctx, database.SearchengineTypeSearxng, false, time.Since(start), err)
 	if err != nil {
 		// Update search log with error
 		if searchLogID > 0 {
@@ -134,25 +181,55 @@ func (s *SearxngTool) Handle(ctx context.Context, name string, args json.RawMess
 	// Update search log with results
 	if searchLogID > 0 {
 		resultJSON, _ := json.Marshal(results)
-		if agentCtx, ok := GetAgentContext(ctx); ok {
-			_, updateErr := s.slp.PutLog(
-				ctx,
-				agentCtx.ParentAgentType,
-				agentCtx.CurrentAgentType,
-				database.SearchengineTypeSearxng,
-				searchArgs.Query,
-				string(resultJSON),
-				s.taskID,
-				s.subtaskID,
-			)
-			if updateErr != nil {
-				logrus.WithError(updateErr).Error("failed to update search log with results")
-			}
+		stats := SearchStats{
+			Duration:    time.Since(start),
+			ResultCount: len(results),
+		}
+		_, updateErr := putSearchLog(
+			ctx,
+			s.slp,
+			database.SearchengineTypeSearxng,
+			searchArgs.Query,
+			string(resultJSON),
+			stats,
+			s.taskID,
+			s.subtaskID,
+		)
+		if updateErr != nil {
+			logrus.WithError(updateErr).Error("failed to update search log with results")
 		}
 	}
 
 	// Format the results
-	return s.formatSearchResults(results, searchArgs.Query), nil
+	result := s.formatSearchResults(results, searchArgs.Query)
+	result = withResultsSummary(ctx, s.summarizer, searchArgs.Summarize, "Searxng", searchArgs.Query,
+		searxngResultItems(results), result)
+
+	return withSourceFooter(result, "Searxng", s.sourceFooter), nil
+}
+
+// searchResults returns structured results for the metasearch
+func (s *SearxngTool) searchResults(ctx context.Context, query string, maxResults int) ([]SearchResultItem, error) {
+	results, err := s.performSearxngSearch(ctx, query, maxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	return searxngResultItems(results[:min(len(results), maxResults)]), nil
+}
+
+func searxngResultItems(results []SearxngResult) []SearchResultItem {
+	items := make([]SearchResultItem, 0, len(results))
+	for _, result := range results {
+		items = append(items, SearchResultItem{
+			Title:     result.Title,
+			URL:       result.URL,
+			Snippet:   result.Content,
+			Published: result.PublishedDate,
+		})
+	}
+
+	return items
 }
 
 // performSearxngSearch performs the actual search against the Searxng API
@@ -189,19 +266,15 @@ func (s *SearxngTool) performSearxngSearch(ctx context.Context, query string, ma
 	apiURL.RawQuery = params.Encode()
 
 	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: s.timeout,
-	}
-
-	// If proxy URL is provided, configure proxy
+	// If proxy URL is provided, check it before the request
 	if s.proxyURL != "" {
-		proxyURL, err := url.Parse(s.proxyURL)
-		if err != nil {
+		if _, err := url.Parse(s.proxyURL); err != nil {
 			return nil, fmt.Errorf("invalid proxy URL: %w", err)
 		}
-		client.Transport = &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
+	}
+	client := newHTTPClient(s.proxyURL, resolveTimeout(SearxngToolName, s.timeout))
+	if s.transport != nil {
+		client.Transport = s.transport
 	}
 
 	// Make the request
@@ -222,12 +295,12 @@ func (s *SearxngTool) performSearxngSearch(ctx context.Context, query string, ma
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, newNetworkError(err, "failed to make request")
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("searxng API returned status code: %d", resp.StatusCode)
+		return nil, newSearchError(errorKindByStatus(resp.StatusCode), "searxng API returned status code: %d", resp.StatusCode)
 	}
 
 	// Parse the response
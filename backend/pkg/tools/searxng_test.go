@@ -58,6 +58,7 @@ func TestNewSearxngTool(t *testing.T) {
 		timeRange,
 		proxyURL,
 		timeout,
+		false,
 		searchLog,
 		summarizer,
 	)
@@ -115,6 +116,21 @@ func TestNewSearxngTool(t *testing.T) {
 	}
 }
 
+func TestNewSearxngToolWithConfig(t *testing.T) {
+	tool := NewSearxngToolWithConfig(SearxngConfig{BaseURL: "https://searxng.example.com", Language: "en"})
+	if tool.timeout != defaultSearxngTimeout {
+		t.Errorf("Expected default timeout %v, got %v", defaultSearxngTimeout, tool.timeout)
+	}
+	if tool.baseURL != "https://searxng.example.com" || tool.language != "en" {
+		t.Errorf("Expected set fields to be kept, got %+v", tool)
+	}
+
+	// the positional constructor takes seconds
+	if tool := NewSearxngTool(0, nil, nil, "", "", "", "", "", "", 30, false, nil, nil); tool.timeout != 30*time.Second {
+		t.Errorf("Expected timeout 30s, got %v", tool.timeout)
+	}
+}
+
 func TestSearxngToolIsAvailable(t *testing.T) {
 	// Test with URL and searchLog available
 	tool1 := &SearxngTool{
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// resultsSummaryMaxLength is the length of the summary asked from the summarizer
+	resultsSummaryMaxLength = 2000
+	// resultsSummaryTopLinks is the number of the top results whose links are kept under the summary
+	resultsSummaryTopLinks = 5
+)
+
+// resultsSummaryTemplate is the prompt of the summarizer which condenses search results of any engine
+var resultsSummaryTemplate = template.Must(template.New("results_summary").Funcs(template.FuncMap{
+	"inc": func(i int) int {
+		return i + 1
+	},
+}).Parse(`<instructions>
+TASK: Summarize {{.Engine}} search results for the following user query:
+
+USER QUERY: "{{.Query}}"
+
+DATA:
+- <results> contains the search results ranked by relevance with their titles, links and snippets
+
+REQUIREMENTS:
+1. Create focused summary (max {{.MaxLength}} chars) that DIRECTLY answers the user query
+2. Synthesize facts found in several results instead of retelling every result
+3. Preserve technical details such as affected versions, CVE identifiers, commands and numbers
+4. Mention the number of the result in brackets (e.g. [2]) when a specific fact is kept
+5. NEVER add information which is absent in the results
+
+FORMAT:
+- Begin with a direct answer to the user query
+- Use bullet points for separate findings
+- Don't list the links, they are appended to the summary separately
+</instructions>
+
+<results>
+{{range $index, $item := .Results}}{{$index | inc}}. {{$item.Title}}
+URL: {{$item.URL}}
+{{if $item.Snippet}}{{$item.Snippet}}
+{{end}}
+{{end}}</results>`))
+
+// withResultsSummary is the opt-in post-processor of search tools, it replaces the formatted results
+// by the short summary with the top links when the call asked for it, the formatted results are kept
+// as is when the summarizer isn't configured or fails, so the call never breaks because of it
+func withResultsSummary(ctx context.Context, summarizer SummarizeHandler, enabled bool,
+	engine, query string, items []SearchResultItem, formatted string,
+) string {
+	if !enabled || summarizer == nil || len(items) == 0 {
+		return formatted
+	}
+
+	summary, err := summarizeResults(ctx, summarizer, engine, query, items)
+	if err != nil {
+		logrus.WithContext(ctx).WithError(err).WithField("engine", engine).
+			Warn("failed to summarize search results, returning them as is")
+		return formatted
+	}
+
+	return summary
+}
+
+// summarizeResults asks the summarizer to condense the results and appends links of the top results,
+// so the agent keeps the sources without the full snippets
+func summarizeResults(ctx context.Context, summarizer SummarizeHandler,
+	engine, query string, items []SearchResultItem,
+) (string, error) {
+	var prompt bytes.Buffer
+	err := resultsSummaryTemplate.Execute(&prompt, map[string]any{
+		"Engine":    engine,
+		"Query":     query,
+		"MaxLength": resultsSummaryMaxLength,
+		"Results":   items,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute summarize prompt: %w", err)
+	}
+
+	summary, err := summarizer(ctx, prompt.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize results: %w", err)
+	}
+	if summary = strings.TrimSpace(summary); summary == "" {
+		return "", errors.New("summarizer returned empty summary")
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# Summary of %s Results\n\n", engine))
+	builder.WriteString(summary)
+	builder.WriteString("\n\n## Top Links\n\n")
+	for i, item := range items[:min(len(items), resultsSummaryTopLinks)] {
+		title := item.Title
+		if title == "" {
+			title = item.URL
+		}
+		builder.WriteString(fmt.Sprintf("%d. [%s](%s)\n", i+1, title, item.URL))
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeSummarizer returns the canned summary or the error and keeps the prompts it was asked with
+type fakeSummarizer struct {
+	summary string
+	err     error
+	prompts []string
+}
+
+func (f *fakeSummarizer) summarize(ctx context.Context, prompt string) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	return f.summary, f.err
+}
+
+var summarizeTestItems = []SearchResultItem{
+	{Title: "Log4Shell advisory", URL: "https://example.com/advisory", Snippet: "log4j 2.0-2.14.1 is affected"},
+	{Title: "", URL: "https://example.com/no-title", Snippet: "patched in 2.15.0"},
+	{Title: "Exploit notes", URL: "https://example.com/notes"},
+	{Title: "Scanner", URL: "https://example.com/scanner"},
+	{Title: "Mitigation", URL: "https://example.com/mitigation"},
+	{Title: "Sixth", URL: "https://example.com/sixth"},
+}
+
+func TestWithResultsSummary(t *testing.T) {
+	summarizer := &fakeSummarizer{summary: "  Log4j 2.0-2.14.1 is affected [1], fixed in 2.15.0 [2]  "}
+
+	result := withResultsSummary(context.Background(), summarizer.summarize, true,
+		"Google", "log4shell versions", summarizeTestItems, "formatted results")
+
+	if !strings.HasPrefix(result, "# Summary of Google Results\n\nLog4j 2.0-2.14.1 is affected [1], fixed in 2.15.0 [2]\n\n") {
+		t.Errorf("expected the trimmed summary under the header, got %q", result)
+	}
+	for _, want := range []string{
+		"## Top Links",
+		"1. [Log4Shell advisory](https://example.com/advisory)",
+		"2. [https://example.com/no-title](https://example.com/no-title)",
+		"5. [Mitigation](https://example.com/mitigation)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %q in the result, got %q", want, result)
+		}
+	}
+	if strings.Contains(result, "Sixth") || strings.Contains(result, "formatted results") {
+		t.Errorf("expected only the top links without the formatted results, got %q", result)
+	}
+
+	if len(summarizer.prompts) != 1 {
+		t.Fatalf("expected one summarizer call, got %d", len(summarizer.prompts))
+	}
+	for _, want := range []string{"Google", `"log4shell versions"`, "log4j 2.0-2.14.1 is affected", "6. Sixth"} {
+		if !strings.Contains(summarizer.prompts[0], want) {
+			t.Errorf("expected %q in the prompt, got %q", want, summarizer.prompts[0])
+		}
+	}
+}
+
+func TestWithResultsSummarySkipped(t *testing.T) {
+	tests := []struct {
+		name       string
+		summarizer *fakeSummarizer
+		enabled    bool
+		items      []SearchResultItem
+		wantCalls  int
+	}{
+		{"not asked", &fakeSummarizer{summary: "summary"}, false, summarizeTestItems, 0},
+		{"no summarizer", nil, true, summarizeTestItems, 0},
+		{"no results", &fakeSummarizer{summary: "summary"}, true, nil, 0},
+		{"summarizer failed", &fakeSummarizer{err: errors.New("model is down")}, true, summarizeTestItems, 1},
+		{"empty summary", &fakeSummarizer{summary: " \n"}, true, summarizeTestItems, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var summarizer SummarizeHandler
+			if tt.summarizer != nil {
+				summarizer = tt.summarizer.summarize
+			}
+
+			result := withResultsSummary(context.Background(), summarizer, tt.enabled,
+				"Google", "log4shell", tt.items, "formatted results")
+			if result != "formatted results" {
+				t.Errorf("expected the formatted results as is, got %q", result)
+			}
+			if tt.summarizer != nil && len(tt.summarizer.prompts) != tt.wantCalls {
+				t.Errorf("expected %d summarizer calls, got %d", tt.wantCalls, len(tt.summarizer.prompts))
+			}
+		})
+	}
+}
+
+func TestMetasearchSummarize(t *testing.T) {
+	newTool := func(summarizer SummarizeHandler) Tool {
+		tool := NewMetasearchTool(0, nil, nil, map[string]Tool{
+			"alpha": &fakeResultsSearcher{available: true, items: []SearchResultItem{
+				{Title: "Alpha", URL: "https://example.com/alpha", Snippet: "alpha is affected"},
+			}},
+		}, 0, ResultLimits{}, false, nil, nil).(*metasearch)
+		tool.summarizer = summarizer
+
+		return tool
+	}
+
+	tests := []struct {
+		name       string
+		summarizer *fakeSummarizer
+		summarize  bool
+		want       string
+		wantCalls  int
+	}{
+		{"summarized", &fakeSummarizer{summary: "short answer"}, true, "# Summary of Metasearch Results\n\nshort answer", 1},
+		{"not asked", &fakeSummarizer{summary: "short answer"}, false, "alpha is affected", 0},
+		{"no summarizer", nil, true, "alpha is affected", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var summarizer SummarizeHandler
+			if tt.summarizer != nil {
+				summarizer = tt.summarizer.summarize
+			}
+
+			args, _ := json.Marshal(SearchAction{Query: "log4shell", MaxResults: 5, Summarize: tt.summarize})
+			result, err := newTool(summarizer).Handle(context.Background(), MetasearchToolName, args)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if !strings.Contains(result, tt.want) {
+				t.Errorf("expected %q in the result, got %q", tt.want, result)
+			}
+			if tt.summarizer != nil && len(tt.summarizer.prompts) != tt.wantCalls {
+				t.Errorf("expected %d summarizer calls, got %d", tt.wantCalls, len(tt.summarizer.prompts))
+			}
+		})
+	}
+}
@@ -4,23 +4,38 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
 	"text/template"
+	"time"
 
 	"pentagi/pkg/database"
-	obs "pentagi/pkg/observability"
-	"pentagi/pkg/observability/langfuse"
 
 	"github.com/sirupsen/logrus"
 )
 
-const tavilyURL = "https://api.tavily.com/search"
+const (
+	tavilyURL        = "https://api.tavily.com/search"
+	tavilyExtractURL = "https://api.tavily.com/extract"
+	// tavilyDefaultResults is the default of the API, tavilyMaxResults is its ceiling
+	tavilyDefaultResults = 5
+	tavilyMaxResults     = 20
+	// tavilyTimeout is used when the timeout isn't set, advanced search depth with raw content is slow
+	tavilyTimeout = 60 * time.Second
+)
 
 const maxRawContentLength = 3000
 
+const (
+	// tavilyExtractMaxURLs is the ceiling of the API for URLs of the single extract request
+	tavilyExtractMaxURLs = 20
+	// tavilyExtractMaxContentLength caps the content of every page, the whole page is rarely needed
+	tavilyExtractMaxContentLength = 10000
+)
+
 type tavilyRequest struct {
 	ApiKey            string   `json:"api_key"`
 	Query             string   `json:"query"`
@@ -34,6 +49,29 @@ type tavilyRequest struct {
 	ExcludeDomains    []string `json:"exclude_domains,omitempty"`
 }
 
+type tavilyExtractRequest struct {
+	ApiKey       string   `json:"api_key"`
+	URLs         []string `json:"urls"`
+	ExtractDepth string   `json:"extract_depth,omitempty"`
+	Format       string   `json:"format,omitempty"`
+}
+
+type tavilyExtractResult struct {
+	Results       []tavilyExtractedPage `json:"results"`
+	FailedResults []tavilyFailedPage    `json:"failed_results"`
+	ResponseTime  float64               `json:"response_time"`
+}
+
+type tavilyExtractedPage struct {
+	URL        string `json:"url"`
+	RawContent string `json:"raw_content"`
+}
+
+type tavilyFailedPage struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
 type tavilySearchResult struct {
 	Answer       string         `json:"answer"`
 	Query        string         `json:"query"`
@@ -50,32 +88,112 @@ type tavilyResult struct {
 }
 
 type tavily struct {
-	flowID     int64
-	taskID     *int64
-	subtaskID  *int64
-	apiKey     string
-	proxyURL   string
-	slp        SearchLogProvider
-	summarizer SummarizeHandler
+	flowID       int64
+	taskID       *int64
+	subtaskID    *int64
+	apiKey       string
+	proxyURL     string
+	apiURL       string
+	extractURL   string
+	transport    http.RoundTripper
+	timeout      time.Duration
+	retry        RetryPolicy
+	maxBody      int64
+	limits       ResultLimits
+	dryRun       bool
+	cache        CacheProvider
+	sourceFooter bool
+	highlight    bool
+	cveEnricher  CVEEnricher
+	slp          SearchLogProvider
+	summarizer   SummarizeHandler
+	tracer       Tracer
+	quota        quotaTracker
+}
+
+// TavilyConfig configures the tavily search tool, zero fields keep the defaults of the tool
+type TavilyConfig struct {
+	FlowID    int64
+	TaskID    *int64
+	SubtaskID *int64
+
+	APIKey   string
+	ProxyURL string
+	Timeout  time.Duration
+	Retry    RetryPolicy
+	MaxBody  int64
+	Limits   ResultLimits
+
+	// APIURL, ExtractURL and Transport replace the public API, e.g. by the mock server
+	APIURL     string
+	ExtractURL string
+	Transport  http.RoundTripper
+
+	DryRun       bool
+	Cache        CacheProvider
+	SourceFooter bool
+	Highlight    bool
+	CVEEnricher  CVEEnricher
+	SearchLog    SearchLogProvider
+	Summarizer   SummarizeHandler
+	Tracer       Tracer
 }
 
+// NewTavilyTool creates tavily search tool, it's kept for existing callers,
+// NewTavilyToolWithConfig is easier to read at the call site
 func NewTavilyTool(flowID int64, taskID, subtaskID *int64, apiKey, proxyURL string,
-	slp SearchLogProvider, summarizer SummarizeHandler,
+	timeout time.Duration, retry RetryPolicy, limits ResultLimits, dryRun bool, sourceFooter, highlight bool, slp SearchLogProvider, summarizer SummarizeHandler,
 ) Tool {
+	return NewTavilyToolWithConfig(TavilyConfig{
+		FlowID:       flowID,
+		TaskID:       taskID,
+		SubtaskID:    subtaskID,
+		APIKey:       apiKey,
+		ProxyURL:     proxyURL,
+		Timeout:      timeout,
+		Retry:        retry,
+		Limits:       limits,
+		DryRun:       dryRun,
+		SourceFooter: sourceFooter,
+		Highlight:    highlight,
+		SearchLog:    slp,
+		Summarizer:   summarizer,
+	})
+}
+
+// NewTavilyToolWithConfig creates tavily search tool, zero timeout means the default one,
+// retry bounds repeats of rate limited and failed by server requests,
+// highlight bolds the query terms in the short content of the links
+func NewTavilyToolWithConfig(cfg TavilyConfig) Tool {
+	cfg.Timeout = resolveTimeout(TavilyToolName, cfg.Timeout)
+
 	return &tavily{
-		flowID:     flowID,
-		taskID:     taskID,
-		subtaskID:  subtaskID,
-		apiKey:     apiKey,
-		proxyURL:   proxyURL,
-		slp:        slp,
-		summarizer: summarizer,
+		flowID:       cfg.FlowID,
+		taskID:       cfg.TaskID,
+		subtaskID:    cfg.SubtaskID,
+		apiKey:       cfg.APIKey,
+		proxyURL:     cfg.ProxyURL,
+		apiURL:       cfg.APIURL,
+		extractURL:   cfg.ExtractURL,
+		transport:    cfg.Transport,
+		timeout:      cfg.Timeout,
+		retry:        cfg.Retry,
+		maxBody:      cfg.MaxBody,
+		limits:       cfg.Limits,
+		dryRun:       cfg.DryRun,
+		cache:        cfg.Cache,
+		sourceFooter: cfg.SourceFooter,
+		highlight:    cfg.Highlight,
+		cveEnricher:  cfg.CVEEnricher,
+		slp:          cfg.SearchLog,
+		summarizer:   cfg.Summarizer,
+		tracer:       cfg.Tracer,
 	}
 }
 
 func (t *tavily) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
 	var action SearchAction
-	ctx, observation := obs.Observer.NewObservation(ctx)
+	ctx, emitter := startTrace(ctx, t.tracer)
 	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
 		"tool": name,
 		"args": string(args),
@@ -85,58 +203,44 @@ func (t *tavily) Handle(ctx context.Context, name string, args json.RawMessage)
 		logger.WithError(err).Error("failed to unmarshal tavily search action")
 		return "", fmt.Errorf("failed to unmarshal %s search action arguments: %w", name, err)
 	}
+	action.Query = sanitizeQuery(action.Query, maxQueryLength)
+	numResults := t.limits.resolve(tavilyDefaultResults, tavilyMaxResults).clamp(action.MaxResults.Int())
 
-	logger = logger.WithFields(logrus.Fields{
-		"query":       action.Query[:min(len(action.Query), 1000)],
-		"max_results": action.MaxResults,
+	logger = withToolFields(logger, "tavily", action.Query, t.flowID, t.taskID, t.subtaskID, logrus.Fields{
+		"max_results": numResults,
 	})
 
-	result, err := t.search(ctx, action.Query, action.MaxResults.Int())
+	cacheKey := searchCacheKey(database.SearchengineTypeTavily, action.Query, numResults, t.highlight)
+	stats, start := SearchStats{Cached: true}, time.Now()
+	result, err := withSearchCache(ctx, dryRunCache(t.cache, t.dryRun), cacheKey, &stats, func() (string, error) {
+		result, count, err := withNoResultsText(t.search(withSearchPriority(ctx, action.Priority), action.Query, numResults))
+		stats.Cached, stats.ResultCount = false, count
+		return result, err
+	})
+	recordSearchCall(ctx, database.SearchengineTypeTavily, stats.Cached, time.Since(start), err)
+	if event, ok := quotaEvent("tavily", t); ok && !stats.Cached {
+		emitter.Emit(event)
+	}
 	if err != nil {
-		observation.Event(
-			langfuse.WithEventName("search engine error swallowed"),
-			langfuse.WithEventInput(action.Query),
-			langfuse.WithEventStatus(err.Error()),
-			langfuse.WithEventLevel(langfuse.ObservationLevelWarning),
-			langfuse.WithEventMetadata(langfuse.Metadata{
-				"tool_name":   TavilyToolName,
-				"engine":      "tavily",
-				"query":       action.Query,
-				"max_results": action.MaxResults.Int(),
-				"error":       err.Error(),
-			}),
-		)
+		emitter.Emit(searchErrorEvent(action.Query, err, map[string]any{
+			"tool_name":   TavilyToolName,
+			"engine":      "tavily",
+			"max_results": numResults,
+		}))
 
 		logger.WithError(err).Error("failed to search in tavily")
 		return fmt.Sprintf("failed to search in tavily: %v", err), nil
 	}
 
-	if agentCtx, ok := GetAgentContext(ctx); ok {
-		_, _ = t.slp.PutLog(
-			ctx,
-			agentCtx.ParentAgentType,
-			agentCtx.CurrentAgentType,
-			database.SearchengineTypeTavily,
-			action.Query,
-			result,
-			t.taskID,
-			t.subtaskID,
-		)
-	}
+	stats.Duration = time.Since(start)
+	_, _ = putSearchLog(ctx, t.slp, database.SearchengineTypeTavily, action.Query, result, stats, t.taskID, t.subtaskID)
 
-	return result, nil
-}
+	result = withReferencedCVEs(ctx, result, t.cveEnricher)
 
-func (t *tavily) search(ctx context.Context, query string, maxResults int) (string, error) {
-	client := http.DefaultClient
-	if t.proxyURL != "" {
-		client.Transport = &http.Transport{
-			Proxy: func(req *http.Request) (*url.URL, error) {
-				return url.Parse(t.proxyURL)
-			},
-		}
-	}
+	return withSourceFooter(result, "Tavily", t.sourceFooter), nil
+}
 
+func (t *tavily) search(ctx context.Context, query string, maxResults int) (string, int, error) {
 	reqPayload := tavilyRequest{
 		Query:             query,
 		ApiKey:            t.apiKey,
@@ -147,62 +251,337 @@ func (t *tavily) search(ctx context.Context, query string, maxResults int) (stri
 		IncludeRawContent: true,
 		MaxResults:        maxResults,
 	}
+
+	if t.dryRun {
+		req, err := t.newRequest(ctx, t.searchURL(), reqPayload)
+		if err != nil {
+			return "", 0, err
+		}
+		result, err := describeRequest(req)
+		return result, 0, err
+	}
+
+	resp, err := t.do(ctx, reqPayload)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	return t.parseHTTPResponse(ctx, resp, query)
+}
+
+// searchResults returns structured results for the metasearch, basic search depth is enough
+// because the answer and raw content aren't used there
+func (t *tavily) searchResults(ctx context.Context, query string, maxResults int) ([]SearchResultItem, error) {
+	reqPayload := tavilyRequest{
+		Query:       query,
+		ApiKey:      t.apiKey,
+		Topic:       "general",
+		SearchDepth: "basic",
+		MaxResults:  maxResults,
+	}
+
+	if t.dryRun {
+		req, err := t.newRequest(ctx, t.searchURL(), reqPayload)
+		if err != nil {
+			return nil, err
+		}
+		return dryRunResults(req)
+	}
+
+	resp, err := t.do(ctx, reqPayload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	t.quota.update(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		return nil, t.handleErrorResponse(resp.StatusCode)
+	}
+
+	var respBody tavilySearchResult
+	if err := t.decodeResponse(ctx, resp, &respBody); err != nil {
+		return nil, err
+	}
+	if len(respBody.Results) == 0 {
+		return nil, newNoResultsError("tavily found no results for the query")
+	}
+
+	items := make([]SearchResultItem, 0, len(respBody.Results))
+	for _, result := range respBody.Results {
+		items = append(items, SearchResultItem{
+			Title:   result.Title,
+			URL:     result.URL,
+			Snippet: result.Content,
+			Score:   result.Score,
+		})
+	}
+
+	return items, nil
+}
+
+// do sends the search request after its turn in the rate limit queue by the priority of the context
+func (t *tavily) do(ctx context.Context, reqPayload tavilyRequest) (*http.Response, error) {
+	if err := waitSearchQueue(ctx, database.SearchengineTypeTavily, searchPriority(ctx)); err != nil {
+		return nil, err
+	}
+
+	return t.post(ctx, t.searchURL(), reqPayload)
+}
+
+// post sends the payload to the endpoint of the API through the client with the proxy and the timeout of the tool
+func (t *tavily) post(ctx context.Context, apiURL string, reqPayload any) (*http.Response, error) {
+	req, err := t.newRequest(ctx, apiURL, reqPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	return doWithRetry(t.createHTTPClient(), req, t.retry)
+}
+
+func (t *tavily) searchURL() string {
+	if t.apiURL != "" {
+		return t.apiURL
+	}
+	return tavilyURL
+}
+
+func (t *tavily) extractPageURL() string {
+	if t.extractURL != "" {
+		return t.extractURL
+	}
+	return tavilyExtractURL
+}
+
+func (t *tavily) newRequest(ctx context.Context, apiURL string, reqPayload any) (*http.Request, error) {
 	reqBody, err := json.Marshal(reqPayload)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request body: %v", err)
+		return nil, fmt.Errorf("failed to marshal request body: %v", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, tavilyURL, bytes.NewBuffer(reqBody))
+	req, err := http.NewRequest(http.MethodPost, apiURL, bytes.NewBuffer(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to build request: %v", err)
+		return nil, fmt.Errorf("failed to build request: %v", err)
 	}
 
 	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	return req, nil
+}
+
+// createHTTPClient builds a new client per request on top of the shared transport unless one is injected
+func (t *tavily) createHTTPClient() *http.Client {
+	timeout := resolveTimeout(TavilyToolName, t.timeout)
+
+	client := newHTTPClient(t.proxyURL, timeout)
+	if t.transport != nil {
+		client.Transport = t.transport
+	}
+
+	return client
+}
+
+func (t *tavily) parseHTTPResponse(ctx context.Context, resp *http.Response, query string) (string, int, error) {
+	t.quota.update(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, t.handleErrorResponse(resp.StatusCode)
+	}
+
+	var respBody tavilySearchResult
+	if err := t.decodeResponse(ctx, resp, &respBody); err != nil {
+		return "", 0, err
+	}
+	if len(respBody.Results) == 0 && strings.TrimSpace(respBody.Answer) == "" {
+		return "", 0, newNoResultsError("tavily found neither the answer nor results for the query")
+	}
+
+	return t.buildTavilyResult(ctx, &respBody, query), len(respBody.Results), nil
+}
+
+// Extract fetches the cleaned content of the pages by the extract endpoint as markdown, it's the way
+// to read found results in depth without the browser scraper; the request goes through the proxy
+// and the timeout of the tool, pages which failed are listed below the content of the others
+// and fail the call only when nothing is extracted
+func (t *tavily) Extract(ctx context.Context, urls []string) (string, error) {
+	urls, err := normalizeTavilyExtractURLs(urls)
+	if err != nil {
+		return "", err
+	}
+
+	reqPayload := tavilyExtractRequest{
+		ApiKey:       t.apiKey,
+		URLs:         urls,
+		ExtractDepth: "basic",
+		Format:       "markdown",
+	}
+
+	if t.dryRun {
+		req, err := t.newRequest(ctx, t.extractPageURL(), reqPayload)
+		if err != nil {
+			return "", err
+		}
+		return describeRequest(req)
+	}
+
+	resp, err := t.post(ctx, t.extractPageURL(), reqPayload)
 	if err != nil {
-		return "", fmt.Errorf("failed to do request: %v", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
-	return t.parseHTTPResponse(ctx, resp)
+	t.quota.update(resp.Header)
+	if resp.StatusCode != http.StatusOK {
+		return "", t.handleErrorResponse(resp.StatusCode)
+	}
+
+	body, err := readLimitedBody(resp.Body, t.maxBody)
+	if err != nil {
+		return "", err
+	}
+
+	var result tavilyExtractResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to decode response body: %v", err)
+	}
+	checkResponseKeys(ctx, "tavily", body, "results")
+
+	return buildTavilyExtractResult(urls, &result)
 }
 
-func (t *tavily) parseHTTPResponse(ctx context.Context, resp *http.Response) (string, error) {
-	switch resp.StatusCode {
-	case http.StatusOK:
-		var respBody tavilySearchResult
-		if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
-			return "", fmt.Errorf("failed to decode response body: %v", err)
+// normalizeTavilyExtractURLs drops blank and duplicate URLs and checks the rest are absolute http(s) ones
+func normalizeTavilyExtractURLs(urls []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(urls))
+	normalized := make([]string, 0, len(urls))
+	for _, rawURL := range urls {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" {
+			continue
+		}
+		if _, ok := seen[rawURL]; ok {
+			continue
+		}
+
+		parsed, err := url.Parse(rawURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("url %q must be the absolute http(s) URL", rawURL)
 		}
-		return t.buildTavilyResult(ctx, &respBody), nil
+
+		seen[rawURL] = struct{}{}
+		normalized = append(normalized, rawURL)
+	}
+
+	if len(normalized) == 0 {
+		return nil, errors.New("no urls to extract")
+	}
+	if len(normalized) > tavilyExtractMaxURLs {
+		return nil, fmt.Errorf("too many urls to extract: %d, the limit is %d", len(normalized), tavilyExtractMaxURLs)
+	}
+
+	return normalized, nil
+}
+
+// buildTavilyExtractResult formats the content of the extracted pages in order of the requested URLs,
+// the URLs missing in the response or returned without content are reported as failed too
+func buildTavilyExtractResult(urls []string, result *tavilyExtractResult) (string, error) {
+	pages := make(map[string]string, len(result.Results))
+	for _, page := range result.Results {
+		if content := strings.TrimSpace(page.RawContent); content != "" {
+			pages[page.URL] = content
+		}
+	}
+
+	failures := make(map[string]string, len(result.FailedResults))
+	for _, page := range result.FailedResults {
+		failures[page.URL] = page.Error
+	}
+
+	var content, failed strings.Builder
+	extracted := 0
+	for _, pageURL := range urls {
+		page, ok := pages[pageURL]
+		if !ok {
+			reason, ok := failures[pageURL]
+			if !ok || reason == "" {
+				reason = "no content returned"
+			}
+			failed.WriteString(fmt.Sprintf("* %s: %s\n", pageURL, reason))
+			continue
+		}
+
+		if runes := []rune(page); len(runes) > tavilyExtractMaxContentLength {
+			page = string(runes[:tavilyExtractMaxContentLength]) + "..."
+		}
+
+		extracted++
+		content.WriteString(fmt.Sprintf("## %d. %s\n\n%s\n\n", extracted, pageURL, page))
+	}
+
+	if extracted == 0 {
+		return "", fmt.Errorf("tavily failed to extract all pages:\n%s", strings.TrimSuffix(failed.String(), "\n"))
+	}
+
+	var writer strings.Builder
+	writer.WriteString("# Extracted Pages\n\n")
+	writer.WriteString(content.String())
+	if failed.Len() != 0 {
+		writer.WriteString("# Failed Pages\n\n")
+		writer.WriteString(failed.String())
+	}
+
+	return writer.String(), nil
+}
+
+// LastQuota returns the API credits left from the last response of Tavily
+func (t *tavily) LastQuota() (int, time.Time, bool) {
+	return t.quota.LastQuota()
+}
+
+// decodeResponse fails only on the malformed body, the missing results are reported as the drift
+// of the response schema because decoding keeps them empty silently
+func (t *tavily) decodeResponse(ctx context.Context, resp *http.Response, result *tavilySearchResult) error {
+	body, err := readLimitedBody(resp.Body, t.maxBody)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("failed to decode response body: %v", err)
+	}
+	checkResponseKeys(ctx, "tavily", body, "results")
+
+	return nil
+}
+
+func (t *tavily) handleErrorResponse(statusCode int) error {
+	switch statusCode {
 	case http.StatusBadRequest:
-		return "", fmt.Errorf("request is invalid")
+		return fmt.Errorf("request is invalid")
 	case http.StatusUnauthorized:
-		return "", fmt.Errorf("API key is wrong")
+		return newSearchError(ErrAuth, "API key is wrong")
 	case http.StatusForbidden:
-		return "", fmt.Errorf("the endpoint requested is hidden for administrators only")
+		return newSearchError(ErrAuth, "the endpoint requested is hidden for administrators only")
 	case http.StatusNotFound:
-		return "", fmt.Errorf("the specified endpoint could not be found")
+		return fmt.Errorf("the specified endpoint could not be found")
 	case http.StatusMethodNotAllowed:
-		return "", fmt.Errorf("there need to try to access an endpoint with an invalid method")
+		return fmt.Errorf("there need to try to access an endpoint with an invalid method")
 	case http.StatusTooManyRequests:
-		return "", fmt.Errorf("there are requesting too many results")
+		return newSearchError(ErrRateLimited, "there are requesting too many results")
 	case http.StatusInternalServerError:
-		return "", fmt.Errorf("there had a problem with our server. try again later")
+		return newSearchError(ErrUpstreamUnavailable, "there had a problem with our server. try again later")
 	case http.StatusBadGateway:
-		return "", fmt.Errorf("there was a problem with the server. Please try again later")
+		return newSearchError(ErrUpstreamUnavailable, "there was a problem with the server. Please try again later")
 	case http.StatusServiceUnavailable:
-		return "", fmt.Errorf("there are temporarily offline for maintenance. please try again later")
+		return newSearchError(ErrUpstreamUnavailable, "there are temporarily offline for maintenance. please try again later")
 	case http.StatusGatewayTimeout:
-		return "", fmt.Errorf("there are temporarily offline for maintenance. please try again later")
+		return newSearchError(ErrUpstreamUnavailable, "there are temporarily offline for maintenance. please try again later")
 	default:
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return newSearchError(errorKindByStatus(statusCode), "unexpected status code: %d", statusCode)
 	}
 }
 
-func (t *tavily) buildTavilyResult(ctx context.Context, result *tavilySearchResult) string {
+func (t *tavily) buildTavilyResult(ctx context.Context, result *tavilySearchResult, query string) string {
 	var writer strings.Builder
 	writer.WriteString("# Answer\n\n")
 	writer.WriteString(result.Answer)
@@ -213,7 +592,11 @@ func (t *tavily) buildTavilyResult(ctx context.Context, result *tavilySearchResu
 		writer.WriteString(fmt.Sprintf("## %d. %s\n\n", i+1, result.Title))
 		writer.WriteString(fmt.Sprintf("* URL %s\n", result.URL))
 		writer.WriteString(fmt.Sprintf("* Match score %3.3f\n\n", result.Score))
-		writer.WriteString(fmt.Sprintf("### Short content\n\n%s\n\n", result.Content))
+		content := result.Content
+		if t.highlight {
+			content = highlightTerms(content, query)
+		}
+		writer.WriteString(fmt.Sprintf("### Short content\n\n%s\n\n", content))
 		if result.RawContent != nil {
 			isRawContentExists = true
 		}
@@ -306,6 +689,38 @@ The summary MUST provide complete answers to the user's query, preserving all re
 	return buf.String(), nil
 }
 
+// EstimateCost prices the advanced search of the number of results which the call would request
+func (t *tavily) EstimateCost(args json.RawMessage) (CostEstimate, error) {
+	var action SearchAction
+	if err := json.Unmarshal(args, &action); err != nil {
+		return CostEstimate{}, fmt.Errorf("failed to unmarshal %s search action arguments: %w", TavilyToolName, err)
+	}
+	numResults := t.limits.resolve(tavilyDefaultResults, tavilyMaxResults).clamp(action.MaxResults.Int())
+
+	return resolvePricing(TavilyToolName).estimate(1, numResults, 0, 1), nil
+}
+
 func (t *tavily) IsAvailable() bool {
-	return t.apiKey != ""
+	return t.apiKey != "" && IsEnabled(TavilyToolName)
+}
+
+// HealthCheck runs the cheapest basic search for a single result to verify the API key and proxy
+func (t *tavily) HealthCheck(ctx context.Context) error {
+	resp, err := t.do(ctx, tavilyRequest{
+		Query:       "test",
+		ApiKey:      t.apiKey,
+		Topic:       "general",
+		SearchDepth: "basic",
+		MaxResults:  1,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return t.handleErrorResponse(resp.StatusCode)
+	}
+
+	return nil
 }
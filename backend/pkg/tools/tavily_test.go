@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTavilyToolDefaultTimeout(t *testing.T) {
+	tool := NewTavilyTool(0, nil, nil, "test-key", "", 0, RetryPolicy{}, ResultLimits{}, false, false, false, nil, nil).(*tavily)
+	if tool.timeout != tavilyTimeout {
+		t.Errorf("timeout = %v, want %v", tool.timeout, tavilyTimeout)
+	}
+
+	tool = NewTavilyTool(0, nil, nil, "test-key", "", 5*time.Second, RetryPolicy{}, ResultLimits{}, false, false, false, nil, nil).(*tavily)
+	if tool.timeout != 5*time.Second {
+		t.Errorf("timeout = %v, want %v", tool.timeout, 5*time.Second)
+	}
+
+	if client := (&tavily{}).createHTTPClient(); client.Timeout != tavilyTimeout {
+		t.Errorf("client timeout = %v, want %v", client.Timeout, tavilyTimeout)
+	}
+}
+
+func TestNewTavilyToolWithConfig(t *testing.T) {
+	tool := NewTavilyToolWithConfig(TavilyConfig{APIKey: "test-key", Highlight: true}).(*tavily)
+	if tool.timeout != tavilyTimeout {
+		t.Errorf("timeout = %v, want %v", tool.timeout, tavilyTimeout)
+	}
+	if tool.apiKey != "test-key" || !tool.highlight {
+		t.Errorf("expected set fields to be kept, got %+v", tool)
+	}
+
+	tool = NewTavilyToolWithConfig(TavilyConfig{Timeout: 5 * time.Second, Limits: ResultLimits{Default: 3}}).(*tavily)
+	if tool.timeout != 5*time.Second || tool.limits.Default != 3 {
+		t.Errorf("expected timeout and limits to be kept, got %v, %+v", tool.timeout, tool.limits)
+	}
+}
+
+func TestTavilySearchTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	tool := &tavily{apiKey: "test-key", apiURL: server.URL, timeout: 20 * time.Millisecond}
+	_, _, err := tool.search(context.Background(), "query", 5)
+	if !errors.Is(err, ErrNetwork) {
+		t.Fatalf("expected %v, got %v", ErrNetwork, err)
+	}
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestTavilySearchDoesNotMutateDefaultClient(t *testing.T) {
+	transport := http.DefaultClient.Transport
+	tool := &tavily{apiKey: "test-key", apiURL: "http://127.0.0.1:0", proxyURL: "http://127.0.0.1:0"}
+	_, _, _ = tool.search(context.Background(), "query", 5)
+
+	if http.DefaultClient.Transport != transport {
+		t.Error("expected http.DefaultClient transport to stay untouched")
+	}
+}
+
+// newTavilyExtractServer answers the extract request by the content of known pages and fails the rest,
+// it keeps the last request to check the payload
+func newTavilyExtractServer(t *testing.T, pages map[string]string, last *tavilyExtractRequest) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/extract" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(last); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+
+		result := tavilyExtractResult{Results: []tavilyExtractedPage{}, FailedResults: []tavilyFailedPage{}}
+		for _, pageURL := range last.URLs {
+			if content, ok := pages[pageURL]; ok {
+				result.Results = append(result.Results, tavilyExtractedPage{URL: pageURL, RawContent: content})
+			} else if !strings.Contains(pageURL, "missing") {
+				result.FailedResults = append(result.FailedResults, tavilyFailedPage{URL: pageURL, Error: "failed to fetch url"})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}))
+}
+
+func TestTavilyExtract(t *testing.T) {
+	pages := map[string]string{
+		"https://example.com/advisory": "# Advisory\n\nlog4j 2.0-2.14.1 is affected",
+		"https://example.com/patch":    "Upgrade to 2.17.1",
+	}
+
+	var last tavilyExtractRequest
+	server := newTavilyExtractServer(t, pages, &last)
+	defer server.Close()
+
+	tool := &tavily{apiKey: "test-key", extractURL: server.URL + "/extract"}
+	result, err := tool.Extract(context.Background(), []string{
+		"https://example.com/advisory",
+		"https://down.example.com/",
+		" https://example.com/patch ",
+		"https://example.com/advisory",
+		"https://example.com/missing",
+	})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+
+	if last.ApiKey != "test-key" || last.Format != "markdown" || len(last.URLs) != 4 {
+		t.Errorf("expected deduplicated urls with the key and markdown format, got %+v", last)
+	}
+	for _, want := range []string{
+		"# Extracted Pages\n\n## 1. https://example.com/advisory\n\n# Advisory\n\nlog4j 2.0-2.14.1 is affected\n\n",
+		"## 2. https://example.com/patch\n\nUpgrade to 2.17.1\n\n",
+		"# Failed Pages\n\n* https://down.example.com/: failed to fetch url\n* https://example.com/missing: no content returned\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("expected %q in the result, got:\n%s", want, result)
+		}
+	}
+}
+
+func TestTavilyExtractFailures(t *testing.T) {
+	var last tavilyExtractRequest
+	server := newTavilyExtractServer(t, nil, &last)
+	defer server.Close()
+
+	tool := &tavily{apiKey: "test-key", extractURL: server.URL + "/extract"}
+	_, err := tool.Extract(context.Background(), []string{"https://down.example.com/"})
+	if err == nil || !strings.Contains(err.Error(), "* https://down.example.com/: failed to fetch url") {
+		t.Errorf("expected all pages to fail with the reason, got %v", err)
+	}
+
+	tooMany := make([]string, 0, tavilyExtractMaxURLs+1)
+	for i := 0; i <= tavilyExtractMaxURLs; i++ {
+		tooMany = append(tooMany, fmt.Sprintf("https://example.com/page/%d", i))
+	}
+
+	for _, urls := range [][]string{
+		nil,
+		{" "},
+		{"file:///etc/passwd"},
+		{"example.com/no-scheme"},
+		tooMany,
+	} {
+		last = tavilyExtractRequest{}
+		if _, err := tool.Extract(context.Background(), urls); err == nil {
+			t.Errorf("expected urls %q to be rejected", urls)
+		}
+		if last.URLs != nil {
+			t.Errorf("expected urls %q to be rejected without the request", urls)
+		}
+	}
+}
+
+func TestTavilyExtractThroughProxy(t *testing.T) {
+	var host string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.URL.Host
+		io.WriteString(w, `{"results":[{"url":"https://example.com/","raw_content":"proxied"}],"failed_results":[]}`)
+	}))
+	defer proxy.Close()
+
+	tool := &tavily{apiKey: "test-key", proxyURL: proxy.URL, extractURL: "http://api.tavily.invalid/extract"}
+	result, err := tool.Extract(context.Background(), []string{"https://example.com/"})
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if host != "api.tavily.invalid" || !strings.Contains(result, "proxied") {
+		t.Errorf("expected the request to go through the proxy, got host %q and result %q", host, result)
+	}
+}
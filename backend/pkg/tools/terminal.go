@@ -383,3 +383,7 @@ func FormatTerminalSystemOutput(text string) string {
 func (t *terminal) IsAvailable() bool {
 	return t.dockerClient != nil
 }
+
+func (t *terminal) HealthCheck(ctx context.Context) error {
+	return nil
+}
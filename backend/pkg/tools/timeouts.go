@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultEngineTimeout is the fallback timeout of engines which are absent in DefaultTimeouts
+const defaultEngineTimeout = 30 * time.Second
+
+// DefaultTimeouts are the hardcoded request timeouts of the engines keyed by their tool names,
+// they are used when neither the tool nor the configuration sets the timeout
+var DefaultTimeouts = map[string]time.Duration{
+	AbuseIPDBToolName:  abuseipdbTimeout,
+	DNSToolName:        dnsTimeout,
+	DuckDuckGoToolName: duckduckgoTimeout,
+	GithubToolName:     githubTimeout,
+	HIBPToolName:       hibpTimeout,
+	HTTPFetchToolName:  httpFetchTimeout,
+	IPInfoToolName:     ipinfoTimeout,
+	MetasearchToolName: metasearchTimeout,
+	PerplexityToolName: perplexityTimeout,
+	SearxngToolName:    defaultSearxngTimeout,
+	TavilyToolName:     tavilyTimeout,
+	TraversaalToolName: traversaalTimeout,
+	WaybackToolName:    waybackTimeout,
+	WhoisToolName:      whoisTimeout,
+}
+
+var (
+	configuredTimeoutsMx sync.RWMutex
+	configuredTimeouts   map[string]time.Duration
+)
+
+// InitDefaultTimeouts sets the configured default timeouts of the engines keyed by their tool names,
+// they override DefaultTimeouts for the tools created without the own timeout, non-positive values are dropped
+func InitDefaultTimeouts(timeouts map[string]time.Duration) {
+	configuredTimeoutsMx.Lock()
+	defer configuredTimeoutsMx.Unlock()
+
+	configuredTimeouts = make(map[string]time.Duration, len(timeouts))
+	for engine, timeout := range timeouts {
+		if timeout > 0 {
+			configuredTimeouts[engine] = timeout
+		}
+	}
+}
+
+// resolveTimeout returns the effective timeout of the engine: the explicit timeout of the tool wins,
+// then the configured default and the hardcoded one at last
+func resolveTimeout(engine string, timeout time.Duration) time.Duration {
+	if timeout > 0 {
+		return timeout
+	}
+
+	configuredTimeoutsMx.RLock()
+	timeout, ok := configuredTimeouts[engine]
+	configuredTimeoutsMx.RUnlock()
+	if ok {
+		return timeout
+	}
+
+	if timeout, ok := DefaultTimeouts[engine]; ok {
+		return timeout
+	}
+
+	return defaultEngineTimeout
+}
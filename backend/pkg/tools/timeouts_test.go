@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveTimeout(t *testing.T) {
+	InitDefaultTimeouts(map[string]time.Duration{
+		PerplexityToolName: 90 * time.Second,
+		WhoisToolName:      0,
+	})
+	defer InitDefaultTimeouts(nil)
+
+	tests := []struct {
+		name    string
+		engine  string
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{"explicit wins over configured", PerplexityToolName, 5 * time.Second, 5 * time.Second},
+		{"zero falls through to configured", PerplexityToolName, 0, 90 * time.Second},
+		{"negative falls through to configured", PerplexityToolName, -time.Second, 90 * time.Second},
+		{"explicit wins over hardcoded", TavilyToolName, 5 * time.Second, 5 * time.Second},
+		{"zero falls through to hardcoded", TavilyToolName, 0, tavilyTimeout},
+		{"non-positive configured is dropped", WhoisToolName, 0, whoisTimeout},
+		{"unknown engine", "unknown", 0, defaultEngineTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveTimeout(tt.engine, tt.timeout); got != tt.want {
+				t.Errorf("resolveTimeout(%q, %v) = %v, want %v", tt.engine, tt.timeout, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolTimeoutPrecedence(t *testing.T) {
+	InitDefaultTimeouts(map[string]time.Duration{
+		PerplexityToolName: 90 * time.Second,
+		SearxngToolName:    15 * time.Second,
+	})
+	defer InitDefaultTimeouts(nil)
+
+	tool := NewPerplexityToolWithConfig(PerplexityConfig{APIKey: "test-key"}).(*perplexity)
+	if tool.timeout != 90*time.Second {
+		t.Errorf("expected the configured default, got %v", tool.timeout)
+	}
+
+	tool = NewPerplexityToolWithConfig(PerplexityConfig{APIKey: "test-key", Timeout: time.Second}).(*perplexity)
+	if tool.timeout != time.Second {
+		t.Errorf("expected the timeout of the tool, got %v", tool.timeout)
+	}
+
+	searxng := NewSearxngToolWithConfig(SearxngConfig{BaseURL: "http://searxng"})
+	if searxng.timeout != 15*time.Second {
+		t.Errorf("expected the configured default, got %v", searxng.timeout)
+	}
+
+	if timeout := (&ipinfo{}).getTimeout(); timeout != ipinfoTimeout {
+		t.Errorf("expected the hardcoded default, got %v", timeout)
+	}
+	if timeout := (&ipinfo{timeout: time.Second}).getTimeout(); timeout != time.Second {
+		t.Errorf("expected the timeout of the tool, got %v", timeout)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"time"
 
 	"pentagi/pkg/config"
 	"pentagi/pkg/database"
@@ -57,9 +59,16 @@ type FunctionInfo struct {
 	Schema string
 }
 
+// Tool is shared by parallel subtasks of the flow, so Handle is called concurrently: tools keep
+// the configuration set at construction and never change it, every call builds its own HTTP client
+// on top of the shared transports, and the state changed by calls (quota, proxy rotation,
+// screenshot slots) is guarded by its own mutex, atomic or channel
 type Tool interface {
 	Handle(ctx context.Context, name string, args json.RawMessage) (string, error)
 	IsAvailable() bool
+	// HealthCheck makes a minimal call to the external service behind the tool,
+	// tools without external dependencies return nil
+	HealthCheck(ctx context.Context) error
 }
 
 type ScreenshotProvider interface {
@@ -139,6 +148,14 @@ type flowToolsExecutor struct {
 	slp    SearchLogProvider
 	tlp    TermLogProvider
 	vslp   VectorStoreLogProvider
+	cache  CacheProvider
+	al     AuditLogger
+	scSem  chan struct{}
+	scTLS  TLSConfig
+	policy *URLPolicy
+	scope  *DomainScope
+
+	fetchTLS TLSConfig
 
 	db             database.Querier
 	cfg            *config.Config
@@ -149,6 +166,10 @@ type flowToolsExecutor struct {
 	primaryID      int64
 	primaryLID     string
 	functions      *Functions
+	resultTemplate *ResultTemplate
+	cveEnricher    CVEEnricher
+	proxyPool      *ProxyPool
+	retryPolicy    RetryPolicy
 
 	definitions map[string]llms.FunctionDefinition
 	handlers    map[string]ExecutorHandler
@@ -309,14 +330,78 @@ func NewFlowToolsExecutor(
 	functions *Functions,
 	flowID int64,
 ) (FlowToolsExecutor, error) {
+	var cache CacheProvider
+	if cfg.SearchCacheTTL > 0 {
+		cacheDir := filepath.Join(cfg.DataDir, searchCacheDirName)
+		cache = NewSearchCacheWithConfig(SearchCacheConfig{
+			Dir:        cacheDir,
+			TTL:        time.Duration(cfg.SearchCacheTTL) * time.Second,
+			StaleAfter: time.Duration(cfg.SearchCacheStaleAfter) * time.Second,
+		})
+	}
+
+	resultTemplate, err := LoadResultTemplate(cfg.SearchResultTemplatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search result template: %w", err)
+	}
+
+	proxyPool, err := NewProxyPool(cfg.ProxyPoolURLs, cfg.ProxyPoolRotation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proxy pool: %w", err)
+	}
+
+	retryPolicy := NewRetryPolicy(cfg.SearchRetries,
+		time.Duration(cfg.SearchRetryMaxDelay)*time.Second, time.Duration(cfg.SearchRetryDeadline)*time.Second)
+
+	var al AuditLogger
+	if cfg.ToolAuditEnabled {
+		al = NewAuditLogger(db)
+	}
+
+	var cveEnricher CVEEnricher
+	if cfg.CVEEnrichEnabled {
+		cveEnricher = NewNVDEnricher(cfg.NVDAPIKey, cfg.ProxyURL)
+	}
+
+	policy, err := NewURLPolicy(cfg.ScraperBlockMetadata, cfg.ScraperAllowCIDRs, cfg.ScraperDenyCIDRs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create browser url policy: %w", err)
+	}
+
+	scope, err := NewDomainScope(cfg.ScraperAllowDomains, cfg.ScraperDenyDomains)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create browser domain scope: %w", err)
+	}
+
+	scTLS, err := NewTLSConfig(cfg.ScraperInsecureSkipVerify, cfg.ScraperCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scraper tls settings: %w", err)
+	}
+
+	fetchTLS, err := NewTLSConfig(cfg.HTTPFetchInsecureSkipVerify, cfg.HTTPFetchCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load http fetch tls settings: %w", err)
+	}
+
 	return &flowToolsExecutor{
-		db:          db,
-		docker:      docker,
-		functions:   functions,
-		cfg:         cfg,
-		flowID:      flowID,
-		definitions: make(map[string]llms.FunctionDefinition),
-		handlers:    make(map[string]ExecutorHandler),
+		db:             db,
+		docker:         docker,
+		functions:      functions,
+		cfg:            cfg,
+		flowID:         flowID,
+		cache:          cache,
+		al:             al,
+		cveEnricher:    cveEnricher,
+		resultTemplate: resultTemplate,
+		proxyPool:      proxyPool,
+		retryPolicy:    retryPolicy,
+		scSem:          newScreenshotSemaphore(cfg.ScraperMaxScreenshots),
+		scTLS:          scTLS,
+		policy:         policy,
+		scope:          scope,
+		fetchTLS:       fetchTLS,
+		definitions:    make(map[string]llms.FunctionDefinition),
+		handlers:       make(map[string]ExecutorHandler),
 	}, nil
 }
 
@@ -421,6 +506,8 @@ func (fte *flowToolsExecutor) Prepare(ctx context.Context) error {
 }
 
 func (fte *flowToolsExecutor) Release(ctx context.Context) error {
+	ResetFlowBudget(fte.flowID)
+
 	if fte.store != nil {
 		fte.store.Close()
 	}
@@ -469,6 +556,7 @@ func (fte *flowToolsExecutor) GetCustomExecutor(cfg CustomExecutorConfig) (Conte
 		mlp:         fte.mlp,
 		vslp:        fte.vslp,
 		db:          fte.db,
+		al:          fte.al,
 		store:       fte.store,
 		definitions: cfg.Definitions,
 		handlers:    cfg.Handlers,
@@ -525,17 +613,40 @@ func (fte *flowToolsExecutor) GetAssistantExecutor(cfg AssistantExecutorConfig)
 	}
 
 	browser := &browser{
-		flowID:   fte.flowID,
-		dataDir:  fte.cfg.DataDir,
-		scPrvURL: fte.cfg.ScraperPrivateURL,
-		scPubURL: fte.cfg.ScraperPublicURL,
-		scp:      fte.scp,
+		flowID:           fte.flowID,
+		dataDir:          fte.cfg.DataDir,
+		scPrvURL:         fte.cfg.ScraperPrivateURL,
+		scPubURL:         fte.cfg.ScraperPublicURL,
+		scTLS:            fte.scTLS,
+		scSem:            fte.scSem,
+		policy:           fte.policy,
+		scope:            fte.scope,
+		maxContentBytes:  fte.cfg.ScraperMaxContentBytes,
+		minSizes:         fte.minContentSizes(),
+		dedupScreenshots: fte.cfg.ScraperDedupScreenshots,
+		scp:              fte.scp,
 	}
 	if browser.IsAvailable() {
 		definitions = append(definitions, registryDefinitions[BrowserToolName])
 		handlers[BrowserToolName] = browser.Handle
 	}
 
+	httpFetch := &httpFetch{
+		flowID:      fte.flowID,
+		enabled:     fte.cfg.HTTPFetchEnabled,
+		proxyURL:    fte.cfg.ProxyURL,
+		proxyPool:   fte.proxyPool,
+		timeout:     time.Duration(fte.cfg.HTTPFetchTimeout) * time.Second,
+		maxBodySize: fte.cfg.HTTPFetchMaxBodySize,
+		tls:         fte.fetchTLS,
+		policy:      fte.policy,
+		scope:       fte.scope,
+	}
+	if httpFetch.IsAvailable() {
+		definitions = append(definitions, registryDefinitions[HTTPFetchToolName])
+		handlers[HTTPFetchToolName] = httpFetch.Handle
+	}
+
 	if cfg.UseAgents {
 		definitions = append(definitions,
 			registryDefinitions[AdviceToolName],
@@ -592,89 +703,257 @@ func (fte *flowToolsExecutor) GetAssistantExecutor(cfg AssistantExecutorConfig)
 			handlers[SearchCodeToolName] = code.Handle
 		}
 
-		google := &google{
-			flowID:   fte.flowID,
-			apiKey:   fte.cfg.GoogleAPIKey,
-			cxKey:    fte.cfg.GoogleCXKey,
-			lrKey:    fte.cfg.GoogleLRKey,
-			proxyURL: fte.cfg.ProxyURL,
-			slp:      fte.slp,
-		}
+		google := fte.searchTool(NewGoogleToolWithConfig(GoogleConfig{
+			FlowID:       fte.flowID,
+			APIKey:       fte.cfg.GoogleAPIKey,
+			CXKey:        fte.cfg.GoogleCXKey,
+			LRKey:        fte.cfg.GoogleLRKey,
+			SafeSearch:   fte.cfg.SearchSafeSearch,
+			ProxyURL:     fte.cfg.ProxyURL,
+			Limits:       ResultLimits{Default: fte.cfg.GoogleDefaultResults, Max: fte.cfg.GoogleMaxResults},
+			DryRun:       fte.cfg.SearchDryRun,
+			Cache:        fte.cache,
+			SourceFooter: fte.cfg.SearchSourceFooter,
+			Highlight:    fte.cfg.SearchHighlightTerms,
+			Template:     fte.resultTemplate,
+			CVEEnricher:  fte.cveEnricher,
+			SearchLog:    fte.slp,
+			Summarizer:   cfg.Summarizer,
+		}))
 		if google.IsAvailable() {
 			definitions = append(definitions, registryDefinitions[GoogleToolName])
-			handlers[GoogleToolName] = google.Handle
+			handlers[GoogleToolName] = withFlowBudget(fte.flowID, google)
 		}
 
-		duckduckgo := &duckduckgo{
-			flowID:   fte.flowID,
-			enabled:  fte.cfg.DuckDuckGoEnabled,
-			proxyURL: fte.cfg.ProxyURL,
-			slp:      fte.slp,
-		}
+		duckduckgo := fte.searchTool(&duckduckgo{
+			flowID:       fte.flowID,
+			enabled:      fte.cfg.DuckDuckGoEnabled,
+			proxyURL:     fte.cfg.ProxyURL,
+			proxyPool:    fte.proxyPool,
+			retry:        fte.retryPolicy,
+			safeSearch:   fte.cfg.SearchSafeSearch,
+			limits:       ResultLimits{Default: fte.cfg.DuckDuckGoDefaultResults, Max: fte.cfg.DuckDuckGoMaxResults},
+			cache:        fte.cache,
+			sourceFooter: fte.cfg.SearchSourceFooter,
+			cveEnricher:  fte.cveEnricher,
+			template:     fte.resultTemplate,
+			slp:          fte.slp,
+			summarizer:   cfg.Summarizer,
+		})
 		if duckduckgo.IsAvailable() {
 			definitions = append(definitions, registryDefinitions[DuckDuckGoToolName])
 			handlers[DuckDuckGoToolName] = duckduckgo.Handle
 		}
 
-		tavily := &tavily{
-			flowID:     fte.flowID,
-			apiKey:     fte.cfg.TavilyAPIKey,
-			proxyURL:   fte.cfg.ProxyURL,
-			slp:        fte.slp,
-			summarizer: cfg.Summarizer,
-		}
+		tavily := fte.searchTool(NewTavilyToolWithConfig(TavilyConfig{
+			FlowID:       fte.flowID,
+			APIKey:       fte.cfg.TavilyAPIKey,
+			ProxyURL:     fte.cfg.ProxyURL,
+			Timeout:      time.Duration(fte.cfg.TavilyTimeout) * time.Second,
+			Retry:        fte.retryPolicy,
+			MaxBody:      fte.cfg.SearchMaxResponseSize,
+			Limits:       ResultLimits{Default: fte.cfg.TavilyDefaultResults, Max: fte.cfg.TavilyMaxResults},
+			DryRun:       fte.cfg.SearchDryRun,
+			Cache:        fte.cache,
+			SourceFooter: fte.cfg.SearchSourceFooter,
+			Highlight:    fte.cfg.SearchHighlightTerms,
+			CVEEnricher:  fte.cveEnricher,
+			SearchLog:    fte.slp,
+			Summarizer:   cfg.Summarizer,
+		}))
 		if tavily.IsAvailable() {
 			definitions = append(definitions, registryDefinitions[TavilyToolName])
-			handlers[TavilyToolName] = tavily.Handle
+			handlers[TavilyToolName] = withFlowBudget(fte.flowID, tavily)
 		}
 
-		traversaal := &traversaal{
-			flowID:   fte.flowID,
-			apiKey:   fte.cfg.TraversaalAPIKey,
-			proxyURL: fte.cfg.ProxyURL,
-			slp:      fte.slp,
-		}
+		traversaal := fte.searchTool(NewTraversaalToolWithConfig(TraversaalConfig{
+			FlowID:       fte.flowID,
+			APIKey:       fte.cfg.TraversaalAPIKey,
+			ProxyURL:     fte.cfg.ProxyURL,
+			Timeout:      time.Duration(fte.cfg.TraversaalTimeout) * time.Second,
+			Retry:        fte.retryPolicy,
+			MaxBody:      fte.cfg.SearchMaxResponseSize,
+			Limits:       ResultLimits{Default: fte.cfg.TraversaalDefaultResults, Max: fte.cfg.TraversaalMaxResults},
+			Fields:       TraversaalFields{Answer: fte.cfg.TraversaalAnswerPath, Links: fte.cfg.TraversaalLinksPath},
+			DryRun:       fte.cfg.SearchDryRun,
+			Cache:        fte.cache,
+			SourceFooter: fte.cfg.SearchSourceFooter,
+			CVEEnricher:  fte.cveEnricher,
+			SearchLog:    fte.slp,
+		}))
 		if traversaal.IsAvailable() {
 			definitions = append(definitions, registryDefinitions[TraversaalToolName])
-			handlers[TraversaalToolName] = traversaal.Handle
+			handlers[TraversaalToolName] = withFlowBudget(fte.flowID, traversaal)
 		}
 
-		perplexity := &perplexity{
-			flowID:      fte.flowID,
-			apiKey:      fte.cfg.PerplexityAPIKey,
-			proxyURL:    fte.cfg.ProxyURL,
-			model:       fte.cfg.PerplexityModel,
-			contextSize: fte.cfg.PerplexityContextSize,
-			temperature: perplexityTemperature,
-			topP:        perplexityTopP,
-			maxTokens:   perplexityMaxTokens,
-			timeout:     perplexityTimeout,
-			slp:         fte.slp,
-			summarizer:  cfg.Summarizer,
-		}
+		perplexity := fte.searchTool(NewPerplexityToolWithConfig(PerplexityConfig{
+			FlowID:                fte.flowID,
+			APIKey:                fte.cfg.PerplexityAPIKey,
+			BaseURL:               fte.cfg.PerplexityServerURL,
+			ProxyURL:              fte.cfg.ProxyURL,
+			ConnectTimeout:        time.Duration(fte.cfg.PerplexityConnectTimeout) * time.Second,
+			ResponseHeaderTimeout: time.Duration(fte.cfg.PerplexityResponseHeaderTimeout) * time.Second,
+			Model:                 fte.cfg.PerplexityModel,
+			ContextSize:           fte.cfg.PerplexityContextSize,
+			SystemPrompt:          fte.cfg.PerplexitySystemPrompt,
+			RelatedQuestions:      fte.cfg.PerplexityRelatedQuestions,
+			DisableSummarize:      !fte.cfg.PerplexitySummarize,
+			KeepReasoning:         fte.cfg.PerplexityKeepReasoning,
+			DryRun:                fte.cfg.SearchDryRun,
+			Cache:                 fte.cache,
+			SourceFooter:          fte.cfg.SearchSourceFooter,
+			SearchLog:             fte.slp,
+			Summarizer:            cfg.Summarizer,
+			Gateway:               NewLLMGateway(fte.cfg),
+		}))
 		if perplexity.IsAvailable() {
 			definitions = append(definitions, registryDefinitions[PerplexityToolName])
-			handlers[PerplexityToolName] = perplexity.Handle
+			handlers[PerplexityToolName] = withFlowBudget(fte.flowID, perplexity)
 		}
 
-		searxng := NewSearxngTool(
-			fte.flowID,
-			nil, // taskID
-			nil, // subtaskID
-			fte.cfg.SearxngURL,
-			fte.cfg.SearxngCategories,
-			fte.cfg.SearxngLanguage,
-			fte.cfg.SearxngSafeSearch,
-			fte.cfg.SearxngTimeRange,
-			fte.cfg.ProxyURL,
-			0, // timeout (will use default)
-			fte.slp,
-			cfg.Summarizer,
-		)
+		searxng := fte.searchTool(NewSearxngToolWithConfig(SearxngConfig{
+			FlowID:       fte.flowID,
+			BaseURL:      fte.cfg.SearxngURL,
+			Categories:   fte.cfg.SearxngCategories,
+			Language:     fte.cfg.SearxngLanguage,
+			SafeSearch:   fte.cfg.SearxngSafeSearch,
+			TimeRange:    fte.cfg.SearxngTimeRange,
+			ProxyURL:     fte.cfg.ProxyURL,
+			SourceFooter: fte.cfg.SearchSourceFooter,
+			SearchLog:    fte.slp,
+			Summarizer:   cfg.Summarizer,
+		}))
 		if searxng.IsAvailable() {
 			definitions = append(definitions, registryDefinitions[SearxngToolName])
 			handlers[SearxngToolName] = searxng.Handle
 		}
+
+		github := &github{
+			flowID:       fte.flowID,
+			enabled:      fte.cfg.GithubSearchEnabled,
+			token:        fte.cfg.GithubSearchToken,
+			proxyURL:     fte.cfg.ProxyURL,
+			maxBody:      fte.cfg.SearchMaxResponseSize,
+			limits:       ResultLimits{Default: fte.cfg.GithubSearchDefaultResults, Max: fte.cfg.GithubSearchMaxResults},
+			cache:        fte.cache,
+			sourceFooter: fte.cfg.SearchSourceFooter,
+			slp:          fte.slp,
+		}
+		if github.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[GithubToolName])
+			handlers[GithubToolName] = github.Handle
+		}
+
+		abuseipdb := &abuseipdb{
+			flowID:   fte.flowID,
+			apiKey:   fte.cfg.AbuseIPDBAPIKey,
+			proxyURL: fte.cfg.ProxyURL,
+			cache:    fte.cache,
+			slp:      fte.slp,
+		}
+		if abuseipdb.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[AbuseIPDBToolName])
+			handlers[AbuseIPDBToolName] = abuseipdb.Handle
+		}
+
+		hibp := &hibp{
+			flowID:   fte.flowID,
+			apiKey:   fte.cfg.HIBPAPIKey,
+			proxyURL: fte.cfg.ProxyURL,
+			retry:    fte.retryPolicy,
+			cache:    fte.cache,
+			slp:      fte.slp,
+		}
+		if hibp.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[HIBPToolName])
+			handlers[HIBPToolName] = hibp.Handle
+		}
+
+		whois := &whois{
+			flowID:   fte.flowID,
+			enabled:  fte.cfg.WhoisEnabled,
+			server:   fte.cfg.WhoisServer,
+			proxyURL: fte.cfg.ProxyURL,
+			timeout:  time.Duration(fte.cfg.WhoisTimeout) * time.Second,
+		}
+		if whois.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[WhoisToolName])
+			handlers[WhoisToolName] = whois.Handle
+		}
+
+		dns := &dns{
+			flowID:  fte.flowID,
+			enabled: fte.cfg.DNSEnabled,
+			server:  fte.cfg.DNSServer,
+			timeout: time.Duration(fte.cfg.DNSTimeout) * time.Second,
+		}
+		if dns.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[DNSToolName])
+			handlers[DNSToolName] = dns.Handle
+		}
+
+		wayback := &wayback{
+			flowID:   fte.flowID,
+			enabled:  fte.cfg.WaybackEnabled,
+			proxyURL: fte.cfg.ProxyURL,
+			timeout:  time.Duration(fte.cfg.WaybackTimeout) * time.Second,
+		}
+		if wayback.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[WaybackToolName])
+			handlers[WaybackToolName] = wayback.Handle
+		}
+
+		attack := &attack{
+			flowID:   fte.flowID,
+			enabled:  fte.cfg.AttackEnabled,
+			dataPath: fte.cfg.AttackDataPath,
+		}
+		if attack.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[AttackToolName])
+			handlers[AttackToolName] = attack.Handle
+		}
+
+		ipinfo := &ipinfo{
+			flowID:   fte.flowID,
+			enabled:  fte.cfg.IPInfoEnabled,
+			token:    fte.cfg.IPInfoToken,
+			proxyURL: fte.cfg.ProxyURL,
+			timeout:  time.Duration(fte.cfg.IPInfoTimeout) * time.Second,
+		}
+		if ipinfo.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[IPInfoToolName])
+			handlers[IPInfoToolName] = ipinfo.Handle
+		}
+
+		exploitdb := &exploitdb{
+			flowID:   fte.flowID,
+			dataPath: fte.cfg.ExploitDBPath,
+		}
+		if exploitdb.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[ExploitDBToolName])
+			handlers[ExploitDBToolName] = exploitdb.Handle
+		}
+
+		metasearch := &metasearch{
+			flowID: fte.flowID,
+			engines: newMetasearchEngines(map[string]Tool{
+				GoogleToolName:     google,
+				DuckDuckGoToolName: duckduckgo,
+				TavilyToolName:     tavily,
+				SearxngToolName:    searxng,
+			}),
+			limits:       ResultLimits{Default: fte.cfg.MetasearchDefaultResults, Max: fte.cfg.MetasearchMaxResults},
+			dryRun:       fte.cfg.SearchDryRun,
+			cache:        fte.searchCache(),
+			sourceFooter: fte.cfg.SearchSourceFooter,
+			template:     fte.resultTemplate,
+			slp:          fte.slp,
+			summarizer:   cfg.Summarizer,
+		}
+		if metasearch.IsAvailable() {
+			definitions = append(definitions, registryDefinitions[MetasearchToolName])
+			handlers[MetasearchToolName] = withFlowBudget(fte.flowID, metasearch)
+		}
 	}
 
 	ce := &customExecutor{
@@ -682,6 +961,7 @@ func (fte *flowToolsExecutor) GetAssistantExecutor(cfg AssistantExecutorConfig)
 		mlp:         fte.mlp,
 		vslp:        fte.vslp,
 		db:          fte.db,
+		al:          fte.al,
 		store:       fte.store,
 		definitions: definitions,
 		handlers:    handlers,
@@ -728,6 +1008,7 @@ func (fte *flowToolsExecutor) GetPrimaryExecutor(cfg PrimaryExecutorConfig) (Con
 		mlp:       fte.mlp,
 		vslp:      fte.vslp,
 		db:        fte.db,
+		al:        fte.al,
 		store:     fte.store,
 		definitions: []llms.FunctionDefinition{
 			registryDefinitions[FinalyToolName],
@@ -801,6 +1082,7 @@ func (fte *flowToolsExecutor) GetInstallerExecutor(cfg InstallerExecutorConfig)
 		mlp:       fte.mlp,
 		vslp:      fte.vslp,
 		db:        fte.db,
+		al:        fte.al,
 		store:     fte.store,
 		definitions: []llms.FunctionDefinition{
 			registryDefinitions[MaintenanceResultToolName],
@@ -825,13 +1107,20 @@ func (fte *flowToolsExecutor) GetInstallerExecutor(cfg InstallerExecutorConfig)
 	}
 
 	browser := &browser{
-		flowID:    fte.flowID,
-		taskID:    cfg.TaskID,
-		subtaskID: cfg.SubtaskID,
-		dataDir:   fte.cfg.DataDir,
-		scPrvURL:  fte.cfg.ScraperPrivateURL,
-		scPubURL:  fte.cfg.ScraperPublicURL,
-		scp:       fte.scp,
+		flowID:           fte.flowID,
+		taskID:           cfg.TaskID,
+		subtaskID:        cfg.SubtaskID,
+		dataDir:          fte.cfg.DataDir,
+		scPrvURL:         fte.cfg.ScraperPrivateURL,
+		scPubURL:         fte.cfg.ScraperPublicURL,
+		scTLS:            fte.scTLS,
+		scSem:            fte.scSem,
+		policy:           fte.policy,
+		scope:            fte.scope,
+		maxContentBytes:  fte.cfg.ScraperMaxContentBytes,
+		minSizes:         fte.minContentSizes(),
+		dedupScreenshots: fte.cfg.ScraperDedupScreenshots,
+		scp:              fte.scp,
 	}
 	if browser.IsAvailable() {
 		ce.definitions = append(ce.definitions, registryDefinitions[BrowserToolName])
@@ -883,6 +1172,7 @@ func (fte *flowToolsExecutor) GetCoderExecutor(cfg CoderExecutorConfig) (Context
 		mlp:       fte.mlp,
 		vslp:      fte.vslp,
 		db:        fte.db,
+		al:        fte.al,
 		store:     fte.store,
 		definitions: []llms.FunctionDefinition{
 			registryDefinitions[CodeResultToolName],
@@ -905,13 +1195,20 @@ func (fte *flowToolsExecutor) GetCoderExecutor(cfg CoderExecutorConfig) (Context
 	}
 
 	browser := &browser{
-		flowID:    fte.flowID,
-		taskID:    cfg.TaskID,
-		subtaskID: cfg.SubtaskID,
-		dataDir:   fte.cfg.DataDir,
-		scPrvURL:  fte.cfg.ScraperPrivateURL,
-		scPubURL:  fte.cfg.ScraperPublicURL,
-		scp:       fte.scp,
+		flowID:           fte.flowID,
+		taskID:           cfg.TaskID,
+		subtaskID:        cfg.SubtaskID,
+		dataDir:          fte.cfg.DataDir,
+		scPrvURL:         fte.cfg.ScraperPrivateURL,
+		scPubURL:         fte.cfg.ScraperPublicURL,
+		scTLS:            fte.scTLS,
+		scSem:            fte.scSem,
+		policy:           fte.policy,
+		scope:            fte.scope,
+		maxContentBytes:  fte.cfg.ScraperMaxContentBytes,
+		minSizes:         fte.minContentSizes(),
+		dedupScreenshots: fte.cfg.ScraperDedupScreenshots,
+		scp:              fte.scp,
 	}
 	if browser.IsAvailable() {
 		ce.definitions = append(ce.definitions, registryDefinitions[BrowserToolName])
@@ -993,6 +1290,7 @@ func (fte *flowToolsExecutor) GetPentesterExecutor(cfg PentesterExecutorConfig)
 		mlp:       fte.mlp,
 		vslp:      fte.vslp,
 		db:        fte.db,
+		al:        fte.al,
 		store:     fte.store,
 		definitions: []llms.FunctionDefinition{
 			registryDefinitions[HackResultToolName],
@@ -1021,19 +1319,44 @@ func (fte *flowToolsExecutor) GetPentesterExecutor(cfg PentesterExecutorConfig)
 	}
 
 	browser := &browser{
-		flowID:    fte.flowID,
-		taskID:    cfg.TaskID,
-		subtaskID: cfg.SubtaskID,
-		dataDir:   fte.cfg.DataDir,
-		scPrvURL:  fte.cfg.ScraperPrivateURL,
-		scPubURL:  fte.cfg.ScraperPublicURL,
-		scp:       fte.scp,
+		flowID:           fte.flowID,
+		taskID:           cfg.TaskID,
+		subtaskID:        cfg.SubtaskID,
+		dataDir:          fte.cfg.DataDir,
+		scPrvURL:         fte.cfg.ScraperPrivateURL,
+		scPubURL:         fte.cfg.ScraperPublicURL,
+		scTLS:            fte.scTLS,
+		scSem:            fte.scSem,
+		policy:           fte.policy,
+		scope:            fte.scope,
+		maxContentBytes:  fte.cfg.ScraperMaxContentBytes,
+		minSizes:         fte.minContentSizes(),
+		dedupScreenshots: fte.cfg.ScraperDedupScreenshots,
+		scp:              fte.scp,
 	}
 	if browser.IsAvailable() {
 		ce.definitions = append(ce.definitions, registryDefinitions[BrowserToolName])
 		ce.handlers[BrowserToolName] = browser.Handle
 	}
 
+	httpFetch := &httpFetch{
+		flowID:      fte.flowID,
+		taskID:      cfg.TaskID,
+		subtaskID:   cfg.SubtaskID,
+		enabled:     fte.cfg.HTTPFetchEnabled,
+		proxyURL:    fte.cfg.ProxyURL,
+		proxyPool:   fte.proxyPool,
+		timeout:     time.Duration(fte.cfg.HTTPFetchTimeout) * time.Second,
+		maxBodySize: fte.cfg.HTTPFetchMaxBodySize,
+		tls:         fte.fetchTLS,
+		policy:      fte.policy,
+		scope:       fte.scope,
+	}
+	if httpFetch.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[HTTPFetchToolName])
+		ce.handlers[HTTPFetchToolName] = httpFetch.Handle
+	}
+
 	guide := &guide{
 		flowID:    fte.flowID,
 		taskID:    cfg.TaskID,
@@ -1078,6 +1401,7 @@ func (fte *flowToolsExecutor) GetSearcherExecutor(cfg SearcherExecutorConfig) (C
 		mlp:       fte.mlp,
 		vslp:      fte.vslp,
 		db:        fte.db,
+		al:        fte.al,
 		store:     fte.store,
 		definitions: []llms.FunctionDefinition{
 			registryDefinitions[SearchResultToolName],
@@ -1094,111 +1418,308 @@ func (fte *flowToolsExecutor) GetSearcherExecutor(cfg SearcherExecutorConfig) (C
 	}
 
 	browser := &browser{
-		flowID:    fte.flowID,
-		taskID:    cfg.TaskID,
-		subtaskID: cfg.SubtaskID,
-		dataDir:   fte.cfg.DataDir,
-		scPrvURL:  fte.cfg.ScraperPrivateURL,
-		scPubURL:  fte.cfg.ScraperPublicURL,
-		scp:       fte.scp,
+		flowID:           fte.flowID,
+		taskID:           cfg.TaskID,
+		subtaskID:        cfg.SubtaskID,
+		dataDir:          fte.cfg.DataDir,
+		scPrvURL:         fte.cfg.ScraperPrivateURL,
+		scPubURL:         fte.cfg.ScraperPublicURL,
+		scTLS:            fte.scTLS,
+		scSem:            fte.scSem,
+		policy:           fte.policy,
+		scope:            fte.scope,
+		maxContentBytes:  fte.cfg.ScraperMaxContentBytes,
+		minSizes:         fte.minContentSizes(),
+		dedupScreenshots: fte.cfg.ScraperDedupScreenshots,
+		scp:              fte.scp,
 	}
 	if browser.IsAvailable() {
 		ce.definitions = append(ce.definitions, registryDefinitions[BrowserToolName])
 		ce.handlers[BrowserToolName] = browser.Handle
 	}
 
-	google := &google{
+	google := fte.searchTool(NewGoogleToolWithConfig(GoogleConfig{
+		FlowID:       fte.flowID,
+		TaskID:       cfg.TaskID,
+		SubtaskID:    cfg.SubtaskID,
+		APIKey:       fte.cfg.GoogleAPIKey,
+		CXKey:        fte.cfg.GoogleCXKey,
+		LRKey:        fte.cfg.GoogleLRKey,
+		SafeSearch:   fte.cfg.SearchSafeSearch,
+		ProxyURL:     fte.cfg.ProxyURL,
+		Limits:       ResultLimits{Default: fte.cfg.GoogleDefaultResults, Max: fte.cfg.GoogleMaxResults},
+		DryRun:       fte.cfg.SearchDryRun,
+		Cache:        fte.cache,
+		SourceFooter: fte.cfg.SearchSourceFooter,
+		Highlight:    fte.cfg.SearchHighlightTerms,
+		Template:     fte.resultTemplate,
+		CVEEnricher:  fte.cveEnricher,
+		SearchLog:    fte.slp,
+		Summarizer:   cfg.Summarizer,
+	}))
+	if google.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[GoogleToolName])
+		ce.handlers[GoogleToolName] = withFlowBudget(fte.flowID, google)
+	}
+
+	duckduckgo := fte.searchTool(&duckduckgo{
+		flowID:       fte.flowID,
+		taskID:       cfg.TaskID,
+		subtaskID:    cfg.SubtaskID,
+		enabled:      fte.cfg.DuckDuckGoEnabled,
+		proxyURL:     fte.cfg.ProxyURL,
+		proxyPool:    fte.proxyPool,
+		retry:        fte.retryPolicy,
+		safeSearch:   fte.cfg.SearchSafeSearch,
+		limits:       ResultLimits{Default: fte.cfg.DuckDuckGoDefaultResults, Max: fte.cfg.DuckDuckGoMaxResults},
+		cache:        fte.cache,
+		sourceFooter: fte.cfg.SearchSourceFooter,
+		cveEnricher:  fte.cveEnricher,
+		template:     fte.resultTemplate,
+		slp:          fte.slp,
+		summarizer:   cfg.Summarizer,
+	})
+	if duckduckgo.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[DuckDuckGoToolName])
+		ce.handlers[DuckDuckGoToolName] = duckduckgo.Handle
+	}
+
+	tavily := fte.searchTool(NewTavilyToolWithConfig(TavilyConfig{
+		FlowID:       fte.flowID,
+		TaskID:       cfg.TaskID,
+		SubtaskID:    cfg.SubtaskID,
+		APIKey:       fte.cfg.TavilyAPIKey,
+		ProxyURL:     fte.cfg.ProxyURL,
+		Timeout:      time.Duration(fte.cfg.TavilyTimeout) * time.Second,
+		Retry:        fte.retryPolicy,
+		MaxBody:      fte.cfg.SearchMaxResponseSize,
+		Limits:       ResultLimits{Default: fte.cfg.TavilyDefaultResults, Max: fte.cfg.TavilyMaxResults},
+		DryRun:       fte.cfg.SearchDryRun,
+		Cache:        fte.cache,
+		SourceFooter: fte.cfg.SearchSourceFooter,
+		Highlight:    fte.cfg.SearchHighlightTerms,
+		CVEEnricher:  fte.cveEnricher,
+		SearchLog:    fte.slp,
+		Summarizer:   cfg.Summarizer,
+	}))
+	if tavily.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[TavilyToolName])
+		ce.handlers[TavilyToolName] = withFlowBudget(fte.flowID, tavily)
+	}
+
+	traversaal := fte.searchTool(NewTraversaalToolWithConfig(TraversaalConfig{
+		FlowID:       fte.flowID,
+		TaskID:       cfg.TaskID,
+		SubtaskID:    cfg.SubtaskID,
+		APIKey:       fte.cfg.TraversaalAPIKey,
+		ProxyURL:     fte.cfg.ProxyURL,
+		Timeout:      time.Duration(fte.cfg.TraversaalTimeout) * time.Second,
+		Retry:        fte.retryPolicy,
+		MaxBody:      fte.cfg.SearchMaxResponseSize,
+		Limits:       ResultLimits{Default: fte.cfg.TraversaalDefaultResults, Max: fte.cfg.TraversaalMaxResults},
+		Fields:       TraversaalFields{Answer: fte.cfg.TraversaalAnswerPath, Links: fte.cfg.TraversaalLinksPath},
+		DryRun:       fte.cfg.SearchDryRun,
+		Cache:        fte.cache,
+		SourceFooter: fte.cfg.SearchSourceFooter,
+		CVEEnricher:  fte.cveEnricher,
+		SearchLog:    fte.slp,
+	}))
+	if traversaal.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[TraversaalToolName])
+		ce.handlers[TraversaalToolName] = withFlowBudget(fte.flowID, traversaal)
+	}
+
+	perplexity := fte.searchTool(NewPerplexityToolWithConfig(PerplexityConfig{
+		FlowID:                fte.flowID,
+		TaskID:                cfg.TaskID,
+		SubtaskID:             cfg.SubtaskID,
+		APIKey:                fte.cfg.PerplexityAPIKey,
+		BaseURL:               fte.cfg.PerplexityServerURL,
+		ProxyURL:              fte.cfg.ProxyURL,
+		ConnectTimeout:        time.Duration(fte.cfg.PerplexityConnectTimeout) * time.Second,
+		ResponseHeaderTimeout: time.Duration(fte.cfg.PerplexityResponseHeaderTimeout) * time.Second,
+		Model:                 fte.cfg.PerplexityModel,
+		ContextSize:           fte.cfg.PerplexityContextSize,
+		SystemPrompt:          fte.cfg.PerplexitySystemPrompt,
+		RelatedQuestions:      fte.cfg.PerplexityRelatedQuestions,
+		DisableSummarize:      !fte.cfg.PerplexitySummarize,
+		KeepReasoning:         fte.cfg.PerplexityKeepReasoning,
+		DryRun:                fte.cfg.SearchDryRun,
+		Cache:                 fte.cache,
+		SourceFooter:          fte.cfg.SearchSourceFooter,
+		SearchLog:             fte.slp,
+		Summarizer:            cfg.Summarizer,
+		Gateway:               NewLLMGateway(fte.cfg),
+	}))
+	if perplexity.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[PerplexityToolName])
+		ce.handlers[PerplexityToolName] = withFlowBudget(fte.flowID, perplexity)
+	}
+
+	searxng := fte.searchTool(NewSearxngToolWithConfig(SearxngConfig{
+		FlowID:       fte.flowID,
+		TaskID:       cfg.TaskID,
+		SubtaskID:    cfg.SubtaskID,
+		BaseURL:      fte.cfg.SearxngURL,
+		Categories:   fte.cfg.SearxngCategories,
+		Language:     fte.cfg.SearxngLanguage,
+		SafeSearch:   fte.cfg.SearxngSafeSearch,
+		TimeRange:    fte.cfg.SearxngTimeRange,
+		ProxyURL:     fte.cfg.ProxyURL,
+		SourceFooter: fte.cfg.SearchSourceFooter,
+		SearchLog:    fte.slp,
+		Summarizer:   cfg.Summarizer,
+	}))
+	if searxng.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[SearxngToolName])
+		ce.handlers[SearxngToolName] = searxng.Handle
+	}
+
+	github := &github{
+		flowID:       fte.flowID,
+		taskID:       cfg.TaskID,
+		subtaskID:    cfg.SubtaskID,
+		enabled:      fte.cfg.GithubSearchEnabled,
+		token:        fte.cfg.GithubSearchToken,
+		proxyURL:     fte.cfg.ProxyURL,
+		maxBody:      fte.cfg.SearchMaxResponseSize,
+		limits:       ResultLimits{Default: fte.cfg.GithubSearchDefaultResults, Max: fte.cfg.GithubSearchMaxResults},
+		cache:        fte.cache,
+		sourceFooter: fte.cfg.SearchSourceFooter,
+		slp:          fte.slp,
+	}
+	if github.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[GithubToolName])
+		ce.handlers[GithubToolName] = github.Handle
+	}
+
+	abuseipdb := &abuseipdb{
 		flowID:    fte.flowID,
 		taskID:    cfg.TaskID,
 		subtaskID: cfg.SubtaskID,
-		apiKey:    fte.cfg.GoogleAPIKey,
-		cxKey:     fte.cfg.GoogleCXKey,
-		lrKey:     fte.cfg.GoogleLRKey,
+		apiKey:    fte.cfg.AbuseIPDBAPIKey,
 		proxyURL:  fte.cfg.ProxyURL,
+		cache:     fte.cache,
 		slp:       fte.slp,
 	}
-	if google.IsAvailable() {
-		ce.definitions = append(ce.definitions, registryDefinitions[GoogleToolName])
-		ce.handlers[GoogleToolName] = google.Handle
+	if abuseipdb.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[AbuseIPDBToolName])
+		ce.handlers[AbuseIPDBToolName] = abuseipdb.Handle
 	}
 
-	duckduckgo := &duckduckgo{
+	hibp := &hibp{
 		flowID:    fte.flowID,
 		taskID:    cfg.TaskID,
 		subtaskID: cfg.SubtaskID,
-		enabled:   fte.cfg.DuckDuckGoEnabled,
+		apiKey:    fte.cfg.HIBPAPIKey,
 		proxyURL:  fte.cfg.ProxyURL,
+		retry:     fte.retryPolicy,
+		cache:     fte.cache,
 		slp:       fte.slp,
 	}
-	if duckduckgo.IsAvailable() {
-		ce.definitions = append(ce.definitions, registryDefinitions[DuckDuckGoToolName])
-		ce.handlers[DuckDuckGoToolName] = duckduckgo.Handle
+	if hibp.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[HIBPToolName])
+		ce.handlers[HIBPToolName] = hibp.Handle
 	}
 
-	tavily := &tavily{
-		flowID:     fte.flowID,
-		taskID:     cfg.TaskID,
-		subtaskID:  cfg.SubtaskID,
-		apiKey:     fte.cfg.TavilyAPIKey,
-		proxyURL:   fte.cfg.ProxyURL,
-		slp:        fte.slp,
-		summarizer: cfg.Summarizer,
+	whois := &whois{
+		flowID:    fte.flowID,
+		taskID:    cfg.TaskID,
+		subtaskID: cfg.SubtaskID,
+		enabled:   fte.cfg.WhoisEnabled,
+		server:    fte.cfg.WhoisServer,
+		proxyURL:  fte.cfg.ProxyURL,
+		timeout:   time.Duration(fte.cfg.WhoisTimeout) * time.Second,
 	}
-	if tavily.IsAvailable() {
-		ce.definitions = append(ce.definitions, registryDefinitions[TavilyToolName])
-		ce.handlers[TavilyToolName] = tavily.Handle
+	if whois.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[WhoisToolName])
+		ce.handlers[WhoisToolName] = whois.Handle
+	}
+
+	dns := &dns{
+		flowID:    fte.flowID,
+		taskID:    cfg.TaskID,
+		subtaskID: cfg.SubtaskID,
+		enabled:   fte.cfg.DNSEnabled,
+		server:    fte.cfg.DNSServer,
+		timeout:   time.Duration(fte.cfg.DNSTimeout) * time.Second,
+	}
+	if dns.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[DNSToolName])
+		ce.handlers[DNSToolName] = dns.Handle
 	}
 
-	traversaal := &traversaal{
+	wayback := &wayback{
 		flowID:    fte.flowID,
 		taskID:    cfg.TaskID,
 		subtaskID: cfg.SubtaskID,
-		apiKey:    fte.cfg.TraversaalAPIKey,
+		enabled:   fte.cfg.WaybackEnabled,
 		proxyURL:  fte.cfg.ProxyURL,
-		slp:       fte.slp,
+		timeout:   time.Duration(fte.cfg.WaybackTimeout) * time.Second,
 	}
-	if traversaal.IsAvailable() {
-		ce.definitions = append(ce.definitions, registryDefinitions[TraversaalToolName])
-		ce.handlers[TraversaalToolName] = traversaal.Handle
+	if wayback.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[WaybackToolName])
+		ce.handlers[WaybackToolName] = wayback.Handle
 	}
 
-	perplexity := &perplexity{
-		flowID:      fte.flowID,
-		taskID:      cfg.TaskID,
-		subtaskID:   cfg.SubtaskID,
-		apiKey:      fte.cfg.PerplexityAPIKey,
-		proxyURL:    fte.cfg.ProxyURL,
-		model:       fte.cfg.PerplexityModel,
-		contextSize: fte.cfg.PerplexityContextSize,
-		temperature: perplexityTemperature,
-		topP:        perplexityTopP,
-		maxTokens:   perplexityMaxTokens,
-		timeout:     perplexityTimeout,
-		slp:         fte.slp,
-		summarizer:  cfg.Summarizer,
+	attack := &attack{
+		flowID:    fte.flowID,
+		taskID:    cfg.TaskID,
+		subtaskID: cfg.SubtaskID,
+		enabled:   fte.cfg.AttackEnabled,
+		dataPath:  fte.cfg.AttackDataPath,
 	}
-	if perplexity.IsAvailable() {
-		ce.definitions = append(ce.definitions, registryDefinitions[PerplexityToolName])
-		ce.handlers[PerplexityToolName] = perplexity.Handle
+	if attack.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[AttackToolName])
+		ce.handlers[AttackToolName] = attack.Handle
 	}
 
-	searxng := NewSearxngTool(
-		fte.flowID,
-		cfg.TaskID,
-		cfg.SubtaskID,
-		fte.cfg.SearxngURL,
-		fte.cfg.SearxngCategories,
-		fte.cfg.SearxngLanguage,
-		fte.cfg.SearxngSafeSearch,
-		fte.cfg.SearxngTimeRange,
-		fte.cfg.ProxyURL,
-		0, // timeout (will use default)
-		fte.slp,
-		cfg.Summarizer,
-	)
-	if searxng.IsAvailable() {
-		ce.definitions = append(ce.definitions, registryDefinitions[SearxngToolName])
-		ce.handlers[SearxngToolName] = searxng.Handle
+	ipinfo := &ipinfo{
+		flowID:    fte.flowID,
+		taskID:    cfg.TaskID,
+		subtaskID: cfg.SubtaskID,
+		enabled:   fte.cfg.IPInfoEnabled,
+		token:     fte.cfg.IPInfoToken,
+		proxyURL:  fte.cfg.ProxyURL,
+		timeout:   time.Duration(fte.cfg.IPInfoTimeout) * time.Second,
+	}
+	if ipinfo.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[IPInfoToolName])
+		ce.handlers[IPInfoToolName] = ipinfo.Handle
+	}
+
+	exploitdb := &exploitdb{
+		flowID:    fte.flowID,
+		taskID:    cfg.TaskID,
+		subtaskID: cfg.SubtaskID,
+		dataPath:  fte.cfg.ExploitDBPath,
+	}
+	if exploitdb.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[ExploitDBToolName])
+		ce.handlers[ExploitDBToolName] = exploitdb.Handle
+	}
+
+	metasearch := &metasearch{
+		flowID:    fte.flowID,
+		taskID:    cfg.TaskID,
+		subtaskID: cfg.SubtaskID,
+		engines: newMetasearchEngines(map[string]Tool{
+			GoogleToolName:     google,
+			DuckDuckGoToolName: duckduckgo,
+			TavilyToolName:     tavily,
+			SearxngToolName:    searxng,
+		}),
+		limits:       ResultLimits{Default: fte.cfg.MetasearchDefaultResults, Max: fte.cfg.MetasearchMaxResults},
+		dryRun:       fte.cfg.SearchDryRun,
+		cache:        fte.searchCache(),
+		sourceFooter: fte.cfg.SearchSourceFooter,
+		template:     fte.resultTemplate,
+		slp:          fte.slp,
+		summarizer:   cfg.Summarizer,
+	}
+	if metasearch.IsAvailable() {
+		ce.definitions = append(ce.definitions, registryDefinitions[MetasearchToolName])
+		ce.handlers[MetasearchToolName] = withFlowBudget(fte.flowID, metasearch)
 	}
 
 	search := &search{
@@ -1247,6 +1768,7 @@ func (fte *flowToolsExecutor) GetGeneratorExecutor(cfg GeneratorExecutorConfig)
 		mlp:    fte.mlp,
 		vslp:   fte.vslp,
 		db:     fte.db,
+		al:     fte.al,
 		store:  fte.store,
 		definitions: []llms.FunctionDefinition{
 			registryDefinitions[MemoristToolName],
@@ -1266,12 +1788,19 @@ func (fte *flowToolsExecutor) GetGeneratorExecutor(cfg GeneratorExecutorConfig)
 	}
 
 	browser := &browser{
-		flowID:   fte.flowID,
-		taskID:   &cfg.TaskID,
-		dataDir:  fte.cfg.DataDir,
-		scPrvURL: fte.cfg.ScraperPrivateURL,
-		scPubURL: fte.cfg.ScraperPublicURL,
-		scp:      fte.scp,
+		flowID:           fte.flowID,
+		taskID:           &cfg.TaskID,
+		dataDir:          fte.cfg.DataDir,
+		scPrvURL:         fte.cfg.ScraperPrivateURL,
+		scPubURL:         fte.cfg.ScraperPublicURL,
+		scTLS:            fte.scTLS,
+		scSem:            fte.scSem,
+		policy:           fte.policy,
+		scope:            fte.scope,
+		maxContentBytes:  fte.cfg.ScraperMaxContentBytes,
+		minSizes:         fte.minContentSizes(),
+		dedupScreenshots: fte.cfg.ScraperDedupScreenshots,
+		scp:              fte.scp,
 	}
 	if browser.IsAvailable() {
 		ce.definitions = append(ce.definitions, registryDefinitions[BrowserToolName])
@@ -1310,6 +1839,7 @@ func (fte *flowToolsExecutor) GetRefinerExecutor(cfg RefinerExecutorConfig) (Con
 		mlp:    fte.mlp,
 		vslp:   fte.vslp,
 		db:     fte.db,
+		al:     fte.al,
 		store:  fte.store,
 		definitions: []llms.FunctionDefinition{
 			registryDefinitions[MemoristToolName],
@@ -1329,12 +1859,19 @@ func (fte *flowToolsExecutor) GetRefinerExecutor(cfg RefinerExecutorConfig) (Con
 	}
 
 	browser := &browser{
-		flowID:   fte.flowID,
-		taskID:   &cfg.TaskID,
-		dataDir:  fte.cfg.DataDir,
-		scPrvURL: fte.cfg.ScraperPrivateURL,
-		scPubURL: fte.cfg.ScraperPublicURL,
-		scp:      fte.scp,
+		flowID:           fte.flowID,
+		taskID:           &cfg.TaskID,
+		dataDir:          fte.cfg.DataDir,
+		scPrvURL:         fte.cfg.ScraperPrivateURL,
+		scPubURL:         fte.cfg.ScraperPublicURL,
+		scTLS:            fte.scTLS,
+		scSem:            fte.scSem,
+		policy:           fte.policy,
+		scope:            fte.scope,
+		maxContentBytes:  fte.cfg.ScraperMaxContentBytes,
+		minSizes:         fte.minContentSizes(),
+		dedupScreenshots: fte.cfg.ScraperDedupScreenshots,
+		scp:              fte.scp,
 	}
 	if browser.IsAvailable() {
 		ce.definitions = append(ce.definitions, registryDefinitions[BrowserToolName])
@@ -1371,6 +1908,7 @@ func (fte *flowToolsExecutor) GetMemoristExecutor(cfg MemoristExecutorConfig) (C
 		mlp:       fte.mlp,
 		vslp:      fte.vslp,
 		db:        fte.db,
+		al:        fte.al,
 		store:     fte.store,
 		definitions: []llms.FunctionDefinition{
 			registryDefinitions[MemoristResultToolName],
@@ -1432,6 +1970,7 @@ func (fte *flowToolsExecutor) GetEnricherExecutor(cfg EnricherExecutorConfig) (C
 		mlp:       fte.mlp,
 		vslp:      fte.vslp,
 		db:        fte.db,
+		al:        fte.al,
 		store:     fte.store,
 		definitions: []llms.FunctionDefinition{
 			registryDefinitions[MemoristToolName],
@@ -1459,9 +1998,37 @@ func (fte *flowToolsExecutor) GetReporterExecutor(cfg ReporterExecutorConfig) (C
 		mlp:         fte.mlp,
 		vslp:        fte.vslp,
 		db:          fte.db,
+		al:          fte.al,
 		store:       fte.store,
 		definitions: []llms.FunctionDefinition{registryDefinitions[ReportResultToolName]},
 		handlers:    map[string]ExecutorHandler{ReportResultToolName: cfg.ReportResult},
 		barriers:    map[string]struct{}{ReportResultToolName: {}},
 	}, nil
 }
+
+// searchTool replaces the search engine by its fake with the canned answer when the fake search is on
+func (fte *flowToolsExecutor) searchTool(tool Tool) Tool {
+	if !fte.cfg.SearchFake {
+		return tool
+	}
+
+	return newFakeSearchTool(tool)
+}
+
+// searchCache is the cache of search results which is off with the fake search to keep canned answers out of it
+func (fte *flowToolsExecutor) searchCache() CacheProvider {
+	if fte.cfg.SearchFake {
+		return nil
+	}
+
+	return fte.cache
+}
+
+// minContentSizes returns the configured minimum sizes of the content scraped by the browser
+func (fte *flowToolsExecutor) minContentSizes() MinContentSizes {
+	return MinContentSizes{
+		Markdown: fte.cfg.ScraperMinMdContentSize,
+		HTML:     fte.cfg.ScraperMinHtmlContentSize,
+		Image:    fte.cfg.ScraperMinImgContentSize,
+	}
+}
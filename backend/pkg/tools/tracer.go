@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"context"
+
+	obs "pentagi/pkg/observability"
+	"pentagi/pkg/observability/langfuse"
+)
+
+type TraceLevel int
+
+const (
+	TraceLevelDefault TraceLevel = iota
+	TraceLevelDebug
+	TraceLevelWarning
+	TraceLevelError
+)
+
+// TraceEvent is a notable point of the tool call which is reported to the observability backend
+type TraceEvent struct {
+	Name     string
+	Input    any
+	Status   string
+	Level    TraceLevel
+	Metadata map[string]any
+}
+
+// EventEmitter reports events of the single tool call
+type EventEmitter interface {
+	Emit(event TraceEvent)
+}
+
+// Tracer decouples tools from the observability backend, langfuse is used by default
+type Tracer interface {
+	// Start opens the observation of the tool call and returns the context bound to it
+	Start(ctx context.Context) (context.Context, EventEmitter)
+}
+
+var defaultTracer Tracer = NewLangfuseTracer()
+
+type langfuseTracer struct{}
+
+type langfuseEmitter struct {
+	observation langfuse.Observation
+}
+
+// NewLangfuseTracer creates tracer which reports events through the global langfuse observer
+func NewLangfuseTracer() Tracer {
+	return langfuseTracer{}
+}
+
+func (langfuseTracer) Start(ctx context.Context) (context.Context, EventEmitter) {
+	ctx, observation := obs.Observer.NewObservation(ctx)
+	return ctx, langfuseEmitter{observation: observation}
+}
+
+func (e langfuseEmitter) Emit(event TraceEvent) {
+	e.observation.Event(
+		langfuse.WithEventName(event.Name),
+		langfuse.WithEventInput(event.Input),
+		langfuse.WithEventStatus(event.Status),
+		langfuse.WithEventLevel(event.Level.langfuseLevel()),
+		langfuse.WithEventMetadata(langfuse.Metadata(event.Metadata)),
+	)
+}
+
+func (l TraceLevel) langfuseLevel() langfuse.ObservationLevel {
+	switch l {
+	case TraceLevelDebug:
+		return langfuse.ObservationLevelDebug
+	case TraceLevelWarning:
+		return langfuse.ObservationLevelWarning
+	case TraceLevelError:
+		return langfuse.ObservationLevelError
+	default:
+		return langfuse.ObservationLevelDefault
+	}
+}
+
+type noopTracer struct{}
+
+type noopEmitter struct{}
+
+// NewNoopTracer creates tracer which drops all events, it's intended for tests
+func NewNoopTracer() Tracer {
+	return noopTracer{}
+}
+
+func (noopTracer) Start(ctx context.Context) (context.Context, EventEmitter) {
+	return ctx, noopEmitter{}
+}
+
+func (noopEmitter) Emit(TraceEvent) {}
+
+// requestEmitter adds the correlation ID of the tool call to the metadata of every event
+type requestEmitter struct {
+	emitter   EventEmitter
+	requestID string
+}
+
+func (e requestEmitter) Emit(event TraceEvent) {
+	metadata := make(map[string]any, len(event.Metadata)+1)
+	for key, value := range event.Metadata {
+		metadata[key] = value
+	}
+	metadata["request_id"] = e.requestID
+	event.Metadata = metadata
+
+	e.emitter.Emit(event)
+}
+
+type emitterContextKey struct{}
+
+// startTrace opens the observation through the tool tracer or the default one if it's not set,
+// the emitter is bound to the returned context to report events from nested calls,
+// the context gets the correlation ID of the call if it doesn't have one yet
+func startTrace(ctx context.Context, tracer Tracer) (context.Context, EventEmitter) {
+	if tracer == nil {
+		tracer = defaultTracer
+	}
+
+	ctx = withRequestID(ctx)
+	ctx, emitter := tracer.Start(ctx)
+	emitter = requestEmitter{emitter: emitter, requestID: GetRequestID(ctx)}
+
+	return context.WithValue(ctx, emitterContextKey{}, emitter), emitter
+}
+
+// emitterFromContext returns the emitter of the tool call bound to the context,
+// events outside of the traced call are dropped
+func emitterFromContext(ctx context.Context) EventEmitter {
+	if emitter, ok := ctx.Value(emitterContextKey{}).(EventEmitter); ok {
+		return emitter
+	}
+
+	return noopEmitter{}
+}
+
+// searchErrorEvent describes the search failure which is returned to the agent as a message
+func searchErrorEvent(query string, err error, metadata map[string]any) TraceEvent {
+	metadata["query"] = query
+	metadata["error"] = err.Error()
+
+	return TraceEvent{
+		Name:     "search engine error swallowed",
+		Input:    query,
+		Status:   err.Error(),
+		Level:    TraceLevelWarning,
+		Metadata: metadata,
+	}
+}
@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// capturingTracer keeps all emitted events to check them in tests
+type capturingTracer struct {
+	mx     sync.Mutex
+	starts int
+	events []TraceEvent
+}
+
+func (c *capturingTracer) Start(ctx context.Context) (context.Context, EventEmitter) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.starts++
+	return ctx, c
+}
+
+func (c *capturingTracer) Emit(event TraceEvent) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.events = append(c.events, event)
+}
+
+func newFailingMetasearch(tracer Tracer) *metasearch {
+	tool := NewMetasearchTool(1, nil, nil, map[string]Tool{
+		"alpha": &fakeResultsSearcher{available: true, err: errors.New("alpha is down")},
+		"beta":  &fakeResultsSearcher{available: true, err: errors.New("beta is down")},
+	}, 0, ResultLimits{}, false, nil, nil).(*metasearch)
+	tool.tracer = tracer
+
+	return tool
+}
+
+func TestSearchErrorEmittedThroughTracer(t *testing.T) {
+	tracer := &capturingTracer{}
+	tool := newFailingMetasearch(tracer)
+
+	args, _ := json.Marshal(SearchAction{Query: "cve-2024-3094", MaxResults: 3})
+	result, err := tool.Handle(context.Background(), MetasearchToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.HasPrefix(result, "failed to search in all engines") {
+		t.Errorf("expected swallowed error message, got %q", result)
+	}
+
+	if tracer.starts != 1 {
+		t.Errorf("expected one started observation, got %d", tracer.starts)
+	}
+	if len(tracer.events) != 1 {
+		t.Fatalf("expected one event, got %d", len(tracer.events))
+	}
+
+	event := tracer.events[0]
+	if event.Name != "search engine error swallowed" || event.Level != TraceLevelWarning {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if event.Input != "cve-2024-3094" || !strings.Contains(event.Status, "alpha is down") {
+		t.Errorf("unexpected event input %v or status %q", event.Input, event.Status)
+	}
+	for key, want := range map[string]any{
+		"tool_name":   MetasearchToolName,
+		"engine":      "metasearch",
+		"query":       "cve-2024-3094",
+		"max_results": 3,
+		"error":       event.Status,
+	} {
+		if got := event.Metadata[key]; got != want {
+			t.Errorf("metadata %q = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestNoopTracer(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	traced, emitter := NewNoopTracer().Start(ctx)
+	if traced.Value(ctxKey{}) != "value" {
+		t.Error("expected noop tracer to keep the context")
+	}
+	emitter.Emit(TraceEvent{Name: "dropped"})
+
+	args, _ := json.Marshal(SearchAction{Query: "query"})
+	if _, err := newFailingMetasearch(NewNoopTracer()).Handle(ctx, MetasearchToolName, args); err != nil {
+		t.Errorf("Handle() error = %v", err)
+	}
+}
+
+func TestStartTraceUsesDefaultTracer(t *testing.T) {
+	saved := defaultTracer
+	defer func() { defaultTracer = saved }()
+
+	tracer := &capturingTracer{}
+	defaultTracer = tracer
+
+	args, _ := json.Marshal(SearchAction{Query: "query"})
+	if _, err := newFailingMetasearch(nil).Handle(context.Background(), MetasearchToolName, args); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if tracer.starts != 1 || len(tracer.events) != 1 {
+		t.Errorf("expected default tracer to be used, got %d starts and %d events", tracer.starts, len(tracer.events))
+	}
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	// handle fails the search and returns the request ID of the trace event and the error log
+	handle := func(t *testing.T, ctx context.Context) (string, string) {
+		t.Helper()
+
+		hook := logtest.NewGlobal()
+		defer hook.Reset()
+
+		tracer := &capturingTracer{}
+		tool := &traversaal{apiKey: "test-key", apiURL: server.URL, tracer: tracer}
+		args, _ := json.Marshal(SearchAction{Query: "log4shell"})
+		if _, err := tool.Handle(ctx, TraversaalToolName, args); err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+
+		if len(tracer.events) != 1 {
+			t.Fatalf("expected one event, got %d", len(tracer.events))
+		}
+		entry := hook.LastEntry()
+		if entry == nil || entry.Message != "failed to search in traversaal" {
+			t.Fatalf("expected the error to be logged, got %+v", entry)
+		}
+
+		eventID, _ := tracer.events[0].Metadata["request_id"].(string)
+		logID, _ := entry.Data["request_id"].(string)
+		return eventID, logID
+	}
+
+	t.Run("given by the agent context", func(t *testing.T) {
+		ctx := PutAgentContext(PutRequestID(context.Background(), "call_42"), "pentester")
+
+		eventID, logID := handle(t, ctx)
+		if eventID != "call_42" || logID != "call_42" {
+			t.Errorf("expected request ID call_42 in the event and the log, got %q and %q", eventID, logID)
+		}
+		if agentCtx, _ := GetAgentContext(ctx); agentCtx.ParentAgentType != "pentester" {
+			t.Errorf("expected the request ID to keep the agent context, got %+v", agentCtx)
+		}
+	})
+
+	t.Run("generated when absent", func(t *testing.T) {
+		eventID, logID := handle(t, context.Background())
+		if _, err := uuid.Parse(eventID); err != nil || logID != eventID {
+			t.Errorf("expected the same generated UUID in the event and the log, got %q and %q", eventID, logID)
+		}
+
+		otherID, _ := handle(t, context.Background())
+		if otherID == eventID {
+			t.Errorf("expected every call to get its own request ID, got %q twice", otherID)
+		}
+	})
+}
+
+func TestStartTraceKeepsRequestID(t *testing.T) {
+	ctx, emitter := startTrace(context.Background(), &capturingTracer{})
+	requestID := GetRequestID(ctx)
+	if requestID == "" {
+		t.Fatal("expected the request ID to be generated")
+	}
+
+	// nested calls share the ID of the outer call
+	nested, _ := startTrace(ctx, &capturingTracer{})
+	if GetRequestID(nested) != requestID {
+		t.Errorf("expected nested call to keep %q, got %q", requestID, GetRequestID(nested))
+	}
+	if emitterFromContext(ctx) != emitter {
+		t.Error("expected the emitter with the request ID to be bound to the context")
+	}
+}
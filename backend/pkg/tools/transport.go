@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// default pool limits of the shared transports, used until InitHTTPTransport is called
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+	// defaultDialKeepAlive is the keep-alive period of the dialer of http.DefaultTransport
+	defaultDialKeepAlive = 30 * time.Second
+)
+
+// Strategies of picking the proxy of the pool for the next request
+const (
+	ProxyRotationRoundRobin = "round-robin"
+	ProxyRotationRandom     = "random"
+)
+
+// TLSConfig tunes the verification of server certificates for internal targets and services,
+// the zero value verifies them against the system roots, skipping the verification is opt-in
+type TLSConfig struct {
+	// InsecureSkipVerify accepts any certificate, e.g. self-signed ones of the lab targets
+	InsecureSkipVerify bool
+	// RootCAs replaces the system roots when it's set, see LoadCAPool
+	RootCAs *x509.CertPool
+}
+
+// clientConfig returns the TLS config of the transport, nil keeps the defaults of the clone
+func (c TLSConfig) clientConfig() *tls.Config {
+	if !c.InsecureSkipVerify && c.RootCAs == nil {
+		return nil
+	}
+
+	return &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		RootCAs:            c.RootCAs,
+	}
+}
+
+// NewTLSConfig builds the TLS settings from the config options, the CA file is optional
+func NewTLSConfig(insecureSkipVerify bool, caFile string) (TLSConfig, error) {
+	roots, err := LoadCAPool(caFile)
+	if err != nil {
+		return TLSConfig{}, err
+	}
+
+	return TLSConfig{InsecureSkipVerify: insecureSkipVerify, RootCAs: roots}, nil
+}
+
+// LoadCAPool reads the PEM encoded CA certificates of the file and adds them to the system roots,
+// empty path returns nil pool which keeps the system roots as they are
+func LoadCAPool(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file '%s': %w", path, err)
+	}
+
+	pool, err := NewCAPool(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load CA file '%s': %w", path, err)
+	}
+
+	return pool, nil
+}
+
+// NewCAPool adds the PEM encoded CA certificates to the system roots, PEM without certificates is an error
+func NewCAPool(pemData []byte) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, errors.New("no CA certificates found in PEM data")
+	}
+
+	return pool, nil
+}
+
+// ConnTimeouts splits the timeout of the request by its stages, so the dead host fails fast while
+// the slow answer of the API is still awaited, zero fields keep the defaults of http.DefaultTransport,
+// the timeout of the client bounds the whole request anyway
+type ConnTimeouts struct {
+	// Connect bounds dialing the host or the proxy
+	Connect time.Duration
+	// ResponseHeader bounds waiting for the response headers after the request is written
+	ResponseHeader time.Duration
+}
+
+// transportKey identifies the shared transport, tools with the same egress reuse its connections
+type transportKey struct {
+	proxyURL string
+	tls      TLSConfig
+	timeouts ConnTimeouts
+}
+
+var (
+	transportsMx            sync.Mutex
+	transports              = make(map[transportKey]*http.Transport)
+	transportMaxIdle        = defaultMaxIdleConns
+	transportMaxIdlePerHost = defaultMaxIdleConnsPerHost
+	transportIdleTimeout    = defaultIdleConnTimeout
+)
+
+// InitHTTPTransport sets the connection pool limits of the transports shared by the tools,
+// zero values keep the defaults, the transports created before are dropped with their idle connections
+func InitHTTPTransport(maxIdleConns, maxIdleConnsPerHost int, idleConnTimeout time.Duration) {
+	transportsMx.Lock()
+	defer transportsMx.Unlock()
+
+	for _, transport := range transports {
+		transport.CloseIdleConnections()
+	}
+	transports = make(map[transportKey]*http.Transport)
+
+	transportMaxIdle = defaultMaxIdleConns
+	if maxIdleConns > 0 {
+		transportMaxIdle = maxIdleConns
+	}
+	transportMaxIdlePerHost = defaultMaxIdleConnsPerHost
+	if maxIdleConnsPerHost > 0 {
+		transportMaxIdlePerHost = maxIdleConnsPerHost
+	}
+	transportIdleTimeout = defaultIdleConnTimeout
+	if idleConnTimeout > 0 {
+		transportIdleTimeout = idleConnTimeout
+	}
+}
+
+// sharedTransport returns the transport for the proxy and the TLS settings of the certificates verification;
+// it's a clone of http.DefaultTransport which is never mutated,
+// malformed proxy URL fails every request instead of silently going around the proxy
+func sharedTransport(proxyURL string, tlsConfig TLSConfig) *http.Transport {
+	return sharedTransportWithTimeouts(proxyURL, tlsConfig, ConnTimeouts{})
+}
+
+// sharedTransportWithTimeouts returns the shared transport which also dials and awaits the response headers
+// within the timeouts, transports of different timeouts don't share connections
+func sharedTransportWithTimeouts(proxyURL string, tlsConfig TLSConfig, timeouts ConnTimeouts) *http.Transport {
+	key := transportKey{proxyURL: proxyURL, tls: tlsConfig, timeouts: timeouts}
+
+	transportsMx.Lock()
+	defer transportsMx.Unlock()
+
+	if transport, ok := transports[key]; ok {
+		return transport
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = transportMaxIdle
+	transport.MaxIdleConnsPerHost = transportMaxIdlePerHost
+	transport.IdleConnTimeout = transportIdleTimeout
+
+	if proxyURL != "" {
+		parsed, err := url.Parse(proxyURL)
+		transport.Proxy = func(*http.Request) (*url.URL, error) {
+			return parsed, err
+		}
+	}
+	if config := tlsConfig.clientConfig(); config != nil {
+		transport.TLSClientConfig = config
+	}
+	if timeouts.Connect > 0 {
+		dialer := &net.Dialer{Timeout: timeouts.Connect, KeepAlive: defaultDialKeepAlive}
+		transport.DialContext = dialer.DialContext
+	}
+	if timeouts.ResponseHeader > 0 {
+		transport.ResponseHeaderTimeout = timeouts.ResponseHeader
+	}
+
+	transports[key] = transport
+
+	return transport
+}
+
+// newHTTPClient builds a client per call with its own timeout on top of the shared transport,
+// so the proxy never leaks into http.DefaultClient
+func newHTTPClient(proxyURL string, timeout time.Duration) *http.Client {
+	return newHTTPClientWithTimeouts(proxyURL, timeout, ConnTimeouts{})
+}
+
+// newHTTPClientWithTimeouts builds a client per call whose timeout bounds the whole request
+// and the connect and response header timeouts bound its stages
+func newHTTPClientWithTimeouts(proxyURL string, timeout time.Duration, timeouts ConnTimeouts) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: sharedTransportWithTimeouts(proxyURL, TLSConfig{}, timeouts),
+	}
+}
+
+// ProxyPool spreads requests of the tools which scrape aggressively among several egress proxies,
+// every request picks the proxy and gets its shared transport, so the pool never mutates a transport
+type ProxyPool struct {
+	proxies []string
+	random  bool
+	next    atomic.Uint64
+}
+
+// NewProxyPool validates the proxy URLs and the rotation which is round-robin by default,
+// the pool without proxies is nil and tools keep using the single proxy URL
+func NewProxyPool(proxies []string, rotation string) (*ProxyPool, error) {
+	pool := &ProxyPool{}
+	for idx, proxyURL := range proxies {
+		proxyURL = strings.TrimSpace(proxyURL)
+		if proxyURL == "" {
+			continue
+		}
+		// the URL may carry credentials, so only its position is reported
+		if parsed, err := url.Parse(proxyURL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL #%d in the pool", idx+1)
+		}
+		pool.proxies = append(pool.proxies, proxyURL)
+	}
+	if len(pool.proxies) == 0 {
+		return nil, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(rotation)) {
+	case "", ProxyRotationRoundRobin:
+	case ProxyRotationRandom:
+		pool.random = true
+	default:
+		return nil, fmt.Errorf("unknown proxy rotation %q, expected %s or %s",
+			rotation, ProxyRotationRoundRobin, ProxyRotationRandom)
+	}
+
+	return pool, nil
+}
+
+// Next returns the proxy for the next request, it's safe for concurrent use
+func (p *ProxyPool) Next() string {
+	if p.random {
+		return p.proxies[rand.IntN(len(p.proxies))]
+	}
+
+	return p.proxies[(p.next.Add(1)-1)%uint64(len(p.proxies))]
+}
+
+// pickProxy returns the next proxy of the pool, or the single proxy URL when the pool isn't configured
+func pickProxy(pool *ProxyPool, proxyURL string) string {
+	if pool == nil {
+		return proxyURL
+	}
+
+	return pool.Next()
+}
@@ -0,0 +1,363 @@
+package tools
+
+import (
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func countSharedTransports() int {
+	transportsMx.Lock()
+	defer transportsMx.Unlock()
+	return len(transports)
+}
+
+func TestSharedTransportReuse(t *testing.T) {
+	InitHTTPTransport(5, 2, time.Minute)
+	defer InitHTTPTransport(0, 0, 0)
+
+	defaultTransport := http.DefaultTransport.(*http.Transport)
+	defaultIdlePerHost := defaultTransport.MaxIdleConnsPerHost
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			(&tavily{proxyURL: "http://127.0.0.1:3128"}).createHTTPClient()
+			(&github{proxyURL: "http://127.0.0.1:3128"}).createHTTPClient()
+			(&duckduckgo{}).createHTTPClient()
+			(&hibp{}).createHTTPClient()
+		}()
+	}
+	wg.Wait()
+
+	if got := countSharedTransports(); got != 2 {
+		t.Errorf("expected 2 shared transports, created %d", got)
+	}
+
+	transport := sharedTransport("http://127.0.0.1:3128", TLSConfig{})
+	if transport.MaxIdleConns != 5 || transport.MaxIdleConnsPerHost != 2 || transport.IdleConnTimeout != time.Minute {
+		t.Errorf("unexpected pool limits %d/%d/%v", transport.MaxIdleConns, transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport == defaultTransport || defaultTransport.MaxIdleConnsPerHost != defaultIdlePerHost {
+		t.Error("expected http.DefaultTransport to stay untouched")
+	}
+
+	insecure := TLSConfig{InsecureSkipVerify: true}
+	if sharedTransport("", insecure) == sharedTransport("", TLSConfig{}) {
+		t.Error("expected insecure transport to be separate")
+	}
+	if sharedTransport("", insecure).TLSClientConfig == nil || !sharedTransport("", insecure).TLSClientConfig.InsecureSkipVerify {
+		t.Error("expected insecure transport to skip certificate verification")
+	}
+
+	InitHTTPTransport(0, 0, 0)
+	if got := countSharedTransports(); got != 0 {
+		t.Errorf("expected transports to be dropped on init, got %d", got)
+	}
+	if transport := sharedTransport("", TLSConfig{}); transport.MaxIdleConnsPerHost != defaultMaxIdleConnsPerHost {
+		t.Errorf("expected default pool limits, got %d", transport.MaxIdleConnsPerHost)
+	}
+}
+
+func TestSharedTransportKeepAlive(t *testing.T) {
+	InitHTTPTransport(0, 0, 0)
+	defer InitHTTPTransport(0, 0, 0)
+
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	// every call builds its own client like the tools do
+	for range 5 {
+		resp, err := newHTTPClient("", time.Second).Get(server.URL)
+		if err != nil {
+			t.Fatalf("request error = %v", err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if got := conns.Load(); got != 1 {
+		t.Errorf("expected clients to reuse 1 connection, opened %d", got)
+	}
+}
+
+func TestSharedTransportMalformedProxy(t *testing.T) {
+	InitHTTPTransport(0, 0, 0)
+	defer InitHTTPTransport(0, 0, 0)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if _, err := sharedTransport("http://[::1", TLSConfig{}).Proxy(req); err == nil {
+		t.Error("expected malformed proxy URL to fail requests")
+	}
+}
+
+func TestProxyPoolRoundRobin(t *testing.T) {
+	pool, err := NewProxyPool([]string{" http://proxy1:8080 ", "", "http://proxy2:8080", "socks5://proxy3:1080"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"http://proxy1:8080", "http://proxy2:8080", "socks5://proxy3:1080", "http://proxy1:8080"}
+	for i, proxy := range want {
+		if got := pool.Next(); got != proxy {
+			t.Errorf("request %d: expected %q, got %q", i, proxy, got)
+		}
+	}
+}
+
+func TestProxyPoolRandom(t *testing.T) {
+	proxies := []string{"http://proxy1:8080", "http://proxy2:8080"}
+	pool, err := NewProxyPool(proxies, ProxyRotationRandom)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	seen := make(map[string]int)
+	for range 100 {
+		seen[pool.Next()]++
+	}
+	if len(seen) != len(proxies) || seen[proxies[0]] == 0 || seen[proxies[1]] == 0 {
+		t.Errorf("expected both proxies to be picked, got %v", seen)
+	}
+}
+
+func TestNewProxyPoolValidation(t *testing.T) {
+	if pool, err := NewProxyPool([]string{"", " "}, ""); pool != nil || err != nil {
+		t.Errorf("expected no pool without proxies, got %v, %v", pool, err)
+	}
+	if _, err := NewProxyPool([]string{"http://proxy1:8080"}, "weighted"); err == nil {
+		t.Error("expected unknown rotation to fail")
+	}
+
+	_, err := NewProxyPool([]string{"http://proxy1:8080", "user:secret@proxy2"}, "")
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("expected invalid proxy error without credentials, got %v", err)
+	}
+
+	if got := pickProxy(nil, "http://single:3128"); got != "http://single:3128" {
+		t.Errorf("expected single proxy without pool, got %q", got)
+	}
+}
+
+func TestProxyPoolRequests(t *testing.T) {
+	InitHTTPTransport(0, 0, 0)
+	defer InitHTTPTransport(0, 0, 0)
+
+	newProxy := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+	}
+	proxy1, proxy2 := newProxy("proxy1"), newProxy("proxy2")
+	defer proxy1.Close()
+	defer proxy2.Close()
+
+	pool, err := NewProxyPool([]string{proxy1.URL, proxy2.URL}, ProxyRotationRoundRobin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ddg := &duckduckgo{proxyURL: "http://127.0.0.1:1", proxyPool: pool}
+	var got []string
+	for range 3 {
+		resp, err := ddg.createHTTPClient().Get("http://search.example/html")
+		if err != nil {
+			t.Fatalf("request error = %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		got = append(got, string(body))
+	}
+
+	if strings.Join(got, ",") != "proxy1,proxy2,proxy1" {
+		t.Errorf("expected requests to rotate the proxies, got %v", got)
+	}
+	// every proxy of the pool gets its own clone of the default transport
+	defaultTransport := http.DefaultTransport.(*http.Transport)
+	transport1, transport2 := sharedTransport(proxy1.URL, TLSConfig{}), sharedTransport(proxy2.URL, TLSConfig{})
+	if transport1 == transport2 || transport1 == defaultTransport || transport2 == defaultTransport {
+		t.Error("expected separate transports for the proxies of the pool")
+	}
+}
+
+// newStallingListener accepts connections and reads requests but never answers them,
+// the connections are closed when the test ends
+func newStallingListener(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		listener.Close()
+		wg.Wait()
+	})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer conn.Close()
+				_, _ = io.Copy(io.Discard, conn)
+			}()
+		}
+	}()
+
+	return "http://" + listener.Addr().String()
+}
+
+func TestSharedTransportTimeouts(t *testing.T) {
+	InitHTTPTransport(0, 0, 0)
+	defer InitHTTPTransport(0, 0, 0)
+
+	timeouts := ConnTimeouts{Connect: time.Second, ResponseHeader: 50 * time.Millisecond}
+	transport := sharedTransportWithTimeouts("", TLSConfig{}, timeouts)
+	if transport.ResponseHeaderTimeout != timeouts.ResponseHeader || transport.DialContext == nil {
+		t.Errorf("expected the timeouts to be set, got response header timeout %v", transport.ResponseHeaderTimeout)
+	}
+	if transport == sharedTransport("", TLSConfig{}) {
+		t.Error("expected different timeouts to get own transport")
+	}
+	if sharedTransport("", TLSConfig{}).ResponseHeaderTimeout != 0 {
+		t.Error("expected default transport not to limit the response headers")
+	}
+	if transport != sharedTransportWithTimeouts("", TLSConfig{}, timeouts) {
+		t.Error("expected the same timeouts to share the transport")
+	}
+}
+
+func TestResponseHeaderTimeout(t *testing.T) {
+	InitHTTPTransport(0, 0, 0)
+	defer InitHTTPTransport(0, 0, 0)
+
+	serverURL := newStallingListener(t)
+
+	// the overall timeout is generous, so only the response header timeout can fire
+	client := newHTTPClientWithTimeouts("", 10*time.Second, ConnTimeouts{ResponseHeader: 100 * time.Millisecond})
+	start := time.Now()
+	resp, err := client.Get(serverURL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the stalled response to fail")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the response header timeout to fire, took %v", elapsed)
+	}
+	if !strings.Contains(err.Error(), "timeout awaiting response headers") {
+		t.Errorf("expected the response header timeout, got %v", err)
+	}
+	if kind := networkErrorKind(err); kind != ErrTimeout {
+		t.Errorf("expected the failure to be classified as %v, got %v", ErrTimeout, kind)
+	}
+}
+
+// serverCAPEM returns the self-signed certificate of the test TLS server in PEM format
+func serverCAPEM(server *httptest.Server) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+}
+
+func TestSharedTransportTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	roots, err := NewCAPool(serverCAPEM(server))
+	if err != nil {
+		t.Fatalf("NewCAPool() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		tls     TLSConfig
+		wantErr bool
+	}{
+		{"default verifies certificate", TLSConfig{}, true},
+		{"insecure skips verification", TLSConfig{InsecureSkipVerify: true}, false},
+		{"custom CA trusts certificate", TLSConfig{RootCAs: roots}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: sharedTransport("", tt.tls), Timeout: 5 * time.Second}
+			resp, err := client.Get(server.URL)
+			if tt.wantErr {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("expected untrusted certificate to fail the request")
+				}
+				if !strings.Contains(err.Error(), "certificate") {
+					t.Errorf("expected certificate error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			defer resp.Body.Close()
+			if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+				t.Errorf("unexpected body %q", body)
+			}
+		})
+	}
+}
+
+func TestLoadCAPool(t *testing.T) {
+	if pool, err := LoadCAPool(""); pool != nil || err != nil {
+		t.Errorf("expected empty path to keep the system roots, got %v, %v", pool, err)
+	}
+
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, serverCAPEM(server), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if pool, err := LoadCAPool(caFile); pool == nil || err != nil {
+		t.Errorf("expected CA file to be loaded, got %v, %v", pool, err)
+	}
+
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCAPool(invalidFile); err == nil || !strings.Contains(err.Error(), "no CA certificates") {
+		t.Errorf("expected error for file without certificates, got %v", err)
+	}
+	if _, err := NewTLSConfig(false, filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("expected error for missing CA file")
+	}
+}
@@ -6,46 +6,146 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
 	"strings"
+	"time"
 
 	"pentagi/pkg/database"
-	obs "pentagi/pkg/observability"
-	"pentagi/pkg/observability/langfuse"
 
 	"github.com/sirupsen/logrus"
 )
 
-const traversaalURL = "https://api-ares.traversaal.ai/live/predict"
+const (
+	traversaalURL     = "https://api-ares.traversaal.ai/live/predict"
+	traversaalTimeout = 30 * time.Second
+	// the API doesn't document the number of sources, so the requested one is also applied to the links
+	traversaalDefaultResults = 5
+	traversaalMaxResults     = 10
+	// the default paths of the answer and the links in the response, they match the typed struct
+	traversaalAnswerPath = "data.response_text"
+	traversaalLinksPath  = "data.web_url"
+)
 
 type traversaalSearchResult struct {
 	Response string   `json:"response_text"`
 	Links    []string `json:"web_url"`
 }
 
+// TraversaalFields are the paths of the answer and the links in the response with keys of nested objects
+// joined by dots, so the renamed fields of the API are followed without a code change, empty paths keep the defaults
+type TraversaalFields struct {
+	Answer string
+	Links  string
+}
+
+var traversaalDefaultFields = TraversaalFields{Answer: traversaalAnswerPath, Links: traversaalLinksPath}
+
+func (f TraversaalFields) withDefaults() TraversaalFields {
+	if f.Answer == "" {
+		f.Answer = traversaalAnswerPath
+	}
+	if f.Links == "" {
+		f.Links = traversaalLinksPath
+	}
+	return f
+}
+
 type traversaal struct {
-	flowID    int64
-	taskID    *int64
-	subtaskID *int64
-	apiKey    string
-	proxyURL  string
-	slp       SearchLogProvider
+	flowID       int64
+	taskID       *int64
+	subtaskID    *int64
+	apiKey       string
+	proxyURL     string
+	apiURL       string
+	transport    http.RoundTripper
+	timeout      time.Duration
+	retry        RetryPolicy
+	maxBody      int64
+	fields       TraversaalFields
+	limits       ResultLimits
+	dryRun       bool
+	cache        CacheProvider
+	sourceFooter bool
+	cveEnricher  CVEEnricher
+	slp          SearchLogProvider
+	tracer       Tracer
+}
+
+// TraversaalConfig configures the traversaal search tool, zero fields keep the defaults of the tool
+type TraversaalConfig struct {
+	FlowID    int64
+	TaskID    *int64
+	SubtaskID *int64
+
+	APIKey   string
+	ProxyURL string
+	Timeout  time.Duration
+	Retry    RetryPolicy
+	MaxBody  int64
+	Limits   ResultLimits
+	Fields   TraversaalFields
+
+	// APIURL and Transport replace the public API, e.g. by the mock server
+	APIURL    string
+	Transport http.RoundTripper
+
+	DryRun       bool
+	Cache        CacheProvider
+	SourceFooter bool
+	CVEEnricher  CVEEnricher
+	SearchLog    SearchLogProvider
+	Tracer       Tracer
+}
+
+// NewTraversaalTool creates traversaal search tool, it's kept for existing callers,
+// NewTraversaalToolWithConfig is easier to read at the call site
+func NewTraversaalTool(flowID int64, taskID, subtaskID *int64, apiKey, proxyURL string,
+	timeout time.Duration, retry RetryPolicy, limits ResultLimits, dryRun bool, sourceFooter bool, slp SearchLogProvider,
+) Tool {
+	return NewTraversaalToolWithConfig(TraversaalConfig{
+		FlowID:       flowID,
+		TaskID:       taskID,
+		SubtaskID:    subtaskID,
+		APIKey:       apiKey,
+		ProxyURL:     proxyURL,
+		Timeout:      timeout,
+		Retry:        retry,
+		Limits:       limits,
+		DryRun:       dryRun,
+		SourceFooter: sourceFooter,
+		SearchLog:    slp,
+	})
 }
 
-func NewTraversaalTool(flowID int64, taskID, subtaskID *int64, apiKey, proxyURL string, slp SearchLogProvider) Tool {
+// NewTraversaalToolWithConfig creates traversaal search tool, zero timeout means the default one,
+// retry bounds repeats of rate limited and failed by server requests
+func NewTraversaalToolWithConfig(cfg TraversaalConfig) Tool {
+	cfg.Timeout = resolveTimeout(TraversaalToolName, cfg.Timeout)
+
 	return &traversaal{
-		flowID:    flowID,
-		taskID:    taskID,
-		subtaskID: subtaskID,
-		apiKey:    apiKey,
-		proxyURL:  proxyURL,
-		slp:       slp,
+		flowID:       cfg.FlowID,
+		taskID:       cfg.TaskID,
+		subtaskID:    cfg.SubtaskID,
+		apiKey:       cfg.APIKey,
+		proxyURL:     cfg.ProxyURL,
+		apiURL:       cfg.APIURL,
+		transport:    cfg.Transport,
+		timeout:      cfg.Timeout,
+		retry:        cfg.Retry,
+		maxBody:      cfg.MaxBody,
+		limits:       cfg.Limits,
+		fields:       cfg.Fields.withDefaults(),
+		dryRun:       cfg.DryRun,
+		cache:        cfg.Cache,
+		sourceFooter: cfg.SourceFooter,
+		cveEnricher:  cfg.CVEEnricher,
+		slp:          cfg.SearchLog,
+		tracer:       cfg.Tracer,
 	}
 }
 
 func (t *traversaal) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
 	var action SearchAction
-	ctx, observation := obs.Observer.NewObservation(ctx)
+	ctx, emitter := startTrace(ctx, t.tracer)
 	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
 		"tool": name,
 		"args": string(args),
@@ -55,108 +155,234 @@ func (t *traversaal) Handle(ctx context.Context, name string, args json.RawMessa
 		logger.WithError(err).Error("failed to unmarshal traversaal search action")
 		return "", fmt.Errorf("failed to unmarshal %s search action arguments: %w", name, err)
 	}
+	action.Query = sanitizeQuery(action.Query, maxQueryLength)
+
+	numResults := t.limits.resolve(traversaalDefaultResults, traversaalMaxResults).clamp(action.MaxResults.Int())
 
-	logger = logger.WithFields(logrus.Fields{
-		"query":       action.Query[:min(len(action.Query), 1000)],
-		"max_results": action.MaxResults,
+	logger = withToolFields(logger, "traversaal", action.Query, t.flowID, t.taskID, t.subtaskID, logrus.Fields{
+		"max_results": numResults,
 	})
 
-	result, err := t.search(ctx, action.Query)
+	cacheKey := searchCacheKey(database.SearchengineTypeTraversaal, action.Query, numResults)
+	stats, start := SearchStats{Cached: true}, time.Now()
+	result, err := withSearchCache(ctx, dryRunCache(t.cache, t.dryRun), cacheKey, &stats, func() (string, error) {
+		if err := waitSearchQueue(ctx, database.SearchengineTypeTraversaal, action.Priority); err != nil {
+			stats.Cached = false
+			return "", err
+		}
+		result, count, err := withNoResultsText(t.search(ctx, action.Query, numResults))
+		stats.Cached, stats.ResultCount = false, count
+		return result, err
+	})
+	recordSearchCall(ctx, database.SearchengineTypeTraversaal, stats.Cached, time.Since(start), err)
 	if err != nil {
-		observation.Event(
-			langfuse.WithEventName("search engine error swallowed"),
-			langfuse.WithEventInput(action.Query),
-			langfuse.WithEventStatus(err.Error()),
-			langfuse.WithEventLevel(langfuse.ObservationLevelWarning),
-			langfuse.WithEventMetadata(langfuse.Metadata{
-				"tool_name":   TraversaalToolName,
-				"engine":      "traversaal",
-				"query":       action.Query,
-				"max_results": action.MaxResults.Int(),
-				"error":       err.Error(),
-			}),
-		)
+		emitter.Emit(searchErrorEvent(action.Query, err, map[string]any{
+			"tool_name":   TraversaalToolName,
+			"engine":      "traversaal",
+			"max_results": numResults,
+		}))
 
 		logger.WithError(err).Error("failed to search in traversaal")
 		return fmt.Sprintf("failed to search in traversaal: %v", err), nil
 	}
 
-	if agentCtx, ok := GetAgentContext(ctx); ok {
-		_, _ = t.slp.PutLog(
-			ctx,
-			agentCtx.ParentAgentType,
-			agentCtx.CurrentAgentType,
-			database.SearchengineTypeTraversaal,
-			action.Query,
-			result,
-			t.taskID,
-			t.subtaskID,
-		)
-	}
+	stats.Duration = time.Since(start)
+	_, _ = putSearchLog(ctx, t.slp, database.SearchengineTypeTraversaal, action.Query, result, stats, t.taskID, t.subtaskID)
+
+	result = withReferencedCVEs(ctx, result, t.cveEnricher)
 
-	return result, nil
+	return withSourceFooter(result, "Traversaal", t.sourceFooter), nil
 }
 
-func (t *traversaal) search(ctx context.Context, query string) (string, error) {
-	client := http.DefaultClient
-	if t.proxyURL != "" {
-		client.Transport = &http.Transport{
-			Proxy: func(req *http.Request) (*url.URL, error) {
-				return url.Parse(t.proxyURL)
-			},
+// search asks for the number of sources and renders not more links than requested
+func (t *traversaal) search(ctx context.Context, query string, maxResults int) (string, int, error) {
+	if t.dryRun {
+		req, err := t.newRequest(ctx, query, maxResults)
+		if err != nil {
+			return "", 0, err
 		}
+		result, err := describeRequest(req)
+		return result, 0, err
+	}
+
+	resp, err := t.do(ctx, query, maxResults)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+
+	return t.parseHTTPResponse(ctx, resp, maxResults)
+}
+
+func (t *traversaal) do(ctx context.Context, query string, maxResults int) (*http.Response, error) {
+	req, err := t.newRequest(ctx, query, maxResults)
+	if err != nil {
+		return nil, err
 	}
 
+	return doWithRetry(t.createHTTPClient(), req, t.retry)
+}
+
+func (t *traversaal) newRequest(ctx context.Context, query string, maxResults int) (*http.Request, error) {
 	reqBody, err := json.Marshal(struct {
-		Query []string `json:"query"`
+		Query      []string `json:"query"`
+		MaxResults int      `json:"max_results,omitempty"`
 	}{
-		Query: []string{query},
+		Query:      []string{query},
+		MaxResults: maxResults,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request body: %v", err)
+		return nil, fmt.Errorf("failed to marshal request body: %v", err)
+	}
+
+	apiURL := t.apiURL
+	if apiURL == "" {
+		apiURL = traversaalURL
 	}
 
-	req, err := http.NewRequest(http.MethodPost, traversaalURL, bytes.NewBuffer(reqBody))
+	req, err := http.NewRequest(http.MethodPost, apiURL, bytes.NewBuffer(reqBody))
 	if err != nil {
-		return "", fmt.Errorf("failed to build request: %v", err)
+		return nil, fmt.Errorf("failed to build request: %v", err)
 	}
 
 	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", t.apiKey)
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to do request: %v", err)
+	return req, nil
+}
+
+// createHTTPClient builds a new client per request on top of the shared transport unless one is injected
+func (t *traversaal) createHTTPClient() *http.Client {
+	timeout := resolveTimeout(TraversaalToolName, t.timeout)
+
+	client := newHTTPClient(t.proxyURL, timeout)
+	if t.transport != nil {
+		client.Transport = t.transport
 	}
-	defer resp.Body.Close()
 
-	return t.parseHTTPResponse(resp)
+	return client
 }
 
-func (t *traversaal) parseHTTPResponse(resp *http.Response) (string, error) {
+// parseHTTPResponse renders the answer with up to maxResults links, zero keeps all of them,
+// the data without the expected fields is reported as the drift of the response schema
+func (t *traversaal) parseHTTPResponse(ctx context.Context, resp *http.Response, maxResults int) (string, int, error) {
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return "", 0, newSearchError(errorKindByStatus(resp.StatusCode), "unexpected status code: %d", resp.StatusCode)
 	}
-	var respBody struct {
-		Data traversaalSearchResult `json:"data"`
+	body, err := readLimitedBody(resp.Body, t.maxBody)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// the typed decode is kept for the default paths, the remapped ones are resolved over the generic JSON
+	var (
+		answer string
+		links  []string
+		fields = t.fields.withDefaults()
+	)
+	if fields == traversaalDefaultFields {
+		answer, links, err = decodeTraversaalResponse(body)
+	} else {
+		answer, links, err = decodeTraversaalMappedResponse(body, fields)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	checkResponseKeys(ctx, "traversaal", body, fields.Answer, fields.Links)
+	if strings.TrimSpace(answer) == "" && len(links) == 0 {
+		return "", 0, newNoResultsError("traversaal found neither the answer nor links for the query")
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&respBody); err != nil {
-		return "", fmt.Errorf("failed to decode response body: %v", err)
+
+	if maxResults > 0 && len(links) > maxResults {
+		links = links[:maxResults]
 	}
 
 	var writer strings.Builder
 	writer.WriteString("# Answer\n\n")
-	writer.WriteString(respBody.Data.Response)
+	writer.WriteString(answer)
 	writer.WriteString("\n\n# Links\n\n")
 
-	for i, resultLink := range respBody.Data.Links {
+	for i, resultLink := range links {
 		writer.WriteString(fmt.Sprintf("%d. %s\n", i+1, resultLink))
 	}
 
-	return writer.String(), nil
+	return writer.String(), len(links), nil
+}
+
+// decodeTraversaalResponse is the fast typed decode of the response with the default field paths
+func decodeTraversaalResponse(body []byte) (string, []string, error) {
+	var respBody struct {
+		Data *traversaalSearchResult `json:"data"`
+	}
+	if err := json.Unmarshal(body, &respBody); err != nil {
+		return "", nil, fmt.Errorf("failed to decode response body: %v, body preview: %q", err, bodyPreview(body))
+	}
+	if respBody.Data == nil {
+		return "", nil, fmt.Errorf("empty response without data, body preview: %q", bodyPreview(body))
+	}
+
+	return respBody.Data.Response, respBody.Data.Links, nil
+}
+
+// decodeTraversaalMappedResponse takes the answer and the links from the configured paths of the response,
+// the links may be a list of strings or a single string, values of other types are skipped
+func decodeTraversaalMappedResponse(body []byte, fields TraversaalFields) (string, []string, error) {
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", nil, fmt.Errorf("failed to decode response body: %v, body preview: %q", err, bodyPreview(body))
+	}
+
+	answerValue, hasAnswer := lookupJSONPath(data, strings.Split(fields.Answer, "."))
+	linksValue, hasLinks := lookupJSONPath(data, strings.Split(fields.Links, "."))
+	if !hasAnswer && !hasLinks {
+		return "", nil, fmt.Errorf("empty response without %s and %s, body preview: %q",
+			fields.Answer, fields.Links, bodyPreview(body))
+	}
+
+	answer, _ := answerValue.(string)
+
+	var links []string
+	switch value := linksValue.(type) {
+	case string:
+		links = append(links, value)
+	case []any:
+		for _, item := range value {
+			if link, ok := item.(string); ok {
+				links = append(links, link)
+			}
+		}
+	}
+
+	return answer, links, nil
+}
+
+// EstimateCost prices the single request of the answer, the number of links doesn't change the price
+func (t *traversaal) EstimateCost(args json.RawMessage) (CostEstimate, error) {
+	var action SearchAction
+	if err := json.Unmarshal(args, &action); err != nil {
+		return CostEstimate{}, fmt.Errorf("failed to unmarshal %s search action arguments: %w", TraversaalToolName, err)
+	}
+	numResults := t.limits.resolve(traversaalDefaultResults, traversaalMaxResults).clamp(action.MaxResults.Int())
+
+	return resolvePricing(TraversaalToolName).estimate(1, numResults, 0, 1), nil
 }
 
 func (t *traversaal) IsAvailable() bool {
-	return t.apiKey != ""
+	return t.apiKey != "" && IsEnabled(TraversaalToolName)
+}
+
+// HealthCheck sends a tiny query to verify the API key and proxy, the answer is dropped
+func (t *traversaal) HealthCheck(ctx context.Context) error {
+	resp, err := t.do(ctx, "ping", 1)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newSearchError(errorKindByStatus(resp.StatusCode), "unexpected status code: %d", resp.StatusCode)
+	}
+
+	return nil
 }
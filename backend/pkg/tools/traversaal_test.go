@@ -0,0 +1,273 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTraversaalToolDefaultTimeout(t *testing.T) {
+	tool := NewTraversaalTool(0, nil, nil, "test-key", "", 0, RetryPolicy{}, ResultLimits{}, false, false, nil).(*traversaal)
+	if tool.timeout != traversaalTimeout {
+		t.Errorf("timeout = %v, want %v", tool.timeout, traversaalTimeout)
+	}
+
+	tool = NewTraversaalTool(0, nil, nil, "test-key", "", 5*time.Second, RetryPolicy{}, ResultLimits{}, false, false, nil).(*traversaal)
+	if tool.timeout != 5*time.Second {
+		t.Errorf("timeout = %v, want %v", tool.timeout, 5*time.Second)
+	}
+}
+
+func TestNewTraversaalToolWithConfig(t *testing.T) {
+	tool := NewTraversaalToolWithConfig(TraversaalConfig{APIKey: "test-key", SourceFooter: true}).(*traversaal)
+	if tool.timeout != traversaalTimeout {
+		t.Errorf("timeout = %v, want %v", tool.timeout, traversaalTimeout)
+	}
+	if tool.apiKey != "test-key" || !tool.sourceFooter {
+		t.Errorf("expected set fields to be kept, got %+v", tool)
+	}
+}
+
+func TestTraversaalSearchTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	tool := &traversaal{apiKey: "test-key", apiURL: server.URL, timeout: 20 * time.Millisecond}
+	_, _, err := tool.search(context.Background(), "query", traversaalDefaultResults)
+	if !errors.Is(err, ErrNetwork) {
+		t.Fatalf("expected %v, got %v", ErrNetwork, err)
+	}
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestTraversaalSearchDoesNotMutateDefaultClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"response_text":"answer","web_url":[]}}`))
+	}))
+	defer server.Close()
+
+	transport := http.DefaultClient.Transport
+	tool := &traversaal{apiKey: "test-key", apiURL: server.URL, proxyURL: "http://127.0.0.1:0"}
+	_, _, _ = tool.search(context.Background(), "query", traversaalDefaultResults)
+
+	if http.DefaultClient.Transport != transport {
+		t.Error("expected http.DefaultClient transport to stay untouched")
+	}
+}
+
+func TestTraversaalParseResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		want      string
+		wantCount int
+		wantErr   []string
+	}{
+		{
+			name:      "answer with links",
+			body:      `{"data":{"response_text":"answer","web_url":["https://example.com/a","https://example.com/b"]}}`,
+			want:      "# Answer\n\nanswer\n\n# Links\n\n1. https://example.com/a\n2. https://example.com/b\n",
+			wantCount: 2,
+		},
+		{
+			name:    "malformed json",
+			body:    `{"data":{"response_text":"answer",`,
+			wantErr: []string{"failed to decode response body", `body preview: "{\"data\":{\"response_text\":\"answer\","`},
+		},
+		{
+			name:    "html page with ok status",
+			body:    "<html><head><title>Maintenance</title></head><body>" + strings.Repeat("Service is under maintenance. ", 20) + "</body></html>",
+			wantErr: []string{"failed to decode response body", `body preview: "<html><head><title>Maintenance</title>`, `..."`},
+		},
+		{
+			name:    "missing data",
+			body:    `{"error":"quota exceeded"}`,
+			wantErr: []string{"empty response without data", `{\"error\":\"quota exceeded\"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			tool := &traversaal{apiKey: "test-key", apiURL: server.URL}
+			result, count, err := tool.search(context.Background(), "query", traversaalDefaultResults)
+			if len(tt.wantErr) != 0 {
+				if err == nil {
+					t.Fatalf("expected error, got result %q", result)
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("error %q doesn't contain %q", err, want)
+					}
+				}
+				if len(err.Error()) > bodyPreviewSize+200 {
+					t.Errorf("expected the body preview to be short, got %d bytes", len(err.Error()))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("search() error = %v", err)
+			}
+			if result != tt.want || count != tt.wantCount {
+				t.Errorf("search() = %q, %d, want %q, %d", result, count, tt.want, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestTraversaalFieldMapping(t *testing.T) {
+	fields := TraversaalFields{Answer: "result.answer", Links: "result.sources"}
+
+	tests := []struct {
+		name      string
+		fields    TraversaalFields
+		body      string
+		want      string
+		wantCount int
+		wantErr   string
+	}{
+		{
+			name:      "remapped paths",
+			fields:    fields,
+			body:      `{"result":{"answer":"answer","sources":["https://example.com/a",42,"https://example.com/b"]}}`,
+			want:      "# Answer\n\nanswer\n\n# Links\n\n1. https://example.com/a\n2. https://example.com/b\n",
+			wantCount: 2,
+		},
+		{
+			name:      "single link",
+			fields:    fields,
+			body:      `{"result":{"answer":"answer","sources":"https://example.com/a"}}`,
+			want:      "# Answer\n\nanswer\n\n# Links\n\n1. https://example.com/a\n",
+			wantCount: 1,
+		},
+		{
+			name:      "only answer path changed",
+			fields:    TraversaalFields{Answer: "data.text"},
+			body:      `{"data":{"text":"answer","web_url":["https://example.com/a"]}}`,
+			want:      "# Answer\n\nanswer\n\n# Links\n\n1. https://example.com/a\n",
+			wantCount: 1,
+		},
+		{
+			name:    "both paths missing",
+			fields:  fields,
+			body:    `{"data":{"response_text":"answer","web_url":["https://example.com/a"]}}`,
+			wantErr: "empty response without result.answer and result.sources",
+		},
+		{
+			name:    "default paths on the remapped response",
+			body:    `{"result":{"answer":"answer","sources":["https://example.com/a"]}}`,
+			wantErr: "empty response without data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			tool := NewTraversaalToolWithConfig(TraversaalConfig{
+				APIKey: "test-key",
+				Fields: tt.fields,
+			}).(*traversaal)
+			tool.apiURL = server.URL
+
+			result, count, err := tool.search(context.Background(), "query", traversaalDefaultResults)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("search() error = %v", err)
+			}
+			if result != tt.want || count != tt.wantCount {
+				t.Errorf("search() = %q, %d, want %q, %d", result, count, tt.want, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestTraversaalMaxResults(t *testing.T) {
+	links := []string{
+		"https://example.com/1", "https://example.com/2", "https://example.com/3",
+		"https://example.com/4", "https://example.com/5", "https://example.com/6",
+	}
+
+	tests := []struct {
+		name       string
+		limits     ResultLimits
+		maxResults Int64
+		want       int
+	}{
+		{"tool default", ResultLimits{}, 0, traversaalDefaultResults},
+		{"requested by agent", ResultLimits{}, 2, 2},
+		{"clamped to tool maximum", ResultLimits{}, 50, traversaalMaxResults},
+		{"clamped to configured maximum", ResultLimits{Max: 3}, 8, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				Query      []string `json:"query"`
+				MaxResults int      `json:"max_results"`
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				json.NewEncoder(w).Encode(map[string]any{
+					"data": map[string]any{"response_text": "answer", "web_url": links},
+				})
+			}))
+			defer server.Close()
+
+			tool := &traversaal{apiKey: "test-key", apiURL: server.URL, limits: tt.limits}
+			args, _ := json.Marshal(SearchAction{Query: "log4shell", MaxResults: tt.maxResults})
+			result, err := tool.Handle(context.Background(), TraversaalToolName, args)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+
+			if body.MaxResults != tt.want {
+				t.Errorf("max_results = %d, want %d", body.MaxResults, tt.want)
+			}
+			if len(body.Query) != 1 || body.Query[0] != "log4shell" {
+				t.Errorf("unexpected query %v", body.Query)
+			}
+
+			wantLinks := min(tt.want, len(links))
+			if count := strings.Count(result, "https://example.com/"); count != wantLinks {
+				t.Errorf("expected %d links, got %d:\n%s", wantLinks, count, result)
+			}
+			if wantLinks < len(links) && strings.Contains(result, links[wantLinks]) {
+				t.Errorf("unexpected link %s over the limit:\n%s", links[wantLinks], result)
+			}
+		})
+	}
+}
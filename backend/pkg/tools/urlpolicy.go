@@ -0,0 +1,281 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const urlPolicyLookupTimeout = 5 * time.Second
+
+// ErrBlockedByPolicy is returned for targets which the browser isn't allowed to reach
+var ErrBlockedByPolicy = errors.New("blocked by policy")
+
+// instance metadata endpoints of cloud providers which expose credentials of the host
+var (
+	metadataIPs = []net.IP{
+		net.ParseIP("169.254.169.254"), // AWS, GCP, Azure, OpenStack, DigitalOcean
+		net.ParseIP("169.254.170.2"),   // AWS ECS task metadata
+		net.ParseIP("100.100.100.200"), // Alibaba Cloud
+		net.ParseIP("fd00:ec2::254"),   // AWS IPv6
+	}
+	metadataHosts = []string{"metadata", "metadata.google.internal"}
+)
+
+// URLPolicy restricts the targets of the browser by their addresses, nil policy allows everything
+type URLPolicy struct {
+	blockMetadata bool
+	allow         []*net.IPNet
+	deny          []*net.IPNet
+	lookup        func(ctx context.Context, host string) ([]net.IP, error)
+}
+
+// NewURLPolicy parses allowed and denied CIDRs (single addresses are accepted too),
+// it returns nil policy when no restriction is set to keep the routing as is
+func NewURLPolicy(blockMetadata bool, allow, deny []string) (*URLPolicy, error) {
+	allowNets, err := parseCIDRs(allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed CIDR: %w", err)
+	}
+
+	denyNets, err := parseCIDRs(deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denied CIDR: %w", err)
+	}
+
+	if !blockMetadata && len(allowNets) == 0 && len(denyNets) == 0 {
+		return nil, nil
+	}
+
+	return &URLPolicy{
+		blockMetadata: blockMetadata,
+		allow:         allowNets,
+		deny:          denyNets,
+		lookup: func(ctx context.Context, host string) ([]net.IP, error) {
+			return net.DefaultResolver.LookupIP(ctx, "ip", host)
+		},
+	}, nil
+}
+
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is neither CIDR nor IP address", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// Check resolves the host and fails with ErrBlockedByPolicy if any of its addresses is disallowed,
+// the host which can't be resolved is blocked too because the scraper may still reach it
+func (p *URLPolicy) Check(ctx context.Context, host string) error {
+	if p == nil {
+		return nil
+	}
+
+	host = normalizeHost(host)
+	if p.blockMetadata && slices.Contains(metadataHosts, host) {
+		return fmt.Errorf("%w: %s is a cloud metadata endpoint", ErrBlockedByPolicy, host)
+	}
+
+	ips, err := p.resolve(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: %s can't be resolved to check its addresses: %v", ErrBlockedByPolicy, host, err)
+	}
+
+	for _, ip := range ips {
+		if p.blockMetadata && slices.ContainsFunc(metadataIPs, ip.Equal) {
+			return fmt.Errorf("%w: %s is a cloud metadata endpoint", ErrBlockedByPolicy, host)
+		}
+		if containsIP(p.deny, ip) {
+			return fmt.Errorf("%w: %s resolves to the denied address %s", ErrBlockedByPolicy, host, ip)
+		}
+		if len(p.allow) != 0 && !containsIP(p.allow, ip) {
+			return fmt.Errorf("%w: %s resolves to the address %s out of the allowed networks", ErrBlockedByPolicy, host, ip)
+		}
+	}
+
+	return nil
+}
+
+func (p *URLPolicy) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := parseHostIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, urlPolicyLookupTimeout)
+	defer cancel()
+
+	return p.lookup(ctx, host)
+}
+
+// parseHostIP parses the host as the address the way browsers do, besides the usual notations
+// IPv4 addresses may be written as one number or with hex and octal parts, e.g. 2852039166,
+// 0xA9FEA9FE and 0251.0376.0251.0376 are all 169.254.169.254
+func parseHostIP(host string) net.IP {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > net.IPv4len {
+		return nil
+	}
+
+	var (
+		numbers = make([]uint64, 0, len(parts))
+		err     error
+	)
+	for _, part := range parts {
+		var number uint64
+		switch {
+		case len(part) > 1 && (strings.HasPrefix(part, "0x") || strings.HasPrefix(part, "0X")):
+			if part = part[2:]; part != "" {
+				number, err = strconv.ParseUint(part, 16, 32)
+			}
+		case len(part) > 1 && strings.HasPrefix(part, "0"):
+			number, err = strconv.ParseUint(part[1:], 8, 32)
+		default:
+			number, err = strconv.ParseUint(part, 10, 32)
+		}
+		if err != nil {
+			return nil
+		}
+		numbers = append(numbers, number)
+	}
+
+	// the leading parts are single bytes and the last one fills the rest of the address
+	last := numbers[len(numbers)-1]
+	if last >= 1<<(8*(net.IPv4len-len(numbers)+1)) {
+		return nil
+	}
+	address := last
+	for i, number := range numbers[:len(numbers)-1] {
+		if number > 255 {
+			return nil
+		}
+		address |= number << (8 * (net.IPv4len - 1 - i))
+	}
+
+	return net.IPv4(byte(address>>24), byte(address>>16), byte(address>>8), byte(address))
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	return slices.ContainsFunc(nets, func(ipNet *net.IPNet) bool {
+		return ipNet.Contains(ip)
+	})
+}
+
+// ErrOutOfScope is returned for targets out of the domains of the engagement scope
+var ErrOutOfScope = errors.New("out of scope")
+
+// DomainScope restricts the targets of the browser by their host names, nil scope allows everything
+type DomainScope struct {
+	allow []string
+	deny  []string
+}
+
+// NewDomainScope normalizes allowed and denied domains, "*.example.com" matches any subdomain
+// of example.com but not example.com itself, it returns nil scope when both lists are empty
+func NewDomainScope(allow, deny []string) (*DomainScope, error) {
+	allowDomains, err := parseDomains(allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed domain: %w", err)
+	}
+
+	denyDomains, err := parseDomains(deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid denied domain: %w", err)
+	}
+
+	if len(allowDomains) == 0 && len(denyDomains) == 0 {
+		return nil, nil
+	}
+
+	return &DomainScope{allow: allowDomains, deny: denyDomains}, nil
+}
+
+func parseDomains(values []string) ([]string, error) {
+	var domains []string
+	for _, value := range values {
+		domain := normalizeHost(value)
+		if domain == "" {
+			continue
+		}
+
+		name := strings.TrimPrefix(domain, "*.")
+		if name == "" || strings.ContainsAny(name, "*/:@ ") {
+			return nil, fmt.Errorf("%q is not a domain or a wildcard like *.example.com", value)
+		}
+		domains = append(domains, domain)
+	}
+
+	return domains, nil
+}
+
+// Check fails with ErrOutOfScope if the host matches the denied domains or the allow list is set
+// and the host doesn't match it, the check doesn't resolve the host
+func (s *DomainScope) Check(host string) error {
+	if s == nil {
+		return nil
+	}
+
+	host = normalizeHost(host)
+	if domain, ok := matchDomain(s.deny, host); ok {
+		return fmt.Errorf("%w: %s matches the denied domain %s", ErrOutOfScope, host, domain)
+	}
+	if _, ok := matchDomain(s.allow, host); len(s.allow) != 0 && !ok {
+		return fmt.Errorf("%w: %s doesn't match any of the allowed domains", ErrOutOfScope, host)
+	}
+
+	return nil
+}
+
+// normalizeHost lowers the host and drops brackets of IPv6 addresses and the trailing dot
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.Trim(strings.TrimSpace(host), "[]")), ".")
+}
+
+// matchDomain returns the first domain of the list which matches the host
+func matchDomain(domains []string, host string) (string, bool) {
+	for _, domain := range domains {
+		if suffix, ok := strings.CutPrefix(domain, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return domain, true
+			}
+			continue
+		}
+		if host == domain {
+			return domain, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,284 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	waybackAvailableURL  = "https://archive.org/wayback/available"
+	waybackCDXURL        = "https://web.archive.org/cdx/search/cdx"
+	waybackSnapshotURL   = "https://web.archive.org/web/"
+	waybackTimeout       = 30 * time.Second
+	waybackMaxCaptures   = 20
+	waybackTimestampTime = "20060102150405"
+)
+
+var waybackTimestampPattern = regexp.MustCompile(`^\d{4,14}$`)
+
+// waybackAvailability is the answer of the availability API, empty archived_snapshots means no snapshots
+type waybackAvailability struct {
+	URL               string `json:"url"`
+	ArchivedSnapshots struct {
+		Closest *waybackSnapshot `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+type waybackSnapshot struct {
+	Available bool   `json:"available"`
+	URL       string `json:"url"`
+	Timestamp string `json:"timestamp"`
+	Status    string `json:"status"`
+}
+
+// waybackCapture is the row of the CDX API answer
+type waybackCapture struct {
+	Timestamp  string
+	Original   string
+	StatusCode string
+	MimeType   string
+}
+
+type wayback struct {
+	flowID       int64
+	taskID       *int64
+	subtaskID    *int64
+	enabled      bool
+	proxyURL     string
+	availableURL string
+	cdxURL       string
+	timeout      time.Duration
+	tracer       Tracer
+}
+
+// NewWaybackTool creates the tool which looks up archived snapshots of URLs in the Internet Archive
+func NewWaybackTool(flowID int64, taskID, subtaskID *int64, enabled bool,
+	proxyURL string, timeout time.Duration,
+) Tool {
+	return &wayback{
+		flowID:    flowID,
+		taskID:    taskID,
+		subtaskID: subtaskID,
+		enabled:   enabled,
+		proxyURL:  proxyURL,
+		timeout:   timeout,
+	}
+}
+
+func (w *wayback) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action WaybackAction
+	ctx, emitter := startTrace(ctx, w.tracer)
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal wayback action")
+		return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+	}
+
+	target := strings.TrimSpace(action.URL)
+	if target == "" {
+		return "url to look up in the wayback machine is empty", nil
+	}
+	timestamp := strings.TrimSpace(action.Timestamp)
+	if timestamp != "" && !waybackTimestampPattern.MatchString(timestamp) {
+		return fmt.Sprintf("invalid timestamp %q: expected format is YYYYMMDD or YYYYMMDDhhmmss", action.Timestamp), nil
+	}
+	captures := min(max(action.Captures.Int(), 0), waybackMaxCaptures)
+	logger = logger.WithField("url", target)
+
+	ctx, cancel := context.WithTimeout(ctx, w.getTimeout())
+	defer cancel()
+
+	snapshot, err := w.closest(ctx, target, timestamp)
+	var recent []waybackCapture
+	if err == nil && snapshot != nil && captures > 0 {
+		recent, err = w.captures(ctx, target, captures)
+	}
+	if err != nil {
+		emitter.Emit(searchErrorEvent(target, err, map[string]any{
+			"tool_name": WaybackToolName,
+			"engine":    "wayback",
+		}))
+
+		logger.WithError(err).Error("failed to look up url in the wayback machine")
+		return fmt.Sprintf("failed to look up %s in the wayback machine: %v", target, err), nil
+	}
+
+	if snapshot == nil {
+		logger.Debug("url has no snapshots in the wayback machine")
+		return fmt.Sprintf("No snapshots of %s found in the Wayback Machine, the page was never archived\n", target), nil
+	}
+
+	return formatWayback(target, snapshot, recent), nil
+}
+
+// closest returns the snapshot closest to the timestamp or the latest one, nil snapshot means no snapshots
+func (w *wayback) closest(ctx context.Context, target, timestamp string) (*waybackSnapshot, error) {
+	query := url.Values{"url": {target}}
+	if timestamp != "" {
+		query.Set("timestamp", timestamp)
+	}
+
+	body, err := w.get(ctx, orDefault(w.availableURL, waybackAvailableURL)+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	return parseWaybackAvailability(body)
+}
+
+// captures returns the most recent captures of the URL from the newest one
+func (w *wayback) captures(ctx context.Context, target string, limit int) ([]waybackCapture, error) {
+	query := url.Values{
+		"url":    {target},
+		"output": {"json"},
+		"fl":     {"timestamp,original,statuscode,mimetype"},
+		// negative limit takes the captures from the end of the index which is sorted by time
+		"limit": {fmt.Sprintf("-%d", limit)},
+	}
+
+	body, err := w.get(ctx, orDefault(w.cdxURL, waybackCDXURL)+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	return parseWaybackCDX(body)
+}
+
+func (w *wayback) get(ctx context.Context, reqURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := newHTTPClient(w.proxyURL, w.getTimeout()).Do(req)
+	if err != nil {
+		return nil, newNetworkError(err, "request to the wayback machine failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newSearchError(errorKindByStatus(resp.StatusCode), "unexpected status code: %d", resp.StatusCode)
+	}
+
+	return readLimitedBody(resp.Body, defaultMaxResponseSize)
+}
+
+func (w *wayback) getTimeout() time.Duration {
+	return resolveTimeout(WaybackToolName, w.timeout)
+}
+
+func parseWaybackAvailability(body []byte) (*waybackSnapshot, error) {
+	var availability waybackAvailability
+	if err := json.Unmarshal(body, &availability); err != nil {
+		return nil, fmt.Errorf("failed to decode availability response: %w", err)
+	}
+
+	closest := availability.ArchivedSnapshots.Closest
+	if closest == nil || !closest.Available || closest.URL == "" {
+		return nil, nil
+	}
+
+	return closest, nil
+}
+
+// parseWaybackCDX reads the rows of the CDX answer by the names in its header row,
+// the answer without captures is an empty body or an empty array
+func parseWaybackCDX(body []byte) ([]waybackCapture, error) {
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil, nil
+	}
+
+	var rows [][]string
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode cdx response: %w", err)
+	}
+	if len(rows) < 2 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int, len(rows[0]))
+	for idx, name := range rows[0] {
+		columns[name] = idx
+	}
+	field := func(row []string, name string) string {
+		if idx, ok := columns[name]; ok && idx < len(row) {
+			return row[idx]
+		}
+		return ""
+	}
+
+	captures := make([]waybackCapture, 0, len(rows)-1)
+	// the index is sorted from the oldest capture
+	for idx := len(rows) - 1; idx > 0; idx-- {
+		captures = append(captures, waybackCapture{
+			Timestamp:  field(rows[idx], "timestamp"),
+			Original:   field(rows[idx], "original"),
+			StatusCode: field(rows[idx], "statuscode"),
+			MimeType:   field(rows[idx], "mimetype"),
+		})
+	}
+
+	return captures, nil
+}
+
+func formatWayback(target string, snapshot *waybackSnapshot, captures []waybackCapture) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("# Wayback Machine snapshots of %s\n\n", target))
+
+	builder.WriteString("## Closest Snapshot\n\n")
+	builder.WriteString(fmt.Sprintf("- URL: %s\n", snapshot.URL))
+	builder.WriteString(fmt.Sprintf("- Captured: %s\n", formatWaybackTimestamp(snapshot.Timestamp)))
+	if snapshot.Status != "" {
+		builder.WriteString(fmt.Sprintf("- Status: %s\n", snapshot.Status))
+	}
+
+	if len(captures) > 0 {
+		builder.WriteString("\n## Recent Captures\n\n")
+		builder.WriteString("| Captured | Status | Type | Snapshot |\n")
+		builder.WriteString("| -------- | ------ | ---- | -------- |\n")
+		for _, capture := range captures {
+			builder.WriteString(fmt.Sprintf("| %s | %s | %s | %s%s/%s |\n",
+				formatWaybackTimestamp(capture.Timestamp), orDefault(capture.StatusCode, "-"),
+				orDefault(capture.MimeType, "-"), waybackSnapshotURL, capture.Timestamp, capture.Original))
+		}
+	}
+
+	return builder.String()
+}
+
+// formatWaybackTimestamp renders the 14 digits timestamp of the archive as the UTC date
+func formatWaybackTimestamp(timestamp string) string {
+	parsed, err := time.Parse(waybackTimestampTime, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return parsed.Format("2006-01-02 15:04:05 UTC")
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func (w *wayback) IsAvailable() bool {
+	return w.enabled && IsEnabled(WaybackToolName)
+}
+
+func (w *wayback) HealthCheck(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseWaybackAvailability(t *testing.T) {
+	data, err := os.ReadFile("testdata/wayback_available.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	snapshot, err := parseWaybackAvailability(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if snapshot == nil {
+		t.Fatal("expected the closest snapshot")
+	}
+	if snapshot.URL != "http://web.archive.org/web/20230514093212/https://example.com/admin" ||
+		snapshot.Timestamp != "20230514093212" || snapshot.Status != "200" {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+
+	data, err = os.ReadFile("testdata/wayback_available_empty.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	if snapshot, err := parseWaybackAvailability(data); err != nil || snapshot != nil {
+		t.Errorf("expected no snapshot without error, got %+v, %v", snapshot, err)
+	}
+
+	if _, err := parseWaybackAvailability([]byte("<html>")); err == nil {
+		t.Error("expected error for malformed response")
+	}
+}
+
+func TestParseWaybackCDX(t *testing.T) {
+	data, err := os.ReadFile("testdata/wayback_cdx.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	captures, err := parseWaybackCDX(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []waybackCapture{
+		{Timestamp: "20240220184455", Original: "https://example.com/admin/", StatusCode: "404", MimeType: "text/html"},
+		{Timestamp: "20230514093212", Original: "https://example.com/admin", StatusCode: "302", MimeType: "text/html"},
+		{Timestamp: "20220105120301", Original: "https://example.com/admin", StatusCode: "200", MimeType: "text/html"},
+	}
+	if len(captures) != len(want) {
+		t.Fatalf("expected %d captures, got %d", len(want), len(captures))
+	}
+	for idx := range want {
+		if captures[idx] != want[idx] {
+			t.Errorf("capture %d: expected %+v, got %+v", idx, want[idx], captures[idx])
+		}
+	}
+
+	for _, body := range []string{"", "[]", "\n", `[["timestamp","original"]]`} {
+		if captures, err := parseWaybackCDX([]byte(body)); err != nil || len(captures) != 0 {
+			t.Errorf("expected no captures for %q, got %v, %v", body, captures, err)
+		}
+	}
+}
+
+func TestWaybackHandle(t *testing.T) {
+	available, err := os.ReadFile("testdata/wayback_available.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	empty, err := os.ReadFile("testdata/wayback_available_empty.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	cdx, err := os.ReadFile("testdata/wayback_cdx.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var cdxQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/cdx":
+			cdxQuery = r.URL.RawQuery
+			w.Write(cdx)
+		case r.URL.Query().Get("url") == "example.com/never-archived":
+			w.Write(empty)
+		case r.URL.Query().Get("url") == "example.com/broken":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write(available)
+		}
+	}))
+	defer server.Close()
+
+	w := &wayback{enabled: true, availableURL: server.URL + "/available", cdxURL: server.URL + "/cdx"}
+
+	for _, tt := range []struct {
+		name     string
+		action   WaybackAction
+		contains []string
+		excludes []string
+	}{
+		{
+			name:   "closest snapshot",
+			action: WaybackAction{URL: "https://example.com/admin", Timestamp: "20230601"},
+			contains: []string{
+				"# Wayback Machine snapshots of https://example.com/admin",
+				"- URL: http://web.archive.org/web/20230514093212/https://example.com/admin",
+				"- Captured: 2023-05-14 09:32:12 UTC",
+				"- Status: 200",
+			},
+			excludes: []string{"## Recent Captures"},
+		},
+		{
+			name:   "recent captures",
+			action: WaybackAction{URL: "https://example.com/admin", Captures: 3},
+			contains: []string{
+				"## Recent Captures",
+				"| 2024-02-20 18:44:55 UTC | 404 | text/html | https://web.archive.org/web/20240220184455/https://example.com/admin/ |",
+				"| 2022-01-05 12:03:01 UTC | 200 | text/html | https://web.archive.org/web/20220105120301/https://example.com/admin |",
+			},
+		},
+		{
+			name:     "no snapshots",
+			action:   WaybackAction{URL: "example.com/never-archived", Captures: 5},
+			contains: []string{"No snapshots of example.com/never-archived found in the Wayback Machine"},
+			excludes: []string{"failed"},
+		},
+		{
+			name:     "invalid timestamp",
+			action:   WaybackAction{URL: "example.com", Timestamp: "2023-06-01"},
+			contains: []string{"invalid timestamp"},
+		},
+		{
+			name:     "upstream failure",
+			action:   WaybackAction{URL: "example.com/broken"},
+			contains: []string{"failed to look up example.com/broken in the wayback machine"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(tt.action)
+			result, err := w.Handle(context.Background(), WaybackToolName, args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, part := range tt.contains {
+				if !strings.Contains(result, part) {
+					t.Errorf("expected %q in result:\n%s", part, result)
+				}
+			}
+			for _, part := range tt.excludes {
+				if strings.Contains(result, part) {
+					t.Errorf("unexpected %q in result:\n%s", part, result)
+				}
+			}
+		})
+	}
+
+	if !strings.Contains(cdxQuery, "limit=-3") || !strings.Contains(cdxQuery, "output=json") {
+		t.Errorf("unexpected cdx query %q", cdxQuery)
+	}
+}
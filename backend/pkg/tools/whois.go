@@ -0,0 +1,454 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/idna"
+	"golang.org/x/net/proxy"
+)
+
+const (
+	whoisDefaultServer   = "whois.iana.org"
+	whoisPort            = "43"
+	whoisTimeout         = 30 * time.Second
+	whoisMaxReferrals    = 2
+	whoisMaxResponseSize = 1024 * 1024
+)
+
+// errWhoisNoMatch means that the domain isn't registered, it's the answer, not the failure
+var errWhoisNoMatch = errors.New("no match for the domain")
+
+var whoisNoMatchPatterns = []string{
+	"no match for",
+	"not found",
+	"no data found",
+	"no entries found",
+	"no object found",
+	"no matching record",
+	"returned 0 objects",
+	"status: free",
+	"status: available",
+	"is available for registration",
+}
+
+// whoisRecord keeps the fields of WHOIS response which are common for most of TLDs
+type whoisRecord struct {
+	registrar    string
+	organization string
+	created      string
+	expires      string
+	updated      string
+	nameServers  []string
+	status       []string
+	refer        string
+	whoisServer  string
+	noMatch      bool
+}
+
+type whois struct {
+	flowID    int64
+	taskID    *int64
+	subtaskID *int64
+	enabled   bool
+	server    string
+	proxyURL  string
+	timeout   time.Duration
+	tracer    Tracer
+}
+
+// NewWhoisTool creates the tool which looks up registration data of domains over WHOIS protocol,
+// empty server starts the lookup from IANA which refers to the server of the TLD
+func NewWhoisTool(flowID int64, taskID, subtaskID *int64, enabled bool,
+	server, proxyURL string, timeout time.Duration,
+) Tool {
+	return &whois{
+		flowID:    flowID,
+		taskID:    taskID,
+		subtaskID: subtaskID,
+		enabled:   enabled,
+		server:    server,
+		proxyURL:  proxyURL,
+		timeout:   timeout,
+	}
+}
+
+func (w *whois) Handle(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	var action WhoisAction
+	ctx, emitter := startTrace(ctx, w.tracer)
+	logger := logrus.WithContext(ctx).WithFields(logrus.Fields{
+		"tool": name,
+		"args": string(args),
+	})
+
+	if err := json.Unmarshal(args, &action); err != nil {
+		logger.WithError(err).Error("failed to unmarshal whois action")
+		return "", fmt.Errorf("failed to unmarshal %s action arguments: %w", name, err)
+	}
+
+	domain, err := normalizeDomain(action.Domain)
+	if err != nil {
+		logger.WithError(err).Error("invalid domain to look up in whois")
+		return fmt.Sprintf("invalid domain %q: %v", action.Domain, err), nil
+	}
+	logger = logger.WithField("domain", domain)
+
+	record, servers, err := w.lookup(ctx, domain)
+	if errors.Is(err, errWhoisNoMatch) {
+		logger.Debug("domain is not found in whois")
+		return fmt.Sprintf("no match for domain %s in WHOIS (%s), the domain is likely not registered",
+			domain, strings.Join(servers, ", ")), nil
+	}
+	if err != nil {
+		emitter.Emit(TraceEvent{
+			Name:   "whois tool error swallowed",
+			Input:  domain,
+			Status: err.Error(),
+			Level:  TraceLevelWarning,
+			Metadata: map[string]any{
+				"tool_name": WhoisToolName,
+				"domain":    domain,
+				"servers":   servers,
+				"error":     err.Error(),
+			},
+		})
+
+		logger.WithError(err).Error("failed to look up domain in whois")
+		return fmt.Sprintf("failed to look up domain %s in whois: %v", domain, err), nil
+	}
+
+	return record.format(domain, servers), nil
+}
+
+// lookup follows referrals from the registry to the registrar server within the single timeout,
+// the data of the later server wins because it's more detailed for thin registries like .com
+func (w *whois) lookup(ctx context.Context, domain string) (*whoisRecord, []string, error) {
+	timeout := resolveTimeout(WhoisToolName, w.timeout)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	server := w.server
+	if server == "" {
+		server = whoisDefaultServer
+	}
+
+	var (
+		record  *whoisRecord
+		servers []string
+	)
+	for hop := 0; hop <= whoisMaxReferrals && server != "" && !slices.Contains(servers, server); hop++ {
+		response, err := w.query(ctx, server, domain)
+		if err != nil && record != nil {
+			// the registrar server is optional, the registry data is enough to answer
+			logrus.WithContext(ctx).WithError(err).WithField("server", server).
+				Warn("failed to query referred whois server")
+			break
+		} else if err != nil {
+			return nil, servers, err
+		}
+		servers = append(servers, server)
+
+		parsed := parseWhois(response)
+		switch {
+		case parsed.refer != "":
+			// IANA describes the TLD itself, only the referral is useful for the domain
+			server = parsed.refer
+			continue
+		case parsed.isEmpty() && record != nil:
+			return record, servers, nil
+		case parsed.isEmpty() && parsed.noMatch:
+			return nil, servers, errWhoisNoMatch
+		case parsed.isEmpty():
+			return nil, servers, fmt.Errorf("whois server %s returned no registration data", server)
+		}
+
+		// only the registry refers to the registrar server, which is the last one in the chain
+		next := parsed.whoisServer
+		if record != nil {
+			next = ""
+		}
+		record = record.merge(parsed)
+		server = next
+	}
+
+	if record == nil {
+		return nil, servers, fmt.Errorf("no whois server returned registration data after %d referrals", whoisMaxReferrals)
+	}
+
+	return record, servers, nil
+}
+
+func (w *whois) query(ctx context.Context, server, domain string) (string, error) {
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, whoisPort)
+	}
+
+	conn, err := w.dial(ctx, addr)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to whois server %s: %w", server, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := fmt.Fprintf(conn, "%s\r\n", domain); err != nil {
+		return "", fmt.Errorf("failed to send query to whois server %s: %w", server, err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(conn, whoisMaxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response of whois server %s: %w", server, err)
+	}
+
+	// some registries answer in legacy encodings which are useless to show as is
+	return strings.ToValidUTF8(string(data), ""), nil
+}
+
+// dial connects to the WHOIS server directly or through the tunnel of HTTP or SOCKS5 proxy
+func (w *whois) dial(ctx context.Context, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{}
+	if w.proxyURL == "" {
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	proxyURL, err := url.Parse(w.proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proxy url: %w", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http":
+		return dialHTTPTunnel(ctx, dialer, proxyURL, addr)
+	case "socks5", "socks5h":
+		socks, err := proxy.FromURL(proxyURL, dialer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create socks5 dialer: %w", err)
+		}
+		if contextDialer, ok := socks.(proxy.ContextDialer); ok {
+			return contextDialer.DialContext(ctx, "tcp", addr)
+		}
+		return socks.Dial("tcp", addr)
+	default:
+		return nil, fmt.Errorf("proxy scheme %q is not supported for raw tcp connections", proxyURL.Scheme)
+	}
+}
+
+// dialHTTPTunnel opens the raw tcp connection through HTTP proxy with CONNECT method
+func dialHTTPTunnel(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, "tcp", proxyURL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send connect request to proxy: %w", err)
+	}
+
+	// the server of the tunnel doesn't send anything before the query, so the reader buffers nothing extra
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read connect response of proxy: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused to connect to %s: %s", addr, resp.Status)
+	}
+
+	return conn, nil
+}
+
+// parseWhois reads "key: value" lines of the response, the keys of the most popular formats are mapped
+// to the common fields and the rest of them are ignored
+func parseWhois(response string) *whoisRecord {
+	record := &whoisRecord{}
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ">>>") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "refer":
+			setWhoisField(&record.refer, value)
+		case "registrar whois server", "whois server":
+			setWhoisField(&record.whoisServer, strings.TrimPrefix(value, "whois://"))
+		case "registrar", "sponsoring registrar", "registrar name":
+			setWhoisField(&record.registrar, value)
+		case "registrant organization", "registrant organisation", "registrant", "org":
+			setWhoisField(&record.organization, value)
+		case "creation date", "created", "created on", "registered on", "registration time",
+			"domain registration date":
+			setWhoisField(&record.created, value)
+		case "registry expiry date", "registrar registration expiration date", "expiry date",
+			"expiration date", "expiration time", "expires", "expires on", "paid-till":
+			setWhoisField(&record.expires, value)
+		case "updated date", "last updated", "last updated on", "changed", "last-modified":
+			setWhoisField(&record.updated, value)
+		case "name server", "nserver", "nameserver":
+			host := strings.TrimSuffix(strings.ToLower(strings.Fields(value)[0]), ".")
+			if !slices.Contains(record.nameServers, host) {
+				record.nameServers = append(record.nameServers, host)
+			}
+		case "domain status", "status", "state":
+			// EPP statuses are followed by the link to their description, sometimes in parentheses
+			status, _, _ := strings.Cut(value, " http")
+			status, _, _ = strings.Cut(status, " (")
+			if !slices.Contains(record.status, status) {
+				record.status = append(record.status, status)
+			}
+		}
+	}
+
+	lower := strings.ToLower(response)
+	for _, pattern := range whoisNoMatchPatterns {
+		if strings.Contains(lower, pattern) {
+			record.noMatch = true
+			break
+		}
+	}
+
+	return record
+}
+
+// setWhoisField keeps the first value of the key because registries repeat keys in contact sections
+func setWhoisField(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// isEmpty ignores the status because free domains are reported by the status in some TLDs
+func (r *whoisRecord) isEmpty() bool {
+	return r.registrar == "" && r.organization == "" && r.created == "" && r.expires == "" &&
+		len(r.nameServers) == 0
+}
+
+// merge returns the record with fields of the next record taking precedence over the current ones
+func (r *whoisRecord) merge(next *whoisRecord) *whoisRecord {
+	if r == nil {
+		return next
+	}
+
+	merged := *r
+	mergeWhoisField(&merged.registrar, next.registrar)
+	mergeWhoisField(&merged.organization, next.organization)
+	mergeWhoisField(&merged.created, next.created)
+	mergeWhoisField(&merged.expires, next.expires)
+	mergeWhoisField(&merged.updated, next.updated)
+	if len(next.nameServers) > 0 {
+		merged.nameServers = next.nameServers
+	}
+	if len(next.status) > 0 {
+		merged.status = next.status
+	}
+
+	return &merged
+}
+
+func mergeWhoisField(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+func (r *whoisRecord) format(domain string, servers []string) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("# WHOIS of %s\n\n", domain))
+	for _, section := range []struct {
+		title string
+		value string
+	}{
+		{"Registrar", r.registrar},
+		{"Registrant Organization", r.organization},
+		{"Created", r.created},
+		{"Expires", r.expires},
+		{"Updated", r.updated},
+		{"Name Servers", strings.Join(r.nameServers, ", ")},
+		{"Status", strings.Join(r.status, ", ")},
+		{"WHOIS Servers", strings.Join(servers, ", ")},
+	} {
+		if section.value != "" {
+			builder.WriteString(fmt.Sprintf("## %s\n%s\n\n", section.title, section.value))
+		}
+	}
+
+	return builder.String()
+}
+
+// normalizeDomain accepts the domain or the URL with it and returns the lower case ASCII form of the domain
+func normalizeDomain(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse url: %w", err)
+		}
+		value = u.Hostname()
+	}
+
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(strings.ToLower(value), "."))
+	if err != nil {
+		return "", fmt.Errorf("domain is malformed: %w", err)
+	}
+
+	if net.ParseIP(domain) != nil {
+		return "", fmt.Errorf("expected domain name, got IP address")
+	}
+	if !strings.Contains(domain, ".") {
+		return "", fmt.Errorf("expected domain name with TLD")
+	}
+
+	return domain, nil
+}
+
+func (w *whois) IsAvailable() bool {
+	return w.enabled && IsEnabled(WhoisToolName)
+}
+
+func (w *whois) HealthCheck(ctx context.Context) error {
+	return nil
+}
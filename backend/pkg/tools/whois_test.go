@@ -0,0 +1,306 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func loadWhoisFixture(t *testing.T, name, refer string) string {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %v", name, err)
+	}
+
+	return strings.ReplaceAll(string(data), "%REFER%", refer)
+}
+
+// newWhoisServer serves the response to every query and records the queries
+func newWhoisServer(t *testing.T, response string) (string, func() []string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var (
+		mx      sync.Mutex
+		queries []string
+	)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			query, _ := bufio.NewReader(conn).ReadString('\n')
+			mx.Lock()
+			queries = append(queries, query)
+			mx.Unlock()
+
+			conn.Write([]byte(response))
+			conn.Close()
+		}
+	}()
+
+	return listener.Addr().String(), func() []string {
+		mx.Lock()
+		defer mx.Unlock()
+		return slices.Clone(queries)
+	}
+}
+
+// closedAddr returns the address where nobody listens
+func closedAddr(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	return addr
+}
+
+func TestParseWhois(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    whoisRecord
+	}{
+		{
+			fixture: "whois_com_registry.txt",
+			want: whoisRecord{
+				registrar:   "MarkMonitor Inc.",
+				created:     "1995-08-14T04:00:00Z",
+				expires:     "2025-08-13T04:00:00Z",
+				updated:     "2024-08-14T07:01:34Z",
+				nameServers: []string{"a.iana-servers.net", "b.iana-servers.net"},
+				status:      []string{"clientDeleteProhibited", "clientTransferProhibited"},
+				whoisServer: "whois.registrar.test",
+			},
+		},
+		{
+			fixture: "whois_com_registrar.txt",
+			want: whoisRecord{
+				registrar:    "MarkMonitor, Inc.",
+				organization: "Internet Assigned Numbers Authority",
+				created:      "1995-08-14T04:00:00+0000",
+				expires:      "2025-08-13T04:00:00+0000",
+				updated:      "2024-08-14T07:01:34+0000",
+				nameServers:  []string{"a.iana-servers.net", "b.iana-servers.net"},
+				status:       []string{"clientUpdateProhibited"},
+				whoisServer:  "whois.markmonitor.com",
+			},
+		},
+		{
+			fixture: "whois_ru.txt",
+			want: whoisRecord{
+				registrar:    "RU-CENTER-RU",
+				organization: "Pentagi Example LLC",
+				created:      "2005-03-29T20:00:00Z",
+				expires:      "2026-03-30T21:00:00Z",
+				nameServers:  []string{"ns1.pentagi-example.ru", "ns2.pentagi-example.ru"},
+				status:       []string{"REGISTERED, DELEGATED, VERIFIED"},
+			},
+		},
+		{
+			fixture: "whois_nomatch.txt",
+			want:    whoisRecord{noMatch: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			got := parseWhois(loadWhoisFixture(t, tt.fixture, "whois.registrar.test"))
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("parseWhois() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+
+	iana := parseWhois(loadWhoisFixture(t, "whois_iana.txt", "whois.verisign-grs.com"))
+	if iana.refer != "whois.verisign-grs.com" {
+		t.Errorf("expected referral to the registry, got %q", iana.refer)
+	}
+}
+
+func TestWhoisHandle(t *testing.T) {
+	registrar, registrarQueries := newWhoisServer(t, loadWhoisFixture(t, "whois_com_registrar.txt", ""))
+	registry, _ := newWhoisServer(t, loadWhoisFixture(t, "whois_com_registry.txt", registrar))
+	iana, ianaQueries := newWhoisServer(t, loadWhoisFixture(t, "whois_iana.txt", registry))
+
+	tool := NewWhoisTool(0, nil, nil, true, iana, "", 5*time.Second)
+
+	args, _ := json.Marshal(WhoisAction{Domain: " https://Pentagi-Example.COM./login "})
+	result, err := tool.Handle(context.Background(), WhoisToolName, args)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	if got := ianaQueries(); !reflect.DeepEqual(got, []string{"pentagi-example.com\r\n"}) {
+		t.Errorf("unexpected queries %q", got)
+	}
+	if got := registrarQueries(); len(got) != 1 {
+		t.Errorf("expected registrar to be asked once, got %q", got)
+	}
+
+	// the registrar data wins and its own referral isn't followed
+	want := "# WHOIS of pentagi-example.com\n\n" +
+		"## Registrar\nMarkMonitor, Inc.\n\n" +
+		"## Registrant Organization\nInternet Assigned Numbers Authority\n\n" +
+		"## Created\n1995-08-14T04:00:00+0000\n\n" +
+		"## Expires\n2025-08-13T04:00:00+0000\n\n" +
+		"## Updated\n2024-08-14T07:01:34+0000\n\n" +
+		"## Name Servers\na.iana-servers.net, b.iana-servers.net\n\n" +
+		"## Status\nclientUpdateProhibited\n\n" +
+		"## WHOIS Servers\n" + strings.Join([]string{iana, registry, registrar}, ", ") + "\n\n"
+	if result != want {
+		t.Errorf("Handle() = %q, want %q", result, want)
+	}
+}
+
+func TestWhoisHandleErrors(t *testing.T) {
+	noMatch, _ := newWhoisServer(t, loadWhoisFixture(t, "whois_nomatch.txt", ""))
+	registryWithDeadRegistrar, _ := newWhoisServer(t, loadWhoisFixture(t, "whois_ru.txt", "")+
+		"Registrar WHOIS Server: "+closedAddr(t)+"\n")
+	garbage, _ := newWhoisServer(t, "Service is temporarily unavailable\n")
+
+	tests := []struct {
+		name   string
+		server string
+		domain string
+		prefix string
+	}{
+		{"no match", noMatch, "pentagi-not-registered.com", "no match for domain pentagi-not-registered.com in WHOIS"},
+		{"dead registrar", registryWithDeadRegistrar, "pentagi-example.ru", "# WHOIS of pentagi-example.ru\n\n## Registrar\nRU-CENTER-RU"},
+		{"no data", garbage, "pentagi-example.com", "failed to look up domain pentagi-example.com in whois: whois server"},
+		{"unreachable", closedAddr(t), "pentagi-example.com", "failed to look up domain pentagi-example.com in whois: failed to connect"},
+		{"invalid domain", noMatch, "10.0.0.1", `invalid domain "10.0.0.1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := &capturingTracer{}
+			tool := &whois{enabled: true, server: tt.server, timeout: 5 * time.Second, tracer: tracer}
+
+			args, _ := json.Marshal(WhoisAction{Domain: tt.domain})
+			result, err := tool.Handle(context.Background(), WhoisToolName, args)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if !strings.HasPrefix(result, tt.prefix) {
+				t.Errorf("Handle() = %q, want prefix %q", result, tt.prefix)
+			}
+
+			// only real failures are reported as errors, not registered domain is the valid answer
+			wantEvents := 0
+			if strings.HasPrefix(tt.prefix, "failed") {
+				wantEvents = 1
+			}
+			if len(tracer.events) != wantEvents {
+				t.Errorf("expected %d error events, got %d", wantEvents, len(tracer.events))
+			}
+		})
+	}
+}
+
+func TestWhoisHTTPProxyTunnel(t *testing.T) {
+	target, queries := newWhoisServer(t, loadWhoisFixture(t, "whois_ru.txt", ""))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	connects := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		connects <- req.Method + " " + req.Host + " " + req.Header.Get("Proxy-Authorization")
+
+		upstream, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			conn.Write([]byte("HTTP/1.1 502 Bad Gateway\r\n\r\n"))
+			return
+		}
+		defer upstream.Close()
+
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go io.Copy(upstream, conn)
+		io.Copy(conn, upstream)
+	}()
+
+	tool := &whois{
+		enabled:  true,
+		server:   target,
+		proxyURL: "http://user:pass@" + listener.Addr().String(),
+		timeout:  5 * time.Second,
+	}
+	record, _, err := tool.lookup(context.Background(), "pentagi-example.ru")
+	if err != nil {
+		t.Fatalf("lookup() error = %v", err)
+	}
+
+	if record.registrar != "RU-CENTER-RU" {
+		t.Errorf("unexpected record %+v", record)
+	}
+	if got, want := <-connects, "CONNECT "+target+" Basic dXNlcjpwYXNz"; got != want {
+		t.Errorf("proxy got %q, want %q", got, want)
+	}
+	if got := queries(); !reflect.DeepEqual(got, []string{"pentagi-example.ru\r\n"}) {
+		t.Errorf("unexpected queries %q", got)
+	}
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "example.com", want: "example.com"},
+		{value: "  WWW.Example.COM. ", want: "www.example.com"},
+		{value: "https://example.com:8443/login?next=/", want: "example.com"},
+		{value: "пример.рф", want: "xn--e1afmkfd.xn--p1ai"},
+		{value: "localhost", wantErr: true},
+		{value: "192.168.1.1", wantErr: true},
+		{value: "exa mple.com", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeDomain(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeDomain(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("normalizeDomain(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
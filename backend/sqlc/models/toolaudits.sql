@@ -0,0 +1,26 @@
+-- name: GetFlowToolAudits :many
+SELECT
+  ta.*
+FROM toolaudits ta
+INNER JOIN flows f ON ta.flow_id = f.id
+WHERE ta.flow_id = $1 AND f.deleted_at IS NULL
+ORDER BY ta.created_at ASC;
+
+-- name: CreateToolAudit :one
+INSERT INTO toolaudits (
+  initiator,
+  executor,
+  name,
+  args,
+  success,
+  error,
+  result_length,
+  duration_seconds,
+  flow_id,
+  task_id,
+  subtask_id
+)
+VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+)
+RETURNING *;